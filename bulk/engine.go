@@ -0,0 +1,298 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package bulk runs an operation against many virtual machines, with bounded concurrency.
+
+Tasks started by the operation are tracked using a single PropertyCollector filter on a ListView,
+rather than a task.Wait call per VM. Tasks are added to the ListView as they are started and removed
+once complete.
+*/
+package bulk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DefaultConcurrency is the default Engine.Concurrency
+const DefaultConcurrency = 8
+
+// Op starts an operation for the given VM.
+// Op may return a nil Task when the operation completes without one, such as ShutdownGuest.
+type Op func(context.Context, *object.VirtualMachine) (*object.Task, error)
+
+// Result is the outcome of an Op for a single VM.
+type Result struct {
+	VM   *object.VirtualMachine
+	Task *types.ManagedObjectReference // Task is the task started by the Op, if any
+	Info *types.TaskInfo               // Info is the completed TaskInfo, if any
+	Err  error                         // Err is the Op error or task fault, of type task.Error
+}
+
+// Results is a list of Result, in the same order as the VMs given to Engine.Run.
+type Results []Result
+
+// Err returns the errors of all results, joined with the VM reference.
+func (r Results) Err() error {
+	var errs []error
+
+	for _, res := range r {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.VM.Reference(), res.Err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Engine runs an Op against many VMs.
+type Engine struct {
+	// Concurrency is the maximum number of operations in flight, defaults to DefaultConcurrency.
+	Concurrency int
+
+	// OnResult, if set, is called as each VM's operation completes. Calls are serialized.
+	OnResult func(Result)
+
+	c *vim25.Client
+}
+
+// NewEngine returns an Engine for the given client.
+func NewEngine(c *vim25.Client) *Engine {
+	return &Engine{
+		Concurrency: DefaultConcurrency,
+		c:           c,
+	}
+}
+
+// run is the state of a single Engine.Run call
+type run struct {
+	mu       sync.Mutex
+	results  Results
+	finished []bool
+	pending  map[types.ManagedObjectReference]int
+
+	report   sync.Mutex
+	onResult func(Result)
+
+	sem  chan struct{}
+	done chan struct{}
+}
+
+// finish records the result of the VM at index i and releases its concurrency slot.
+func (r *run) finish(i int, info *types.TaskInfo, err error) {
+	r.mu.Lock()
+	if r.finished[i] {
+		r.mu.Unlock()
+		return
+	}
+	r.finished[i] = true
+	r.results[i].Info = info
+	r.results[i].Err = err
+	res := r.results[i]
+	r.mu.Unlock()
+
+	if r.onResult != nil {
+		r.report.Lock()
+		r.onResult(res)
+		r.report.Unlock()
+	}
+
+	<-r.sem
+	r.done <- struct{}{}
+}
+
+// abort records the given error for all VMs that have not finished.
+func (r *run) abort(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.results {
+		if !r.finished[i] {
+			r.finished[i] = true
+			r.results[i].Err = err
+		}
+	}
+}
+
+// start calls op for the VM at index i, adding its task (if any) to the ListView.
+func (r *run) start(ctx context.Context, i int, op Op, list *view.ListView) {
+	t, err := op(ctx, r.results[i].VM)
+	if err != nil || t == nil {
+		r.finish(i, nil, err)
+		return
+	}
+
+	ref := t.Reference()
+
+	r.mu.Lock()
+	r.results[i].Task = &ref
+	r.pending[ref] = i
+	r.mu.Unlock()
+
+	if _, err = list.Add(ctx, []types.ManagedObjectReference{ref}); err != nil {
+		r.mu.Lock()
+		delete(r.pending, ref)
+		r.mu.Unlock()
+		r.finish(i, nil, err)
+	}
+}
+
+// update handles task info updates, returning the tasks that have completed.
+func (r *run) update(updates []types.ObjectUpdate) []types.ManagedObjectReference {
+	var completed []types.ManagedObjectReference
+
+	for _, update := range updates {
+		for _, change := range update.ChangeSet {
+			info, ok := change.Val.(types.TaskInfo)
+			if !ok {
+				continue
+			}
+
+			var err error
+
+			switch info.State {
+			case types.TaskInfoStateSuccess:
+			case types.TaskInfoStateError:
+				err = task.Error{LocalizedMethodFault: info.Error, Description: info.Description}
+			default:
+				continue
+			}
+
+			r.mu.Lock()
+			i, ok := r.pending[update.Obj]
+			delete(r.pending, update.Obj)
+			r.mu.Unlock()
+
+			if ok {
+				completed = append(completed, update.Obj)
+				r.finish(i, &info, err)
+			}
+		}
+	}
+
+	return completed
+}
+
+// Run calls op for each of the given VMs, with at most Engine.Concurrency operations in flight,
+// and waits for the tasks to complete. Per-VM errors are recorded in the returned Results,
+// see Results.Err. The returned error is non-nil if the tasks could not be tracked or the
+// context was canceled, in which case the results of VMs that did not complete have the same error.
+func (e *Engine) Run(ctx context.Context, vms []*object.VirtualMachine, op Op) (Results, error) {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	r := &run{
+		results:  make(Results, len(vms)),
+		finished: make([]bool, len(vms)),
+		pending:  make(map[types.ManagedObjectReference]int),
+		onResult: e.OnResult,
+		sem:      make(chan struct{}, concurrency),
+		done:     make(chan struct{}, len(vms)),
+	}
+
+	for i, vm := range vms {
+		r.results[i].VM = vm
+	}
+
+	if len(vms) == 0 {
+		return r.results, nil
+	}
+
+	list, err := view.NewManager(e.c).CreateListView(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = list.Destroy(context.Background())
+	}()
+
+	pc, err := property.DefaultCollector(e.c).Create(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = pc.Destroy(context.Background())
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	filter := new(property.WaitFilter).Add(list.Reference(), "Task", []string{"info"}, list.TraversalSpec())
+
+	werr := make(chan error, 1)
+	go func() {
+		werr <- property.WaitForUpdatesEx(ctx, pc, filter, func(updates []types.ObjectUpdate) bool {
+			if completed := r.update(updates); len(completed) != 0 {
+				_, _ = list.Remove(ctx, completed)
+			}
+			return false
+		})
+	}()
+
+	var wg sync.WaitGroup
+	dispatched := make(chan struct{})
+
+	go func() {
+		defer close(dispatched)
+
+		for i := range vms {
+			select {
+			case r.sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.start(ctx, i, op, list)
+			}()
+		}
+	}()
+
+	waiting := true
+
+	for range vms {
+		select {
+		case <-r.done:
+			continue
+		case err = <-werr:
+			waiting = false
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		if err == nil {
+			err = errors.New("property collector stopped waiting for updates")
+		}
+		break
+	}
+
+	cancel()
+	<-dispatched
+	wg.Wait()
+	if waiting {
+		<-werr
+	}
+
+	if err != nil {
+		r.abort(err)
+	}
+
+	return r.results, err
+}
@@ -0,0 +1,159 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package bulk_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/bulk"
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/task"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// counter counts method calls by request body type name
+type counter struct {
+	sync.Mutex
+	rt    soap.RoundTripper
+	calls map[string]int
+}
+
+func (c *counter) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	c.Lock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	c.calls[reflect.Indirect(reflect.ValueOf(req)).Type().Name()]++
+	c.Unlock()
+
+	return c.rt.RoundTrip(ctx, req, res)
+}
+
+func (c *counter) count(name string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.calls[name]
+}
+
+func TestEngine(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 5
+
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		calls := &counter{rt: c.RoundTripper}
+		c.RoundTripper = calls
+
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		require.NoError(t, err)
+		require.Len(t, vms, 10)
+
+		e := bulk.NewEngine(c)
+		e.Concurrency = 3
+
+		var inflight, peak atomic.Int32
+		var reported []string
+
+		e.OnResult = func(res bulk.Result) {
+			inflight.Add(-1)
+			reported = append(reported, res.VM.Reference().Value)
+		}
+
+		op := func(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+			n := inflight.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			return vm.PowerOff(ctx)
+		}
+
+		res, err := e.Run(ctx, vms, op)
+		require.NoError(t, err)
+		require.NoError(t, res.Err())
+		require.Len(t, res, len(vms))
+		assert.Len(t, reported, len(vms))
+		assert.LessOrEqual(t, peak.Load(), int32(3))
+
+		for i, r := range res {
+			assert.Equal(t, vms[i], r.VM)
+			require.NotNil(t, r.Task)
+			require.NotNil(t, r.Info)
+			assert.Equal(t, *r.Task, r.Info.Task)
+			assert.Equal(t, types.TaskInfoStateSuccess, r.Info.State)
+
+			state, err := r.VM.PowerState(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, state)
+		}
+
+		// all tasks are tracked by a single filter
+		assert.Equal(t, 1, calls.count("CreateFilterBody"))
+		assert.Equal(t, 1, calls.count("CreateListViewBody"))
+
+		// per-VM task faults
+		e.OnResult = nil
+		res, err = e.Run(ctx, vms, bulk.PowerOff)
+		require.NoError(t, err)
+		require.Len(t, res, len(vms))
+		for _, r := range res {
+			var terr task.Error
+			require.ErrorAs(t, r.Err, &terr)
+			assert.True(t, fault.Is(r.Err, &types.InvalidPowerState{}))
+		}
+		assert.Error(t, res.Err())
+
+		// Op errors and operations without a task
+		fail := errors.New("fail")
+		res, err = e.Run(ctx, vms[:2], func(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+			if vm == vms[0] {
+				return nil, fail
+			}
+			return nil, nil
+		})
+		require.NoError(t, err)
+		assert.ErrorIs(t, res[0].Err, fail)
+		assert.NoError(t, res[1].Err)
+		assert.Nil(t, res[1].Task)
+		assert.ErrorIs(t, res.Err(), fail)
+
+		res, err = e.Run(ctx, vms, bulk.CreateSnapshot("backup", "", false, false))
+		require.NoError(t, err)
+		require.NoError(t, res.Err())
+		for _, r := range res {
+			ref, ok := r.Info.Result.(types.ManagedObjectReference)
+			require.True(t, ok)
+			assert.Equal(t, "VirtualMachineSnapshot", ref.Type)
+		}
+
+		res, err = e.Run(ctx, vms, bulk.Destroy)
+		require.NoError(t, err)
+		require.NoError(t, res.Err())
+
+		vms, err = find.NewFinder(c).VirtualMachineList(ctx, "*")
+		assert.Empty(t, vms)
+		assert.Error(t, err)
+
+		// canceled context
+		cctx, cancel := context.WithCancel(ctx)
+		cancel()
+		_, err = e.Run(cctx, []*object.VirtualMachine{res[0].VM}, bulk.PowerOn)
+		assert.ErrorIs(t, err, context.Canceled)
+	}, model)
+}
@@ -0,0 +1,58 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package bulk
+
+import (
+	"context"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// PowerOn is an Op that powers on a VM.
+func PowerOn(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	return vm.PowerOn(ctx)
+}
+
+// PowerOff is an Op that powers off a VM.
+func PowerOff(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	return vm.PowerOff(ctx)
+}
+
+// Reset is an Op that resets a VM.
+func Reset(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	return vm.Reset(ctx)
+}
+
+// Suspend is an Op that suspends a VM.
+func Suspend(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	return vm.Suspend(ctx)
+}
+
+// Destroy is an Op that destroys a VM, which must be powered off.
+func Destroy(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	return vm.Destroy(ctx)
+}
+
+// Reconfigure returns an Op that reconfigures a VM with the given spec.
+func Reconfigure(spec types.VirtualMachineConfigSpec) Op {
+	return func(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+		return vm.Reconfigure(ctx, spec)
+	}
+}
+
+// CreateSnapshot returns an Op that creates a snapshot of a VM.
+func CreateSnapshot(name string, description string, memory bool, quiesce bool) Op {
+	return func(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+		return vm.CreateSnapshot(ctx, name, description, memory, quiesce)
+	}
+}
+
+// Relocate returns an Op that relocates a VM with the given spec.
+func Relocate(spec types.VirtualMachineRelocateSpec, priority types.VirtualMachineMovePriority) Op {
+	return func(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+		return vm.Relocate(ctx, spec, priority)
+	}
+}
@@ -24,6 +24,11 @@ import (
 type download struct {
 	*flags.DatastoreFlag
 	*flags.HostSystemFlag
+
+	resume   bool
+	retries  int
+	parallel int
+	checksum string
 }
 
 func init() {
@@ -36,6 +41,11 @@ func (cmd *download) Register(ctx context.Context, f *flag.FlagSet) {
 
 	cmd.HostSystemFlag, ctx = flags.NewHostSystemFlag(ctx)
 	cmd.HostSystemFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.resume, "resume", false, "Resume partial download of DEST")
+	f.IntVar(&cmd.retries, "retries", 0, "Number of times to resume a failed download")
+	f.IntVar(&cmd.parallel, "parallel", 1, "Number of concurrent ranged requests")
+	f.StringVar(&cmd.checksum, "checksum", "", "Verify DEST checksum (sha1|sha256|sha512:hex)")
 }
 
 func (cmd *download) Process(ctx context.Context) error {
@@ -57,8 +67,12 @@ func (cmd *download) Description() string {
 
 If DEST name is "-", source is written to stdout.
 
+The '-resume', '-retries' and '-parallel' options use HTTP Range requests, useful for large files such as VMDKs.
+
 Examples:
   govc datastore.download vm-name/vmware.log ./local.log
+  govc datastore.download -resume -retries 3 -parallel 4 vm-name/vm-name-flat.vmdk ./disk.vmdk
+  govc datastore.download -checksum sha256:$(sha256sum disk.iso | cut -d' ' -f1) iso/disk.iso ./disk.iso
   govc datastore.download vm-name/vmware.log - | grep -i error
   govc datastore.download -json vm-name/vm-name.vmdk - | jq .ddb
   ovf=$(govc library.info -l -L vmservice/photon-5.0/*.ovf)
@@ -99,6 +113,10 @@ func (cmd *download) Run(ctx context.Context, f *flag.FlagSet) error {
 	}
 
 	p := soap.DefaultDownload
+	p.Resume = cmd.resume
+	p.Retries = cmd.retries
+	p.Parallel = cmd.parallel
+	p.Checksum = cmd.checksum
 
 	if dst == "-" {
 		f, _, err := ds.Download(ctx, src, &p)
@@ -18,6 +18,9 @@ import (
 type upload struct {
 	*flags.OutputFlag
 	*flags.DatastoreFlag
+
+	retries int
+	resume  bool
 }
 
 func init() {
@@ -30,6 +33,9 @@ func (cmd *upload) Register(ctx context.Context, f *flag.FlagSet) {
 
 	cmd.DatastoreFlag, ctx = flags.NewDatastoreFlag(ctx)
 	cmd.DatastoreFlag.Register(ctx, f)
+
+	f.IntVar(&cmd.retries, "retries", 0, "Number of times to retry a failed upload")
+	f.BoolVar(&cmd.resume, "resume", false, "Resume a partial or failed upload using Content-Range (if supported by the endpoint)")
 }
 
 func (cmd *upload) Process(ctx context.Context) error {
@@ -53,7 +59,8 @@ If SOURCE name is "-", read source from stdin.
 
 Examples:
   govc datastore.upload -ds datastore1 ./config.iso vm-name/config.iso
-  genisoimage ... | govc datastore.upload -ds datastore1 - vm-name/config.iso`
+  genisoimage ... | govc datastore.upload -ds datastore1 - vm-name/config.iso
+  govc datastore.upload -ds datastore1 -retries 3 ./disk.vmdk vm-name/disk.vmdk`
 }
 
 func (cmd *upload) Run(ctx context.Context, f *flag.FlagSet) error {
@@ -68,6 +75,8 @@ func (cmd *upload) Run(ctx context.Context, f *flag.FlagSet) error {
 	}
 
 	p := soap.DefaultUpload
+	p.Retries = cmd.retries
+	p.Resume = cmd.resume
 
 	src := args[0]
 	dst := args[1]
@@ -6,9 +6,11 @@ package importx
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"flag"
 	"fmt"
+	"os"
 
 	"github.com/vmware/govmomi/cli"
 	"github.com/vmware/govmomi/cli/flags"
@@ -34,6 +36,7 @@ type ovfx struct {
 
 	lease bool
 	net   string // No need for *flags.NetworkFlag here
+	roots string
 }
 
 func init() {
@@ -57,6 +60,8 @@ func (cmd *ovfx) Register(ctx context.Context, f *flag.FlagSet) {
 
 	f.StringVar(&cmd.Importer.Name, "name", "", "Name to use for new entity")
 	f.BoolVar(&cmd.Importer.VerifyManifest, "m", false, "Verify checksum of uploaded files against manifest (.mf)")
+	f.BoolVar(&cmd.Importer.VerifySignature, "s", false, "Verify manifest signature using certificate (.cert), implies -m")
+	f.StringVar(&cmd.roots, "roots", "", "PEM encoded trusted root certificates used by -s (default: system roots)")
 	f.BoolVar(&cmd.Importer.Hidden, "hidden", false, "Enable hidden properties")
 	f.BoolVar(&cmd.lease, "lease", false, "Output NFC Lease only")
 	f.StringVar(&cmd.net, "net", "", "Network")
@@ -81,6 +86,16 @@ func (cmd *ovfx) Process(ctx context.Context) error {
 	if err := cmd.FolderFlag.Process(ctx); err != nil {
 		return err
 	}
+	if cmd.roots != "" {
+		pem, err := os.ReadFile(cmd.roots)
+		if err != nil {
+			return err
+		}
+		cmd.Importer.Roots = x509.NewCertPool()
+		if !cmd.Importer.Roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", cmd.roots)
+		}
+	}
 	return nil
 }
 
@@ -6,6 +6,8 @@ package library
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -24,6 +26,10 @@ type export struct {
 	*flags.ClientFlag
 	*flags.OutputFlag
 	library.Item
+
+	ova      bool
+	signCert string
+	signKey  string
 }
 
 func init() {
@@ -36,6 +42,10 @@ func (cmd *export) Register(ctx context.Context, f *flag.FlagSet) {
 
 	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
 	cmd.OutputFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.ova, "ova", false, "Export OVF library item as an OVA with a SHA256 manifest")
+	f.StringVar(&cmd.signCert, "sign-cert", "", "PEM encoded certificate used to sign the OVA manifest")
+	f.StringVar(&cmd.signKey, "sign-key", "", "PEM encoded private key used to sign the OVA manifest")
 }
 
 func (cmd *export) Usage() string {
@@ -54,10 +64,17 @@ If DEST is given for a library item, files are saved there instead of the curren
 If DEST is given for a library item file, the file will be saved with that name.
 If DEST is '-', the file contents are written to stdout instead of saving to a file.
 
+With the '-ova' flag, the files of an OVF library item are written to a single OVA archive,
+including a generated SHA256 manifest (.mf). If DEST is not given, the archive is saved as ITEM_NAME.ova.
+If '-sign-cert' and '-sign-key' are given, the manifest is signed and the certificate (.cert) file
+is added to the archive, see 'import.ova -s'.
+
 Examples:
   govc library.export library_name/item_name
   govc library.export library_name/item_name/file_name
-  govc library.export library_name/item_name/*.ovf -`
+  govc library.export library_name/item_name/*.ovf -
+  govc library.export -ova library_name/item_name item_name.ova
+  govc library.export -ova -sign-cert cert.pem -sign-key key.pem library_name/item_name item_name.ova`
 }
 
 func (cmd *export) Process(ctx context.Context) error {
@@ -92,6 +109,14 @@ func (cmd *export) Run(ctx context.Context, f *flag.FlagSet) error {
 	}
 
 	dst := f.Arg(1)
+
+	if cmd.ova {
+		if len(names) != 0 {
+			return fmt.Errorf("%q is not a library item", f.Arg(0))
+		}
+		return cmd.exportOVA(ctx, m, dst)
+	}
+
 	one := len(names) == 1
 	var log io.Writer = os.Stdout
 	isStdout := one && dst == "-"
@@ -178,3 +203,39 @@ func (cmd *export) Run(ctx context.Context, f *flag.FlagSet) error {
 
 	return nil
 }
+
+func (cmd *export) exportOVA(ctx context.Context, m *library.Manager, dst string) error {
+	var opts library.ExportOptions
+
+	if cmd.signCert != "" || cmd.signKey != "" {
+		if cmd.signCert == "" || cmd.signKey == "" {
+			return errors.New("both -sign-cert and -sign-key are required to sign")
+		}
+		cert, err := tls.LoadX509KeyPair(cmd.signCert, cmd.signKey)
+		if err != nil {
+			return err
+		}
+		opts.Certificate = &cert
+	}
+
+	if dst == "" {
+		dst = cmd.Name + ".ova"
+	}
+
+	if dst == "-" {
+		return m.ExportLibraryItemOVA(ctx, cmd.ID, os.Stdout, opts)
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if err = m.ExportLibraryItemOVA(ctx, cmd.ID, f, opts); err != nil {
+		_ = f.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+
+	return f.Close()
+}
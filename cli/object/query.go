@@ -0,0 +1,176 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package object
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/fault"
+	govfind "github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type query struct {
+	*flags.DatacenterFlag
+
+	ref  bool
+	id   bool
+	long bool
+}
+
+func init() {
+	cli.Register("find.query", &query{})
+}
+
+func (cmd *query) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.DatacenterFlag, ctx = flags.NewDatacenterFlag(ctx)
+	cmd.DatacenterFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.ref, "i", false, "Print the managed object reference")
+	f.BoolVar(&cmd.id, "I", false, "Print the managed object ID")
+	f.BoolVar(&cmd.long, "l", false, "Long listing format")
+}
+
+func (cmd *query) Usage() string {
+	return "QUERY"
+}
+
+func (cmd *query) Description() string {
+	return `Find managed objects using a query.
+
+The QUERY syntax is:
+  [PATH] [where EXPR] [sort OPERAND [asc|desc]] [limit N]
+
+PATH is an inventory path or glob, defaulting to the root folder.
+Objects matching PATH and all objects they contain are candidates for the query.
+
+EXPR combines comparisons with 'and', 'or', 'not' and parentheses.
+A comparison is 'OPERAND OP VALUE', where OP is one of: = != < <= > >=
+OPERAND is a property path, optionally combined with numbers using '*' and '/'.
+The = and != operators match strings using glob patterns.
+Use the govc 'collect' command to view possible object property paths.
+
+The following selectors can be used in place of a property path:
+  type           the managed object type, such as VirtualMachine
+  tag            the name of any attached tag
+  tag.CATEGORY   the name of any attached tag in the given category
+  field.NAME     the value of the custom field with the given name
+
+A reference property followed by '->' matches properties of the referenced objects.
+
+Examples:
+  govc find.query "where type = VirtualMachine and runtime.powerState = poweredOn"
+  govc find.query "/dc1/vm/prod where type = VirtualMachine and tag = prod sort name limit 10"
+  govc find.query "where type = VirtualMachine and datastore->(summary.freeSpace / summary.capacity < 0.1)"
+  govc find.query "where type = Datastore sort summary.freeSpace desc limit 1"
+  govc find.query "where type = HostSystem and (hardware.cpuInfo.numCpuCores >= 16 or field.owner = ops*)"`
+}
+
+// tags implements govfind.QueryTagFunc using the vAPI tagging service
+func (cmd *query) tags(ctx context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference][]govfind.QueryTag, error) {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return nil, err
+	}
+
+	m := tags.NewManager(c)
+
+	categories, err := m.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+	}
+
+	objs := make([]mo.Reference, len(refs))
+	for i := range refs {
+		objs[i] = refs[i]
+	}
+
+	attached, err := m.GetAttachedTagsOnObjects(ctx, objs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[types.ManagedObjectReference][]govfind.QueryTag, len(attached))
+	for _, a := range attached {
+		ref := a.ObjectID.Reference()
+		for _, tag := range a.Tags {
+			res[ref] = append(res[ref], govfind.QueryTag{Category: names[tag.CategoryID], Name: tag.Name})
+		}
+	}
+
+	return res, nil
+}
+
+func (cmd *query) Run(ctx context.Context, f *flag.FlagSet) error {
+	q, err := govfind.ParseQuery(strings.Join(f.Args(), " "))
+	if err != nil {
+		return err
+	}
+	q.Tags = cmd.tags
+
+	finder, err := cmd.Finder()
+	if err != nil {
+		return err
+	}
+
+	content, err := finder.Query(ctx, q)
+	if err != nil {
+		return err
+	}
+
+	if cmd.id {
+		cmd.ref = true
+	}
+
+	var paths []string
+
+	for _, o := range content {
+		if cmd.ref && !cmd.long {
+			paths = append(paths, mor(o.Obj, cmd.id))
+			continue
+		}
+
+		e, err := finder.Element(ctx, o.Obj)
+		if err != nil {
+			if fault.Is(err, &types.ManagedObjectNotFound{}) {
+				continue // object was deleted after the query
+			}
+			return err
+		}
+
+		path := e.Path
+		if cmd.long {
+			id := strings.TrimPrefix(o.Obj.Type, "Vmware")
+			if cmd.ref {
+				id = mor(o.Obj, cmd.id)
+			}
+			path = id + "\t" + path
+		}
+		paths = append(paths, path)
+	}
+
+	if cmd.long {
+		return cmd.WriteResult(findResultLong(paths))
+	}
+	return cmd.WriteResult(findResult(paths))
+}
+
+func mor(ref types.ManagedObjectReference, id bool) string {
+	if id {
+		return ref.Value
+	}
+	return ref.String()
+}
@@ -0,0 +1,46 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"net/url"
+
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+)
+
+// locationUsage describes the LOCATION argument of the vcsa.backup commands
+const locationUsage = `LOCATION is a URL with one of the schemes: ftp, ftps, http, https, scp, sftp, nfs or smb.
+The location user and password can be specified in the URL.`
+
+// location of a backup
+type location struct {
+	Type     string
+	URL      string
+	User     string
+	Password string
+}
+
+// parseLocation parses the LOCATION argument, removing any user info from the URL.
+func parseLocation(arg string) (*location, error) {
+	kind, err := backup.LocationType(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := &location{Type: kind}
+	if u.User != nil {
+		loc.User = u.User.Username()
+		loc.Password, _ = u.User.Password()
+		u.User = nil
+	}
+	loc.URL = u.String()
+
+	return loc, nil
+}
@@ -0,0 +1,106 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+)
+
+type info struct {
+	*flags.ClientFlag
+	*flags.OutputFlag
+}
+
+func init() {
+	cli.Register("vcsa.backup.info", &info{})
+}
+
+func (cmd *info) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
+	cmd.OutputFlag.Register(ctx, f)
+}
+
+func (cmd *info) Process(ctx context.Context) error {
+	if err := cmd.ClientFlag.Process(ctx); err != nil {
+		return err
+	}
+	return cmd.OutputFlag.Process(ctx)
+}
+
+func (cmd *info) Usage() string {
+	return "[ID]..."
+}
+
+func (cmd *info) Description() string {
+	return `Display the status of appliance backup jobs.
+
+If no ID is specified, the status of all backup jobs is displayed.
+
+Examples:
+  govc vcsa.backup.info
+  govc vcsa.backup.info 20241018-020000-1
+  govc vcsa.backup.info -json | jq -r '.[] | select(.state == "FAILED") | .id'`
+}
+
+type jobResult []backup.JobStatus
+
+func (r jobResult) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	for _, s := range r {
+		fmt.Fprintf(tw, "ID:\t%s\n", s.ID)
+		fmt.Fprintf(tw, "  State:\t%s\n", s.State)
+		fmt.Fprintf(tw, "  Progress:\t%d%%\n", s.Progress)
+		fmt.Fprintf(tw, "  Start time:\t%s\n", s.StartTime.Format(time.ANSIC))
+		if s.EndTime != nil {
+			fmt.Fprintf(tw, "  End time:\t%s\n", s.EndTime.Format(time.ANSIC))
+		}
+		for _, m := range s.Messages {
+			fmt.Fprintf(tw, "  Message:\t%s\n", m.DefaultMessage)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (cmd *info) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	m := backup.NewManager(c)
+
+	ids := f.Args()
+	if len(ids) == 0 {
+		ids, err = m.ListJobs(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	var res jobResult
+	for _, id := range ids {
+		job, err := m.GetJob(ctx, id)
+		if err != nil {
+			return err
+		}
+		res = append(res, *job)
+	}
+
+	return cmd.WriteResult(res)
+}
@@ -0,0 +1,78 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+)
+
+type parts struct {
+	*flags.ClientFlag
+	*flags.OutputFlag
+}
+
+func init() {
+	cli.Register("vcsa.backup.parts", &parts{})
+}
+
+func (cmd *parts) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
+	cmd.OutputFlag.Register(ctx, f)
+}
+
+func (cmd *parts) Process(ctx context.Context) error {
+	if err := cmd.ClientFlag.Process(ctx); err != nil {
+		return err
+	}
+	return cmd.OutputFlag.Process(ctx)
+}
+
+func (cmd *parts) Description() string {
+	return `List the parts that can be included in an appliance backup.
+
+Parts that are not optional are always included in a backup.
+
+Examples:
+  govc vcsa.backup.parts
+  govc vcsa.backup.parts -json`
+}
+
+type partsResult []backup.Part
+
+func (r partsResult) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "ID\tOptional\tDefault\tDescription\n")
+	for _, p := range r {
+		fmt.Fprintf(tw, "%s\t%t\t%t\t%s\n", p.ID, p.Optional, p.SelectedByDefault, p.Description.DefaultMessage)
+	}
+
+	return tw.Flush()
+}
+
+func (cmd *parts) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := backup.NewManager(c).ListParts(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cmd.WriteResult(partsResult(res))
+}
@@ -0,0 +1,77 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package schedule
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+)
+
+type run struct {
+	*flags.ClientFlag
+
+	comment string
+	async   bool
+}
+
+func init() {
+	cli.Register("vcsa.backup.schedule.run", &run{})
+}
+
+func (cmd *run) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	f.StringVar(&cmd.comment, "comment", "", "Backup comment")
+	f.BoolVar(&cmd.async, "async", false, "Do not wait for the backup job to complete")
+}
+
+func (cmd *run) Usage() string {
+	return "ID"
+}
+
+func (cmd *run) Description() string {
+	return `Run an appliance backup schedule now.
+
+The backup job ID is printed on success.
+Unless -async is specified, the command waits for the backup job to complete.
+
+Examples:
+  govc vcsa.backup.schedule.run default
+  govc vcsa.backup.info $(govc vcsa.backup.schedule.run -async default)`
+}
+
+func (cmd *run) Run(ctx context.Context, f *flag.FlagSet) error {
+	if f.NArg() != 1 {
+		return flag.ErrHelp
+	}
+
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	m := backup.NewManager(c)
+
+	job, err := m.RunSchedule(ctx, f.Arg(0), cmd.comment)
+	if err != nil {
+		return err
+	}
+
+	if !cmd.async {
+		if _, err = m.WaitForJob(ctx, job.ID, time.Second); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println(job.ID)
+	return nil
+}
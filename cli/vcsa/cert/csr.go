@@ -0,0 +1,58 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type csr struct {
+	*flags.ClientFlag
+
+	subject subjectFlag
+}
+
+func init() {
+	cli.Register("vcsa.cert.csr", &csr{})
+}
+
+func (cmd *csr) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.subject.Register(f)
+}
+
+func (cmd *csr) Description() string {
+	return `Generate a certificate signing request (CSR) for the vCenter machine (TLS) certificate.
+
+The private key is kept by vCenter, the signed certificate can be installed using vcsa.cert.replace without the -private-key flag.
+
+Examples:
+  govc vcsa.cert.csr -cn vcsa.example.com -o Example -c US -san vcsa.example.com,10.0.0.10 > vcsa.csr
+  # sign vcsa.csr with your CA, then:
+  govc vcsa.cert.replace -root ca.pem vcsa.pem`
+}
+
+func (cmd *csr) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	res, err := certificatemanagement.NewManager(c).GenerateCSR(ctx, cmd.subject.Spec())
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Print(res.CSR)
+	return err
+}
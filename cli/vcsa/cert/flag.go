@@ -0,0 +1,57 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+// readFile returns the contents of the given PEM file, reading from stdin if name is "-".
+func readFile(name string) (string, error) {
+	var b []byte
+	var err error
+
+	if name == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(filepath.Clean(name))
+	}
+
+	return string(b), err
+}
+
+// subjectFlag registers flags for the certificate subject of a CSR or VMCA signed certificate.
+type subjectFlag struct {
+	certificatemanagement.CSRSpec
+
+	san string
+}
+
+func (s *subjectFlag) Register(f *flag.FlagSet) {
+	f.Int64Var(&s.KeySize, "key-size", 0, "Key size in bits")
+	f.StringVar(&s.CommonName, "cn", "", "Common name")
+	f.StringVar(&s.Organization, "o", "", "Organization")
+	f.StringVar(&s.OrganizationUnit, "ou", "", "Organizational unit")
+	f.StringVar(&s.Locality, "l", "", "Locality")
+	f.StringVar(&s.StateOrProvince, "st", "", "State or province")
+	f.StringVar(&s.Country, "c", "", "Country")
+	f.StringVar(&s.EmailAddress, "email", "", "Email address")
+	f.StringVar(&s.san, "san", "", "Comma separated list of subject alternative names")
+}
+
+// Spec returns the CSRSpec for the given flags.
+func (s *subjectFlag) Spec() certificatemanagement.CSRSpec {
+	spec := s.CSRSpec
+	if s.san != "" {
+		spec.SubjectAltName = strings.Split(s.san, ",")
+	}
+	return spec
+}
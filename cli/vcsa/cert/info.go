@@ -0,0 +1,97 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type info struct {
+	*flags.ClientFlag
+	*flags.OutputFlag
+
+	show bool
+}
+
+func init() {
+	cli.Register("vcsa.cert.info", &info{})
+}
+
+func (cmd *info) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
+	cmd.OutputFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.show, "show", false, "Show PEM encoded certificate")
+}
+
+func (cmd *info) Process(ctx context.Context) error {
+	if err := cmd.ClientFlag.Process(ctx); err != nil {
+		return err
+	}
+	return cmd.OutputFlag.Process(ctx)
+}
+
+func (cmd *info) Description() string {
+	return `Display the vCenter machine (TLS) certificate.
+
+Examples:
+  govc vcsa.cert.info
+  govc vcsa.cert.info -show
+  govc vcsa.cert.info -json | jq -r .valid_to`
+}
+
+type infoResult struct {
+	*certificatemanagement.TLSInfo
+	show bool
+}
+
+func (r *infoResult) Write(w io.Writer) error {
+	if r.show {
+		_, err := fmt.Fprint(w, r.Cert)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Subject:\t%s\n", r.SubjectDN)
+	fmt.Fprintf(tw, "Issuer:\t%s\n", r.IssuerDN)
+	fmt.Fprintf(tw, "Serial number:\t%s\n", r.SerialNumber)
+	fmt.Fprintf(tw, "Valid from:\t%s\n", r.ValidFrom.Format(time.ANSIC))
+	fmt.Fprintf(tw, "Valid to:\t%s\n", r.ValidTo.Format(time.ANSIC))
+	fmt.Fprintf(tw, "Thumbprint:\t%s\n", r.Thumbprint)
+	fmt.Fprintf(tw, "Signature algorithm:\t%s\n", r.SignatureAlgorithm)
+	fmt.Fprintf(tw, "Key usage:\t%s\n", strings.Join(r.KeyUsage, ","))
+	fmt.Fprintf(tw, "Extended key usage:\t%s\n", strings.Join(r.ExtendedKeyUsage, ","))
+	fmt.Fprintf(tw, "Subject alternative names:\t%s\n", strings.Join(r.SubjectAlternativeName, ","))
+
+	return tw.Flush()
+}
+
+func (cmd *info) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := certificatemanagement.NewManager(c).GetTLS(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cmd.WriteResult(&infoResult{info, cmd.show})
+}
@@ -0,0 +1,50 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"context"
+	"flag"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type renew struct {
+	*flags.ClientFlag
+
+	duration int64
+}
+
+func init() {
+	cli.Register("vcsa.cert.renew", &renew{})
+}
+
+func (cmd *renew) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	f.Int64Var(&cmd.duration, "duration", 0, "Validity period of the renewed certificate in days (default set by vCenter)")
+}
+
+func (cmd *renew) Description() string {
+	return `Renew the VMCA signed vCenter machine (TLS) certificate.
+
+The renewed certificate has the same subject as the current certificate.
+
+Examples:
+  govc vcsa.cert.renew
+  govc vcsa.cert.renew -duration 365`
+}
+
+func (cmd *renew) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	return certificatemanagement.NewManager(c).RenewTLS(ctx, cmd.duration)
+}
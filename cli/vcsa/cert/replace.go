@@ -0,0 +1,90 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cert
+
+import (
+	"context"
+	"flag"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type replace struct {
+	*flags.ClientFlag
+
+	vmca    bool
+	key     string
+	root    string
+	subject subjectFlag
+}
+
+func init() {
+	cli.Register("vcsa.cert.replace", &replace{})
+}
+
+func (cmd *replace) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.vmca, "vmca", false, "Replace with a new VMCA signed certificate")
+	f.StringVar(&cmd.key, "private-key", "", "Private key FILE of the custom certificate")
+	f.StringVar(&cmd.root, "root", "", "Root certificate FILE to add to the trusted root chains")
+	cmd.subject.Register(f)
+}
+
+func (cmd *replace) Usage() string {
+	return "[FILE]"
+}
+
+func (cmd *replace) Description() string {
+	return `Replace the vCenter machine (TLS) certificate.
+
+With the -vmca flag, the certificate is replaced with a new VMCA signed certificate,
+using the subject flags. Otherwise the certificate is replaced with the custom certificate FILE,
+which must be signed by a trusted root or the -root certificate.
+If the -private-key flag is not specified, the private key generated by vcsa.cert.csr is used.
+If FILE name is "-", read the certificate from stdin.
+
+Examples:
+  govc vcsa.cert.replace -vmca -cn vcsa.example.com -o Example -c US -san vcsa.example.com
+  govc vcsa.cert.replace -private-key vcsa.key -root ca.pem vcsa.pem`
+}
+
+func (cmd *replace) Run(ctx context.Context, f *flag.FlagSet) error {
+	if cmd.vmca == (f.NArg() == 1) || f.NArg() > 1 {
+		return flag.ErrHelp
+	}
+
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	m := certificatemanagement.NewManager(c)
+
+	if cmd.vmca {
+		return m.ReplaceTLSWithVMCASigned(ctx, cmd.subject.Spec())
+	}
+
+	var spec certificatemanagement.TLSSpec
+
+	if spec.Cert, err = readFile(f.Arg(0)); err != nil {
+		return err
+	}
+	if cmd.key != "" {
+		if spec.Key, err = readFile(cmd.key); err != nil {
+			return err
+		}
+	}
+	if cmd.root != "" {
+		if spec.RootCert, err = readFile(cmd.root); err != nil {
+			return err
+		}
+	}
+
+	return m.SetTLS(ctx, spec)
+}
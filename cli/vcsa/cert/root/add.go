@@ -0,0 +1,90 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package root
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type add struct {
+	*flags.ClientFlag
+
+	id string
+}
+
+func init() {
+	cli.Register("vcsa.cert.root.add", &add{})
+}
+
+func (cmd *add) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	f.StringVar(&cmd.id, "id", "", "Chain ID (default generated by vCenter)")
+}
+
+func (cmd *add) Usage() string {
+	return "FILE"
+}
+
+func (cmd *add) Description() string {
+	return `Add the PEM encoded certificate chain FILE to the vCenter trusted root chains.
+
+The chain ID is printed on success.
+If FILE name is "-", read the certificate chain from stdin.
+
+Examples:
+  govc vcsa.cert.root.add ca.pem
+  govc vcsa.cert.root.add -id corp-root - < ca.pem`
+}
+
+func (cmd *add) Run(ctx context.Context, f *flag.FlagSet) error {
+	if f.NArg() != 1 {
+		return flag.ErrHelp
+	}
+
+	var b []byte
+	var err error
+
+	if name := f.Arg(0); name == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(filepath.Clean(name))
+	}
+	if err != nil {
+		return err
+	}
+
+	spec := certificatemanagement.TrustedRootChainCreateSpec{
+		CertChain: certificatemanagement.X509CertChain{CertChain: []string{string(b)}},
+		Chain:     cmd.id,
+	}
+
+	if _, err = spec.CertChain.Certificates(); err != nil {
+		return err
+	}
+
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	id, err := certificatemanagement.NewManager(c).CreateTrustedRootChain(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(id)
+	return nil
+}
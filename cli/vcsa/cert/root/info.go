@@ -0,0 +1,107 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package root
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+type info struct {
+	*flags.ClientFlag
+	*flags.OutputFlag
+
+	show bool
+}
+
+func init() {
+	cli.Register("vcsa.cert.root.info", &info{})
+}
+
+func (cmd *info) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
+	cmd.OutputFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.show, "show", false, "Show PEM encoded certificate chain")
+}
+
+func (cmd *info) Process(ctx context.Context) error {
+	if err := cmd.ClientFlag.Process(ctx); err != nil {
+		return err
+	}
+	return cmd.OutputFlag.Process(ctx)
+}
+
+func (cmd *info) Usage() string {
+	return "ID"
+}
+
+func (cmd *info) Description() string {
+	return `Display a vCenter trusted root chain.
+
+Examples:
+  govc vcsa.cert.root.info $(govc vcsa.cert.root.ls | head -1)
+  govc vcsa.cert.root.info -show ID > ca.pem`
+}
+
+type infoResult struct {
+	*certificatemanagement.TrustedRootChainInfo
+	show bool
+}
+
+func (r *infoResult) Write(w io.Writer) error {
+	if r.show {
+		for _, s := range r.CertChain.CertChain {
+			fmt.Fprint(w, s)
+		}
+		return nil
+	}
+
+	certs, err := r.CertChain.Certificates()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	for _, cert := range certs {
+		fmt.Fprintf(tw, "Subject:\t%s\n", cert.Subject)
+		fmt.Fprintf(tw, "  Issuer:\t%s\n", cert.Issuer)
+		fmt.Fprintf(tw, "  Valid to:\t%s\n", cert.NotAfter.Format(time.ANSIC))
+		fmt.Fprintf(tw, "  Thumbprint:\t%s\n", soap.ThumbprintSHA256(cert))
+	}
+
+	return tw.Flush()
+}
+
+func (cmd *info) Run(ctx context.Context, f *flag.FlagSet) error {
+	if f.NArg() != 1 {
+		return flag.ErrHelp
+	}
+
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := certificatemanagement.NewManager(c).GetTrustedRootChain(ctx, f.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return cmd.WriteResult(&infoResult{info, cmd.show})
+}
@@ -0,0 +1,105 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+type info struct {
+	*flags.ClientFlag
+	*flags.OutputFlag
+
+	show bool
+}
+
+func init() {
+	cli.Register("vcsa.cert.signing.info", &info{})
+}
+
+func (cmd *info) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	cmd.OutputFlag, ctx = flags.NewOutputFlag(ctx)
+	cmd.OutputFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.show, "show", false, "Show PEM encoded active signing certificate chain")
+}
+
+func (cmd *info) Process(ctx context.Context) error {
+	if err := cmd.ClientFlag.Process(ctx); err != nil {
+		return err
+	}
+	return cmd.OutputFlag.Process(ctx)
+}
+
+func (cmd *info) Description() string {
+	return `Display the VMCA signing certificate chains.
+
+The active chain is listed first.
+
+Examples:
+  govc vcsa.cert.signing.info
+  govc vcsa.cert.signing.info -show > vmca.pem`
+}
+
+type infoResult struct {
+	*certificatemanagement.SigningCertificateInfo
+	show bool
+}
+
+func (r *infoResult) Write(w io.Writer) error {
+	if r.show {
+		for _, s := range r.ActiveCertChain.CertChain {
+			fmt.Fprint(w, s)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 2, 0, 2, ' ', 0)
+
+	for i, chain := range append([]certificatemanagement.X509CertChain{r.ActiveCertChain}, r.SigningCertChains...) {
+		certs, err := chain.Certificates()
+		if err != nil {
+			return err
+		}
+		cert := certs[0]
+		if i == 0 {
+			fmt.Fprintf(tw, "Active:\t%s\n", cert.Subject)
+		} else {
+			fmt.Fprintf(tw, "Chain:\t%s\n", cert.Subject)
+		}
+		fmt.Fprintf(tw, "  Issuer:\t%s\n", cert.Issuer)
+		fmt.Fprintf(tw, "  Valid to:\t%s\n", cert.NotAfter.Format(time.ANSIC))
+		fmt.Fprintf(tw, "  Thumbprint:\t%s\n", soap.ThumbprintSHA256(cert))
+	}
+
+	return tw.Flush()
+}
+
+func (cmd *info) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	info, err := certificatemanagement.NewManager(c).GetSigningCertificate(ctx)
+	if err != nil {
+		return err
+	}
+
+	return cmd.WriteResult(&infoResult{info, cmd.show})
+}
@@ -0,0 +1,58 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package signing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/vmware/govmomi/cli"
+	"github.com/vmware/govmomi/cli/flags"
+	"github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+type refresh struct {
+	*flags.ClientFlag
+
+	force bool
+}
+
+func init() {
+	cli.Register("vcsa.cert.signing.refresh", &refresh{})
+}
+
+func (cmd *refresh) Register(ctx context.Context, f *flag.FlagSet) {
+	cmd.ClientFlag, ctx = flags.NewClientFlag(ctx)
+	cmd.ClientFlag.Register(ctx, f)
+
+	f.BoolVar(&cmd.force, "f", false, "Replace the signing certificate even if it is a custom certificate")
+}
+
+func (cmd *refresh) Description() string {
+	return `Replace the VMCA signing certificate with a new self-signed certificate.
+
+The new PEM encoded signing certificate is printed on success.
+Existing machine certificates are not reissued, see vcsa.cert.renew.
+
+Examples:
+  govc vcsa.cert.signing.refresh
+  govc vcsa.cert.renew`
+}
+
+func (cmd *refresh) Run(ctx context.Context, f *flag.FlagSet) error {
+	c, err := cmd.RestClient()
+	if err != nil {
+		return err
+	}
+
+	cert, err := certificatemanagement.NewManager(c).RefreshSigningCertificate(ctx, cmd.force)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Print(cert)
+	return err
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"flag"
 
+	"github.com/vmware/govmomi/bulk"
 	"github.com/vmware/govmomi/cli"
 	"github.com/vmware/govmomi/cli/flags"
 	"github.com/vmware/govmomi/object"
@@ -17,6 +18,8 @@ import (
 type destroy struct {
 	*flags.ClientFlag
 	*flags.SearchFlag
+
+	parallel int
 }
 
 func init() {
@@ -29,6 +32,8 @@ func (cmd *destroy) Register(ctx context.Context, f *flag.FlagSet) {
 
 	cmd.SearchFlag, ctx = flags.NewSearchFlag(ctx, flags.SearchVirtualMachines)
 	cmd.SearchFlag.Register(ctx, f)
+
+	f.IntVar(&cmd.parallel, "parallel", 1, "Number of VMs to destroy in parallel")
 }
 
 func (cmd *destroy) Process(ctx context.Context) error {
@@ -53,7 +58,8 @@ Use the 'device.remove -vm VM -keep disk-*' command to detach and
 keep disks if needed, prior to calling vm.destroy.
 
 Examples:
-  govc vm.destroy my-vm`
+  govc vm.destroy my-vm
+  govc vm.destroy -parallel 10 $(govc find . -type m -name 'test-*')`
 }
 
 func (cmd *destroy) Run(ctx context.Context, f *flag.FlagSet) error {
@@ -62,29 +68,24 @@ func (cmd *destroy) Run(ctx context.Context, f *flag.FlagSet) error {
 		return err
 	}
 
-	for _, vm := range vms {
-		var (
-			task  *object.Task
-			state types.VirtualMachinePowerState
-		)
-
-		state, err = vm.PowerState(ctx)
+	if cmd.parallel > 1 {
+		c, err := cmd.Client()
 		if err != nil {
 			return err
 		}
 
-		if state == types.VirtualMachinePowerStatePoweredOn {
-			task, err = vm.PowerOff(ctx)
-			if err != nil {
-				return err
-			}
+		e := bulk.NewEngine(c)
+		e.Concurrency = cmd.parallel
 
-			// Ignore error since the VM may already been in powered off state.
-			// vm.Destroy will fail if the VM is still powered on.
-			_ = task.Wait(ctx)
+		res, err := e.Run(ctx, vms, cmd.destroy)
+		if err != nil {
+			return err
 		}
+		return res.Err()
+	}
 
-		task, err = vm.Destroy(ctx)
+	for _, vm := range vms {
+		task, err := cmd.destroy(ctx, vm)
 		if err != nil {
 			return err
 		}
@@ -96,3 +97,24 @@ func (cmd *destroy) Run(ctx context.Context, f *flag.FlagSet) error {
 
 	return nil
 }
+
+// destroy powers off the VM if needed, returning the Destroy task
+func (cmd *destroy) destroy(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	state, err := vm.PowerState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == types.VirtualMachinePowerStatePoweredOn {
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ignore error since the VM may already been in powered off state.
+		// vm.Destroy will fail if the VM is still powered on.
+		_ = task.Wait(ctx)
+	}
+
+	return vm.Destroy(ctx)
+}
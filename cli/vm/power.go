@@ -6,13 +6,16 @@ package vm
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
+	"github.com/vmware/govmomi/bulk"
 	"github.com/vmware/govmomi/cli"
 	"github.com/vmware/govmomi/cli/flags"
 	"github.com/vmware/govmomi/fault"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/task"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -30,6 +33,7 @@ type power struct {
 	Force    bool
 	Multi    bool
 	Wait     bool
+	Parallel int
 }
 
 func init() {
@@ -53,6 +57,7 @@ func (cmd *power) Register(ctx context.Context, f *flag.FlagSet) {
 	f.BoolVar(&cmd.Force, "force", false, "Force (ignore state error and hard shutdown/reboot if tools unavailable)")
 	f.BoolVar(&cmd.Multi, "M", false, "Use Datacenter.PowerOnMultiVM method instead of VirtualMachine.PowerOnVM")
 	f.BoolVar(&cmd.Wait, "wait", true, "Wait for the operation to complete")
+	f.IntVar(&cmd.Parallel, "parallel", 1, "Number of VM operations to run in parallel")
 }
 
 func (cmd *power) Usage() string {
@@ -65,6 +70,7 @@ func (cmd *power) Description() string {
 Examples:
   govc vm.power -on VM1 VM2 VM3
   govc vm.power -on -M VM1 VM2 VM3
+  govc vm.power -off -parallel 10 $(govc find . -type m -name 'web-*')
   govc vm.power -off -force VM1`
 }
 
@@ -141,45 +147,14 @@ func (cmd *power) Run(ctx context.Context, f *flag.FlagSet) error {
 		}
 	}
 
-	for _, vm := range vms {
-		var task *object.Task
-
-		switch {
-		case cmd.On:
-			fmt.Fprintf(cmd, "Powering on %s... ", vm.Reference())
-			task, err = vm.PowerOn(ctx)
-		case cmd.Off:
-			fmt.Fprintf(cmd, "Powering off %s... ", vm.Reference())
-			task, err = vm.PowerOff(ctx)
-		case cmd.Reset:
-			fmt.Fprintf(cmd, "Reset %s... ", vm.Reference())
-			task, err = vm.Reset(ctx)
-		case cmd.Suspend:
-			fmt.Fprintf(cmd, "Suspend %s... ", vm.Reference())
-			task, err = vm.Suspend(ctx)
-		case cmd.Reboot:
-			fmt.Fprintf(cmd, "Reboot guest %s... ", vm.Reference())
-			err = vm.RebootGuest(ctx)
-
-			if err != nil && cmd.Force && isToolsUnavailable(err) {
-				task, err = vm.Reset(ctx)
-			}
-		case cmd.Shutdown:
-			fmt.Fprintf(cmd, "Shutdown guest %s... ", vm.Reference())
-			err = vm.ShutdownGuest(ctx)
-
-			if err != nil && cmd.Force && isToolsUnavailable(err) {
-				task, err = vm.PowerOff(ctx)
-			}
-		case cmd.Standby:
-			fmt.Fprintf(cmd, "Standby guest %s... ", vm.Reference())
-			err = vm.StandbyGuest(ctx)
+	if cmd.Wait && cmd.Parallel > 1 {
+		return cmd.parallel(ctx, vms)
+	}
 
-			if err != nil && cmd.Force && isToolsUnavailable(err) {
-				task, err = vm.Suspend(ctx)
-			}
-		}
+	for _, vm := range vms {
+		fmt.Fprintf(cmd, "%s %s... ", cmd.action(), vm.Reference())
 
+		task, err := cmd.do(ctx, vm)
 		if err != nil {
 			return err
 		}
@@ -202,3 +177,97 @@ func (cmd *power) Run(ctx context.Context, f *flag.FlagSet) error {
 
 	return nil
 }
+
+// action returns the progress message prefix for the selected operation
+func (cmd *power) action() string {
+	switch {
+	case cmd.On:
+		return "Powering on"
+	case cmd.Off:
+		return "Powering off"
+	case cmd.Reset:
+		return "Reset"
+	case cmd.Suspend:
+		return "Suspend"
+	case cmd.Reboot:
+		return "Reboot guest"
+	case cmd.Shutdown:
+		return "Shutdown guest"
+	default:
+		return "Standby guest"
+	}
+}
+
+// do starts the selected operation, the returned task is nil for guest operations
+func (cmd *power) do(ctx context.Context, vm *object.VirtualMachine) (*object.Task, error) {
+	var task *object.Task
+	var err error
+
+	switch {
+	case cmd.On:
+		task, err = vm.PowerOn(ctx)
+	case cmd.Off:
+		task, err = vm.PowerOff(ctx)
+	case cmd.Reset:
+		task, err = vm.Reset(ctx)
+	case cmd.Suspend:
+		task, err = vm.Suspend(ctx)
+	case cmd.Reboot:
+		err = vm.RebootGuest(ctx)
+
+		if err != nil && cmd.Force && isToolsUnavailable(err) {
+			task, err = vm.Reset(ctx)
+		}
+	case cmd.Shutdown:
+		err = vm.ShutdownGuest(ctx)
+
+		if err != nil && cmd.Force && isToolsUnavailable(err) {
+			task, err = vm.PowerOff(ctx)
+		}
+	case cmd.Standby:
+		err = vm.StandbyGuest(ctx)
+
+		if err != nil && cmd.Force && isToolsUnavailable(err) {
+			task, err = vm.Suspend(ctx)
+		}
+	}
+
+	return task, err
+}
+
+// parallel runs the selected operation using the bulk engine
+func (cmd *power) parallel(ctx context.Context, vms []*object.VirtualMachine) error {
+	c, err := cmd.Client()
+	if err != nil {
+		return err
+	}
+
+	e := bulk.NewEngine(c)
+	e.Concurrency = cmd.Parallel
+	e.OnResult = func(res bulk.Result) {
+		msg := "OK"
+		if res.Err != nil {
+			msg = fmt.Sprintf("Error: %s", res.Err)
+		}
+		fmt.Fprintf(cmd, "%s %s... %s\n", cmd.action(), res.VM.Reference(), msg)
+	}
+
+	res, err := e.Run(ctx, vms, cmd.do)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Force {
+		// as with the sequential loop, only task errors are ignored
+		var errs bulk.Results
+		for _, r := range res {
+			var terr task.Error
+			if r.Err != nil && !errors.As(r.Err, &terr) {
+				errs = append(errs, r)
+			}
+		}
+		res = errs
+	}
+
+	return res.Err()
+}
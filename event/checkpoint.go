@@ -0,0 +1,117 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Checkpoint is the position of the last processed event in a Stream.
+type Checkpoint struct {
+	Key         int32     `json:"key"`
+	CreatedTime time.Time `json:"createdTime"`
+}
+
+// NewCheckpoint returns the Checkpoint for the given event.
+func NewCheckpoint(event types.BaseEvent) Checkpoint {
+	e := event.GetEvent()
+	return Checkpoint{Key: e.Key, CreatedTime: e.CreatedTime}
+}
+
+// CheckpointStore persists the Checkpoint of a Stream.
+type CheckpointStore interface {
+	// Load returns the saved Checkpoint, or nil if none has been saved.
+	Load(context.Context) (*Checkpoint, error)
+	// Save persists the given Checkpoint.
+	Save(context.Context, Checkpoint) error
+}
+
+// FileCheckpoint is a CheckpointStore that saves the Checkpoint as JSON in the file at Path.
+type FileCheckpoint struct {
+	Path string
+}
+
+// Load implements CheckpointStore.
+func (f FileCheckpoint) Load(_ context.Context) (*Checkpoint, error) {
+	b, err := os.ReadFile(f.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var c Checkpoint
+	if err = json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Save implements CheckpointStore.
+// The Checkpoint is written to a temporary file that is then renamed to Path,
+// such that a partial write does not corrupt an existing checkpoint.
+func (f FileCheckpoint) Save(_ context.Context, c Checkpoint) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.Path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// MemoryCheckpoint is a CheckpointStore that keeps the Checkpoint in memory.
+type MemoryCheckpoint struct {
+	mu sync.Mutex
+	c  *Checkpoint
+}
+
+// Load implements CheckpointStore.
+func (m *MemoryCheckpoint) Load(_ context.Context) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.c == nil {
+		return nil, nil
+	}
+	c := *m.c
+	return &c, nil
+}
+
+// Save implements CheckpointStore.
+func (m *MemoryCheckpoint) Save(_ context.Context, c Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.c = &c
+	return nil
+}
@@ -0,0 +1,205 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package event
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// StreamSpec configures a Stream.
+type StreamSpec struct {
+	// Filter selects the events to stream.
+	Filter types.EventFilterSpec
+
+	// PageSize is the maximum number of events read per ReadNextEvents call, defaults to 100.
+	PageSize int32
+
+	// Follow continues streaming new events after the existing events have been streamed,
+	// until the context is canceled. Otherwise, the Stream ends after the existing events.
+	Follow bool
+
+	// Checkpoint, if set, is used to resume the Stream after the last committed event.
+	Checkpoint CheckpointStore
+
+	// AutoCommit commits each event as soon as it has been received from the Stream channel.
+	// Otherwise, Stream.Commit should be called once an event has been processed.
+	AutoCommit bool
+}
+
+// Stream emits events on a channel, oldest first, optionally resuming from a Checkpoint.
+// Event keys are assumed to increase monotonically, which is used to deduplicate events
+// across collector pages and when resuming from a Checkpoint.
+type Stream struct {
+	spec   StreamSpec
+	events chan types.BaseEvent
+
+	mu   sync.Mutex
+	err  error
+	last int32 // key of the last committed event
+}
+
+// Stream creates an EventHistoryCollector using the given spec and starts streaming events.
+// If spec.Checkpoint has a saved Checkpoint, the Filter's BeginTime is set to the Checkpoint
+// CreatedTime and events up to and including the Checkpoint Key are skipped.
+// The Stream ends when the context is canceled, or after the existing events if spec.Follow is false.
+func (m Manager) Stream(ctx context.Context, spec StreamSpec) (*Stream, error) {
+	if spec.PageSize <= 0 {
+		spec.PageSize = 100
+	}
+
+	s := &Stream{
+		spec:   spec,
+		events: make(chan types.BaseEvent),
+		last:   invalidKey,
+	}
+
+	filter := spec.Filter
+
+	if spec.Checkpoint != nil {
+		c, err := spec.Checkpoint.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if c != nil {
+			s.last = c.Key
+
+			t := types.EventFilterSpecByTime{}
+			if filter.Time != nil {
+				t = *filter.Time
+			}
+			if t.BeginTime == nil || t.BeginTime.Before(c.CreatedTime) {
+				t.BeginTime = &c.CreatedTime
+			}
+			filter.Time = &t
+		}
+	}
+
+	collector, err := m.CreateCollectorForEvents(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// a new collector is positioned at the oldest event, ReadNextEvents pages forward from there
+	if err = collector.SetPageSize(ctx, spec.PageSize); err != nil {
+		_ = collector.Destroy(context.Background())
+		return nil, err
+	}
+
+	go s.run(ctx, m, collector)
+
+	return s, nil
+}
+
+// Events returns the channel on which events are emitted, which is closed when the Stream ends.
+func (s *Stream) Events() <-chan types.BaseEvent {
+	return s.events
+}
+
+// Err returns the error that ended the Stream, if any, other than cancellation of the Stream context.
+// Err should be called after the Events channel is closed.
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Commit saves the Checkpoint of the given event, which has been processed.
+// Commit is a no-op if StreamSpec.Checkpoint is nil, or if a later event has already been committed.
+func (s *Stream) Commit(ctx context.Context, event types.BaseEvent) error {
+	if s.spec.Checkpoint == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := NewCheckpoint(event)
+	if s.last != invalidKey && c.Key <= s.last {
+		return nil
+	}
+
+	if err := s.spec.Checkpoint.Save(ctx, c); err != nil {
+		return err
+	}
+
+	s.last = c.Key
+
+	return nil
+}
+
+func (s *Stream) run(ctx context.Context, m Manager, collector *HistoryCollector) {
+	defer close(s.events)
+
+	defer func() {
+		_ = collector.Destroy(context.Background())
+	}()
+
+	s.mu.Lock()
+	last := s.last // key of the last emitted event
+	s.mu.Unlock()
+
+	// drain emits all events that have not yet been read from the collector
+	drain := func() error {
+		for {
+			events, err := collector.ReadNextEvents(ctx, s.spec.PageSize)
+			if err != nil {
+				return err
+			}
+
+			if len(events) == 0 {
+				return nil
+			}
+
+			for _, event := range events {
+				key := event.GetEvent().Key
+				if last != invalidKey && key <= last {
+					continue
+				}
+				last = key
+
+				select {
+				case s.events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+
+				if s.spec.AutoCommit {
+					if err = s.Commit(ctx, event); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	err := drain()
+
+	if err == nil && s.spec.Follow {
+		pc := property.DefaultCollector(m.Client())
+
+		var derr error
+		err = property.Wait(ctx, pc, collector.Reference(), []string{"latestPage"}, func([]types.PropertyChange) bool {
+			derr = drain()
+			return derr != nil
+		})
+		if err == nil {
+			err = derr
+		}
+	}
+
+	if ctx.Err() != nil {
+		err = nil
+	}
+
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
@@ -0,0 +1,192 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package event_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func streamKeys(t *testing.T, s *event.Stream) []int32 {
+	var keys []int32
+	for e := range s.Events() {
+		keys = append(keys, e.GetEvent().Key)
+	}
+	require.NoError(t, s.Err())
+	return keys
+}
+
+func TestStreamResume(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		m := event.NewManager(c)
+
+		s, err := m.Stream(ctx, event.StreamSpec{PageSize: 7})
+		require.NoError(t, err)
+		all := streamKeys(t, s)
+		require.NotEmpty(t, all)
+		assert.IsIncreasing(t, all)
+
+		// process some of the events, then stop
+		store := new(event.MemoryCheckpoint)
+		n := len(all) / 2
+
+		sctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		s, err = m.Stream(sctx, event.StreamSpec{PageSize: 7, Checkpoint: store})
+		require.NoError(t, err)
+
+		var keys []int32
+		for e := range s.Events() {
+			keys = append(keys, e.GetEvent().Key)
+			require.NoError(t, s.Commit(ctx, e))
+			if len(keys) == n {
+				cancel()
+				break
+			}
+		}
+		for range s.Events() {
+			// drain until closed
+		}
+		require.NoError(t, s.Err())
+
+		cp, err := store.Load(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, cp)
+		assert.Equal(t, keys[n-1], cp.Key)
+
+		// resume where we left off: no events are missed or delivered twice
+		s, err = m.Stream(ctx, event.StreamSpec{PageSize: 7, Checkpoint: store, AutoCommit: true})
+		require.NoError(t, err)
+		keys = append(keys, streamKeys(t, s)...)
+		assert.Equal(t, all, keys)
+
+		cp, err = store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, all[len(all)-1], cp.Key)
+	})
+}
+
+func TestStreamFollow(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		m := event.NewManager(c)
+		store := event.FileCheckpoint{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+		checkpoint, err := store.Load(ctx)
+		require.NoError(t, err)
+		require.Nil(t, checkpoint)
+
+		s, err := m.Stream(ctx, event.StreamSpec{Checkpoint: store, AutoCommit: true})
+		require.NoError(t, err)
+		history := streamKeys(t, s)
+		require.NotEmpty(t, history)
+
+		sctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		s, err = m.Stream(sctx, event.StreamSpec{PageSize: 2, Checkpoint: store, AutoCommit: true, Follow: true})
+		require.NoError(t, err)
+
+		var posted []string
+
+		for i := 0; i < 5; i++ {
+			msg := time.Now().String()
+			posted = append(posted, msg)
+			require.NoError(t, m.PostEvent(ctx, &types.GeneralEvent{Message: msg}))
+		}
+
+		var received []string
+		var last types.BaseEvent
+
+		for e := range s.Events() {
+			last = e
+			if x, ok := e.(*types.GeneralEvent); ok {
+				received = append(received, x.Message)
+			}
+			if len(received) == len(posted) {
+				cancel()
+			}
+		}
+		require.NoError(t, s.Err())
+		assert.Equal(t, posted, received)
+
+		checkpoint, err = store.Load(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, checkpoint)
+		assert.Equal(t, last.GetEvent().Key, checkpoint.Key)
+		assert.True(t, last.GetEvent().CreatedTime.Equal(checkpoint.CreatedTime))
+
+		// nothing new since the checkpoint
+		s, err = m.Stream(ctx, event.StreamSpec{Checkpoint: store})
+		require.NoError(t, err)
+		assert.Empty(t, streamKeys(t, s))
+	})
+}
+
+// failCheckpoint loads from the embedded store, failing to save
+type failCheckpoint struct {
+	*event.MemoryCheckpoint
+	err error
+}
+
+func (f failCheckpoint) Save(context.Context, event.Checkpoint) error {
+	return f.err
+}
+
+// waiting closes ch when the first WaitForUpdatesEx call is made
+type waiting struct {
+	soap.RoundTripper
+	once sync.Once
+	ch   chan struct{}
+}
+
+func (w *waiting) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	if _, ok := req.(*methods.WaitForUpdatesExBody); ok {
+		w.once.Do(func() { close(w.ch) })
+	}
+	return w.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+func TestStreamFollowCommitError(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		m := event.NewManager(c)
+		store := new(event.MemoryCheckpoint)
+
+		s, err := m.Stream(ctx, event.StreamSpec{Checkpoint: store, AutoCommit: true})
+		require.NoError(t, err)
+		require.NotEmpty(t, streamKeys(t, s))
+
+		w := &waiting{RoundTripper: c.RoundTripper, ch: make(chan struct{})}
+		c.RoundTripper = w
+
+		errSave := errors.New("save failed")
+		s, err = m.Stream(ctx, event.StreamSpec{Checkpoint: failCheckpoint{store, errSave}, AutoCommit: true, Follow: true})
+		require.NoError(t, err)
+
+		// the first new event is read while following and fails to commit, ending the stream with the Save error
+		<-w.ch
+		require.NoError(t, m.PostEvent(ctx, &types.GeneralEvent{Message: "commit"}))
+
+		var received []types.BaseEvent
+		for e := range s.Events() {
+			received = append(received, e)
+		}
+		assert.Len(t, received, 1)
+		assert.ErrorIs(t, s.Err(), errSave)
+	})
+}
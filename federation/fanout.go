@@ -0,0 +1,125 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/view"
+)
+
+// Result is a value tagged with the Member it originated from.
+type Result[T any] struct {
+	Endpoint     string `json:"endpoint"`     // Endpoint is the Member name
+	InstanceUUID string `json:"instanceUuid"` // InstanceUUID is the Member's vCenter instance UUID
+	Value        T      `json:"value"`
+}
+
+// MemberError is returned by fan-out functions for each Member that failed.
+type MemberError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *MemberError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Endpoint, e.Err)
+}
+
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// Each calls fn concurrently for each Member of the Pool, returning the results in Member name order.
+// Results are returned for members that succeed, the returned error joins a MemberError for each Member that failed.
+func Each[T any](ctx context.Context, p *Pool, fn func(context.Context, *Member) ([]T, error)) ([]Result[T], error) {
+	members := p.Members()
+	values := make([][]T, len(members))
+	errs := make([]error, len(members))
+
+	var limit chan struct{}
+	if p.Concurrency > 0 {
+		limit = make(chan struct{}, p.Concurrency)
+	}
+
+	var wg sync.WaitGroup
+
+	for i, m := range members {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if limit != nil {
+				select {
+				case limit <- struct{}{}:
+					defer func() { <-limit }()
+				case <-ctx.Done():
+					errs[i] = &MemberError{m.Name, ctx.Err()}
+					return
+				}
+			}
+
+			val, err := fn(ctx, m)
+			if err != nil {
+				errs[i] = &MemberError{m.Name, err}
+				return
+			}
+			values[i] = val
+		}()
+	}
+
+	wg.Wait()
+
+	var results []Result[T]
+
+	for i, m := range members {
+		for _, val := range values[i] {
+			results = append(results, Result[T]{
+				Endpoint:     m.Name,
+				InstanceUUID: m.InstanceUUID(),
+				Value:        val,
+			})
+		}
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// Find calls fn with a find.Finder for each Member of the Pool, see Each.
+// Example:
+//
+//	vms, err := federation.Find(ctx, pool, func(ctx context.Context, f *find.Finder) ([]*object.VirtualMachine, error) {
+//		return f.VirtualMachineList(ctx, "/*/vm/...")
+//	})
+func Find[T any](ctx context.Context, p *Pool, fn func(context.Context, *find.Finder) ([]T, error)) ([]Result[T], error) {
+	return Each(ctx, p, func(ctx context.Context, m *Member) ([]T, error) {
+		return fn(ctx, find.NewFinder(m.Client.Client))
+	})
+}
+
+// Retrieve retrieves the given properties of all managed objects of the given kind for each Member of the Pool,
+// using a recursive ContainerView of the root Folder. The dst type T is typically an mo type, such as mo.VirtualMachine.
+// See Each.
+func Retrieve[T any](ctx context.Context, p *Pool, kind []string, ps []string) ([]Result[T], error) {
+	return Each(ctx, p, func(ctx context.Context, m *Member) ([]T, error) {
+		c := m.Client.Client
+
+		v, err := view.NewManager(c).CreateContainerView(ctx, c.ServiceContent.RootFolder, kind, true)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			_ = v.Destroy(ctx)
+		}()
+
+		var dst []T
+		err = v.Retrieve(ctx, kind, ps, &dst)
+		return dst, err
+	})
+}
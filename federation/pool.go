@@ -0,0 +1,268 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package federation provides a pool of authenticated clients for multiple vCenter endpoints,
+with helpers to fan out finder queries and property retrieval across all of them.
+*/
+package federation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/lookup"
+	"github.com/vmware/govmomi/lookup/types"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/session/cache"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Member is an authenticated client for a Pool endpoint.
+type Member struct {
+	Name   string          // Name is the endpoint key, the URL Host
+	URL    *url.URL        // URL of the endpoint, with password removed
+	Client *govmomi.Client // Client is the authenticated client
+
+	session cache.Session
+}
+
+// InstanceUUID returns the vCenter instance UUID of the Member.
+func (m *Member) InstanceUUID() string {
+	return m.Client.ServiceContent.About.InstanceUuid
+}
+
+// Pool owns a set of authenticated clients, keyed by endpoint.
+type Pool struct {
+	// Session is used as a template for each endpoint's session, for example to set the cache directory,
+	// Insecure, Passthrough or LoginSOAP fields. The Session.URL field is set per endpoint.
+	Session cache.Session
+
+	// Config, if set, is applied to each endpoint's soap.Client, such as TLS settings.
+	Config func(*soap.Client) error
+
+	// Concurrency limits the number of concurrent calls made by fan-out functions, zero is unlimited.
+	Concurrency int
+
+	mu      sync.Mutex
+	members map[string]*Member
+	logins  map[string]*pending
+}
+
+// pending is an endpoint login in progress, such that concurrent Add calls for the same endpoint
+// wait for a single login rather than holding the Pool lock during the login.
+type pending struct {
+	done chan struct{}
+	m    *Member
+	err  error
+}
+
+// NewPool returns an empty Pool.
+func NewPool() *Pool {
+	return &Pool{members: make(map[string]*Member)}
+}
+
+// key returns the Pool key for the given URL
+func key(u *url.URL) string {
+	return strings.ToLower(u.Host)
+}
+
+// Add returns the Member for the given URL, creating an authenticated session if the endpoint is not yet in the pool.
+// Sessions are created using cache.Session.Login, such that cached sessions are reused when valid.
+func (p *Pool) Add(ctx context.Context, u *url.URL) (*Member, error) {
+	return p.add(ctx, u, p.Config)
+}
+
+func (p *Pool) add(ctx context.Context, u *url.URL, config func(*soap.Client) error) (*Member, error) {
+	name := key(u)
+
+	p.mu.Lock()
+	if m, ok := p.members[name]; ok {
+		p.mu.Unlock()
+		return m, nil
+	}
+	if l, ok := p.logins[name]; ok {
+		// wait for the login in progress
+		p.mu.Unlock()
+		select {
+		case <-l.done:
+			return l.m, l.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	l := &pending{done: make(chan struct{})}
+	if p.logins == nil {
+		p.logins = make(map[string]*pending)
+	}
+	p.logins[name] = l
+	p.mu.Unlock()
+
+	l.m, l.err = p.login(ctx, name, u, config)
+
+	p.mu.Lock()
+	if l.err == nil {
+		if p.members == nil {
+			p.members = make(map[string]*Member)
+		}
+		p.members[name] = l.m
+	}
+	delete(p.logins, name)
+	p.mu.Unlock()
+	close(l.done)
+
+	return l.m, l.err
+}
+
+// login creates an authenticated session for the given URL, without holding the Pool lock.
+func (p *Pool) login(ctx context.Context, name string, u *url.URL, config func(*soap.Client) error) (*Member, error) {
+	s := p.Session
+	s.URL = u
+
+	vc := new(vim25.Client)
+	if err := s.Login(ctx, vc, config); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return &Member{
+		Name:    name,
+		URL:     s.Endpoint(),
+		Client:  &govmomi.Client{Client: vc, SessionManager: session.NewManager(vc)},
+		session: s,
+	}, nil
+}
+
+// Member returns the Member with the given name, or nil if not found.
+func (p *Pool) Member(name string) *Member {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.members[strings.ToLower(name)]
+}
+
+// Members returns the Pool members, sorted by name.
+func (p *Pool) Members() []*Member {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	members := make([]*Member, 0, len(p.members))
+	for _, m := range p.members {
+		members = append(members, m)
+	}
+
+	slices.SortFunc(members, func(a, b *Member) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return members
+}
+
+// Remove removes the Member with the given name from the Pool.
+// The Member's session is logged out if Session.Passthrough is true, otherwise the session remains cached.
+func (p *Pool) Remove(ctx context.Context, name string) error {
+	p.mu.Lock()
+	m, ok := p.members[strings.ToLower(name)]
+	delete(p.members, strings.ToLower(name))
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return m.session.Logout(ctx, m.Client.Client)
+}
+
+// Logout removes all members from the Pool, see Remove.
+func (p *Pool) Logout(ctx context.Context) error {
+	var errs []error
+
+	for _, m := range p.Members() {
+		if err := p.Remove(ctx, m.Name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", m.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Discover adds the linked mode peers of the given Member to the Pool, using the Lookup Service
+// to find the vCenter endpoints registered in the same SSO domain.
+// Peer sessions are created with the same URL credentials as the given Member and the Pool.Session template,
+// trusting the certificate thumbprint registered with the Lookup Service.
+// Members added without a password in their URL, such as those using a cached session or a token
+// based Session.LoginSOAP func, can only log in to peers if the Pool.Session template can create a
+// new session without a password, for example using LoginSOAP with a token valid for the SSO domain.
+// Returns the Members added to the Pool, which does not include the given Member or existing members.
+func (p *Pool) Discover(ctx context.Context, m *Member) ([]*Member, error) {
+	lu, err := lookup.NewClient(ctx, m.Client.Client)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Name, err)
+	}
+
+	filter := &types.LookupServiceRegistrationFilter{
+		ServiceType: &types.LookupServiceRegistrationServiceType{
+			Product: "com.vmware.cis",
+			Type:    "vcenterserver",
+		},
+		EndpointType: &types.LookupServiceRegistrationEndpointType{
+			Protocol: "vmomi",
+			Type:     "com.vmware.vim",
+		},
+	}
+
+	info, err := lu.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m.Name, err)
+	}
+
+	var peers []*Member
+	var errs []error
+
+	for _, service := range info {
+		if service.ServiceId == m.InstanceUUID() || len(service.ServiceEndpoints) == 0 {
+			continue
+		}
+
+		endpoint := &service.ServiceEndpoints[0]
+		u, err := url.Parse(endpoint.Url)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if p.Member(key(u)) != nil {
+			continue
+		}
+
+		u.User = m.session.URL.User
+
+		thumbprint := lookup.EndpointThumbprint(endpoint)
+		config := func(c *soap.Client) error {
+			if thumbprint != "" {
+				c.SetThumbprint(u.Host, thumbprint)
+			}
+			if p.Config != nil {
+				return p.Config(c)
+			}
+			return nil
+		}
+
+		peer, err := p.add(ctx, u, config)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		peers = append(peers, peer)
+	}
+
+	return peers, errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package federation_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/federation"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/lookup"
+	lsim "github.com/vmware/govmomi/lookup/simulator"
+	"github.com/vmware/govmomi/lookup/types"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// vpx starts a vCenter simulator instance, returning the server and its lookup service registry.
+func vpx(t *testing.T, hosts int) (*simulator.Server, *simulator.Registry) {
+	model := simulator.VPX()
+	model.Host = hosts
+	model.Cluster = 0
+	require.NoError(t, model.Create())
+	t.Cleanup(model.Remove)
+
+	s := model.Service.NewServer()
+	t.Cleanup(s.Close)
+
+	sdk := lsim.New()
+	model.Service.RegisterSDK(sdk)
+
+	return s, sdk
+}
+
+func TestPool(t *testing.T) {
+	ctx := context.Background()
+
+	// s2 is created first, as the lookup service URL settings are shared by simulator instances
+	s2, _ := vpx(t, 2)
+	s1, sdk := vpx(t, 1)
+
+	p := federation.NewPool()
+	p.Session.DirSOAP = t.TempDir()
+
+	m1, err := p.Add(ctx, s1.URL)
+	require.NoError(t, err)
+	assert.Equal(t, s1.URL.Host, m1.Name)
+	_, ok := m1.URL.User.Password()
+	assert.False(t, ok)
+
+	same, err := p.Add(ctx, s1.URL)
+	require.NoError(t, err)
+	assert.Same(t, m1, same)
+
+	// register s2 as a linked mode peer of s1
+	lu, err := lookup.NewClient(ctx, m1.Client.Client)
+	require.NoError(t, err)
+	r := sdk.Get(*lu.ServiceContent.ServiceRegistration).(*lsim.ServiceRegistration)
+	for _, info := range r.Info {
+		if info.ServiceType.Type == "vcenterserver" {
+			peer := info
+			peer.ServiceId = "peer"
+			peer.ServiceEndpoints = []types.LookupServiceRegistrationEndpoint{info.ServiceEndpoints[0]}
+			u := *s2.URL
+			u.User = nil
+			peer.ServiceEndpoints[0].Url = u.String()
+			r.Info = append(r.Info, peer)
+			break
+		}
+	}
+
+	peers, err := p.Discover(ctx, m1)
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	assert.Equal(t, s2.URL.Host, peers[0].Name)
+	assert.Len(t, p.Members(), 2)
+
+	peers, err = p.Discover(ctx, m1)
+	require.NoError(t, err)
+	assert.Empty(t, peers)
+
+	// finder fan out
+	vms, err := federation.Find(ctx, p, func(ctx context.Context, f *find.Finder) ([]*object.VirtualMachine, error) {
+		return f.VirtualMachineList(ctx, "*")
+	})
+	require.NoError(t, err)
+
+	count := map[string]int{}
+	for _, vm := range vms {
+		count[vm.Endpoint]++
+		assert.Equal(t, p.Member(vm.Endpoint).Client.Client, vm.Value.Client())
+	}
+	assert.Len(t, count, 2)
+	assert.Less(t, count[s1.URL.Host], count[s2.URL.Host])
+
+	// property retrieval fan out
+	hosts, err := federation.Retrieve[mo.HostSystem](ctx, p, []string{"HostSystem"}, []string{"name"})
+	require.NoError(t, err)
+	count = map[string]int{}
+	for _, host := range hosts {
+		count[host.Endpoint]++
+		assert.NotEmpty(t, host.Value.Name)
+		assert.NotEmpty(t, host.InstanceUUID)
+	}
+	assert.Equal(t, map[string]int{s1.URL.Host: 1, s2.URL.Host: 2}, count)
+
+	// partial failure
+	fail := errors.New("fail")
+	names, err := federation.Each(ctx, p, func(ctx context.Context, m *federation.Member) ([]string, error) {
+		if m.Name == s2.URL.Host {
+			return nil, fail
+		}
+		return []string{m.Name}, nil
+	})
+	require.ErrorIs(t, err, fail)
+	var merr *federation.MemberError
+	require.ErrorAs(t, err, &merr)
+	assert.Equal(t, s2.URL.Host, merr.Endpoint)
+	require.Len(t, names, 1)
+	assert.Equal(t, s1.URL.Host, names[0].Value)
+
+	// cached session is reused
+	id := func(m *federation.Member) string {
+		s, err := m.Client.SessionManager.UserSession(ctx)
+		require.NoError(t, err)
+		return s.Key
+	}
+	key := id(m1)
+	require.NoError(t, p.Remove(ctx, m1.Name))
+	assert.Nil(t, p.Member(m1.Name))
+	m1, err = p.Add(ctx, s1.URL)
+	require.NoError(t, err)
+	assert.Equal(t, key, id(m1))
+
+	require.NoError(t, p.Logout(ctx))
+	assert.Empty(t, p.Members())
+}
+
+func TestPoolConcurrentAdd(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := vpx(t, 1)
+
+	p := federation.NewPool()
+	p.Session.DirSOAP = t.TempDir()
+
+	login := make(chan struct{})
+	unblock := make(chan struct{})
+	p.Config = func(*soap.Client) error {
+		close(login)
+		<-unblock
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	members := make([]*federation.Member, 2)
+	for i := range members {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m, err := p.Add(ctx, s.URL)
+			assert.NoError(t, err)
+			members[i] = m
+		}(i)
+	}
+
+	// the Pool is not locked during login, and the login is done once
+	<-login
+	assert.Nil(t, p.Member(s.URL.Host))
+	assert.Empty(t, p.Members())
+	close(unblock)
+
+	wg.Wait()
+	require.NotNil(t, members[0])
+	assert.Same(t, members[0], members[1])
+	assert.Same(t, members[0], p.Member(s.URL.Host))
+}
@@ -0,0 +1,763 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package find
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// QueryTag is a tag attached to an inventory object.
+type QueryTag struct {
+	Category string
+	Name     string
+}
+
+// QueryTagFunc returns the tags attached to the given objects.
+// The find package does not depend on the vAPI tagging client, callers provide the implementation,
+// typically using tags.Manager.GetAttachedTagsOnObjects.
+type QueryTagFunc func(context.Context, []types.ManagedObjectReference) (map[types.ManagedObjectReference][]QueryTag, error)
+
+// Query is a parsed inventory query, see ParseQuery.
+type Query struct {
+	Path  string       // Path is an inventory path or glob, matching the search roots
+	Limit int          // Limit is the maximum number of results, zero is unlimited
+	Tags  QueryTagFunc // Tags is required by queries that use the tag selectors
+
+	where expr
+	sort  operand
+	desc  bool
+}
+
+// ParseQuery parses an inventory query of the form:
+//
+//	[PATH] [where EXPR] [sort OPERAND [asc|desc]] [limit N]
+//
+// PATH is an inventory path or glob, as accepted by Finder.ManagedObjectList, defaulting to the root folder.
+// The objects matched by PATH and all objects they contain (recursively) are candidates for the query.
+//
+// EXPR combines comparisons with "and", "or", "not" and parentheses.
+// A comparison is "OPERAND OP VALUE", where OP is one of: = != < <= > >=
+// OPERAND is a property path, such as "runtime.powerState", optionally combined with numbers
+// using the '*' and '/' operators, such as "summary.freeSpace / summary.capacity".
+// VALUE is a word or quoted string, compared numerically when the property value is a number.
+// The = and != operators match strings using path.Match patterns.
+// When the property value is an array, the comparison is true if any element matches.
+//
+// The following selectors can be used in place of a property path:
+//
+//	type           the managed object type, such as VirtualMachine
+//	tag            the name of any attached tag
+//	tag.CATEGORY   the name of any attached tag in the given category
+//	field.NAME     the value of the custom field with the given name
+//
+// A reference property can be followed with "->" to match properties of the referenced objects,
+// such that "datastore->(summary.freeSpace / summary.capacity < 0.1)" is true if any datastore
+// used by a VirtualMachine has less than 10% free space.
+//
+// Where possible, the query is compiled into PropertyCollector specs: "type = NAME" comparisons
+// at the top level of EXPR select the ContainerView types and only the properties used by the query
+// are retrieved. The rest of EXPR is evaluated client side.
+func ParseQuery(s string) (*Query, error) {
+	q := new(Query)
+	p := &queryParser{s: s}
+
+	if err := p.parse(q); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// kinds returns the types implied by "type = NAME" comparisons, if the query can only match those types
+func (q *Query) kinds() []string {
+	var implied func(x expr) []string
+	implied = func(x expr) []string {
+		switch x := x.(type) {
+		case *andExpr:
+			if kinds := implied(x.x); kinds != nil {
+				return kinds
+			}
+			return implied(x.y)
+		case *orExpr:
+			a, b := implied(x.x), implied(x.y)
+			if a == nil || b == nil {
+				return nil
+			}
+			return append(a, b...)
+		case *cmpExpr:
+			if x.lhs == propOperand("type") && x.op == "=" && !strings.ContainsAny(x.val, "*?[\\") {
+				return []string{x.val}
+			}
+		}
+		return nil
+	}
+
+	kinds := implied(q.where)
+	slices.Sort(kinds)
+	return slices.Compact(kinds)
+}
+
+// selectors returns true if the tag or custom field selectors are used by x, including within "->" scopes
+func selectors(x expr) (tags bool, fields bool) {
+	var s scope
+	var walk func(x expr)
+	walk = func(x expr) {
+		switch x := x.(type) {
+		case *orExpr:
+			walk(x.x)
+			walk(x.y)
+		case *andExpr:
+			walk(x.x)
+			walk(x.y)
+		case *notExpr:
+			walk(x.x)
+		case *refExpr:
+			walk(x.x)
+		case *cmpExpr:
+			s.operand(x.lhs)
+		}
+	}
+	walk(x)
+	return s.tags, s.fields
+}
+
+// scope collects the property paths used by x, excluding those within refExpr scopes
+type scope struct {
+	paths  []string
+	refs   []*refExpr
+	tags   bool
+	fields bool
+}
+
+func (s *scope) add(path string) {
+	if !slices.Contains(s.paths, path) {
+		s.paths = append(s.paths, path)
+	}
+}
+
+func (s *scope) operand(x operand) {
+	switch x := x.(type) {
+	case propOperand:
+		name := string(x)
+		switch {
+		case name == "type":
+		case name == "tag", strings.HasPrefix(name, "tag."):
+			s.tags = true
+		case strings.HasPrefix(name, "field."):
+			s.fields = true
+			s.add("customValue")
+		default:
+			s.add(name)
+		}
+	case *arithOperand:
+		s.operand(x.x)
+		s.operand(x.y)
+	}
+}
+
+func (s *scope) expr(x expr) {
+	switch x := x.(type) {
+	case *orExpr:
+		s.expr(x.x)
+		s.expr(x.y)
+	case *andExpr:
+		s.expr(x.x)
+		s.expr(x.y)
+	case *notExpr:
+		s.expr(x.x)
+	case *refExpr:
+		s.add(x.path)
+		s.refs = append(s.refs, x)
+	case *cmpExpr:
+		s.operand(x.lhs)
+	}
+}
+
+// queryEval holds the properties, tags and custom field definitions used to evaluate a Query
+type queryEval struct {
+	pc     *property.Collector
+	props  map[types.ManagedObjectReference]map[string]any
+	tags   map[types.ManagedObjectReference][]QueryTag
+	fields map[string]int32
+
+	tagged []types.ManagedObjectReference
+	seen   map[types.ManagedObjectReference]bool
+}
+
+// tag adds the given objects to the list of objects to retrieve attached tags for
+func (e *queryEval) tag(refs []types.ManagedObjectReference) {
+	for _, ref := range refs {
+		if !e.seen[ref] {
+			e.seen[ref] = true
+			e.tagged = append(e.tagged, ref)
+		}
+	}
+}
+
+// hasProperty returns false if the given managed object type does not have the top-level property of path
+func hasProperty(kind string, path string) bool {
+	name, _, _ := strings.Cut(path, ".")
+	name, _, _ = strings.Cut(name, "[")
+
+	val, ok := mo.Value(types.ManagedObjectReference{Type: kind})
+	if !ok {
+		return true // let the server decide
+	}
+
+	var find func(t reflect.Type) bool
+	find = func(t reflect.Type) bool {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.Anonymous {
+				if find(f.Type) {
+					return true
+				}
+				continue
+			}
+			tag, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if tag == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	return find(reflect.TypeOf(val))
+}
+
+// propSpec returns a PropertySpec for each of the given types, including the paths that are valid for the type
+func propSpec(kinds []string, paths []string) []types.PropertySpec {
+	var specs []types.PropertySpec
+
+	for _, kind := range kinds {
+		spec := types.PropertySpec{Type: kind, PathSet: []string{"name"}}
+		for _, p := range paths {
+			if p != "name" && hasProperty(kind, p) {
+				spec.PathSet = append(spec.PathSet, p)
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	return specs
+}
+
+func (e *queryEval) add(content []types.ObjectContent) {
+	for _, o := range content {
+		props, ok := e.props[o.Obj]
+		if !ok {
+			props = make(map[string]any)
+			e.props[o.Obj] = props
+		}
+		for _, p := range o.PropSet {
+			props[p.Name] = p.Val
+		}
+	}
+}
+
+// load retrieves the given property paths for the given objects
+func (e *queryEval) load(ctx context.Context, refs []types.ManagedObjectReference, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	var objs []types.ObjectSpec
+	var kinds []string
+
+	for _, ref := range refs {
+		objs = append(objs, types.ObjectSpec{Obj: ref})
+		if !slices.Contains(kinds, ref.Type) {
+			kinds = append(kinds, ref.Type)
+		}
+	}
+
+	if len(objs) == 0 {
+		return nil
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{{
+			ObjectSet: objs,
+			PropSet:   propSpec(kinds, paths),
+		}},
+	}
+
+	res, err := e.pc.RetrieveProperties(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	e.add(res.Returnval)
+
+	return nil
+}
+
+// prefetch loads the properties used by x for the given objects, then the properties of objects referenced via "->"
+func (e *queryEval) prefetch(ctx context.Context, refs []types.ManagedObjectReference, x expr, loaded bool) error {
+	var s scope
+	s.expr(x)
+
+	if !loaded {
+		if err := e.load(ctx, refs, s.paths); err != nil {
+			return err
+		}
+	}
+
+	if s.tags {
+		e.tag(refs)
+	}
+
+	for _, r := range s.refs {
+		var targets []types.ManagedObjectReference
+		seen := make(map[types.ManagedObjectReference]bool)
+		for _, ref := range refs {
+			for _, val := range flatten(e.props[ref][r.path]) {
+				if target, ok := val.(types.ManagedObjectReference); ok && !seen[target] {
+					seen[target] = true
+					targets = append(targets, target)
+				}
+			}
+		}
+
+		if err := e.prefetch(ctx, targets, r.x, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// containerKinds are the types that can be used as a ContainerView root
+var containerKinds = []string{
+	"Folder", "Datacenter", "ComputeResource", "ClusterComputeResource", "ResourcePool", "VirtualApp", "HostSystem",
+}
+
+// Query returns the objects matching the given Query.
+// The ObjectContent PropSet of each result includes the "name" property and any properties used by the query.
+func (f *Finder) Query(ctx context.Context, q *Query) ([]types.ObjectContent, error) {
+	var roots []types.ManagedObjectReference
+
+	if q.Path == "" {
+		roots = append(roots, f.client.ServiceContent.RootFolder)
+	} else {
+		l, err := f.ManagedObjectList(ctx, q.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(l) == 0 {
+			return nil, &NotFoundError{"object", q.Path}
+		}
+		for _, e := range l {
+			roots = append(roots, e.Object.Reference())
+		}
+	}
+
+	kinds := q.kinds()
+
+	var s scope
+	if q.where != nil {
+		s.expr(q.where)
+	}
+	if q.sort != nil {
+		s.operand(q.sort)
+	}
+
+	tags, fields := selectors(q.where)
+	tags = tags || s.tags
+	fields = fields || s.fields
+
+	if tags && q.Tags == nil {
+		return nil, fmt.Errorf("query: tag selector requires Query.Tags")
+	}
+
+	m := view.NewManager(f.client)
+	var objs []types.ObjectSpec
+
+	for _, root := range roots {
+		objs = append(objs, types.ObjectSpec{Obj: root})
+
+		if !slices.Contains(containerKinds, root.Type) {
+			continue
+		}
+
+		v, err := m.CreateContainerView(ctx, root, kinds, true)
+		if err != nil {
+			return nil, err
+		}
+
+		defer func() {
+			_ = v.Destroy(ctx)
+		}()
+
+		objs = append(objs, types.ObjectSpec{
+			Obj:  v.Reference(),
+			Skip: types.NewBool(true),
+			SelectSet: []types.BaseSelectionSpec{
+				&types.TraversalSpec{
+					Type: v.Reference().Type,
+					Path: "view",
+				},
+			},
+		})
+	}
+
+	// With known types, the query's properties are retrieved along with the objects.
+	// Otherwise, only names are retrieved here and the properties are loaded per type below.
+	pspec := propSpec(kinds, s.paths)
+	if len(kinds) == 0 {
+		pspec = propSpec([]string{"ManagedEntity"}, nil)
+	}
+
+	e := &queryEval{
+		pc:    property.DefaultCollector(f.client),
+		props: make(map[types.ManagedObjectReference]map[string]any),
+		seen:  make(map[types.ManagedObjectReference]bool),
+	}
+
+	req := types.RetrieveProperties{
+		SpecSet: []types.PropertyFilterSpec{{
+			ObjectSet: objs,
+			PropSet:   pspec,
+		}},
+	}
+
+	res, err := e.pc.RetrieveProperties(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	e.add(res.Returnval)
+
+	var refs []types.ManagedObjectReference
+	seen := make(map[types.ManagedObjectReference]bool)
+	for _, o := range res.Returnval {
+		if !seen[o.Obj] {
+			seen[o.Obj] = true
+			refs = append(refs, o.Obj)
+		}
+	}
+
+	if len(kinds) == 0 && len(s.paths) != 0 {
+		if err = e.load(ctx, refs, s.paths); err != nil {
+			return nil, err
+		}
+	}
+
+	if q.where != nil {
+		if err = e.prefetch(ctx, refs, q.where, true); err != nil {
+			return nil, err
+		}
+	}
+	if s.tags {
+		e.tag(refs)
+	}
+
+	if len(e.tagged) != 0 {
+		if e.tags, err = q.Tags(ctx, e.tagged); err != nil {
+			return nil, err
+		}
+	}
+
+	if fields {
+		m, err := object.GetCustomFieldsManager(f.client)
+		if err != nil {
+			return nil, err
+		}
+		defs, err := m.Field(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.fields = make(map[string]int32, len(defs))
+		for _, def := range defs {
+			e.fields[def.Name] = def.Key
+		}
+	}
+
+	var matches []types.ManagedObjectReference
+	for _, ref := range refs {
+		if q.where == nil || q.where.eval(e, ref) {
+			matches = append(matches, ref)
+		}
+	}
+
+	if q.sort != nil {
+		key := make(map[types.ManagedObjectReference]any, len(matches))
+		for _, ref := range matches {
+			if vals := q.sort.values(e, ref); len(vals) != 0 {
+				key[ref] = vals[0]
+			}
+		}
+
+		slices.SortStableFunc(matches, func(a, b types.ManagedObjectReference) int {
+			x, xok := key[a]
+			y, yok := key[b]
+			switch {
+			case !xok && !yok:
+				return 0
+			case !xok:
+				return 1 // missing values sort last, regardless of order
+			case !yok:
+				return -1
+			}
+			c := compare(x, y)
+			if q.desc {
+				c = -c
+			}
+			return c
+		})
+	}
+
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+
+	content := make([]types.ObjectContent, 0, len(matches))
+	for _, ref := range matches {
+		o := types.ObjectContent{Obj: ref}
+		props := e.props[ref]
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			o.PropSet = append(o.PropSet, types.DynamicProperty{Name: name, Val: props[name]})
+		}
+		content = append(content, o)
+	}
+
+	return content, nil
+}
+
+// flatten returns the elements of an ArrayOf* value, or the value itself
+func flatten(val any) []any {
+	if val == nil {
+		return nil
+	}
+
+	rval := reflect.ValueOf(val)
+	if rval.Kind() == reflect.Struct && strings.HasPrefix(rval.Type().Name(), "ArrayOf") && rval.NumField() == 1 {
+		field := rval.Field(0)
+		vals := make([]any, field.Len())
+		for i := range vals {
+			vals[i] = field.Index(i).Interface()
+		}
+		return vals
+	}
+
+	return []any{val}
+}
+
+// scalar converts a property value to a float64, bool, time.Time or string
+func scalar(val any) any {
+	switch v := val.(type) {
+	case float64, bool, string, time.Time:
+		return v
+	case types.ManagedObjectReference:
+		return v.Value
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	rval := reflect.ValueOf(val)
+	switch rval.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rval.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rval.Uint())
+	case reflect.Float32:
+		return rval.Float()
+	case reflect.String:
+		return rval.String() // enum types
+	}
+
+	return fmt.Sprint(val)
+}
+
+func (x orExpr) eval(e *queryEval, obj types.ManagedObjectReference) bool {
+	return x.x.eval(e, obj) || x.y.eval(e, obj)
+}
+
+func (x andExpr) eval(e *queryEval, obj types.ManagedObjectReference) bool {
+	return x.x.eval(e, obj) && x.y.eval(e, obj)
+}
+
+func (x notExpr) eval(e *queryEval, obj types.ManagedObjectReference) bool {
+	return !x.x.eval(e, obj)
+}
+
+func (x refExpr) eval(e *queryEval, obj types.ManagedObjectReference) bool {
+	for _, val := range flatten(e.props[obj][x.path]) {
+		if ref, ok := val.(types.ManagedObjectReference); ok && x.x.eval(e, ref) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x cmpExpr) eval(e *queryEval, obj types.ManagedObjectReference) bool {
+	op := x.op
+	if op == "!=" {
+		op = "="
+	}
+
+	match := slices.ContainsFunc(x.lhs.values(e, obj), func(val any) bool {
+		return compareOp(op, val, x.val)
+	})
+
+	if x.op == "!=" {
+		return !match
+	}
+	return match
+}
+
+func (x propOperand) values(e *queryEval, obj types.ManagedObjectReference) []any {
+	name := string(x)
+
+	switch {
+	case name == "type":
+		return []any{obj.Type}
+	case name == "tag", strings.HasPrefix(name, "tag."):
+		category, filter := strings.CutPrefix(name, "tag.")
+		var vals []any
+		for _, tag := range e.tags[obj] {
+			if !filter || tag.Category == category {
+				vals = append(vals, tag.Name)
+			}
+		}
+		return vals
+	case strings.HasPrefix(name, "field."):
+		key, ok := e.fields[strings.TrimPrefix(name, "field.")]
+		if !ok {
+			return nil
+		}
+		for _, val := range flatten(e.props[obj]["customValue"]) {
+			if v, ok := val.(*types.CustomFieldStringValue); ok && v.Key == key {
+				return []any{v.Value}
+			}
+		}
+		return nil
+	}
+
+	var vals []any
+	for _, val := range flatten(e.props[obj][name]) {
+		vals = append(vals, scalar(val))
+	}
+	return vals
+}
+
+func (x numOperand) values(*queryEval, types.ManagedObjectReference) []any {
+	return []any{float64(x)}
+}
+
+func (x arithOperand) values(e *queryEval, obj types.ManagedObjectReference) []any {
+	var vals []any
+
+	for _, a := range x.x.values(e, obj) {
+		for _, b := range x.y.values(e, obj) {
+			fa, aok := a.(float64)
+			fb, bok := b.(float64)
+			if !aok || !bok {
+				continue
+			}
+			switch x.op {
+			case '*':
+				vals = append(vals, fa*fb)
+			case '/':
+				if fb != 0 {
+					vals = append(vals, fa/fb)
+				}
+			}
+		}
+	}
+
+	return vals
+}
+
+// compare orders values of the same scalar type, falling back to string comparison
+func compare(a, b any) int {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// compareOp compares a property value with the given query VALUE
+func compareOp(op string, val any, s string) bool {
+	var c int
+
+	switch v := val.(type) {
+	case float64:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return false
+		}
+		c = compare(v, n)
+	case bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil || op != "=" {
+			return false
+		}
+		return v == b
+	case time.Time:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return false
+		}
+		c = v.Compare(t)
+	default:
+		str := fmt.Sprint(v)
+		if op == "=" {
+			if str == s {
+				return true
+			}
+			ok, _ := path.Match(s, str)
+			return ok
+		}
+		c = strings.Compare(str, s)
+	}
+
+	switch op {
+	case "=":
+		return c == 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+
+	return false
+}
@@ -0,0 +1,335 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package find
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// expr is a query predicate
+type expr interface {
+	eval(e *queryEval, obj types.ManagedObjectReference) bool
+}
+
+// operand is the left side of a comparison, which may evaluate to multiple values, such as an array property.
+type operand interface {
+	values(e *queryEval, obj types.ManagedObjectReference) []any
+}
+
+type orExpr struct{ x, y expr }
+
+type andExpr struct{ x, y expr }
+
+type notExpr struct{ x expr }
+
+// refExpr is true if any object referenced by the property path matches x
+type refExpr struct {
+	path string
+	x    expr
+}
+
+type cmpExpr struct {
+	lhs operand
+	op  string
+	val string
+}
+
+// propOperand is a property path or one of the selectors: type, tag, tag.CATEGORY, field.NAME
+type propOperand string
+
+type numOperand float64
+
+type arithOperand struct {
+	op   byte
+	x, y operand
+}
+
+type queryParser struct {
+	s   string
+	pos int
+}
+
+func (p *queryParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("query: %s (at offset %d)", fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.s) && unicode.IsSpace(rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *queryParser) eof() bool {
+	p.skipSpace()
+	return p.pos >= len(p.s)
+}
+
+func isIdent(c byte, start bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		return true
+	case c >= '0' && c <= '9', c == '.', c == '-':
+		return !start
+	}
+	return false
+}
+
+// peek returns the next identifier without consuming it
+func (p *queryParser) peek() string {
+	p.skipSpace()
+	end := p.pos
+	for end < len(p.s) && isIdent(p.s[end], end == p.pos) {
+		if p.s[end] == '-' && end+1 < len(p.s) && p.s[end+1] == '>' {
+			break
+		}
+		end++
+	}
+	return p.s[p.pos:end]
+}
+
+// keyword consumes the next identifier if it matches word
+func (p *queryParser) keyword(word string) bool {
+	if p.peek() == word {
+		p.pos += len(word)
+		return true
+	}
+	return false
+}
+
+// token consumes the given token if it is next
+func (p *queryParser) token(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) ident() (string, error) {
+	id := p.peek()
+	if id == "" {
+		return "", p.errorf("expected property name")
+	}
+	p.pos += len(id)
+	return id, nil
+}
+
+// word consumes a quoted string or a run of characters up to the next space or ')'
+func (p *queryParser) word() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return "", p.errorf("unexpected end of query")
+	}
+
+	switch q := p.s[p.pos]; q {
+	case '"', '\'':
+		end := strings.IndexByte(p.s[p.pos+1:], q)
+		if end < 0 {
+			return "", p.errorf("unterminated string")
+		}
+		s := p.s[p.pos : p.pos+end+2]
+		p.pos += len(s)
+		if q == '\'' {
+			return s[1 : len(s)-1], nil
+		}
+		return strconv.Unquote(s)
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) && !unicode.IsSpace(rune(p.s[p.pos])) && p.s[p.pos] != ')' {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected value")
+	}
+	return p.s[start:p.pos], nil
+}
+
+func (p *queryParser) parseOr() (expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &orExpr{x, y}
+	}
+	return x, nil
+}
+
+func (p *queryParser) parseAnd() (expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &andExpr{x, y}
+	}
+	return x, nil
+}
+
+func (p *queryParser) parseUnary() (expr, error) {
+	if p.keyword("not") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x}, nil
+	}
+
+	if p.token("(") {
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.token(")") {
+			return nil, p.errorf("expected ')'")
+		}
+		return x, nil
+	}
+
+	start := p.pos
+	id, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if p.token("->") {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &refExpr{id, x}, nil
+	}
+	p.pos = start
+
+	return p.parseCmp()
+}
+
+var queryOps = []string{"!=", "<=", ">=", "=", "<", ">"} // longest match first
+
+func (p *queryParser) parseCmp() (expr, error) {
+	lhs, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range queryOps {
+		if p.token(op) {
+			val, err := p.word()
+			if err != nil {
+				return nil, err
+			}
+			return &cmpExpr{lhs, op, val}, nil
+		}
+	}
+
+	return nil, p.errorf("expected comparison operator")
+}
+
+func (p *queryParser) parseOperand() (operand, error) {
+	x, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.s) || (p.s[p.pos] != '*' && p.s[p.pos] != '/') {
+			return x, nil
+		}
+		op := p.s[p.pos]
+		p.pos++
+		y, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		x = &arithOperand{op, x, y}
+	}
+}
+
+func (p *queryParser) parseTerm() (operand, error) {
+	p.skipSpace()
+	if p.pos < len(p.s) && (p.s[p.pos] >= '0' && p.s[p.pos] <= '9') {
+		start := p.pos
+		for p.pos < len(p.s) && (p.s[p.pos] >= '0' && p.s[p.pos] <= '9' || p.s[p.pos] == '.') {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.s[start:p.pos])
+		}
+		return numOperand(n), nil
+	}
+
+	id, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	return propOperand(id), nil
+}
+
+// parse parses the query text, see ParseQuery
+func (p *queryParser) parse(q *Query) error {
+	switch p.peek() {
+	case "where", "sort", "limit":
+	default:
+		if !p.eof() {
+			path, err := p.word()
+			if err != nil {
+				return err
+			}
+			q.Path = path
+		}
+	}
+
+	if p.keyword("where") {
+		x, err := p.parseOr()
+		if err != nil {
+			return err
+		}
+		q.where = x
+	}
+
+	if p.keyword("sort") {
+		x, err := p.parseOperand()
+		if err != nil {
+			return err
+		}
+		q.sort = x
+		if p.keyword("desc") {
+			q.desc = true
+		} else {
+			_ = p.keyword("asc")
+		}
+	}
+
+	if p.keyword("limit") {
+		n, err := p.word()
+		if err != nil {
+			return err
+		}
+		if q.Limit, err = strconv.Atoi(n); err != nil || q.Limit < 0 {
+			return p.errorf("invalid limit %q", n)
+		}
+	}
+
+	if !p.eof() {
+		return p.errorf("unexpected %q", p.s[p.pos:])
+	}
+
+	return nil
+}
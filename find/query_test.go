@@ -0,0 +1,164 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package find_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestParseQuery(t *testing.T) {
+	valid := []string{
+		"",
+		"/DC0/vm",
+		"where name = foo",
+		"'/DC0/host/my cluster' where type = HostSystem and not (runtime.powerState != poweredOn)",
+		"where datastore->(summary.freeSpace / summary.capacity < 0.1) or tag = prod",
+		"where datastore->summary.accessible = true sort summary.storage.committed desc limit 10",
+		`where field.owner = "Alice Smith" sort name`,
+	}
+
+	for _, s := range valid {
+		if _, err := find.ParseQuery(s); err != nil {
+			t.Errorf("%q: %s", s, err)
+		}
+	}
+
+	invalid := []string{
+		"where",
+		"where name",
+		"where name =",
+		"where (name = foo",
+		"where name = 'foo",
+		"limit ten",
+		"sort name limit 1 where name = foo",
+		"/DC0 /DC1",
+	}
+
+	for _, s := range invalid {
+		if _, err := find.ParseQuery(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		finder := find.NewFinder(c)
+
+		query := func(s string, tags ...find.QueryTagFunc) []string {
+			t.Helper()
+			q, err := find.ParseQuery(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tags) != 0 {
+				q.Tags = tags[0]
+			}
+			content, err := finder.Query(ctx, q)
+			if err != nil {
+				t.Fatalf("%q: %s", s, err)
+			}
+			var names []string
+			for _, o := range content {
+				for _, p := range o.PropSet {
+					if p.Name == "name" {
+						names = append(names, p.Val.(string))
+					}
+				}
+			}
+			return names
+		}
+
+		expect := func(s string, names ...string) {
+			t.Helper()
+			if res := query(s); !slices.Equal(res, names) {
+				t.Errorf("%q: %v", s, res)
+			}
+		}
+
+		vm, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		expect("where type = VirtualMachine and runtime.powerState = poweredOn sort name",
+			"DC0_C0_RP0_VM0", "DC0_C0_RP0_VM1", "DC0_H0_VM1")
+		expect("/DC0/vm where type = VirtualMachine and runtime.powerState = poweredOn sort name desc limit 2",
+			"DC0_H0_VM1", "DC0_C0_RP0_VM1")
+		expect("where type = VirtualMachine and not runtime.powerState = poweredOn", "DC0_H0_VM0")
+		expect("/DC0/host/DC0_C0 where name = DC0_C0* and type != VirtualMachine sort name",
+			"DC0_C0", "DC0_C0_H0", "DC0_C0_H1", "DC0_C0_H2")
+
+		// properties that are not valid for all types
+		expect("where runtime.powerState = poweredOff", "DC0_H0_VM0")
+		expect("where (type = HostSystem or type = VirtualMachine) and summary.config.name = DC0_H0", "DC0_H0")
+		expect("where config.hardware.numCPU >= 1 and name = DC0_H0_* sort name", "DC0_H0_VM0", "DC0_H0_VM1")
+
+		// reference properties
+		expect("where type = VirtualMachine and datastore->(summary.freeSpace / summary.capacity < 0) sort name")
+		if n := len(query("where type = VirtualMachine and datastore->(summary.freeSpace / summary.capacity < 1)")); n != 4 {
+			t.Errorf("datastore free: %d", n)
+		}
+		expect("where type = VirtualMachine and runtime.host->name = DC0_H0 sort name", "DC0_H0_VM0", "DC0_H0_VM1")
+
+		// custom fields
+		m, err := object.GetCustomFieldsManager(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		field, err := m.Add(ctx, "owner", "", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = m.Set(ctx, vm.Reference(), field.Key, "Alice Smith"); err != nil {
+			t.Fatal(err)
+		}
+		expect(`where field.owner = "Alice*"`, "DC0_H0_VM0")
+		expect(`where type = VirtualMachine and field.owner = "Bob*"`)
+
+		// tags
+		q, err := find.ParseQuery("where tag = prod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = finder.Query(ctx, q); err == nil {
+			t.Error("expected error")
+		}
+
+		tags := func(_ context.Context, refs []types.ManagedObjectReference) (map[types.ManagedObjectReference][]find.QueryTag, error) {
+			res := make(map[types.ManagedObjectReference][]find.QueryTag)
+			for _, ref := range refs {
+				if ref == vm.Reference() {
+					res[ref] = []find.QueryTag{{Category: "env", Name: "prod"}}
+				}
+			}
+			return res, nil
+		}
+		if res := query("where type = VirtualMachine and tag = prod", tags); !slices.Equal(res, []string{"DC0_H0_VM0"}) {
+			t.Errorf("tag: %v", res)
+		}
+		if res := query("where tag.env = prod", tags); !slices.Equal(res, []string{"DC0_H0_VM0"}) {
+			t.Errorf("tag.env: %v", res)
+		}
+		if res := query("where tag.owner = prod", tags); len(res) != 0 {
+			t.Errorf("tag.owner: %v", res)
+		}
+	})
+}
@@ -100,6 +100,11 @@ import (
 	_ "github.com/vmware/govmomi/cli/vcsa/access/dcui"
 	_ "github.com/vmware/govmomi/cli/vcsa/access/shell"
 	_ "github.com/vmware/govmomi/cli/vcsa/access/ssh"
+	_ "github.com/vmware/govmomi/cli/vcsa/backup"
+	_ "github.com/vmware/govmomi/cli/vcsa/backup/schedule"
+	_ "github.com/vmware/govmomi/cli/vcsa/cert"
+	_ "github.com/vmware/govmomi/cli/vcsa/cert/root"
+	_ "github.com/vmware/govmomi/cli/vcsa/cert/signing"
 	_ "github.com/vmware/govmomi/cli/vcsa/log"
 	_ "github.com/vmware/govmomi/cli/vcsa/proxy"
 	_ "github.com/vmware/govmomi/cli/vcsa/shutdown"
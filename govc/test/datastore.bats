@@ -187,6 +187,32 @@ upload_file() {
   rm "$BATS_TMPDIR/$name"
 }
 
+@test "datastore.download -parallel" {
+  vcsim_env -esx
+
+  name=$(new_id)
+  run govc datastore.upload -retries 2 datastore.bats "$name"
+  assert_success
+
+  sum=$(sha256sum datastore.bats | cut -d' ' -f1)
+
+  run govc datastore.download -parallel 4 -checksum "sha256:$sum" "$name" "$BATS_TMPDIR/$name"
+  assert_success
+  run cmp datastore.bats "$BATS_TMPDIR/$name"
+  assert_success
+
+  head -c 100 datastore.bats > "$BATS_TMPDIR/$name"
+  run govc datastore.download -resume -checksum "sha256:$sum" "$name" "$BATS_TMPDIR/$name"
+  assert_success
+  run cmp datastore.bats "$BATS_TMPDIR/$name"
+  assert_success
+
+  run govc datastore.download -checksum "sha256:0000" "$name" "$BATS_TMPDIR/$name"
+  assert_failure
+
+  rm "$BATS_TMPDIR/$name"
+}
+
 @test "datastore.upload" {
   vcsim_env -esx
 
@@ -37,3 +37,30 @@ load test_helper
 
   rm -rf "$dir"
 }
+
+@test "export.ovf vcsim" {
+  vcsim_env
+
+  vm=DC0_H0_VM0
+  dir=$BATS_TMPDIR/$vm-export
+
+  run govc export.ovf -vm $vm "$dir"
+  assert_failure # powered on
+
+  run govc vm.power -off $vm
+  assert_success
+
+  run govc export.ovf -sha 256 -vm $vm "$dir"
+  assert_success
+
+  run ls "$dir/$vm/$vm-disk-0.vmdk" "$dir/$vm/$vm.ovf" "$dir/$vm/$vm.mf"
+  assert_success
+
+  run govc import.ovf -name "$vm-import" "$dir/$vm/$vm.ovf"
+  assert_success
+
+  run govc device.info -vm "$vm-import" disk-*
+  assert_success
+
+  rm -rf "$dir"
+}
@@ -290,6 +290,42 @@ load test_helper
   assert_equal 'connected' $status
 }
 
+@test "host.shutdown and host.reboot" {
+  vcsim_env -method-delay Reboot:1000 # time the host is down
+
+  run govc host.shutdown -r "$GOVC_HOST"
+  assert_failure # powered on VMs and not in maintenance mode
+
+  govc host.shutdown -r -f "$GOVC_HOST" &
+  pid=$!
+
+  state=""
+  for _ in $(seq 30) ; do
+    state=$(govc object.collect -s "$GOVC_HOST" runtime.connectionState)
+    if [ "$state" = "notResponding" ] ; then
+      break
+    fi
+    sleep 0.1
+  done
+  assert_equal notResponding "$state"
+
+  run wait $pid
+  assert_success
+
+  run govc object.collect -s "$GOVC_HOST" runtime.connectionState runtime.powerState
+  assert_success "$(printf "connected\npoweredOn")"
+
+  run govc host.shutdown "$GOVC_HOST"
+  assert_success # VMs were powered off by the forced reboot
+
+  run govc object.collect -s "$GOVC_HOST" runtime.powerState
+  assert_success poweredOff
+
+  run govc events -type HostShutdownEvent "$GOVC_HOST"
+  assert_success
+  assert_equal 2 "${#lines[@]}"
+}
+
 @test "host.tpm" {
   vcsim_env
 
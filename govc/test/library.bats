@@ -211,6 +211,53 @@ load test_helper
   assert_matches "$summary"
 }
 
+@test "library.export -ova" {
+  vcsim_env
+
+  run govc library.create my-content
+  assert_success
+
+  run govc library.import /my-content "$GOVC_IMAGES/$TTYLINUX_NAME.ova"
+  assert_success
+
+  dir="$BATS_TMPDIR/govc-library-export-ova"
+  mkdir -p "$dir"
+  openssl req -x509 -newkey rsa:2048 -nodes -keyout "$dir/key.pem" -out "$dir/cert.pem" -days 1 -subj /CN=govc
+
+  run govc library.export -ova "/my-content/$TTYLINUX_NAME/*.ovf" "$dir/file.ova"
+  assert_failure # not an item
+
+  run govc library.export -ova -sign-cert "$dir/cert.pem" "/my-content/$TTYLINUX_NAME" "$dir/$TTYLINUX_NAME.ova"
+  assert_failure # -sign-key required
+
+  run govc library.export -ova "/my-content/$TTYLINUX_NAME" "$dir/unsigned.ova"
+  assert_success
+
+  run tar -tf "$dir/unsigned.ova"
+  assert_success "$(printf "%s\n" "$TTYLINUX_NAME.ovf" "$TTYLINUX_NAME-disk1.vmdk" "$TTYLINUX_NAME.mf")"
+
+  run govc library.export -ova -sign-cert "$dir/cert.pem" -sign-key "$dir/key.pem" "/my-content/$TTYLINUX_NAME" "$dir/$TTYLINUX_NAME.ova"
+  assert_success
+
+  run tar -tf "$dir/$TTYLINUX_NAME.ova"
+  assert_success
+  assert_matches "$TTYLINUX_NAME.cert"
+
+  run govc import.ova -m -name unsigned "$dir/unsigned.ova"
+  assert_success
+
+  run govc import.ova -s -roots "$dir/cert.pem" -name unsigned-verify "$dir/unsigned.ova"
+  assert_failure # missing .cert
+
+  run govc import.ova -s -name untrusted "$dir/$TTYLINUX_NAME.ova"
+  assert_failure # unknown authority
+
+  run govc import.ova -s -roots "$dir/cert.pem" -name signed "$dir/$TTYLINUX_NAME.ova"
+  assert_success
+
+  rm -r "$dir"
+}
+
 @test "library.deploy" {
   vcsim_env
 
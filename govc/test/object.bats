@@ -697,6 +697,44 @@ EOF
   assert_matches :dvs- # DistributedVirtualSwitch moid value
 }
 
+@test "object.find.query" {
+  vcsim_env
+
+  run govc find.query "where"
+  assert_failure
+
+  run govc find.query "/enoent"
+  assert_failure
+
+  run govc find.query "where type = VirtualMachine and runtime.powerState = poweredOn"
+  assert_success
+  assert_equal 4 ${#lines[@]}
+
+  run govc vm.power -off /DC0/vm/DC0_H0_VM0
+  assert_success
+
+  run govc find.query "where type = VirtualMachine and runtime.powerState = poweredOff"
+  assert_success "/DC0/vm/DC0_H0_VM0"
+
+  run govc find.query "/DC0/host where type = HostSystem sort name desc limit 1"
+  assert_success "/DC0/host/DC0_H0/DC0_H0"
+
+  run govc find.query -I "where type = VirtualMachine and datastore->(summary.freeSpace / summary.capacity < 0)"
+  assert_success ""
+
+  run govc tags.category.create env
+  assert_success
+
+  run govc tags.create -c env prod
+  assert_success
+
+  run govc tags.attach prod /DC0/vm/DC0_H0_VM1
+  assert_success
+
+  run govc find.query "where type = VirtualMachine and tag.env = prod"
+  assert_success "/DC0/vm/DC0_H0_VM1"
+}
+
 @test "object.method" {
   vcsim_env_todo
 
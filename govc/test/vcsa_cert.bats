@@ -0,0 +1,82 @@
+#!/usr/bin/env bats
+
+load test_helper
+
+@test "vcsa.cert" {
+  vcsim_env
+
+  run govc vcsa.cert.info
+  assert_success
+
+  thumbprint=$(govc vcsa.cert.info -json | jq -r .thumbprint)
+
+  run govc vcsa.cert.renew -duration 30
+  assert_success
+
+  run govc vcsa.cert.info -json
+  assert_success
+  assert_equal "CN=CA,O=vcsim" "$(jq -r .issuer_dn <<<"$output")"
+  [ "$thumbprint" != "$(jq -r .thumbprint <<<"$output")" ]
+
+  # the simulator serves the renewed certificate
+  run govc about.cert -thumbprint
+  assert_success
+  assert_matches "$(govc vcsa.cert.info -json | jq -r .thumbprint)"
+
+  run govc vcsa.cert.replace
+  assert_failure # FILE or -vmca required
+
+  run govc vcsa.cert.replace -vmca -cn vcsa.example.com -o govc -san vcsa.example.com,127.0.0.1
+  assert_success
+
+  run govc vcsa.cert.info
+  assert_success
+  assert_matches "CN=vcsa.example.com,O=govc"
+  assert_matches "vcsa.example.com,127.0.0.1"
+
+  run govc vcsa.cert.csr -cn vcsa.example.com
+  assert_success
+  assert_matches "BEGIN CERTIFICATE REQUEST"
+}
+
+@test "vcsa.cert.root" {
+  vcsim_env
+
+  run govc vcsa.cert.root.ls
+  assert_success
+  vmca="$output"
+
+  run govc vcsa.cert.signing.info -show
+  assert_success
+  pem="$output"
+
+  run govc vcsa.cert.root.info -show "$vmca"
+  assert_success "$pem"
+
+  run govc vcsa.cert.root.add -id test - <<<"$pem"
+  assert_success "test"
+
+  run govc vcsa.cert.root.add -id test - <<<"$pem"
+  assert_failure
+
+  run govc vcsa.cert.root.ls
+  assert_success
+  assert_matches test
+
+  run govc vcsa.cert.root.info test
+  assert_success
+  assert_matches "CN=CA,O=vcsim"
+
+  run govc vcsa.cert.root.rm "$vmca"
+  assert_failure # active VMCA root
+
+  run govc vcsa.cert.root.rm enoent
+  assert_failure
+
+  run govc vcsa.cert.signing.refresh
+  assert_success
+
+  run govc vcsa.cert.signing.info -json
+  assert_success
+  assert_equal 2 "$(jq '.signing_cert_chains | length' <<<"$output")"
+}
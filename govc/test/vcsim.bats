@@ -591,6 +591,157 @@ EOF
   assert_success
 }
 
+@test "vcsim checkpoint" {
+  dir="$BATS_TMPDIR/$(new_id)"
+
+  vcsim_env -checkpoint "$dir"
+
+  run govc vm.create -on=false checkpoint-vm
+  assert_success
+
+  run govc tags.category.create checkpoint
+  assert_success
+
+  run govc tags.create -c checkpoint checkpoint
+  assert_success
+
+  run govc tags.attach checkpoint vm/checkpoint-vm
+  assert_success
+
+  vcsim_stop # saves checkpoint on exit
+
+  run ls "$dir/vcsim/events.xml"
+  assert_success
+
+  vcsim_env -checkpoint "$dir"
+
+  run govc vm.info checkpoint-vm
+  assert_success
+
+  run govc tags.attached.ls checkpoint
+  assert_success "$(govc find -i vm -name checkpoint-vm)"
+
+  run govc vm.power -on checkpoint-vm
+  assert_success
+
+  vcsim_stop
+  rm -rf "$dir"
+}
+
+@test "vcsim fault injection" {
+  scenario="$BATS_TMPDIR/$(new_id).yaml"
+  cat > "$scenario" <<EOF
+rules:
+  - method: PowerOffVM_Task
+    objectName: DC0_H0_VM0
+    fault: InvalidPowerState
+    message: power off fault
+    maxCount: 1
+EOF
+
+  vcsim_env -admin -fault-scenario "$scenario"
+  rm -f "$scenario"
+
+  url="https://$(govc env GOVC_URL)/vcsim/faults"
+  cookie="vmware_soap_session=$(govc session.login -l)"
+
+  run curl -skf "$url" # session required
+  assert_failure
+
+  run govc vm.power -off DC0_H0_VM0
+  assert_failure
+  assert_matches "power off fault"
+
+  run govc vm.power -off DC0_H0_VM0 # maxCount reached
+  assert_success
+
+  run curl -skf -b "$cookie" "$url/stats"
+  assert_success
+  [ "$(jq .total_injections <<<"$output")" == "1" ]
+
+  run curl -skf -b "$cookie" "$url" -d '{"rules": [{"method": "PowerOnVM_Task", "fault": "NotAuthenticated", "message": "power on fault", "enabled": false}]}'
+  assert_success
+  [ "$(jq -c . <<<"$output")" == "[1]" ]
+
+  run govc vm.power -on DC0_H0_VM0 # rule is disabled
+  assert_success
+
+  run govc vm.power -off DC0_H0_VM0
+  assert_success
+
+  run curl -skf -b "$cookie" "$url/1" -X PATCH -d '{"enabled": true}'
+  assert_success
+
+  run govc vm.power -on DC0_H0_VM0
+  assert_failure
+  assert_matches "power on fault"
+
+  run curl -skf -b "$cookie" "$url"
+  assert_success
+  [ "$(jq '.rules | length' <<<"$output")" == "2" ]
+  [ "$(jq .rules[1].count <<<"$output")" == "1" ]
+
+  run curl -skf -b "$cookie" "$url" -d '{"rules": [{"fault": "NoSuchFault"}]}'
+  assert_failure
+
+  run curl -skf -b "$cookie" "$url" -X DELETE
+  assert_success
+
+  run govc vm.power -on DC0_H0_VM0
+  assert_success
+
+  run vcsim -fault-scenario /no/such/file.yaml
+  assert_failure
+}
+
+@test "vcsim host failure" {
+  vcsim_env -admin
+
+  url="https://$(govc env GOVC_URL)/vcsim/hosts"
+  cookie="vmware_soap_session=$(govc session.login -l)"
+
+  run govc cluster.change -ha-enabled DC0_C0
+  assert_success
+
+  run govc object.collect -s vm/DC0_C0_RP0_VM0 runtime.dasVmProtection.dasProtected
+  assert_success "true"
+
+  host=$(govc object.collect -s vm/DC0_C0_RP0_VM0 runtime.host)
+
+  run curl -skf -b "$cookie" "$url/${host#HostSystem:}/fail" -X POST
+  assert_success
+
+  run curl -skf -b "$cookie" "$url/${host#HostSystem:}/fail" -X POST
+  assert_failure
+
+  run govc object.collect -s "$host" runtime.connectionState
+  assert_success "notResponding"
+
+  run govc object.collect -s vm/DC0_C0_RP0_VM0 runtime.host
+  assert_success
+  [ "$output" != "$host" ]
+
+  run govc object.collect -s vm/DC0_C0_RP0_VM0 runtime.powerState
+  assert_success "poweredOn"
+
+  run govc events -type DasHostFailedEvent DC0_C0
+  assert_success
+  assert_matches "host failure"
+
+  run govc events -type VmRestartedOnAlternateHostEvent vm/DC0_C0_RP0_VM0
+  assert_success
+  assert_matches "was restarted on"
+
+  run curl -skf -b "$cookie" "$url/${host#HostSystem:}/recover" -X POST
+  assert_success
+
+  run govc object.collect -s "$host" runtime.connectionState
+  assert_success "connected"
+
+  run curl -skf -b "$cookie" "$url/enoent/fail" -X POST
+  assert_failure
+}
+
 @test "vcsim trace file" {
   file="$BATS_TMPDIR/$(new_id).trace"
 
@@ -328,6 +328,30 @@ load test_helper
   done
 }
 
+@test "vm.power -parallel" {
+  vcsim_env -autostart=false
+
+  vms=($(govc find / -type m | sort))
+
+  run govc vm.power -on -parallel 3 "${vms[@]}"
+  assert_success
+
+  on=($(govc find / -type m -runtime.powerState poweredOn | sort))
+  assert_equal "${vms[*]}" "${on[*]}"
+
+  run govc vm.power -on -parallel 3 "${vms[@]}"
+  assert_failure # already powered on
+
+  run govc vm.power -on -parallel 3 -force "${vms[@]}"
+  assert_success
+
+  run govc vm.destroy -parallel 3 "${vms[@]}"
+  assert_success
+
+  run govc find / -type m
+  assert_output ""
+}
+
 @test "vm.power -force" {
   vcsim_env
 
@@ -984,6 +1008,40 @@ load test_helper
   assert_failure
 }
 
+@test "vm.instantclone" {
+  vcsim_env
+
+  vm="DC0_H0_VM0"
+  clone=$(new_id)
+
+  run govc vm.power -off "$vm"
+  assert_success
+
+  run govc vm.instantclone -vm "$vm" "$clone"
+  assert_failure # source must be powered on
+
+  run govc vm.power -on "$vm"
+  assert_success
+
+  run govc vm.instantclone -vm "$vm" -e guestinfo.hostname="$clone" "$clone"
+  assert_success
+
+  run govc object.collect -s "vm/$clone" runtime.powerState
+  assert_success poweredOn
+
+  run govc vm.info -e "$clone"
+  assert_success
+  assert_matches "guestinfo.hostname: *$clone"
+
+  parent=$(govc device.info -json -vm "$vm" disk-* | jq -r .devices[].backing.fileName)
+  run govc device.info -json -vm "$clone" disk-*
+  assert_success
+  assert_equal "$parent" "$(jq -r .devices[].backing.parent.fileName <<<"$output")"
+
+  run govc vm.instantclone -vm "$vm" "$clone"
+  assert_failure # already exists
+}
+
 @test "vm.migrate" {
   vcsim_env -cluster 2
 
@@ -1113,6 +1171,38 @@ load test_helper
   assert_success
 }
 
+@test "vm.console vcsim" {
+  vcsim_env
+
+  vm=DC0_H0_VM0
+
+  run govc vm.console "$vm"
+  assert_success
+
+  run govc vm.console -h5 "$vm"
+  assert_success
+  assert_matches "webconsole.html"
+
+  run govc vm.console -wss "$vm"
+  assert_success
+  assert_matches "wss://$(govc env -x GOVC_URL_HOST):$(govc env -x GOVC_URL_PORT)/ticket/"
+
+  png="$BATS_TMPDIR/$(new_id).png"
+  run govc vm.console -capture "$png" "$vm"
+  assert_success
+  [ "$(head -c 4 "$png" | tail -c 3)" = "PNG" ]
+  rm -f "$png"
+
+  run govc vm.power -off "$vm"
+  assert_success
+
+  run govc vm.console -wss "$vm"
+  assert_failure
+
+  run govc vm.console -capture - "$vm"
+  assert_failure
+}
+
 @test "vm.upgrade" {
   vcsim_env
 
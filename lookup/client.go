@@ -148,7 +148,7 @@ func EndpointURL(ctx context.Context, c *vim25.Client, path string, filter *type
 					// Set thumbprint only for endpoints on hosts outside this vCenter.
 					// Platform Services may live on multiple hosts.
 					if c.URL().Host != u.Host && c.Thumbprint(u.Host) == "" {
-						c.SetThumbprint(u.Host, endpointThumbprint(endpoint))
+						c.SetThumbprint(u.Host, EndpointThumbprint(endpoint))
 					}
 				}
 			}
@@ -157,8 +157,9 @@ func EndpointURL(ctx context.Context, c *vim25.Client, path string, filter *type
 	return path
 }
 
-// endpointThumbprint converts the base64 encoded endpoint certificate to a SHA1 thumbprint.
-func endpointThumbprint(endpoint *types.LookupServiceRegistrationEndpoint) string {
+// EndpointThumbprint converts the base64 encoded endpoint certificate to a SHA1 thumbprint.
+// An empty string is returned if the endpoint has no SslTrust certificate.
+func EndpointThumbprint(endpoint *types.LookupServiceRegistrationEndpoint) string {
 	if len(endpoint.SslTrust) == 0 {
 		return ""
 	}
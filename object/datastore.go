@@ -301,6 +301,7 @@ func (d Datastore) uploadTicket(ctx context.Context, path string, param *soap.Up
 	if ticket != nil {
 		p.Ticket = ticket
 		p.Close = true // disable Keep-Alive connection to ESX
+		p.Retries = 0  // service tickets are single use
 	}
 
 	return u, &p, nil
@@ -320,6 +321,8 @@ func (d Datastore) downloadTicket(ctx context.Context, path string, param *soap.
 	if ticket != nil {
 		p.Ticket = ticket
 		p.Close = true // disable Keep-Alive connection to ESX
+		p.Retries = 0  // service tickets are single use
+		p.Parallel = 0
 	}
 
 	return u, &p, nil
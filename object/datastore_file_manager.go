@@ -168,6 +168,34 @@ func (m *DatastoreFileManager) Move(ctx context.Context, src string, dst string)
 	return m.wait(ctx, task)
 }
 
+// DownloadFile calls Datastore.DownloadFile, using the progress.Sinker of WithProgress if param.Progress is not set
+func (m *DatastoreFileManager) DownloadFile(ctx context.Context, name string, file string, param *soap.Download) error {
+	p := soap.DefaultDownload
+	if param != nil {
+		p = *param // copy
+	}
+
+	if s, ok := ctx.Value(m).(progress.Sinker); ok && p.Progress == nil {
+		p.Progress = s
+	}
+
+	return m.Datastore.DownloadFile(ctx, m.Path(name).Path, file, &p)
+}
+
+// UploadFile calls Datastore.UploadFile, using the progress.Sinker of WithProgress if param.Progress is not set
+func (m *DatastoreFileManager) UploadFile(ctx context.Context, file string, name string, param *soap.Upload) error {
+	p := soap.DefaultUpload
+	if param != nil {
+		p = *param // copy
+	}
+
+	if s, ok := ctx.Value(m).(progress.Sinker); ok && p.Progress == nil {
+		p.Progress = s
+	}
+
+	return m.Datastore.UploadFile(ctx, file, m.Path(name).Path, &p)
+}
+
 // Path converts path name to a DatastorePath
 func (m *DatastoreFileManager) Path(name string) *DatastorePath {
 	var p DatastorePath
@@ -7,8 +7,15 @@ package importer
 import (
 	"bytes"
 	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"path"
 	"path/filepath"
 	"strings"
@@ -32,6 +39,12 @@ type Importer struct {
 	VerifyManifest bool
 	Hidden         bool
 
+	// VerifySignature enables verification of the manifest signature using the package certificate (.cert) file.
+	// The certificate chain is verified against Roots, or the system roots if nil.
+	// Implies VerifyManifest, and the OVF descriptor must match its manifest checksum.
+	VerifySignature bool
+	Roots           *x509.CertPool
+
 	Client *vim25.Client
 	Finder *find.Finder
 	Sinker progress.Sinker
@@ -44,6 +57,8 @@ type Importer struct {
 
 	Archive  Archive
 	Manifest map[string]*library.Checksum
+	// Signer is the manifest signer's certificate, set by ReadManifest when VerifySignature is enabled.
+	Signer *x509.Certificate
 }
 
 func (imp *Importer) manifestPath(fpath string) string {
@@ -52,15 +67,90 @@ func (imp *Importer) manifestPath(fpath string) string {
 	return filepath.Join(filepath.Dir(fpath), strings.Replace(base, ext, ".mf", 1))
 }
 
+func (imp *Importer) certificatePath(fpath string) string {
+	mf := imp.manifestPath(fpath)
+	return strings.TrimSuffix(mf, ".mf") + ".cert"
+}
+
+func (imp *Importer) verifyManifest() bool {
+	return imp.VerifyManifest || imp.VerifySignature
+}
+
 func (imp *Importer) ReadManifest(fpath string) error {
 	mf, _, err := imp.Archive.Open(imp.manifestPath(fpath))
 	if err != nil {
 		msg := fmt.Sprintf("failed to read manifest %q: %s", mf, err)
 		return errors.New(msg)
 	}
-	imp.Manifest, err = library.ReadManifest(mf)
+	data, err := io.ReadAll(mf)
 	_ = mf.Close()
-	return err
+	if err != nil {
+		return err
+	}
+
+	imp.Manifest, err = library.ReadManifest(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if imp.VerifySignature {
+		return imp.verifySignature(fpath, data)
+	}
+	return nil
+}
+
+func (imp *Importer) verifySignature(fpath string, manifest []byte) error {
+	cert, _, err := imp.Archive.Open(imp.certificatePath(fpath))
+	if err != nil {
+		return fmt.Errorf("failed to read certificate: %s", err)
+	}
+	data, err := io.ReadAll(cert)
+	_ = cert.Close()
+	if err != nil {
+		return err
+	}
+
+	imp.Signer, err = ovf.VerifyManifest(manifest, data, x509.VerifyOptions{Roots: imp.Roots})
+	if err != nil {
+		return fmt.Errorf("failed to verify manifest signature: %s", err)
+	}
+	return nil
+}
+
+// verifyDescriptor validates the OVF descriptor content against its manifest checksum
+func (imp *Importer) verifyDescriptor(fpath string, data []byte) error {
+	name := path.Base(filepath.ToSlash(fpath))
+	sum, ok := imp.Manifest[name]
+	if !ok {
+		// an OVA descriptor is opened by pattern, see TapeArchive.Open
+		for file, s := range imp.Manifest {
+			if matched, _ := path.Match(name, file); matched {
+				name, sum, ok = file, s, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return fmt.Errorf("missing checksum for %v in manifest file", name)
+	}
+
+	var h hash.Hash
+	switch sum.Algorithm {
+	case "SHA1":
+		h = sha1.New()
+	case "SHA256":
+		h = sha256.New()
+	case "SHA512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %q for %v", sum.Algorithm, name)
+	}
+	_, _ = h.Write(data)
+
+	if !strings.EqualFold(hex.EncodeToString(h.Sum(nil)), sum.Checksum) {
+		return fmt.Errorf("manifest checksum %v mismatch for file %v", sum.Checksum, name)
+	}
+	return nil
 }
 
 func (imp *Importer) ImportVApp(ctx context.Context, fpath string, opts Options) (*nfc.LeaseInfo, *nfc.Lease, error) {
@@ -69,6 +159,15 @@ func (imp *Importer) ImportVApp(ctx context.Context, fpath string, opts Options)
 		return nil, nil, err
 	}
 
+	if imp.VerifySignature {
+		if err := imp.ReadManifest(fpath); err != nil {
+			return nil, nil, err
+		}
+		if err := imp.verifyDescriptor(fpath, o); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	e, err := ReadEnvelope(o)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse ovf: %s", err)
@@ -136,7 +235,7 @@ func (imp *Importer) ImportVApp(ctx context.Context, fpath string, opts Options)
 		}
 	}
 
-	if imp.VerifyManifest {
+	if imp.VerifyManifest && !imp.VerifySignature {
 		if err := imp.ReadManifest(fpath); err != nil {
 			return nil, nil, err
 		}
@@ -306,7 +405,7 @@ func (imp *Importer) Upload(ctx context.Context, lease *nfc.Lease, item nfc.File
 		return err
 	}
 
-	if imp.VerifyManifest {
+	if imp.verifyManifest() {
 		mapImportKeyToKey := func(urls []types.HttpNfcLeaseDeviceUrl, importKey string) string {
 			for _, url := range urls {
 				if url.ImportKey == importKey {
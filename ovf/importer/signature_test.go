@@ -0,0 +1,130 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package importer
+
+import (
+	"archive/tar"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/ovf"
+)
+
+func signingCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "govmomi"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestImporter_VerifySignature(t *testing.T) {
+	dir := t.TempDir()
+	cert := signingCertificate(t)
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+
+	descriptor := []byte(`<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"/>`)
+	disk := []byte("disk")
+	manifest := []byte(fmt.Sprintf("SHA256(vm.ovf)= %x\nSHA256(vm-disk1.vmdk)= %x\n",
+		sha256.Sum256(descriptor), sha256.Sum256(disk)))
+	signature, err := ovf.SignManifest("vm.mf", manifest, cert)
+	require.NoError(t, err)
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"vm.ovf", descriptor},
+		{"vm-disk1.vmdk", disk},
+		{"vm.mf", manifest},
+		{"vm.cert", signature},
+	}
+
+	for _, file := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file.name), file.content, 0600))
+	}
+
+	ova, err := os.Create(filepath.Join(dir, "vm.ova"))
+	require.NoError(t, err)
+	tw := tar.NewWriter(ova)
+	for _, file := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0644, Size: int64(len(file.content))}))
+		_, err = tw.Write(file.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, ova.Close())
+
+	fpath := filepath.Join(dir, "vm.ovf")
+
+	tests := []struct {
+		name    string
+		archive Archive
+		fpath   string
+	}{
+		{"ovf", &FileArchive{Path: fpath}, fpath},
+		{"ova", &TapeArchive{Path: ova.Name()}, "*.ovf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imp := Importer{Archive: test.archive, VerifySignature: true, Roots: roots}
+
+			require.NoError(t, imp.ReadManifest(test.fpath))
+			assert.Equal(t, "govmomi", imp.Signer.Subject.CommonName)
+			assert.Len(t, imp.Manifest, 2)
+
+			assert.NoError(t, imp.verifyDescriptor(test.fpath, descriptor))
+			assert.ErrorContains(t, imp.verifyDescriptor(test.fpath, append(descriptor, '\n')), "mismatch")
+
+			imp = Importer{Archive: test.archive, VerifySignature: true, Roots: x509.NewCertPool()}
+			assert.ErrorContains(t, imp.ReadManifest(test.fpath), "failed to verify manifest signature")
+		})
+	}
+
+	// tampered manifest
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vm.mf"), append(manifest, '\n'), 0600))
+	imp := Importer{Archive: &FileArchive{Path: fpath}, VerifySignature: true, Roots: roots}
+	assert.ErrorContains(t, imp.ReadManifest(fpath), "failed to verify manifest signature")
+
+	// unsigned
+	require.NoError(t, os.Remove(filepath.Join(dir, "vm.cert")))
+	assert.ErrorContains(t, imp.ReadManifest(fpath), "failed to read certificate")
+
+	imp.VerifySignature = false
+	assert.NoError(t, imp.ReadManifest(fpath))
+}
@@ -0,0 +1,188 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package ovf
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "crypto/sha1" // register hash functions used by OVF manifests
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+var signatureHash = map[string]crypto.Hash{
+	"SHA1":   crypto.SHA1,
+	"SHA256": crypto.SHA256,
+	"SHA512": crypto.SHA512,
+}
+
+// Signature is the content of an OVF certificate (.cert) file,
+// containing the signature of the package manifest and the signer's certificate chain.
+type Signature struct {
+	Algorithm    string
+	Name         string
+	Value        []byte
+	Certificates []*x509.Certificate
+}
+
+// SignManifest signs the OVF manifest with the given name and content, using SHA256 and the given certificate's private key.
+// The result is the content of the OVF certificate (.cert) file, which includes the certificate chain.
+func SignManifest(name string, manifest []byte, cert tls.Certificate) ([]byte, error) {
+	signer, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", cert.PrivateKey)
+	}
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("certificate is required to sign manifest")
+	}
+
+	sum := crypto.SHA256.New()
+	_, _ = sum.Write(manifest)
+
+	sig, err := signer.Sign(rand.Reader, sum.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	_, _ = fmt.Fprintf(&buf, "SHA256(%s)= %x\n", name, sig)
+	for _, der := range cert.Certificate {
+		err = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ParseSignature parses the content of an OVF certificate (.cert) file.
+func ParseSignature(data []byte) (*Signature, error) {
+	var s Signature
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// "ALG(file name)= signature", as in the manifest
+		lparen := strings.Index(line, "(")
+		rparen := strings.LastIndex(line, ")")
+		if lparen < 0 || rparen < lparen {
+			return nil, fmt.Errorf("invalid signature line: %q", line)
+		}
+		value := strings.TrimSpace(line[rparen+1:])
+		if !strings.HasPrefix(value, "=") {
+			return nil, fmt.Errorf("invalid signature line: %q", line)
+		}
+		sig, err := hex.DecodeString(strings.TrimSpace(strings.TrimPrefix(value, "=")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature: %s", err)
+		}
+		s.Algorithm = strings.TrimSpace(line[:lparen])
+		s.Name = line[lparen+1 : rparen]
+		s.Value = sig
+		break
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if s.Value == nil {
+		return nil, errors.New("signature not found")
+	}
+
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		s.Certificates = append(s.Certificates, cert)
+	}
+	if len(s.Certificates) == 0 {
+		return nil, errors.New("certificate not found")
+	}
+
+	return &s, nil
+}
+
+// Verify checks that the signature is valid for the given manifest content and verifies the signer's certificate chain
+// using the given options. The certificates following the signer certificate are used as intermediates.
+// If opts.KeyUsages is empty, any key usage is accepted. The signer certificate is returned.
+func (s *Signature) Verify(manifest []byte, opts x509.VerifyOptions) (*x509.Certificate, error) {
+	h, ok := signatureHash[s.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", s.Algorithm)
+	}
+
+	sum := h.New()
+	_, _ = sum.Write(manifest)
+	digest := sum.Sum(nil)
+
+	cert := s.Certificates[0]
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, h, digest, s.Value); err != nil {
+			return nil, fmt.Errorf("manifest signature: %s", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest, s.Value) {
+			return nil, errors.New("manifest signature: verification error")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", cert.PublicKey)
+	}
+
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	if len(s.Certificates) > 1 {
+		if opts.Intermediates == nil {
+			opts.Intermediates = x509.NewCertPool()
+		} else {
+			opts.Intermediates = opts.Intermediates.Clone()
+		}
+		for _, c := range s.Certificates[1:] {
+			opts.Intermediates.AddCert(c)
+		}
+	}
+
+	if _, err := cert.Verify(opts); err != nil {
+		return nil, err
+	}
+
+	return cert, nil
+}
+
+// VerifyManifest parses the given OVF certificate (.cert) file content and verifies the manifest signature,
+// see Signature.Verify.
+func VerifyManifest(manifest, cert []byte, opts x509.VerifyOptions) (*x509.Certificate, error) {
+	s, err := ParseSignature(cert)
+	if err != nil {
+		return nil, err
+	}
+	return s.Verify(manifest, opts)
+}
@@ -0,0 +1,109 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package ovf
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate(t *testing.T, key crypto.Signer, name string, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  parent == nil,
+	}
+
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.Leaf, parent.PrivateKey.(crypto.Signer)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), signer)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+	if parent != nil {
+		cert.Certificate = append(cert.Certificate, parent.Certificate...)
+	}
+	return cert
+}
+
+func TestSignManifest(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca := testCertificate(t, rsaKey, "govmomi CA", nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	manifest := []byte("SHA256(vm.ovf)= 0123\nSHA256(vm-disk1.vmdk)= 4567\n")
+
+	for _, cert := range []tls.Certificate{
+		ca,
+		testCertificate(t, ecKey, "govmomi ecdsa", &ca),
+		testCertificate(t, rsaKey, "govmomi rsa", &ca),
+	} {
+		name := cert.Leaf.Subject.CommonName
+
+		data, err := SignManifest("vm.mf", manifest, cert)
+		require.NoError(t, err, name)
+		assert.True(t, strings.HasPrefix(string(data), "SHA256(vm.mf)= "), name)
+
+		s, err := ParseSignature(data)
+		require.NoError(t, err, name)
+		assert.Equal(t, "SHA256", s.Algorithm)
+		assert.Equal(t, "vm.mf", s.Name)
+		assert.Len(t, s.Certificates, len(cert.Certificate))
+
+		signer, err := VerifyManifest(manifest, data, x509.VerifyOptions{Roots: roots})
+		require.NoError(t, err, name)
+		assert.Equal(t, name, signer.Subject.CommonName)
+
+		_, err = VerifyManifest(append(manifest, '\n'), data, x509.VerifyOptions{Roots: roots})
+		assert.ErrorContains(t, err, "manifest signature", name)
+
+		_, err = VerifyManifest(manifest, data, x509.VerifyOptions{Roots: x509.NewCertPool()})
+		assert.Error(t, err, name)
+	}
+
+	_, err = SignManifest("vm.mf", manifest, tls.Certificate{PrivateKey: rsaKey})
+	assert.Error(t, err)
+
+	for _, data := range []string{
+		"",
+		"SHA256(vm.mf) 0123",
+		"SHA256(vm.mf)= xyz",
+		"SHA256(vm.mf)= 0123",
+	} {
+		_, err = ParseSignature([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
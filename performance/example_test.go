@@ -92,8 +92,8 @@ func ExampleManager_ToMetricSeries() {
 	})
 
 	// Output:
-	// DC0_H0_VM0	*	sys.uptime.latest	s
-	// DC0_H0_VM1	*	sys.uptime.latest	s
-	// DC0_C0_RP0_VM0	*	sys.uptime.latest	s
-	// DC0_C0_RP0_VM1	*	sys.uptime.latest	s
+	// DC0_H0_VM0	-	sys.uptime.latest	s
+	// DC0_H0_VM1	-	sys.uptime.latest	s
+	// DC0_C0_RP0_VM0	-	sys.uptime.latest	s
+	// DC0_C0_RP0_VM1	-	sys.uptime.latest	s
 }
@@ -0,0 +1,634 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package cache provides an informer-style, in-memory mirror of managed objects.
+
+A Cache uses a ContainerView and a property.Collector to maintain a local copy of the selected
+properties for all objects of a given type, delivering notifications to the registered handlers
+as objects are added, updated and deleted.
+Objects are indexed by name and parent, along with any custom indexes.
+
+When the session is lost or updates cannot be continued from the current version,
+the view and collector are re-created and the local copy is reconciled against a full listing of the objects.
+*/
+package cache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/view"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Builtin index names
+const (
+	IndexName   = "name"   // ManagedEntity.Name
+	IndexParent = "parent" // ManagedEntity.Parent.Value
+)
+
+// IndexFunc returns the index values of an object
+type IndexFunc[T any] func(obj *T) []string
+
+// CustomValueIndex returns an IndexFunc for the value of the custom field with the given key.
+// The "customValue" property must be included in Options.Properties.
+func CustomValueIndex[T any](key int32) IndexFunc[T] {
+	return func(obj *T) []string {
+		var values []string
+		if e := entity(obj); e != nil {
+			for _, v := range e.CustomValue {
+				if s, ok := v.(*types.CustomFieldStringValue); ok && s.Key == key {
+					values = append(values, s.Value)
+				}
+			}
+		}
+		return values
+	}
+}
+
+// TagIndex returns an IndexFunc for the tags attached to an object, as returned by the given lookup func,
+// for example the tag IDs from a tags.Manager.GetAttachedTagsOnObjects listing.
+// Attaching or detaching a tag does not change the object's properties, the index is only updated
+// when the object is updated or Cache.Resync is called.
+func TagIndex[T any](lookup func(types.ManagedObjectReference) []string) IndexFunc[T] {
+	return func(obj *T) []string {
+		if e := entity(obj); e != nil {
+			return lookup(e.Self)
+		}
+		return nil
+	}
+}
+
+// Handler is notified of changes to the objects in a Cache.
+// Any of the funcs can be nil.
+// Handlers are called sequentially and must not call Cache.AddHandler.
+type Handler[T any] struct {
+	Add    func(obj T)
+	Update func(old, obj T)
+	Delete func(obj T)
+}
+
+// Options for a Cache
+type Options[T any] struct {
+	// Container to watch, defaults to the root folder.
+	Container types.ManagedObjectReference
+
+	// Properties to mirror, defaults to all properties.
+	// The "name" and "parent" properties are always included, when specified.
+	Properties []string
+
+	// Indexers are added to the builtin name and parent indexes.
+	Indexers map[string]IndexFunc[T]
+
+	// ResyncPeriod, if non-zero, is the interval in which handlers receive an Update for all objects.
+	ResyncPeriod time.Duration
+
+	// RetryInterval is the delay between attempts to re-establish the watch after an error, defaults to 1 second.
+	RetryInterval time.Duration
+
+	// MaxObjectUpdates limits the number of objects in each WaitForUpdatesEx response.
+	MaxObjectUpdates int32
+
+	// Login, if set, is called to establish a new session when a NotAuthenticated fault is returned.
+	Login func(context.Context) error
+}
+
+type entry[T any] struct {
+	obj   T
+	props map[string]any
+	index map[string][]string
+}
+
+type key = types.ManagedObjectReference
+
+// Cache is an in-memory mirror of managed objects of type T, such as mo.VirtualMachine.
+// Objects returned by the Cache are shallow copies and must not be modified.
+type Cache[T any] struct {
+	c    *vim25.Client
+	kind string
+	opts Options[T]
+
+	mu       sync.RWMutex
+	objects  map[key]*entry[T]
+	indexers map[string]IndexFunc[T]
+	indices  map[string]map[string]map[key]struct{}
+
+	hmu      sync.Mutex
+	handlers []Handler[T]
+
+	synced   chan struct{}
+	syncOnce sync.Once
+	relist   chan struct{}
+}
+
+// New creates a Cache for the managed object type T, such as mo.VirtualMachine.
+// The Cache is populated once Run is called.
+func New[T any](c *vim25.Client, opts Options[T]) *Cache[T] {
+	if opts.Container.Type == "" {
+		opts.Container = c.ServiceContent.RootFolder
+	}
+	if opts.RetryInterval == 0 {
+		opts.RetryInterval = time.Second
+	}
+	if len(opts.Properties) != 0 {
+		for _, name := range []string{"name", "parent"} {
+			if !slices.Contains(opts.Properties, name) {
+				opts.Properties = append(opts.Properties, name)
+			}
+		}
+	}
+
+	cache := &Cache[T]{
+		c:    c,
+		kind: reflect.TypeFor[T]().Name(),
+		opts: opts,
+
+		objects: make(map[key]*entry[T]),
+		indexers: map[string]IndexFunc[T]{
+			IndexName: func(obj *T) []string {
+				if e := entity(obj); e != nil {
+					return []string{e.Name}
+				}
+				return nil
+			},
+			IndexParent: func(obj *T) []string {
+				if e := entity(obj); e != nil && e.Parent != nil {
+					return []string{e.Parent.Value}
+				}
+				return nil
+			},
+		},
+		indices: make(map[string]map[string]map[key]struct{}),
+		synced:  make(chan struct{}),
+		relist:  make(chan struct{}, 1),
+	}
+
+	for name, f := range opts.Indexers {
+		cache.indexers[name] = f
+	}
+
+	for name := range cache.indexers {
+		cache.indices[name] = make(map[string]map[key]struct{})
+	}
+
+	return cache
+}
+
+func entity[T any](obj *T) *mo.ManagedEntity {
+	if e, ok := any(obj).(mo.Entity); ok {
+		return e.Entity()
+	}
+	return nil
+}
+
+// Kind returns the managed object type of the Cache
+func (c *Cache[T]) Kind() string {
+	return c.kind
+}
+
+// AddHandler registers a Handler, which receives an Add notification for each object already in the Cache.
+func (c *Cache[T]) AddHandler(h Handler[T]) {
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+
+	c.handlers = append(c.handlers, h)
+
+	if h.Add != nil {
+		for _, obj := range c.List() {
+			h.Add(obj)
+		}
+	}
+}
+
+// Get returns the object with the given reference
+func (c *Cache[T]) Get(ref types.ManagedObjectReference) (T, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.objects[ref]
+	if !ok {
+		var obj T
+		return obj, false
+	}
+	return e.obj, true
+}
+
+// List returns all objects in the Cache
+func (c *Cache[T]) List() []T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	objs := make([]T, 0, len(c.objects))
+	for _, e := range c.objects {
+		objs = append(objs, e.obj)
+	}
+	return objs
+}
+
+// Len returns the number of objects in the Cache
+func (c *Cache[T]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.objects)
+}
+
+// ByIndex returns the objects with the given value in the named index
+func (c *Cache[T]) ByIndex(name, value string) []T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var objs []T
+	for ref := range c.indices[name][value] {
+		objs = append(objs, c.objects[ref].obj)
+	}
+	return objs
+}
+
+// HasSynced returns true once the Cache has been populated with the initial listing of objects
+func (c *Cache[T]) HasSynced() bool {
+	select {
+	case <-c.synced:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitForSync waits until the Cache has been populated with the initial listing of objects
+func (c *Cache[T]) WaitForSync(ctx context.Context) error {
+	select {
+	case <-c.synced:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resync re-creates the watch, reconciling the Cache with a full listing of the objects
+func (c *Cache[T]) Resync() {
+	select {
+	case c.relist <- struct{}{}:
+	default:
+	}
+}
+
+// Run maintains the Cache until the given context is canceled.
+// Errors are retried after Options.RetryInterval, except for faults that cannot be recovered from,
+// such as an invalid property name.
+func (c *Cache[T]) Run(ctx context.Context) error {
+	if c.opts.ResyncPeriod != 0 {
+		go c.resync(ctx)
+	}
+
+	for {
+		err := c.watch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			if err = c.recover(ctx, err); err != nil {
+				return err
+			}
+
+			select {
+			case <-time.After(c.opts.RetryInterval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// recover returns nil if the watch can be re-established after the given error
+func (c *Cache[T]) recover(ctx context.Context, err error) error {
+	switch {
+	case fault.Is(err, &types.NotAuthenticated{}):
+		if c.opts.Login != nil {
+			_ = c.opts.Login(ctx) // retried if the next watch fails
+		}
+		return nil
+	case fault.Is(err, &types.InvalidCollectorVersion{}),
+		fault.Is(err, &types.ManagedObjectNotFound{}),
+		fault.Is(err, &types.RequestCanceled{}):
+		return nil
+	case soap.IsSoapFault(err), soap.IsVimFault(err):
+		return err
+	}
+
+	return nil // transport errors
+}
+
+func (c *Cache[T]) resync(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.ResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if !c.HasSynced() {
+				continue
+			}
+			c.hmu.Lock()
+			for _, obj := range c.List() {
+				for _, h := range c.handlers {
+					if h.Update != nil {
+						h.Update(obj, obj)
+					}
+				}
+			}
+			c.hmu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Cache[T]) watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	v, err := view.NewManager(c.c).CreateContainerView(ctx, c.opts.Container, []string{c.kind}, true)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = v.Destroy(context.Background()) }()
+
+	pc, err := property.DefaultCollector(c.c).Create(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = pc.Destroy(context.Background()) }()
+
+	filter := new(property.WaitFilter).Add(v.Reference(), c.kind, c.opts.Properties, &types.TraversalSpec{
+		Type: v.Reference().Type,
+		Path: "view",
+	})
+	filter.Spec.ObjectSet[0].Skip = types.NewBool(true)
+	if c.opts.MaxObjectUpdates != 0 {
+		filter.Options = &types.WaitOptions{MaxObjectUpdates: c.opts.MaxObjectUpdates}
+	}
+
+	if _, err = pc.CreateFilter(ctx, filter.CreateFilter); err != nil {
+		return err
+	}
+
+	go func() {
+		select {
+		case <-c.relist:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Objects in the initial listing, used to prune objects deleted while the watch was down
+	listed := make(map[key]bool)
+	synced := false
+	var applyErr error
+
+	err = pc.WaitForUpdatesEx(ctx, &filter.WaitOptions, func(updates []types.ObjectUpdate) bool {
+		for _, update := range updates {
+			if !synced {
+				listed[update.Obj] = true
+			}
+			if applyErr = c.apply(ctx, update); applyErr != nil {
+				return true
+			}
+		}
+
+		if !synced && !filter.Truncated {
+			synced = true
+			c.prune(listed)
+			c.syncOnce.Do(func() { close(c.synced) })
+		}
+
+		return false
+	})
+
+	if err == nil {
+		err = applyErr
+	}
+
+	if err == nil && ctx.Err() != nil {
+		return nil // Resync or Run canceled
+	}
+
+	return err
+}
+
+// retrieve the object's properties, used when an update cannot be applied to the cached properties
+func (c *Cache[T]) retrieve(ctx context.Context, ref key) (map[string]any, error) {
+	req := types.RetrieveProperties{
+		This: c.c.ServiceContent.PropertyCollector,
+		SpecSet: []types.PropertyFilterSpec{{
+			ObjectSet: []types.ObjectSpec{{Obj: ref}},
+			PropSet: []types.PropertySpec{{
+				Type:    ref.Type,
+				PathSet: c.opts.Properties,
+				All:     types.NewBool(len(c.opts.Properties) == 0),
+			}},
+		}},
+	}
+
+	res, err := methods.RetrieveProperties(ctx, c.c, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]any)
+	for _, content := range res.Returnval {
+		for _, p := range content.PropSet {
+			if p.Val != nil {
+				props[p.Name] = p.Val
+			}
+		}
+	}
+
+	return props, nil
+}
+
+func (c *Cache[T]) apply(ctx context.Context, update types.ObjectUpdate) error {
+	ref := update.Obj
+
+	// Serialize with AddHandler, such that a new handler sees each change exactly once
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+
+	c.mu.RLock()
+	old, exists := c.objects[ref]
+	c.mu.RUnlock()
+
+	if update.Kind == types.ObjectUpdateKindLeave {
+		if exists {
+			c.remove(ref)
+			c.notify(func(h Handler[T]) {
+				if h.Delete != nil {
+					h.Delete(old.obj)
+				}
+			})
+		}
+		return nil
+	}
+
+	props := make(map[string]any)
+	if update.Kind == types.ObjectUpdateKindModify && exists {
+		for name, val := range old.props {
+			props[name] = val
+		}
+	}
+
+	for _, change := range update.ChangeSet {
+		if strings.Contains(change.Name, "[") {
+			// Indexed changes to array properties are applied by fetching the current properties
+			var err error
+			if props, err = c.retrieve(ctx, ref); err != nil {
+				return err
+			}
+			break
+		}
+
+		switch change.Op {
+		case types.PropertyChangeOpRemove, types.PropertyChangeOpIndirectRemove:
+			delete(props, change.Name)
+		default:
+			if change.Val == nil {
+				delete(props, change.Name)
+			} else {
+				props[change.Name] = change.Val
+			}
+		}
+	}
+
+	if exists && reflect.DeepEqual(old.props, props) {
+		return nil
+	}
+
+	e, err := c.newEntry(ref, props)
+	if err != nil {
+		return err
+	}
+
+	c.put(ref, e)
+
+	c.notify(func(h Handler[T]) {
+		switch {
+		case !exists && h.Add != nil:
+			h.Add(e.obj)
+		case exists && h.Update != nil:
+			h.Update(old.obj, e.obj)
+		}
+	})
+
+	return nil
+}
+
+func (c *Cache[T]) newEntry(ref key, props map[string]any) (*entry[T], error) {
+	content := types.ObjectContent{Obj: ref}
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	slices.Sort(names) // nested properties are assigned after their parent
+
+	for _, name := range names {
+		content.PropSet = append(content.PropSet, types.DynamicProperty{Name: name, Val: props[name]})
+	}
+
+	val, err := mo.ObjectContentToType(content)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, ok := val.(T)
+	if !ok {
+		return nil, errors.New("unexpected type " + reflect.TypeOf(val).String() + " for " + ref.String())
+	}
+
+	e := &entry[T]{obj: obj, props: props, index: make(map[string][]string)}
+	for name, f := range c.indexers {
+		e.index[name] = f(&e.obj)
+	}
+
+	return e, nil
+}
+
+func (c *Cache[T]) put(ref key, e *entry[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unindex(ref)
+	c.objects[ref] = e
+
+	for name, values := range e.index {
+		for _, value := range values {
+			refs, ok := c.indices[name][value]
+			if !ok {
+				refs = make(map[key]struct{})
+				c.indices[name][value] = refs
+			}
+			refs[ref] = struct{}{}
+		}
+	}
+}
+
+func (c *Cache[T]) remove(ref key) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unindex(ref)
+	delete(c.objects, ref)
+}
+
+func (c *Cache[T]) unindex(ref key) {
+	e, ok := c.objects[ref]
+	if !ok {
+		return
+	}
+
+	for name, values := range e.index {
+		for _, value := range values {
+			delete(c.indices[name][value], ref)
+			if len(c.indices[name][value]) == 0 {
+				delete(c.indices[name], value)
+			}
+		}
+	}
+}
+
+// prune removes objects that are no longer listed
+func (c *Cache[T]) prune(listed map[key]bool) {
+	c.mu.RLock()
+	var removed []key
+	for ref := range c.objects {
+		if !listed[ref] {
+			removed = append(removed, ref)
+		}
+	}
+	c.mu.RUnlock()
+
+	for _, ref := range removed {
+		_ = c.apply(context.Background(), types.ObjectUpdate{Obj: ref, Kind: types.ObjectUpdateKindLeave})
+	}
+}
+
+// notify calls f for each handler, with hmu held
+func (c *Cache[T]) notify(f func(Handler[T])) {
+	for _, h := range c.handlers {
+		f(h)
+	}
+}
@@ -0,0 +1,252 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property/cache"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// recorder records handler notifications
+type recorder struct {
+	sync.Mutex
+	events []string
+}
+
+func (r *recorder) handler() cache.Handler[mo.VirtualMachine] {
+	add := func(kind string, vm mo.VirtualMachine) {
+		r.Lock()
+		defer r.Unlock()
+		r.events = append(r.events, kind+" "+vm.Name)
+	}
+
+	return cache.Handler[mo.VirtualMachine]{
+		Add:    func(vm mo.VirtualMachine) { add("add", vm) },
+		Update: func(_, vm mo.VirtualMachine) { add("update", vm) },
+		Delete: func(vm mo.VirtualMachine) { add("delete", vm) },
+	}
+}
+
+func (r *recorder) has(event string) func() bool {
+	return func() bool {
+		r.Lock()
+		defer r.Unlock()
+		for _, e := range r.events {
+			if e == event {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// run starts the cache and waits for the initial sync, the returned func stops the cache
+func run[T any](t *testing.T, ctx context.Context, c *cache.Cache[T]) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error)
+
+	go func() { done <- c.Run(ctx) }()
+
+	wait, cancelWait := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelWait()
+	require.NoError(t, c.WaitForSync(wait))
+
+	return func() {
+		cancel()
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestCache(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		finder := find.NewFinder(c)
+
+		vms, err := finder.VirtualMachineList(ctx, "*")
+		require.NoError(t, err)
+
+		fields, err := object.GetCustomFieldsManager(c)
+		require.NoError(t, err)
+		field, err := fields.Add(ctx, "owner", "VirtualMachine", nil, nil)
+		require.NoError(t, err)
+
+		vmc := cache.New(c, cache.Options[mo.VirtualMachine]{
+			Properties:       []string{"runtime.powerState", "customValue"},
+			MaxObjectUpdates: 2, // page the initial listing
+			Indexers: map[string]cache.IndexFunc[mo.VirtualMachine]{
+				"power": func(vm *mo.VirtualMachine) []string {
+					return []string{string(vm.Runtime.PowerState)}
+				},
+				"owner": cache.CustomValueIndex[mo.VirtualMachine](field.Key),
+				"tag": cache.TagIndex[mo.VirtualMachine](func(ref types.ManagedObjectReference) []string {
+					if ref == vms[1].Reference() {
+						return []string{"urn:vmomi:InventoryServiceTag:prod:GLOBAL"}
+					}
+					return nil
+				}),
+			},
+		})
+		assert.Equal(t, "VirtualMachine", vmc.Kind())
+
+		events := new(recorder)
+		vmc.AddHandler(events.handler())
+
+		defer run(t, ctx, vmc)()
+
+		assert.Equal(t, len(vms), vmc.Len())
+		for _, vm := range vms {
+			assert.Condition(t, events.has("add "+vm.Name()))
+		}
+
+		vm := vms[0]
+		obj, ok := vmc.Get(vm.Reference())
+		require.True(t, ok)
+		assert.Equal(t, vm.Name(), obj.Name)
+		assert.Len(t, vmc.ByIndex(cache.IndexName, vm.Name()), 1)
+		assert.NotEmpty(t, vmc.ByIndex(cache.IndexParent, obj.Parent.Value))
+		assert.Len(t, vmc.ByIndex("power", string(types.VirtualMachinePowerStatePoweredOn)), len(vms))
+		tagged := vmc.ByIndex("tag", "urn:vmomi:InventoryServiceTag:prod:GLOBAL")
+		require.Len(t, tagged, 1)
+		assert.Equal(t, vms[1].Reference(), tagged[0].Self)
+
+		// updates
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		assert.Eventually(t, events.has("update "+vm.Name()), 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			return len(vmc.ByIndex("power", string(types.VirtualMachinePowerStatePoweredOff))) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, fields.Set(ctx, vm.Reference(), field.Key, "alice"))
+		assert.Eventually(t, func() bool {
+			owned := vmc.ByIndex("owner", "alice")
+			return len(owned) == 1 && owned[0].Self == vm.Reference()
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// delete
+		task, err = vm.Destroy(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		assert.Eventually(t, events.has("delete "+vm.Name()), 5*time.Second, 10*time.Millisecond)
+		_, ok = vmc.Get(vm.Reference())
+		assert.False(t, ok)
+		assert.Empty(t, vmc.ByIndex(cache.IndexName, vm.Name()))
+
+		// late handlers see the current objects
+		late := new(recorder)
+		vmc.AddHandler(late.handler())
+		assert.Condition(t, late.has("add "+vms[1].Name()))
+		assert.False(t, late.has("add "+vm.Name())())
+	})
+}
+
+func TestCacheResync(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		hosts := cache.New(c, cache.Options[mo.HostSystem]{
+			Properties:   []string{"runtime.connectionState"},
+			ResyncPeriod: 50 * time.Millisecond,
+		})
+
+		var updates atomic.Int32
+		hosts.AddHandler(cache.Handler[mo.HostSystem]{
+			Update: func(old, obj mo.HostSystem) {
+				updates.Add(1)
+			},
+		})
+
+		defer run(t, ctx, hosts)()
+
+		n := hosts.Len()
+		require.NotZero(t, n)
+
+		assert.Eventually(t, func() bool { return updates.Load() >= int32(2*n) }, 5*time.Second, 10*time.Millisecond)
+
+		hosts.Resync()
+		assert.Eventually(t, func() bool { return hosts.Len() == n }, 5*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestCacheRecover(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		s := simulator.ServiceFromContext(ctx)
+		sm := session.NewManager(c)
+
+		var logins atomic.Int32
+		vmc := cache.New(c, cache.Options[mo.VirtualMachine]{
+			Properties:    []string{"config.annotation"},
+			RetryInterval: 10 * time.Millisecond,
+			Login: func(ctx context.Context) error {
+				err := sm.Login(ctx, simulator.DefaultLogin)
+				if err == nil {
+					logins.Add(1)
+				}
+				return err
+			},
+		})
+
+		events := new(recorder)
+		vmc.AddHandler(events.handler())
+
+		defer run(t, ctx, vmc)()
+
+		vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+		require.NoError(t, err)
+
+		rename := func(vm *object.VirtualMachine, name string) {
+			task, err := vm.Rename(ctx, name)
+			require.NoError(t, err)
+			require.NoError(t, task.Wait(ctx))
+			assert.Eventually(t, events.has("update "+name), 5*time.Second, 10*time.Millisecond)
+		}
+
+		rename(vms[0], "renamed-0")
+
+		// the next update cannot be continued from the current version
+		s.AddFaultRule(&simulator.FaultInjectionRule{
+			MethodName:  "WaitForUpdatesEx",
+			ObjectType:  "*",
+			ObjectName:  "*",
+			Probability: 1.0,
+			FaultType:   simulator.FaultTypeCustom,
+			Fault:       new(types.InvalidCollectorVersion),
+			MaxCount:    1,
+			Enabled:     true,
+		})
+
+		// not using task.Wait here, as the rule applies to any collector
+		_, err = vms[1].Rename(ctx, "renamed-1")
+		require.NoError(t, err)
+		assert.Eventually(t, events.has("update renamed-1"), 5*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			return s.GetFaultStats()["total_injections"] == int64(1)
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// session loss
+		require.NoError(t, sm.Logout(ctx))
+		vmc.Resync()
+
+		assert.Eventually(t, func() bool { return logins.Load() != 0 }, 5*time.Second, 10*time.Millisecond)
+
+		rename(vms[2], "renamed-2")
+		assert.Equal(t, len(vms), vmc.Len())
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/vmware/govmomi/telemetry"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -48,6 +49,11 @@ func (p *Collector) Reference() types.ManagedObjectReference {
 	return p.reference
 }
 
+// Telemetry returns the instrumentation Provider of the Collector's client, if any.
+func (p *Collector) Telemetry() *telemetry.Provider {
+	return telemetry.FromRoundTripper(p.roundTripper)
+}
+
 // Create creates a new session-specific Collector that can be used to
 // retrieve property updates independent of any other Collector.
 func (p *Collector) Create(ctx context.Context) (*Collector, error) {
@@ -280,9 +286,20 @@ func (p *Collector) WaitForUpdatesEx(
 		Options: opts.Options,
 	}
 
+	ctx, span := telemetry.Start(ctx, p.Telemetry(), "property.WaitForUpdatesEx",
+		telemetry.String(telemetry.AttrObjectType, req.This.Type),
+		telemetry.String(telemetry.AttrObjectValue, req.This.Value))
+	polls := 0
+	defer func() {
+		span.SetAttributes(telemetry.Int(telemetry.AttrPollCount, polls))
+		span.End()
+	}()
+
 	for {
+		polls++
 		res, err := methods.WaitForUpdatesEx(ctx, p.roundTripper, &req)
 		if err != nil {
+			span.RecordError(err)
 			if ctx.Err() == context.Canceled {
 				return p.CancelWaitForUpdates(context.Background())
 			}
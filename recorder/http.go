@@ -0,0 +1,153 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// sessionHeader is the vAPI session ID header
+const sessionHeader = "vmware-api-session-id"
+
+type transport struct {
+	*Recorder
+
+	rt http.RoundTripper
+}
+
+// Transport returns an http.RoundTripper that records the exchanges sent via rt,
+// or replays them in Replay mode, in which case rt is not used.
+// If rt is nil, http.DefaultTransport is used.
+func (r *Recorder) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{Recorder: r, rt: rt}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	r := Request{
+		Kind:   KindHTTP,
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Action: req.Header.Get("SOAPAction"),
+		Body:   body,
+	}
+	if strings.Contains(req.Header.Get("Content-Type"), "xml") {
+		r.Operation = operation(body)
+	}
+	t.sessions(req.Header, req.Cookies())
+
+	if t.mode == Replay {
+		i := &Interaction{Request: r}
+		t.redact(i)
+		i, err := t.match(&i.Request)
+		if err != nil {
+			return nil, err
+		}
+		return i.Response.http(req)
+	}
+
+	res, err := t.rt.RoundTrip(req)
+	if err != nil {
+		i := &Interaction{Request: r, Response: Response{Error: err.Error()}}
+		t.redact(i)
+		t.record(i)
+		return nil, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	t.sessions(res.Header, res.Cookies())
+	if req.Method == http.MethodPost && path.Base(req.URL.Path) == "session" {
+		t.session(resBody)
+	}
+
+	i := &Interaction{
+		Request: r,
+		Response: Response{
+			Status: res.StatusCode,
+			Header: res.Header.Clone(),
+			Body:   resBody,
+		},
+	}
+	t.redact(i)
+	t.record(i)
+
+	return res, nil
+}
+
+// sessions adds the session IDs found in the given header and cookies to the secrets to redact
+func (t *transport) sessions(header http.Header, cookies []*http.Cookie) {
+	for _, id := range header.Values(sessionHeader) {
+		t.secret(id)
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == soap.SessionCookieName {
+			t.secret(cookie.Value)
+		}
+	}
+}
+
+// session adds the session ID returned by a vAPI session create request to the secrets to redact,
+// in the format of either the /api or /rest endpoint.
+func (t *transport) session(body []byte) {
+	var id string
+	if json.Unmarshal(body, &id) == nil {
+		t.secret(id)
+		return
+	}
+
+	var res struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(body, &res) == nil {
+		t.secret(res.Value)
+	}
+}
+
+// http converts the recorded Response to an http.Response
+func (r *Response) http(req *http.Request) (*http.Response, error) {
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(r.Header).Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
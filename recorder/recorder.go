@@ -0,0 +1,312 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package recorder records API request/response exchanges into a cassette file
+and replays them later, such that tests can reproduce the behavior of a
+specific vCenter or ESX without a simulator or lab.
+
+A Recorder can wrap an http.RoundTripper, which captures any traffic sent via
+a soap.Client, including SOAP and vAPI REST requests, or a soap.RoundTripper,
+which captures SOAP method calls before they are encoded for the wire.
+Both share the same cassette.
+
+	rec, err := recorder.New("testdata/issue-1234.json", recorder.Record)
+	...
+	c.Client.Transport = rec.Transport(c.Client.Transport)
+	...
+	err = rec.Save()
+
+In Replay mode, requests are matched against the recorded interactions using
+the Recorder.Matcher and no requests are sent to a server.
+*/
+package recorder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// Mode of a Recorder
+type Mode int
+
+const (
+	// Record sends requests to the server and records the exchanges.
+	Record = Mode(iota)
+	// Replay responds to requests using the recorded exchanges.
+	Replay
+)
+
+// Kind of a recorded interaction
+const (
+	KindHTTP = "http"
+	KindSOAP = "soap"
+)
+
+// ErrNoMatch is returned in Replay mode when a request has no matching interaction.
+var ErrNoMatch = errors.New("recorder: no matching interaction")
+
+// Request is the recorded form of a request.
+type Request struct {
+	Kind      string `json:"kind"`
+	Method    string `json:"method,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Action    string `json:"action,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Body      Body   `json:"body,omitempty"`
+}
+
+// Response is the recorded form of a response.
+type Response struct {
+	Status int                 `json:"status,omitempty"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   Body                `json:"body,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+// Interaction is a recorded request/response exchange.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the file format used to store interactions.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Body is encoded as a JSON string when valid UTF-8, base64 encoded otherwise.
+type Body []byte
+
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+
+	var enc struct {
+		Base64 []byte `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	*b = enc.Base64
+	return nil
+}
+
+// Matcher reports whether the request matches a recorded request.
+type Matcher func(req, recorded *Request) bool
+
+// MatchRequest is the default Matcher, requiring an exact match of the request, including body.
+func MatchRequest(req, recorded *Request) bool {
+	return MatchOperation(req, recorded) && bytes.Equal(req.Body, recorded.Body)
+}
+
+// MatchOperation ignores the request body, matching the request method, URL and the SOAP operation name.
+// Use this Matcher when request bodies vary between runs, such as those that include timestamps.
+func MatchOperation(req, recorded *Request) bool {
+	return req.Kind == recorded.Kind &&
+		req.Method == recorded.Method &&
+		req.URL == recorded.URL &&
+		req.Action == recorded.Action &&
+		req.Operation == recorded.Operation
+}
+
+const redacted = "********"
+
+var (
+	passwords = regexp.MustCompile(`(<password[^>]*>)[^<]*(</password>)`)
+	cookies   = regexp.MustCompile(`(<vcSessionCookie>)[^<]*(</vcSessionCookie>)`)
+	// WS-Security SOAP headers, such as those used by LoginByToken
+	security = regexp.MustCompile(`(?s)(<(?:\w+:)?Security\b[^>]*>).*?(</(?:\w+:)?Security>)`)
+	// SAML tokens, such as those returned by the STS
+	assertions = regexp.MustCompile(`(?s)(<(?:\w+:)?Assertion\b[^>]*>).*?(</(?:\w+:)?Assertion>)`)
+)
+
+func redactBody(b []byte) []byte {
+	for _, re := range []*regexp.Regexp{passwords, cookies, security, assertions} {
+		b = re.ReplaceAll(b, []byte("${1}"+redacted+"${2}"))
+	}
+	return b
+}
+
+// RedactSecrets is the default Recorder.Redact func, replacing SOAP Login passwords, SAML tokens and WS-Security
+// headers, along with the session IDs seen by the Recorder. Session IDs include vmware_soap_session cookies,
+// vmware-api-session-id headers and the IDs returned by vAPI session create requests, which are replaced
+// in both request and response bodies and in response headers.
+func (r *Recorder) RedactSecrets(i *Interaction) {
+	r.mu.Lock()
+	secrets := make([][]byte, 0, len(r.secrets))
+	for s := range r.secrets {
+		secrets = append(secrets, []byte(s))
+	}
+	r.mu.Unlock()
+
+	replace := func(b []byte) []byte {
+		for _, s := range secrets {
+			b = bytes.ReplaceAll(b, s, []byte(redacted))
+		}
+		return b
+	}
+
+	i.Request.URL = string(replace([]byte(i.Request.URL)))
+	i.Request.Body = replace(redactBody(i.Request.Body))
+	i.Response.Body = replace(redactBody(i.Response.Body))
+
+	for name, values := range i.Response.Header {
+		for j := range values {
+			values[j] = string(replace([]byte(values[j])))
+		}
+		i.Response.Header[name] = values
+	}
+}
+
+// Recorder records and replays interactions.
+type Recorder struct {
+	// Matcher defaults to MatchRequest.
+	Matcher Matcher
+	// Redact is applied to interactions before they are recorded and to requests before they are matched,
+	// defaults to Recorder.RedactSecrets.
+	Redact func(*Interaction)
+
+	mode    Mode
+	file    string
+	mu      sync.Mutex
+	tape    Cassette
+	played  []bool
+	secrets map[string]bool
+}
+
+// New creates a Recorder for the given cassette file.
+// In Replay mode, the file is loaded and must exist.
+func New(file string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		Matcher: MatchRequest,
+		mode:    mode,
+		file:    file,
+		secrets: make(map[string]bool),
+	}
+	r.Redact = r.RedactSecrets
+
+	if mode == Replay {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &r.tape); err != nil {
+			return nil, fmt.Errorf("recorder: %s: %w", file, err)
+		}
+		r.played = make([]bool, len(r.tape.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the Recorder Mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Interactions returns the recorded interactions.
+func (r *Recorder) Interactions() []*Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Interaction(nil), r.tape.Interactions...)
+}
+
+// Save writes the recorded interactions to the cassette file.
+// Save is a no-op in Replay mode.
+func (r *Recorder) Save() error {
+	if r.mode == Replay {
+		return nil
+	}
+
+	r.mu.Lock()
+	b, err := json.MarshalIndent(&r.tape, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.file, b, 0600)
+}
+
+func (r *Recorder) redact(i *Interaction) {
+	if r.Redact != nil {
+		r.Redact(i)
+	}
+}
+
+// secret adds a session ID to be redacted by RedactSecrets
+func (r *Recorder) secret(id string) {
+	if id == "" || id == redacted {
+		return
+	}
+	r.mu.Lock()
+	r.secrets[id] = true
+	r.mu.Unlock()
+}
+
+func (r *Recorder) record(i *Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tape.Interactions = append(r.tape.Interactions, i)
+}
+
+// match returns the first interaction not yet played that matches req.
+func (r *Recorder) match(req *Request) (*Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, rec := range r.tape.Interactions {
+		if r.played[i] || !r.Matcher(req, &rec.Request) {
+			continue
+		}
+		r.played[i] = true
+		return rec, nil
+	}
+
+	name := req.Operation
+	if name == "" {
+		name = req.Method + " " + req.URL
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, req.Kind, name)
+}
+
+// operation returns the name of the first element in a SOAP envelope Body, if any.
+func operation(body []byte) string {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	inBody := false
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if inBody {
+				return start.Name.Local
+			}
+			inBody = start.Name.Local == "Body"
+		}
+	}
+}
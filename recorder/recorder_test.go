@@ -0,0 +1,237 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package recorder_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/recorder"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func vmNames(ctx context.Context, c *vim25.Client) ([]string, error) {
+	vms, err := find.NewFinder(c).VirtualMachineList(ctx, "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, vm := range vms {
+		names = append(names, vm.Name())
+	}
+
+	// method fault
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-enoent"}
+	_, err = object.NewVirtualMachine(c, ref).Device(ctx)
+	if !fault.Is(err, &types.ManagedObjectNotFound{}) {
+		return nil, errors.New("expected ManagedObjectNotFound")
+	}
+
+	return names, nil
+}
+
+// newClient creates a vim25.Client using the given recorder, with a soap.Client for the given URL,
+// or a URL where nothing is listening when replaying.
+func newClient(t *testing.T, ctx context.Context, u *url.URL, rec *recorder.Recorder, soapRT bool) *vim25.Client {
+	if u == nil {
+		var err error
+		u, err = soap.ParseURL("https://127.0.0.1:1/sdk")
+		require.NoError(t, err)
+	}
+
+	sc := soap.NewClient(u, true)
+
+	var rt soap.RoundTripper = sc
+	if soapRT {
+		rt = rec.RoundTripper(sc)
+	} else {
+		sc.Transport = rec.Transport(sc.Transport)
+	}
+
+	c, err := vim25.NewClient(ctx, rt)
+	require.NoError(t, err)
+	c.Client = sc
+
+	return c
+}
+
+func TestTransport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	var expect []string
+
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		rec, err := recorder.New(file, recorder.Record)
+		require.NoError(t, err)
+
+		c := newClient(t, ctx, vc.URL(), rec, false)
+		require.NoError(t, session.NewManager(c).Login(ctx, url.UserPassword("user", "s3cret")))
+
+		expect, err = vmNames(ctx, c)
+		require.NoError(t, err)
+		require.NotEmpty(t, expect)
+
+		rc := rest.NewClient(c)
+		rc.Transport = rec.Transport(rc.Transport)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+		_, err = tags.NewManager(rc).CreateCategory(ctx, &tags.Category{Name: "recorded"})
+		require.NoError(t, err)
+
+		require.NoError(t, rec.Save())
+	})
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "s3cret")
+
+	rec, err := recorder.New(file, recorder.Replay)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c := newClient(t, ctx, nil, rec, false)
+	require.NoError(t, session.NewManager(c).Login(ctx, url.UserPassword("user", "other")))
+
+	names, err := vmNames(ctx, c)
+	require.NoError(t, err)
+	assert.Equal(t, expect, names)
+
+	rc := rest.NewClient(c)
+	rc.Transport = rec.Transport(nil)
+	require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+	_, err = tags.NewManager(rc).CreateCategory(ctx, &tags.Category{Name: "recorded"})
+	require.NoError(t, err)
+
+	_, err = tags.NewManager(rc).GetCategories(ctx)
+	assert.ErrorContains(t, err, recorder.ErrNoMatch.Error()) // was not recorded
+
+	// each interaction is played once
+	_, err = methods.GetServiceContent(ctx, c)
+	assert.ErrorIs(t, err, recorder.ErrNoMatch)
+}
+
+func TestRoundTripper(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	var expect []string
+
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		rec, err := recorder.New(file, recorder.Record)
+		require.NoError(t, err)
+
+		c := newClient(t, ctx, vc.URL(), rec, true)
+		require.NoError(t, session.NewManager(c).Login(ctx, url.UserPassword("user", "s3cret")))
+
+		expect, err = vmNames(ctx, c)
+		require.NoError(t, err)
+
+		require.NoError(t, rec.Save())
+	})
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "s3cret")
+
+	rec, err := recorder.New(file, recorder.Replay)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	c := newClient(t, ctx, nil, rec, true)
+
+	require.NoError(t, session.NewManager(c).Login(ctx, url.UserPassword("user", "other")))
+
+	names, err := vmNames(ctx, c)
+	require.NoError(t, err)
+	assert.Equal(t, expect, names)
+}
+
+func TestRedactSessions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "cassette.json")
+	var secrets []string
+
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		rec, err := recorder.New(file, recorder.Record)
+		require.NoError(t, err)
+
+		c := newClient(t, ctx, vc.URL(), rec, false)
+		require.NoError(t, session.NewManager(c).Login(ctx, url.UserPassword("user", "s3cret")))
+		_, err = methods.GetCurrentTime(ctx, c)
+		require.NoError(t, err)
+
+		for _, cookie := range c.Client.Jar.Cookies(c.URL()) {
+			if cookie.Name == soap.SessionCookieName {
+				secrets = append(secrets, cookie.Value)
+			}
+		}
+
+		rc := rest.NewClient(c)
+		rc.Transport = rec.Transport(rc.Transport)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+		_, err = tags.NewManager(rc).GetCategories(ctx)
+		require.NoError(t, err)
+		secrets = append(secrets, rc.SessionID())
+
+		require.NoError(t, rec.Save())
+	})
+
+	require.Len(t, secrets, 2)
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	for _, s := range secrets {
+		assert.NotEmpty(t, s)
+		assert.NotContains(t, string(b), s)
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	rec, err := recorder.New(filepath.Join(t.TempDir(), "cassette.json"), recorder.Record)
+	require.NoError(t, err)
+
+	i := &recorder.Interaction{
+		Request: recorder.Request{
+			Body: []byte(`<Envelope><Header><wsse:Security xmlns:wsse="ns"><saml2:Assertion ID="a">token</saml2:Assertion>` +
+				`<ds:Signature>sig</ds:Signature></wsse:Security><vcSessionCookie>cookie</vcSessionCookie></Header>` +
+				`<Body><Login><password>s3cret</password></Login></Body></Envelope>`),
+		},
+		Response: recorder.Response{
+			Body: []byte(`<RequestedSecurityToken><saml2:Assertion ID="b">issued</saml2:Assertion></RequestedSecurityToken>`),
+		},
+	}
+	rec.RedactSecrets(i)
+
+	for _, s := range []string{"token", "sig", "cookie", "s3cret"} {
+		assert.NotContains(t, string(i.Request.Body), s)
+	}
+	assert.NotContains(t, string(i.Response.Body), "issued")
+	assert.Contains(t, string(i.Response.Body), "<RequestedSecurityToken><saml2:Assertion")
+}
+
+func TestMatchOperation(t *testing.T) {
+	req := &recorder.Request{Kind: recorder.KindSOAP, Operation: "CurrentTime", Body: []byte("a")}
+	rec := &recorder.Request{Kind: recorder.KindSOAP, Operation: "CurrentTime", Body: []byte("b")}
+
+	assert.False(t, recorder.MatchRequest(req, rec))
+	assert.True(t, recorder.MatchOperation(req, rec))
+
+	rec.Operation = "RetrieveServiceContent"
+	assert.False(t, recorder.MatchOperation(req, rec))
+}
@@ -0,0 +1,86 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+type roundTripper struct {
+	*Recorder
+
+	rt soap.RoundTripper
+}
+
+// RoundTripper returns a soap.RoundTripper that records the method calls sent via rt,
+// or replays them in Replay mode, in which case rt is not used and can be nil.
+func (r *Recorder) RoundTripper(rt soap.RoundTripper) soap.RoundTripper {
+	return &roundTripper{Recorder: r, rt: rt}
+}
+
+// Unwrap returns the wrapped soap.RoundTripper.
+func (t *roundTripper) Unwrap() soap.RoundTripper {
+	return t.rt
+}
+
+func (t *roundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	body, err := xml.Marshal(soap.Envelope{Body: req})
+	if err != nil {
+		return err
+	}
+
+	r := Request{
+		Kind:      KindSOAP,
+		Operation: operation(body),
+		Body:      body,
+	}
+
+	if t.mode == Replay {
+		i := &Interaction{Request: r}
+		t.redact(i)
+		i, err := t.match(&i.Request)
+		if err != nil {
+			return err
+		}
+		if i.Response.Error != "" {
+			return errors.New(i.Response.Error)
+		}
+
+		dec := xml.NewDecoder(bytes.NewReader(i.Response.Body))
+		dec.TypeFunc = types.TypeFunc()
+		if err := dec.Decode(&soap.Envelope{Body: res}); err != nil {
+			return err
+		}
+		if f := res.Fault(); f != nil {
+			return soap.WrapSoapFault(f)
+		}
+		return nil
+	}
+
+	err = t.rt.RoundTrip(ctx, req, res)
+
+	var rec Response
+	if err != nil && res.Fault() == nil {
+		rec.Error = err.Error()
+	} else {
+		b, merr := xml.Marshal(soap.Envelope{Body: res})
+		if merr != nil {
+			return merr
+		}
+		rec.Body = b
+	}
+
+	i := &Interaction{Request: r, Response: rec}
+	t.redact(i)
+	t.record(i)
+
+	return err
+}
@@ -140,6 +140,11 @@ type HandlerSOAP struct {
 	roundTripper soap.RoundTripper
 }
 
+// Unwrap returns the wrapped soap.RoundTripper.
+func (h *HandlerSOAP) Unwrap() soap.RoundTripper {
+	return h.roundTripper
+}
+
 // RoundTrip implements soap.RoundTripper
 func (h *HandlerSOAP) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
 	// Stop ticker on logout.
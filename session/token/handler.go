@@ -0,0 +1,194 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// relogin serializes session re-establishment, such that concurrent requests failing
+// with the same stale session result in a single login.
+type relogin struct {
+	mu  sync.Mutex
+	gen uint64
+}
+
+func (r *relogin) generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gen
+}
+
+// login calls fn, unless another request has logged in since the given generation.
+func (r *relogin) login(gen uint64, fn func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.gen != gen {
+		return nil
+	}
+
+	if err := fn(); err != nil {
+		return err
+	}
+
+	r.gen++
+	return nil
+}
+
+// HandlerSOAP is a soap.RoundTripper for use with vim25.Client,
+// re-establishing the session via LoginByToken when a method call fails with NotAuthenticated,
+// then retrying the method call.
+type HandlerSOAP struct {
+	relogin
+
+	m            *Manager
+	c            *vim25.Client
+	roundTripper soap.RoundTripper
+}
+
+// NewHandlerSOAP returns a HandlerSOAP that wraps the given vim25.Client's RoundTripper.
+// The returned handler should be set as the Client's RoundTripper.
+func NewHandlerSOAP(m *Manager, c *vim25.Client) *HandlerSOAP {
+	return &HandlerSOAP{m: m, c: c, roundTripper: c.RoundTripper}
+}
+
+// Unwrap returns the wrapped soap.RoundTripper.
+func (h *HandlerSOAP) Unwrap() soap.RoundTripper {
+	return h.roundTripper
+}
+
+// RoundTrip implements soap.RoundTripper
+func (h *HandlerSOAP) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	switch req.(type) {
+	case *methods.LoginBody, *methods.LoginExtensionByCertificateBody, *methods.LoginByTokenBody, *methods.LogoutBody:
+		return h.roundTripper.RoundTrip(ctx, req, res)
+	}
+
+	gen := h.generation()
+
+	err := h.roundTripper.RoundTrip(ctx, req, res)
+	if err == nil || !fault.Is(err, &types.NotAuthenticated{}) {
+		return err
+	}
+
+	if lerr := h.login(gen, func() error { return h.m.Login(ctx, h.c) }); lerr != nil {
+		return err
+	}
+
+	// clear the fault from the previous attempt
+	if v := reflect.ValueOf(res); v.Kind() == reflect.Pointer && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+
+	return h.roundTripper.RoundTrip(ctx, req, res)
+}
+
+// HandlerREST is an http.RoundTripper for use with rest.Client,
+// re-establishing the session via LoginByToken when a request fails with 401 Unauthorized,
+// then retrying the request.
+// Requests with a non-empty body are only retried if http.Request.GetBody is set.
+type HandlerREST struct {
+	relogin
+
+	m            *Manager
+	c            *rest.Client
+	roundTripper http.RoundTripper
+}
+
+// NewHandlerREST returns a HandlerREST that wraps the given rest.Client's Transport.
+// The returned handler should be set as the Client's Transport.
+func NewHandlerREST(m *Manager, c *rest.Client) *HandlerREST {
+	return &HandlerREST{m: m, c: c, roundTripper: c.Transport}
+}
+
+const sessionHeader = "vmware-api-session-id"
+
+// isSession returns true if the request targets the session endpoint
+func isSession(req *http.Request) bool {
+	return req.URL.Path == "/rest/com/vmware/cis/session" || req.URL.Path == "/api/session"
+}
+
+// peekBody reads the first byte of a body with unknown length, returning a copy of req
+// with http.NoBody if the body is empty, such as the default rest.Resource.Request body,
+// or with the byte read restored otherwise.
+func peekBody(req *http.Request) *http.Request {
+	var b [1]byte
+	n, err := io.ReadFull(req.Body, b[:])
+
+	r := req.Clone(req.Context())
+	if n == 0 && err == io.EOF {
+		_ = req.Body.Close()
+		r.Body = http.NoBody
+		return r
+	}
+
+	if err == nil {
+		err = io.EOF // MultiReader continues with req.Body
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b[:n]), errReader{err}, req.Body), req.Body}
+
+	return r
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// RoundTrip implements http.RoundTripper
+func (h *HandlerREST) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil && req.ContentLength == 0 {
+		req = peekBody(req)
+	}
+
+	retry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	if !retry || isSession(req) {
+		return h.roundTripper.RoundTrip(req)
+	}
+
+	gen := h.generation()
+
+	res, err := h.roundTripper.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	ctx := req.Context()
+	if lerr := h.login(gen, func() error { return h.m.LoginREST(ctx, h.c) }); lerr != nil {
+		return res, nil
+	}
+	_ = res.Body.Close()
+
+	retryReq := req.Clone(ctx)
+	if req.GetBody != nil {
+		if retryReq.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	if id := h.c.SessionID(); id != "" {
+		retryReq.Header.Set(sessionHeader, id)
+	}
+
+	return h.roundTripper.RoundTrip(retryReq)
+}
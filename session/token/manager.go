@@ -0,0 +1,214 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package token renews SAML tokens issued by the STS before they expire,
+and re-establishes SOAP and REST sessions using the current token when the server drops a session.
+*/
+package token
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/sts"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Manager issues a SAML token and keeps it valid, by renewing the token before it expires.
+type Manager struct {
+	// RenewBefore is the time before token expiration when the token is renewed.
+	// Defaults to 1/5 of the token's lifetime.
+	RenewBefore time.Duration
+
+	// OnRenew, if set, is called after a token is issued or renewed.
+	OnRenew func(*sts.Signer)
+
+	client  *sts.Client
+	request sts.TokenRequest
+
+	mu     sync.Mutex
+	signer *sts.Signer
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewManager returns a Manager that issues tokens using the given STS client and request.
+// Tokens are renewed via sts.Client.Renew when the request is Renewable and has a Certificate (Holder-of-Key),
+// otherwise a new token is issued with the given request.
+func NewManager(c *sts.Client, req sts.TokenRequest) *Manager {
+	return &Manager{client: c, request: req}
+}
+
+// window returns the time before expiration when the given token should be renewed.
+func (m *Manager) window(s *sts.Signer) time.Duration {
+	if m.RenewBefore > 0 {
+		return m.RenewBefore
+	}
+	return s.Lifetime.Expires.Sub(s.Lifetime.Created) / 5
+}
+
+// expiring returns true if the token is nil or within the renewal window.
+func (m *Manager) expiring(s *sts.Signer) bool {
+	if s == nil {
+		return true
+	}
+	if s.Lifetime.Expires.IsZero() {
+		return false // lifetime unknown
+	}
+	return time.Until(s.Lifetime.Expires) <= m.window(s)
+}
+
+// Signer returns the current token, issuing or renewing the token if needed.
+func (m *Manager) Signer(ctx context.Context) (*sts.Signer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.expiring(m.signer) {
+		return m.signer, nil
+	}
+
+	return m.renew(ctx)
+}
+
+// Renew renews the current token, or issues a new token if it cannot be renewed.
+func (m *Manager) Renew(ctx context.Context) (*sts.Signer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.renew(ctx)
+}
+
+func (m *Manager) renew(ctx context.Context) (*sts.Signer, error) {
+	var s *sts.Signer
+	var rerr error
+
+	cur := m.signer
+	if cur != nil && m.request.Renewable && m.request.Certificate != nil && time.Now().Before(cur.Lifetime.Expires) {
+		req := m.request
+		req.Token = cur.Token
+		req.ActAs = false
+		s, rerr = m.client.Renew(ctx, req)
+	}
+
+	if s == nil {
+		// not renewable, expired or failed to renew
+		var err error
+		s, err = m.client.Issue(ctx, m.request)
+		if err != nil {
+			return nil, errors.Join(rerr, err)
+		}
+	}
+
+	m.signer = s
+
+	if m.OnRenew != nil {
+		m.OnRenew(s)
+	}
+
+	return s, nil
+}
+
+// Start starts a goroutine that renews the token before it expires, until Stop is called.
+// If renewal fails, it is retried after 1/4 of the renewal window.
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		return
+	}
+
+	m.stop = make(chan struct{})
+	m.wg.Add(1)
+
+	go func(stop chan struct{}) {
+		defer m.wg.Done()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-stop
+			cancel()
+		}()
+
+		for {
+			m.mu.Lock()
+			s := m.signer
+			m.mu.Unlock()
+
+			var wait time.Duration
+			if s != nil {
+				if s.Lifetime.Expires.IsZero() {
+					<-stop // lifetime unknown
+					return
+				}
+				wait = time.Until(s.Lifetime.Expires) - m.window(s)
+			}
+
+			timer := time.NewTimer(max(wait, 0))
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if _, err := m.Signer(ctx); err != nil {
+				retry := time.Second
+				if s != nil {
+					retry = max(m.window(s)/4, retry)
+				}
+				timer.Reset(retry)
+				select {
+				case <-stop:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}
+	}(m.stop)
+}
+
+// Stop stops the renewal goroutine.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		m.wg.Wait()
+	}
+}
+
+// Login creates a new session via SessionManager.LoginByToken, using the current token.
+func (m *Manager) Login(ctx context.Context, c *vim25.Client) error {
+	s, err := m.Signer(ctx)
+	if err != nil {
+		return err
+	}
+
+	header := soap.Header{Security: s}
+
+	return session.NewManager(c).LoginByToken(c.WithHeader(ctx, header))
+}
+
+// LoginREST creates a new session via rest.Client.LoginByToken, using the current token.
+func (m *Manager) LoginREST(ctx context.Context, c *rest.Client) error {
+	s, err := m.Signer(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.LoginByToken(c.WithSigner(ctx, s))
+}
@@ -0,0 +1,229 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package token_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/vmware/govmomi/lookup/simulator"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/session/token"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/sts"
+	_ "github.com/vmware/govmomi/sts/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+func certificate(t *testing.T) *tls.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "govmomi-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// counter counts method calls by request body type name, failing calls with an error in fail
+type counter struct {
+	sync.Mutex
+	rt    soap.RoundTripper
+	calls map[string]int
+	fail  map[string]error
+}
+
+func (c *counter) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	c.Lock()
+	if c.calls == nil {
+		c.calls = make(map[string]int)
+	}
+	name := reflect.Indirect(reflect.ValueOf(req)).Type().Name()
+	c.calls[name]++
+	err := c.fail[name]
+	c.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return c.rt.RoundTrip(ctx, req, res)
+}
+
+func (c *counter) count(name string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.calls[name]
+}
+
+func TestManagerRenew(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		stsClient, err := sts.NewClient(ctx, c)
+		require.NoError(t, err)
+		calls := &counter{rt: stsClient.RoundTripper}
+		stsClient.RoundTripper = calls
+
+		lifetime := time.Second
+		m := token.NewManager(stsClient, sts.TokenRequest{
+			Certificate: certificate(t),
+			Lifetime:    lifetime,
+			Renewable:   true,
+		})
+
+		var mu sync.Mutex
+		var tokens []*sts.Signer
+		m.OnRenew = func(s *sts.Signer) {
+			mu.Lock()
+			tokens = append(tokens, s)
+			mu.Unlock()
+		}
+
+		s, err := m.Signer(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, lifetime, s.Lifetime.Expires.Sub(s.Lifetime.Created))
+
+		same, err := m.Signer(ctx)
+		require.NoError(t, err)
+		assert.Same(t, s, same)
+		assert.Equal(t, 1, calls.count("RequestSecurityTokenBody"))
+
+		m.Start()
+		assert.Eventually(t, func() bool {
+			return calls.count("RenewSecurityTokenBody") >= 2
+		}, 5*time.Second, 10*time.Millisecond)
+		m.Stop()
+
+		mu.Lock()
+		defer mu.Unlock()
+		for i := 1; i < len(tokens); i++ {
+			// each token is renewed before the previous one expires
+			assert.True(t, tokens[i].Lifetime.Created.Before(tokens[i-1].Lifetime.Expires))
+		}
+		assert.Equal(t, 1, calls.count("RequestSecurityTokenBody"))
+
+		// not renewable: a new token is issued
+		m = token.NewManager(stsClient, sts.TokenRequest{Certificate: certificate(t)})
+		_, err = m.Signer(ctx)
+		require.NoError(t, err)
+		_, err = m.Renew(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls.count("RequestSecurityTokenBody"))
+
+		// renew and issue both fail: both errors are returned
+		m = token.NewManager(stsClient, sts.TokenRequest{Certificate: certificate(t), Renewable: true})
+		_, err = m.Signer(ctx)
+		require.NoError(t, err)
+
+		errRenew, errIssue := errors.New("renew"), errors.New("issue")
+		calls.Lock()
+		calls.fail = map[string]error{"RenewSecurityTokenBody": errRenew, "RequestSecurityTokenBody": errIssue}
+		calls.Unlock()
+
+		_, err = m.Renew(ctx)
+		assert.ErrorIs(t, err, errRenew)
+		assert.ErrorIs(t, err, errIssue)
+	})
+}
+
+func TestHandlerSOAP(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		stsClient, err := sts.NewClient(ctx, c)
+		require.NoError(t, err)
+		m := token.NewManager(stsClient, sts.TokenRequest{Certificate: certificate(t)})
+
+		vc, err := vim25.NewClient(ctx, soap.NewClient(c.URL(), true))
+		require.NoError(t, err)
+		calls := &counter{rt: vc.RoundTripper}
+		vc.RoundTripper = calls
+		vc.RoundTripper = token.NewHandlerSOAP(m, vc)
+
+		sm := session.NewManager(vc)
+
+		// not logged in: NotAuthenticated -> LoginByToken -> retry
+		_, err = methods.GetCurrentTime(ctx, vc)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls.count("LoginByTokenBody"))
+
+		s, err := sm.UserSession(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, s)
+
+		// session dropped by the server
+		require.NoError(t, session.NewManager(c).TerminateSession(ctx, []string{s.Key}))
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := methods.GetCurrentTime(ctx, vc)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 2, calls.count("LoginByTokenBody"))
+
+		// Logout is not retried
+		require.NoError(t, sm.Logout(ctx))
+		assert.Error(t, sm.Logout(ctx))
+		assert.Equal(t, 2, calls.count("LoginByTokenBody"))
+	})
+}
+
+func TestHandlerREST(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		stsClient, err := sts.NewClient(ctx, c)
+		require.NoError(t, err)
+		m := token.NewManager(stsClient, sts.TokenRequest{Certificate: certificate(t)})
+
+		rc := rest.NewClient(c)
+		rc.Transport = token.NewHandlerREST(m, rc)
+
+		tm := tags.NewManager(rc)
+
+		// not logged in: 401 -> LoginByToken -> retry
+		_, err = tm.GetCategories(ctx)
+		require.NoError(t, err)
+		id := rc.SessionID()
+		assert.NotEmpty(t, id)
+
+		// session dropped by the server, request with a body is also retried
+		require.NoError(t, rc.Logout(ctx))
+
+		_, err = tm.CreateCategory(ctx, &tags.Category{Name: "retry"})
+		require.NoError(t, err)
+		assert.NotEqual(t, id, rc.SessionID())
+
+		categories, err := tm.GetCategories(ctx)
+		require.NoError(t, err)
+		require.Len(t, categories, 1)
+		assert.Equal(t, "retry", categories[0].Name)
+	})
+}
@@ -0,0 +1,290 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
+)
+
+// checkpointDir is the Service.Checkpoint subdirectory for state other than the vim25 inventory.
+// Model.Load only reads top-level files, so a checkpoint can also be loaded via 'vcsim -load'.
+const checkpointDir = "vcsim"
+
+// Checkpointer is implemented by endpoint handlers that have state to include in a Service checkpoint,
+// for example vapi/simulator's tags and content libraries.
+// Handlers passed to Service.Handle that implement Checkpointer are registered automatically.
+// The same directory is given to all Checkpointers, each must use file names unique to the endpoint.
+type Checkpointer interface {
+	Checkpoint(dir string) error
+	Restore(dir string) error
+}
+
+func (s *Service) addCheckpointer(c Checkpointer) {
+	if slices.Contains(s.checkpointers, c) {
+		return // registered with multiple patterns
+	}
+	s.checkpointers = append(s.checkpointers, c)
+
+	if s.restore != "" {
+		if err := c.Restore(s.restore); err != nil {
+			tracef("restore %T: %s", c, err)
+		}
+	}
+}
+
+// checkpointObjects returns the vim25 inventory, ordered such that Model.Load can restore from it.
+func (s *Service) checkpointObjects() []types.ObjectContent {
+	ctx := s.Context
+
+	refs := ctx.Map.AllReference("")
+	setting := *ctx.Map.content().Setting
+
+	rank := func(ref types.ManagedObjectReference) int {
+		switch ref {
+		case vim25.ServiceInstance:
+			return 0
+		case setting: // Model.Load creates the vcsim.home directory before any Datastore
+			return 1
+		}
+		return 2
+	}
+
+	slices.SortFunc(refs, func(a, b mo.Reference) int {
+		x, y := a.Reference(), b.Reference()
+		if n := rank(x) - rank(y); n != 0 {
+			return n
+		}
+		if n := strings.Compare(x.Type, y.Type); n != 0 {
+			return n
+		}
+		return strings.Compare(x.Value, y.Value)
+	})
+
+	rr := new(retrieveResult)
+	var objects []types.ObjectContent
+
+	for _, obj := range refs {
+		content := types.ObjectContent{Obj: obj.Reference()}
+
+		ctx.WithLock(obj, func() {
+			rval, ok := getObject(ctx, content.Obj)
+			if !ok {
+				return
+			}
+			rr.collectAll(ctx, rval, rval.Type(), &content)
+
+			var dst types.ObjectContent
+			deepCopy(&content, &dst)
+			content = dst
+		})
+
+		if content.Obj.Type == "Task" {
+			checkpointTask(&content)
+		}
+
+		objects = append(objects, content)
+	}
+
+	return objects
+}
+
+// checkpointTask marks a Task that has not yet completed as failed,
+// as it cannot be resumed after a restore.
+func checkpointTask(content *types.ObjectContent) {
+	for i, p := range content.PropSet {
+		if p.Name != "info" {
+			continue
+		}
+		info := p.Val.(types.TaskInfo)
+		switch info.State {
+		case types.TaskInfoStateQueued, types.TaskInfoStateRunning:
+			info.State = types.TaskInfoStateError
+			info.Error = &types.LocalizedMethodFault{
+				Fault:            new(types.RequestCanceled),
+				LocalizedMessage: "Task interrupted by vcsim checkpoint",
+			}
+			content.PropSet[i].Val = info
+		}
+	}
+}
+
+func checkpointWrite(name string, data any) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(f)
+	e.Indent("", "  ")
+
+	if err = e.Encode(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// copyDir copies the files in src to dst, creating dst if needed.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			return err
+		}
+
+		if _, err = io.Copy(out, in); err != nil {
+			_ = out.Close()
+			return err
+		}
+
+		return out.Close()
+	})
+}
+
+// Checkpoint saves the live state of the Service to dir, replacing any existing content,
+// which can be restored via Model.Restore. This includes the vim25 inventory (in the same format as
+// 'govc object.save'), Tasks, Events, datastore files and the state of any registered Checkpointer,
+// such as tags and content libraries. Sessions and session scoped objects, such as views and leases,
+// are not included.
+func (s *Service) Checkpoint(dir string) error {
+	dir = filepath.Clean(dir)
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+"-checkpoint-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for i, content := range s.checkpointObjects() {
+		name := filepath.Join(tmp, fmt.Sprintf("%04d-%s.xml", i, content.Obj.Encode()))
+		if err = checkpointWrite(name, content); err != nil {
+			return err
+		}
+	}
+
+	ctx := s.Context
+	state := filepath.Join(tmp, checkpointDir)
+
+	for _, ds := range ctx.Map.All("Datastore") {
+		ds := ds.(*Datastore)
+		src := ds.Info.GetDatastoreInfo().Url
+		if _, err = os.Stat(src); err != nil {
+			continue
+		}
+		if err = copyDir(src, filepath.Join(state, "datastore", ds.Self.Value)); err != nil {
+			return err
+		}
+	}
+
+	m := ctx.Map.EventManager()
+	var events types.ArrayOfEvent
+	ctx.WithLock(m, func() {
+		for e := m.history.page.Front(); e != nil; e = e.Next() {
+			events.Event = append(events.Event, e.Value.(types.BaseEvent))
+		}
+	})
+	if err = checkpointWrite(filepath.Join(state, "events.xml"), events); err != nil {
+		return err
+	}
+
+	endpoint := filepath.Join(state, "endpoint")
+	if err = os.MkdirAll(endpoint, 0700); err != nil {
+		return err
+	}
+	for _, c := range s.checkpointers {
+		if err = c.Checkpoint(endpoint); err != nil {
+			return err
+		}
+	}
+
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dir)
+}
+
+// Restore Model from the given directory, as created by Service.Checkpoint.
+// Checkpointer state is restored when endpoints are registered by Service.NewServer.
+func (m *Model) Restore(dir string) error {
+	if err := m.Load(dir); err != nil {
+		return err
+	}
+
+	ctx := m.Service.Context
+	state := filepath.Join(dir, checkpointDir)
+
+	for _, ds := range ctx.Map.All("Datastore") {
+		ds := ds.(*Datastore)
+		src := filepath.Join(state, "datastore", ds.Self.Value)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		if err := copyDir(src, ds.Info.GetDatastoreInfo().Url); err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range ctx.Map.All("VirtualMachine") {
+		vm := obj.(*VirtualMachine)
+		if vm.Config == nil {
+			continue
+		}
+		if f, fault := vm.createFile(ctx, vm.Config.Files.LogDirectory, "vmware.log", true); fault == nil {
+			vm.log = f.Name()
+			_ = f.Close()
+		}
+	}
+
+	var events types.ArrayOfEvent
+	if err := m.decode(filepath.Join(state, "events.xml"), &events); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	em := ctx.Map.EventManager()
+	for _, event := range events.Event {
+		pushHistory(em.history.page, event)
+		em.key = max(em.key, event.GetEvent().Key)
+	}
+
+	m.Service.restore = filepath.Join(state, "endpoint")
+
+	return nil
+}
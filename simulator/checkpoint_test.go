@@ -0,0 +1,124 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestServiceCheckpoint(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	data := []byte("checkpoint")
+	var tagID string
+	var vmRef types.ManagedObjectReference
+	var nevents int
+
+	m := simulator.VPX()
+
+	err := m.Run(func(ctx context.Context, c *vim25.Client) error {
+		finder := find.NewFinder(c)
+		vm, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		vmRef = vm.Reference()
+
+		task, err := vm.Rename(ctx, "checkpoint-vm")
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		task, err = vm.CreateSnapshot(ctx, "s1", "", false, false)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+		require.NoError(t, ds.Upload(ctx, bytes.NewReader(data), "checkpoint.txt", &soap.DefaultUpload))
+
+		rc := rest.NewClient(c)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+		tm := tags.NewManager(rc)
+		cat, err := tm.CreateCategory(ctx, &tags.Category{Name: "checkpoint"})
+		require.NoError(t, err)
+		tagID, err = tm.CreateTag(ctx, &tags.Tag{Name: "checkpoint", CategoryID: cat})
+		require.NoError(t, err)
+		require.NoError(t, tm.AttachTag(ctx, tagID, vm))
+
+		events, err := event.NewManager(c).QueryEvents(ctx, types.EventFilterSpec{})
+		require.NoError(t, err)
+		nevents = len(events)
+
+		require.NoError(t, m.Service.Checkpoint(dir))
+		// a checkpoint replaces any existing content
+		require.NoError(t, m.Service.Checkpoint(dir))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	r := simulator.VPX()
+	require.NoError(t, r.Restore(dir))
+
+	err = r.Run(func(ctx context.Context, c *vim25.Client) error {
+		finder := find.NewFinder(c)
+		vm, err := finder.VirtualMachine(ctx, "checkpoint-vm")
+		require.NoError(t, err)
+		assert.Equal(t, vmRef, vm.Reference())
+
+		snapshot, err := vm.FindSnapshot(ctx, "s1")
+		require.NoError(t, err)
+		task, err := vm.RevertToSnapshot(ctx, snapshot.Value, true)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+		f, _, err := ds.Download(ctx, "checkpoint.txt", &soap.DefaultDownload)
+		require.NoError(t, err)
+		content, err := io.ReadAll(f)
+		_ = f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, data, content)
+
+		events, err := event.NewManager(c).QueryEvents(ctx, types.EventFilterSpec{})
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, len(events), nevents)
+
+		rc := rest.NewClient(c)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+		attached, err := tags.NewManager(rc).ListAttachedObjects(ctx, tagID)
+		require.NoError(t, err)
+		require.Len(t, attached, 1)
+		assert.Equal(t, vmRef, attached[0].Reference())
+
+		// new objects do not collide with restored references
+		folder, err := finder.Folder(ctx, "vm")
+		require.NoError(t, err)
+		task, err = vm.Clone(ctx, folder, "checkpoint-clone", types.VirtualMachineCloneSpec{})
+		require.NoError(t, err)
+		info, err := task.WaitForResult(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, vmRef, info.Result)
+
+		return nil
+	})
+	require.NoError(t, err)
+}
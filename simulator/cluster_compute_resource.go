@@ -23,7 +23,8 @@ import (
 type ClusterComputeResource struct {
 	mo.ClusterComputeResource
 
-	ruleKey int32
+	ruleKey           int32
+	recommendationKey int32
 }
 
 func (c *ClusterComputeResource) RenameTask(ctx *Context, req *types.Rename_Task) soap.HasFault {
@@ -99,6 +100,29 @@ func (c *ClusterComputeResource) update(_ *Context, cfg *types.ClusterConfigInfo
 		if val := cspec.DasConfig.AdmissionControlEnabled; val != nil {
 			cfg.DasConfig.AdmissionControlEnabled = val
 		}
+		if val := cspec.DasConfig.AdmissionControlPolicy; val != nil {
+			cfg.DasConfig.AdmissionControlPolicy = val
+		}
+		if val := cspec.DasConfig.HostMonitoring; val != "" {
+			cfg.DasConfig.HostMonitoring = val
+		}
+		if val := cspec.DasConfig.FailoverLevel; val != 0 {
+			cfg.DasConfig.FailoverLevel = val
+		}
+		if val := cspec.DasConfig.DefaultVmSettings; val != nil {
+			if cfg.DasConfig.DefaultVmSettings == nil {
+				cfg.DasConfig.DefaultVmSettings = new(types.ClusterDasVmSettings)
+			}
+			if val.RestartPriority != "" {
+				cfg.DasConfig.DefaultVmSettings.RestartPriority = val.RestartPriority
+			}
+			if val.RestartPriorityTimeout != 0 {
+				cfg.DasConfig.DefaultVmSettings.RestartPriorityTimeout = val.RestartPriorityTimeout
+			}
+			if val.IsolationResponse != "" {
+				cfg.DasConfig.DefaultVmSettings.IsolationResponse = val.IsolationResponse
+			}
+		}
 	}
 	if cspec.DrsConfig != nil {
 		if val := cspec.DrsConfig.Enabled; val != nil {
@@ -107,6 +131,12 @@ func (c *ClusterComputeResource) update(_ *Context, cfg *types.ClusterConfigInfo
 		if val := cspec.DrsConfig.DefaultVmBehavior; val != "" {
 			cfg.DrsConfig.DefaultVmBehavior = val
 		}
+		if val := cspec.DrsConfig.VmotionRate; val != 0 {
+			cfg.DrsConfig.VmotionRate = val
+		}
+		if val := cspec.DrsConfig.EnableVmBehaviorOverrides; val != nil {
+			cfg.DrsConfig.EnableVmBehaviorOverrides = val
+		}
 	}
 
 	return nil
@@ -400,12 +430,24 @@ func (c *ClusterComputeResource) ReconfigureComputeResourceTask(ctx *Context, re
 			c.updateVSAN,
 		}
 
+		das := c.dasEnabled()
+
 		for _, update := range updates {
 			if err := update(ctx, c.ConfigurationEx.(*types.ClusterConfigInfoEx), spec); err != nil {
 				return nil, err
 			}
 		}
 
+		if das != c.dasEnabled() {
+			if das {
+				ctx.postEvent(&types.DasDisabledEvent{ClusterEvent: c.event(ctx)})
+			} else {
+				ctx.postEvent(&types.DasEnabledEvent{ClusterEvent: c.event(ctx)})
+			}
+		}
+
+		c.dasProtect(ctx)
+
 		return nil, nil
 	})
 
@@ -481,10 +523,13 @@ func (c *ClusterComputeResource) PlaceVm(ctx *Context, req *types.PlaceVm) soap.
 	switch types.PlacementSpecPlacementType(req.PlacementSpec.PlacementType) {
 	case types.PlacementSpecPlacementTypeClone, types.PlacementSpecPlacementTypeCreate:
 		spec := &types.VirtualMachineRelocateSpec{
-			Datastore: &datastores[rand.Intn(len(c.Datastore))],
-			Host:      &hosts[rand.Intn(len(c.Host))],
+			Datastore: &datastores[rand.Intn(len(datastores))],
+			Host:      &hosts[rand.Intn(len(hosts))],
 			Pool:      c.ResourcePool,
 		}
+		if c.drsEnabled() {
+			c.placeVm(ctx, &req.PlacementSpec, hosts, datastores, spec)
+		}
 		res.Action = append(res.Action, &types.PlacementAction{
 			Vm:           req.PlacementSpec.Vm,
 			TargetHost:   spec.Host,
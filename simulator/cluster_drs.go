@@ -0,0 +1,694 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// DrsHost is the simulated CPU and memory capacity and demand of a host, as scored by a DrsEngine.
+type DrsHost struct {
+	Host types.ManagedObjectReference
+
+	CpuCapacity int64 // MHz
+	MemCapacity int64 // MB
+	CpuDemand   int64 // MHz
+	MemDemand   int64 // MB
+
+	// Vm is the list of powered on VMs contributing to the demand.
+	Vm []types.ManagedObjectReference
+
+	registered int // all VMs registered with the host, used as a tie-breaker for placement
+}
+
+// DrsEngine scores the load of a host for DRS placement and load balancing, where a lower score is better.
+// Placement chooses the host with the lowest score after adding the VM's demand.
+// Load balancing migrates VMs from the highest to lowest scored hosts, while the difference is
+// greater than the cluster's migration threshold (drsConfig.vmotionRate).
+// Registry.Drs can be set to replace the default engine, DrsUtilization.
+type DrsEngine interface {
+	Score(host *DrsHost) float64
+}
+
+// DrsUtilization is the default DrsEngine, scoring a host by the utilization of its most contended resource.
+// VM demand is taken from the VM's summary.quickStats (overallCpuDemand or overallCpuUsage and
+// hostMemoryUsage or guestMemoryUsage), falling back to the configured memory size.
+// See VirtualMachine.SetQuickStats.
+type DrsUtilization struct{}
+
+// Score implements DrsEngine
+func (DrsUtilization) Score(host *DrsHost) float64 {
+	var cpu, mem float64
+	if host.CpuCapacity > 0 {
+		cpu = float64(host.CpuDemand) / float64(host.CpuCapacity)
+	}
+	if host.MemCapacity > 0 {
+		mem = float64(host.MemDemand) / float64(host.MemCapacity)
+	}
+	return max(cpu, mem)
+}
+
+// drsVm is the demand of a VM, ref is empty for VMs that do not yet exist.
+type drsVm struct {
+	ref  types.ManagedObjectReference
+	host *DrsHost
+	cpu  int64
+	mem  int64
+}
+
+// drsMove is a migration chosen by DRS load balancing or rule enforcement.
+type drsMove struct {
+	vm     *drsVm
+	src    DrsHost
+	dst    DrsHost
+	reason types.RecommendationReasonCode
+	rating int32
+}
+
+// drsCluster is a snapshot of the DRS state of a ClusterComputeResource.
+type drsCluster struct {
+	ctx    *Context
+	config *types.ClusterConfigInfoEx
+	engine DrsEngine
+	hosts  []*DrsHost // eligible hosts, in cluster order
+	host   map[types.ManagedObjectReference]*DrsHost
+	vm     map[types.ManagedObjectReference]*drsVm
+}
+
+func (r *Registry) drs() DrsEngine {
+	if r.Drs != nil {
+		return r.Drs
+	}
+	return DrsUtilization{}
+}
+
+func (c *ClusterComputeResource) drsEnabled() bool {
+	return isTrue(c.ConfigurationEx.(*types.ClusterConfigInfoEx).DrsConfig.Enabled)
+}
+
+// drsHostEligible returns true if VMs can be placed on or migrated to the given host.
+func drsHostEligible(h *HostSystem) bool {
+	return h.Runtime.ConnectionState == types.HostSystemConnectionStateConnected &&
+		h.Runtime.PowerState == types.HostSystemPowerStatePoweredOn &&
+		!h.Runtime.InMaintenanceMode
+}
+
+// drsDemand returns the simulated CPU (MHz) and memory (MB) demand of the given VM.
+func drsDemand(vm *VirtualMachine) (int64, int64) {
+	stats := vm.Summary.QuickStats
+
+	cpu := int64(stats.OverallCpuDemand)
+	if cpu == 0 {
+		cpu = int64(stats.OverallCpuUsage)
+	}
+
+	mem := int64(stats.HostMemoryUsage)
+	if mem == 0 {
+		mem = int64(stats.GuestMemoryUsage)
+	}
+	if mem == 0 && vm.Config != nil {
+		mem = int64(vm.Config.Hardware.MemoryMB)
+	}
+
+	return cpu, mem
+}
+
+func newDrsCluster(ctx *Context, c *ClusterComputeResource) *drsCluster {
+	d := &drsCluster{
+		ctx:    ctx,
+		config: c.ConfigurationEx.(*types.ClusterConfigInfoEx),
+		engine: ctx.Map.drs(),
+		host:   make(map[types.ManagedObjectReference]*DrsHost),
+		vm:     make(map[types.ManagedObjectReference]*drsVm),
+	}
+
+	for _, ref := range c.Host {
+		h, ok := ctx.Map.Get(ref).(*HostSystem)
+		if !ok || !drsHostEligible(h) {
+			continue
+		}
+
+		host := &DrsHost{Host: ref, registered: len(h.Vm)}
+		if hw := h.Summary.Hardware; hw != nil {
+			host.CpuCapacity = int64(hw.CpuMhz) * int64(hw.NumCpuCores)
+			host.MemCapacity = hw.MemorySize >> 20
+		}
+
+		d.hosts = append(d.hosts, host)
+		d.host[ref] = host
+
+		for _, vref := range h.Vm {
+			vm, ok := ctx.Map.Get(vref).(*VirtualMachine)
+			if !ok || vm.Runtime.Host == nil || *vm.Runtime.Host != ref {
+				continue
+			}
+			if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+				continue
+			}
+			x := &drsVm{ref: vref}
+			x.cpu, x.mem = drsDemand(vm)
+			d.vm[vref] = x
+			d.add(x, host)
+		}
+	}
+
+	return d
+}
+
+func (d *drsCluster) add(vm *drsVm, host *DrsHost) {
+	vm.host = host
+	host.Vm = append(host.Vm, vm.ref)
+	host.CpuDemand += vm.cpu
+	host.MemDemand += vm.mem
+}
+
+func (d *drsCluster) remove(vm *drsVm) {
+	host := vm.host
+	RemoveReference(&host.Vm, vm.ref)
+	host.CpuDemand -= vm.cpu
+	host.MemDemand -= vm.mem
+	vm.host = nil
+}
+
+// scoreWith returns the score of the given host with the addition of vm
+func (d *drsCluster) scoreWith(host *DrsHost, vm *drsVm) float64 {
+	if vm.host == host {
+		return d.engine.Score(host)
+	}
+	h := *host
+	h.Vm = append(slices.Clone(host.Vm), vm.ref)
+	h.CpuDemand += vm.cpu
+	h.MemDemand += vm.mem
+	return d.engine.Score(&h)
+}
+
+// spread returns the difference between the highest and lowest scored hosts
+func (d *drsCluster) spread() (float64, *DrsHost) {
+	var hi, lo float64
+	var busiest *DrsHost
+
+	for i, h := range d.hosts {
+		score := d.engine.Score(h)
+		if i == 0 || score > hi {
+			hi = score
+			busiest = h
+		}
+		if i == 0 || score < lo {
+			lo = score
+		}
+	}
+
+	return hi - lo, busiest
+}
+
+// behavior returns the automation level of the given VM, or "" if DRS is disabled for the VM.
+func (d *drsCluster) behavior(vm types.ManagedObjectReference) types.DrsBehavior {
+	behavior := d.config.DrsConfig.DefaultVmBehavior
+	if behavior == "" {
+		behavior = types.DrsBehaviorFullyAutomated
+	}
+
+	if overrides := d.config.DrsConfig.EnableVmBehaviorOverrides; overrides != nil && !*overrides {
+		return behavior
+	}
+
+	for _, c := range d.config.DrsVmConfig {
+		if c.Key != vm {
+			continue
+		}
+		if c.Enabled != nil && !*c.Enabled {
+			return ""
+		}
+		if c.Behavior != "" {
+			behavior = c.Behavior
+		}
+	}
+
+	return behavior
+}
+
+func (d *drsCluster) group(name string) types.BaseClusterGroupInfo {
+	for _, g := range d.config.Group {
+		if g.GetClusterGroupInfo().Name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+func (d *drsCluster) inVmGroup(name string, vm types.ManagedObjectReference) bool {
+	if g, ok := d.group(name).(*types.ClusterVmGroup); ok {
+		return slices.Contains(g.Vm, vm)
+	}
+	return false
+}
+
+func (d *drsCluster) inHostGroup(name string, host types.ManagedObjectReference) bool {
+	if g, ok := d.group(name).(*types.ClusterHostGroup); ok {
+		return slices.Contains(g.Host, host)
+	}
+	return false
+}
+
+// violation returns the reason the given VM cannot run on the given host, or "" if no rule is violated.
+// If mandatory is true, only mandatory rules are considered.
+func (d *drsCluster) violation(vm types.ManagedObjectReference, host *DrsHost, mandatory bool) types.RecommendationReasonCode {
+	if vm.Value == "" {
+		return ""
+	}
+
+	placed := func(other types.ManagedObjectReference) *DrsHost {
+		if x, ok := d.vm[other]; ok {
+			return x.host
+		}
+		return nil
+	}
+
+	for _, r := range d.config.Rule {
+		info := r.GetClusterRuleInfo()
+		if !isTrue(info.Enabled) || (mandatory && !isTrue(info.Mandatory)) {
+			continue
+		}
+
+		switch rule := r.(type) {
+		case *types.ClusterVmHostRuleInfo:
+			if !d.inVmGroup(rule.VmGroupName, vm) {
+				continue
+			}
+			reason := types.RecommendationReasonCodeVmHostSoftAffinity
+			if isTrue(rule.Mandatory) {
+				reason = types.RecommendationReasonCodeVmHostHardAffinity
+			}
+			if rule.AffineHostGroupName != "" && !d.inHostGroup(rule.AffineHostGroupName, host.Host) {
+				return reason
+			}
+			if rule.AntiAffineHostGroupName != "" && d.inHostGroup(rule.AntiAffineHostGroupName, host.Host) {
+				return reason
+			}
+		case *types.ClusterAffinityRuleSpec:
+			if !slices.Contains(rule.Vm, vm) {
+				continue
+			}
+			for _, other := range rule.Vm {
+				if h := placed(other); other != vm && h != nil && h != host {
+					return types.RecommendationReasonCodeJointAffin
+				}
+			}
+		case *types.ClusterAntiAffinityRuleSpec:
+			if !slices.Contains(rule.Vm, vm) {
+				continue
+			}
+			for _, other := range rule.Vm {
+				if other != vm && placed(other) == host {
+					return types.RecommendationReasonCodeAntiAffin
+				}
+			}
+		}
+	}
+
+	return ""
+}
+
+// rank returns the hosts that vm can be placed on, ordered from best to worst,
+// along with their scores. All enabled rules are honored where possible, otherwise only mandatory rules.
+func (d *drsCluster) rank(vm *drsVm, hosts []*DrsHost) ([]*DrsHost, []float64) {
+	for _, mandatory := range []bool{false, true} {
+		var ranked []*DrsHost
+		score := make(map[*DrsHost]float64)
+
+		for _, h := range hosts {
+			if d.violation(vm.ref, h, mandatory) != "" {
+				continue
+			}
+			ranked = append(ranked, h)
+			score[h] = d.scoreWith(h, vm)
+		}
+
+		if len(ranked) == 0 {
+			continue
+		}
+
+		slices.SortStableFunc(ranked, func(a, b *DrsHost) int {
+			if score[a] < score[b] {
+				return -1
+			}
+			if score[a] > score[b] {
+				return 1
+			}
+			return a.registered - b.registered
+		})
+
+		scores := make([]float64, len(ranked))
+		for i, h := range ranked {
+			scores[i] = score[h]
+		}
+		return ranked, scores
+	}
+
+	return nil, nil
+}
+
+// place returns the best host for vm, or nil if no host is eligible.
+func (d *drsCluster) place(vm *drsVm, hosts []*DrsHost) *DrsHost {
+	ranked, _ := d.rank(vm, hosts)
+	if len(ranked) == 0 {
+		return nil
+	}
+	return ranked[0]
+}
+
+func (d *drsCluster) move(vm *drsVm, dst *DrsHost, reason types.RecommendationReasonCode, rating int32) drsMove {
+	m := drsMove{vm: vm, src: *vm.host, reason: reason, rating: rating}
+	d.remove(vm)
+	d.add(vm, dst)
+	m.dst = *dst
+	return m
+}
+
+// drsTolerance returns the maximum difference in host scores before load balancing is applied,
+// for the given migration threshold, where 1 is the most conservative and 5 the most aggressive.
+func drsTolerance(rate int32) float64 {
+	switch rate {
+	case 1:
+		return math.Inf(1) // rule enforcement only
+	case 2:
+		return 0.3
+	case 4:
+		return 0.1
+	case 5:
+		return 0.05
+	default:
+		return 0.2
+	}
+}
+
+// balance returns the migrations needed to correct rule violations and balance the load of the cluster.
+func (d *drsCluster) balance() []drsMove {
+	var moves []drsMove
+
+	var vms []*drsVm
+	for _, vm := range d.vm {
+		if d.behavior(vm.ref) != "" {
+			vms = append(vms, vm)
+		}
+	}
+	slices.SortFunc(vms, func(a, b *drsVm) int {
+		return strings.Compare(a.ref.Value, b.ref.Value)
+	})
+
+	for _, vm := range vms {
+		reason := d.violation(vm.ref, vm.host, false)
+		if reason == "" {
+			continue
+		}
+		var others []*DrsHost
+		for _, h := range d.hosts {
+			if h != vm.host {
+				others = append(others, h)
+			}
+		}
+		if dst := d.place(vm, others); dst != nil && d.violation(vm.ref, dst, false) == "" {
+			moves = append(moves, d.move(vm, dst, reason, 5))
+		}
+	}
+
+	tolerance := drsTolerance(d.config.DrsConfig.VmotionRate)
+
+	for range vms {
+		spread, src := d.spread()
+		if spread <= tolerance {
+			break
+		}
+
+		var best *drsVm
+		var target *DrsHost
+		lowest := spread
+		load := d.engine.Score(src)
+
+		for _, vm := range vms {
+			if vm.host != src {
+				continue
+			}
+			for _, dst := range d.hosts {
+				if dst == src || d.violation(vm.ref, dst, false) != "" {
+					continue
+				}
+				d.remove(vm)
+				d.add(vm, dst)
+				// the move must reduce the load of the source host, without overloading the destination
+				if d.engine.Score(src) < load && d.engine.Score(dst) < load {
+					if s, _ := d.spread(); s < lowest {
+						lowest, best, target = s, vm, dst
+					}
+				}
+				d.remove(vm)
+				d.add(vm, src)
+			}
+		}
+
+		if best == nil {
+			break
+		}
+
+		reason := types.RecommendationReasonCodeFairnessMemAvg
+		if src.CpuCapacity > 0 && src.MemCapacity > 0 &&
+			float64(src.CpuDemand)/float64(src.CpuCapacity) > float64(src.MemDemand)/float64(src.MemCapacity) {
+			reason = types.RecommendationReasonCodeFairnessCpuAvg
+		}
+
+		// rating is relative to the improvement in balance, 1 (least) to 5 (most)
+		rating := int32(math.Ceil(5 * (spread - lowest) / spread))
+		moves = append(moves, d.move(best, target, reason, max(1, min(rating, 5))))
+	}
+
+	return moves
+}
+
+// placeHost returns the DRS chosen host for a new VM with the given demand, from the given list of hosts.
+func (c *ClusterComputeResource) placeHost(ctx *Context, cpu, mem int64, hosts []types.ManagedObjectReference) *types.ManagedObjectReference {
+	d := newDrsCluster(ctx, c)
+
+	var candidates []*DrsHost
+	for _, ref := range hosts {
+		if h, ok := d.host[ref]; ok {
+			candidates = append(candidates, h)
+		}
+	}
+
+	if h := d.place(&drsVm{cpu: cpu, mem: mem}, candidates); h != nil {
+		return &h.Host
+	}
+
+	return nil
+}
+
+// placeVm updates spec with the DRS chosen host for a PlaceVm create or clone request,
+// and a datastore from the given list mounted by that host.
+func (c *ClusterComputeResource) placeVm(ctx *Context, req *types.PlacementSpec, hosts, datastores []types.ManagedObjectReference, spec *types.VirtualMachineRelocateSpec) {
+	var cpu, mem int64
+	if req.Vm != nil {
+		if vm, ok := ctx.Map.Get(*req.Vm).(*VirtualMachine); ok {
+			cpu, mem = drsDemand(vm)
+		}
+	}
+	if req.ConfigSpec != nil && req.ConfigSpec.MemoryMB != 0 {
+		mem = req.ConfigSpec.MemoryMB
+	}
+
+	host := c.placeHost(ctx, cpu, mem, hosts)
+	if host == nil {
+		return
+	}
+	spec.Host = host
+
+	if h, ok := ctx.Map.Get(*host).(*HostSystem); ok {
+		for i := range datastores {
+			if slices.Contains(h.Datastore, datastores[i]) {
+				spec.Datastore = &datastores[i]
+				break
+			}
+		}
+	}
+}
+
+func (c *ClusterComputeResource) migrate(ctx *Context, ref, host types.ManagedObjectReference) types.BaseMethodFault {
+	vm, ok := ctx.Map.Get(ref).(*VirtualMachine)
+	if !ok {
+		return &types.ManagedObjectNotFound{Obj: ref}
+	}
+
+	var fault types.BaseMethodFault
+
+	ctx.WithLock(vm, func() {
+		res := vm.RelocateVMTask(ctx, &types.RelocateVM_Task{
+			This: ref,
+			Spec: types.VirtualMachineRelocateSpec{Host: &host},
+		}).(*methods.RelocateVM_TaskBody).Res
+
+		task := ctx.Map.Get(res.Returnval).(*Task)
+		task.Wait()
+		if task.Info.Error != nil {
+			fault = task.Info.Error.Fault
+		}
+	})
+
+	return fault
+}
+
+func (c *ClusterComputeResource) recommendation(m drsMove) types.ClusterRecommendation {
+	c.recommendationKey++
+	key := strconv.Itoa(int(c.recommendationKey))
+	now := time.Now()
+
+	return types.ClusterRecommendation{
+		Key:        key,
+		Type:       "V1",
+		Time:       now,
+		Rating:     m.rating,
+		Reason:     string(m.reason),
+		ReasonText: string(m.reason),
+		Target:     &c.Self,
+		Action: []types.BaseClusterAction{
+			&types.ClusterMigrationAction{
+				ClusterAction: types.ClusterAction{
+					Type:   "MigrationV1",
+					Target: &m.vm.ref,
+				},
+				DrsMigration: &types.ClusterDrsMigration{
+					Key:                   key,
+					Time:                  now,
+					Vm:                    m.vm.ref,
+					CpuLoad:               int32(m.vm.cpu),
+					MemoryLoad:            m.vm.mem << 20,
+					Source:                m.src.Host,
+					SourceCpuLoad:         int32(m.src.CpuDemand),
+					SourceMemoryLoad:      m.src.MemDemand << 20,
+					Destination:           m.dst.Host,
+					DestinationCpuLoad:    int32(m.dst.CpuDemand),
+					DestinationMemoryLoad: m.dst.MemDemand << 20,
+				},
+			},
+		},
+	}
+}
+
+// refreshRecommendation runs DRS load balancing and rule enforcement, migrating VMs with
+// the fullyAutomated behavior and populating the cluster recommendation property for other VMs.
+// Unlike vCenter, the simulator does not periodically rebalance or react to VM and host power state,
+// demand or placement changes: load balancing is only applied when RefreshRecommendation is called.
+// Initial placement of new VMs (CreateVM_Task, PlaceVm) is not affected by this limitation.
+func (c *ClusterComputeResource) refreshRecommendation(ctx *Context) {
+	var recommendations []types.ClusterRecommendation
+
+	if c.drsEnabled() {
+		d := newDrsCluster(ctx, c)
+
+		for _, m := range d.balance() {
+			if d.behavior(m.vm.ref) == types.DrsBehaviorFullyAutomated {
+				if err := c.migrate(ctx, m.vm.ref, m.dst.Host); err != nil {
+					tracef("drs migrate %s: %#v", m.vm.ref, err)
+				}
+				continue
+			}
+			recommendations = append(recommendations, c.recommendation(m))
+		}
+	}
+
+	ctx.Update(c, []types.PropertyChange{{Name: "recommendation", Val: recommendations}})
+}
+
+func (c *ClusterComputeResource) RefreshRecommendation(ctx *Context, req *types.RefreshRecommendation) soap.HasFault {
+	c.refreshRecommendation(ctx)
+
+	return &methods.RefreshRecommendationBody{
+		Res: new(types.RefreshRecommendationResponse),
+	}
+}
+
+func (c *ClusterComputeResource) removeRecommendation(ctx *Context, key string) *types.ClusterRecommendation {
+	for i, r := range c.Recommendation {
+		if r.Key == key {
+			recommendations := slices.Delete(slices.Clone(c.Recommendation), i, i+1)
+			ctx.Update(c, []types.PropertyChange{{Name: "recommendation", Val: recommendations}})
+			return &r
+		}
+	}
+	return nil
+}
+
+func (c *ClusterComputeResource) ApplyRecommendation(ctx *Context, req *types.ApplyRecommendation) soap.HasFault {
+	body := new(methods.ApplyRecommendationBody)
+
+	r := c.removeRecommendation(ctx, req.Key)
+	if r == nil {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "key"})
+		return body
+	}
+
+	for _, action := range r.Action {
+		if m, ok := action.(*types.ClusterMigrationAction); ok && m.DrsMigration != nil {
+			if err := c.migrate(ctx, m.DrsMigration.Vm, m.DrsMigration.Destination); err != nil {
+				body.Fault_ = Fault("", err)
+				return body
+			}
+		}
+	}
+
+	body.Res = new(types.ApplyRecommendationResponse)
+	return body
+}
+
+func (c *ClusterComputeResource) CancelRecommendation(ctx *Context, req *types.CancelRecommendation) soap.HasFault {
+	body := new(methods.CancelRecommendationBody)
+
+	if c.removeRecommendation(ctx, req.Key) == nil {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "key"})
+		return body
+	}
+
+	body.Res = new(types.CancelRecommendationResponse)
+	return body
+}
+
+func (c *ClusterComputeResource) RecommendHostsForVm(ctx *Context, req *types.RecommendHostsForVm) soap.HasFault {
+	body := new(methods.RecommendHostsForVmBody)
+
+	vm, ok := ctx.Map.Get(req.Vm).(*VirtualMachine)
+	if !ok {
+		body.Fault_ = Fault("", &types.ManagedObjectNotFound{Obj: req.Vm})
+		return body
+	}
+
+	d := newDrsCluster(ctx, c)
+
+	x, ok := d.vm[req.Vm]
+	if !ok {
+		x = &drsVm{ref: req.Vm}
+		x.cpu, x.mem = drsDemand(vm)
+	}
+
+	ranked, scores := d.rank(x, d.hosts)
+
+	res := &types.RecommendHostsForVmResponse{}
+	for i, h := range ranked {
+		// rating is 5 for an idle host, down to 1 for a fully utilized host
+		rating := int32(math.Round(5 - 4*math.Min(scores[i], 1)))
+		res.Returnval = append(res.Returnval, types.ClusterHostRecommendation{
+			Host:   h.Host,
+			Rating: rating,
+		})
+	}
+
+	body.Res = res
+	return body
+}
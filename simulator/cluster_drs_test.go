@@ -0,0 +1,361 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type drsTest struct {
+	t       *testing.T
+	ctx     context.Context
+	c       *vim25.Client
+	cluster *object.ClusterComputeResource
+	hosts   []*object.HostSystem
+	folder  *object.Folder
+	pool    *object.ResourcePool
+}
+
+func newDrsTest(ctx context.Context, t *testing.T, c *vim25.Client) *drsTest {
+	finder := find.NewFinder(c)
+
+	cluster, err := finder.ClusterComputeResource(ctx, "DC0_C0")
+	require.NoError(t, err)
+
+	hosts, err := cluster.Hosts(ctx)
+	require.NoError(t, err)
+
+	folder, err := finder.Folder(ctx, "vm")
+	require.NoError(t, err)
+
+	pool, err := cluster.ResourcePool(ctx)
+	require.NoError(t, err)
+
+	return &drsTest{t, ctx, c, cluster, hosts, folder, pool}
+}
+
+func (d *drsTest) reconfigure(spec types.ClusterConfigSpecEx) {
+	task, err := d.cluster.Reconfigure(d.ctx, &spec, true)
+	require.NoError(d.t, err)
+	require.NoError(d.t, task.Wait(d.ctx))
+}
+
+// createVM creates and powers on a VM with the given CPU usage (MHz) on the given host
+func (d *drsTest) createVM(name string, host *object.HostSystem, cpu int) *object.VirtualMachine {
+	spec := types.VirtualMachineConfigSpec{
+		Name:    name,
+		GuestId: string(types.VirtualMachineGuestOsIdentifierOtherGuest),
+		Files:   &types.VirtualMachineFileInfo{VmPathName: "[LocalDS_0]"},
+	}
+
+	task, err := d.folder.CreateVM(d.ctx, spec, d.pool, host)
+	require.NoError(d.t, err)
+	info, err := task.WaitForResult(d.ctx)
+	require.NoError(d.t, err)
+	vm := object.NewVirtualMachine(d.c, info.Result.(types.ManagedObjectReference))
+
+	task, err = vm.PowerOn(d.ctx)
+	require.NoError(d.t, err)
+	require.NoError(d.t, task.Wait(d.ctx))
+
+	d.setQuickStats(vm, types.VirtualMachineQuickStats{OverallCpuUsage: int32(cpu)})
+
+	return vm
+}
+
+func (d *drsTest) setQuickStats(vm *object.VirtualMachine, stats types.VirtualMachineQuickStats) {
+	Map(d.ctx).Get(vm.Reference()).(*VirtualMachine).SetQuickStats(d.ctx.(*Context), stats)
+}
+
+func (d *drsTest) host(vm *object.VirtualMachine) types.ManagedObjectReference {
+	var props mo.VirtualMachine
+	require.NoError(d.t, vm.Properties(d.ctx, vm.Reference(), []string{"runtime.host"}, &props))
+	return *props.Runtime.Host
+}
+
+func (d *drsTest) refresh() []types.ClusterRecommendation {
+	_, err := methods.RefreshRecommendation(d.ctx, d.c, &types.RefreshRecommendation{This: d.cluster.Reference()})
+	require.NoError(d.t, err)
+
+	var props mo.ClusterComputeResource
+	require.NoError(d.t, d.cluster.Properties(d.ctx, d.cluster.Reference(), []string{"recommendation"}, &props))
+	return props.Recommendation
+}
+
+func (d *drsTest) count(vms []*object.VirtualMachine) map[types.ManagedObjectReference]int {
+	count := make(map[types.ManagedObjectReference]int)
+	for _, vm := range vms {
+		count[d.host(vm)]++
+	}
+	return count
+}
+
+func TestClusterDrsManual(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DrsConfig: &types.ClusterDrsConfigInfo{DefaultVmBehavior: types.DrsBehaviorManual},
+		})
+
+		busy := d.hosts[0].Reference()
+		var vms []*object.VirtualMachine
+		for i := range 4 {
+			vms = append(vms, d.createVM(fmt.Sprintf("drs-%d", i), d.hosts[0], 1000))
+		}
+
+		recs := d.refresh()
+		require.NotEmpty(t, recs)
+		assert.Equal(t, 4, d.count(vms)[busy], "manual mode must not migrate")
+
+		for _, r := range recs {
+			assert.Equal(t, d.cluster.Reference(), *r.Target)
+			assert.Equal(t, string(types.RecommendationReasonCodeFairnessCpuAvg), r.Reason)
+			require.Len(t, r.Action, 1)
+			m := r.Action[0].(*types.ClusterMigrationAction).DrsMigration
+			assert.Equal(t, busy, m.Source)
+			assert.NotEqual(t, busy, m.Destination)
+			assert.Equal(t, int32(1000), m.CpuLoad)
+		}
+
+		// refresh is deterministic
+		again := d.refresh()
+		require.Len(t, again, len(recs))
+		for i := range recs {
+			assert.Equal(t, recs[i].Action[0].(*types.ClusterMigrationAction).DrsMigration.Vm,
+				again[i].Action[0].(*types.ClusterMigrationAction).DrsMigration.Vm)
+		}
+		recs = again
+
+		_, err := methods.CancelRecommendation(ctx, c, &types.CancelRecommendation{This: d.cluster.Reference(), Key: "invalid"})
+		assert.True(t, fault.Is(err, &types.InvalidArgument{}))
+
+		for _, r := range recs {
+			m := r.Action[0].(*types.ClusterMigrationAction).DrsMigration
+			_, err = methods.ApplyRecommendation(ctx, c, &types.ApplyRecommendation{This: d.cluster.Reference(), Key: r.Key})
+			require.NoError(t, err)
+			assert.Equal(t, m.Destination, d.host(object.NewVirtualMachine(c, m.Vm)))
+		}
+
+		_, err = methods.ApplyRecommendation(ctx, c, &types.ApplyRecommendation{This: d.cluster.Reference(), Key: recs[0].Key})
+		assert.True(t, fault.Is(err, &types.InvalidArgument{}))
+
+		assert.Empty(t, d.refresh(), "cluster is balanced")
+		assert.Less(t, d.count(vms)[busy], 4)
+
+		host := Map(ctx).Get(busy).(*HostSystem)
+		for _, vm := range vms {
+			if d.host(vm) != busy {
+				assert.NotContains(t, host.Vm, vm.Reference())
+			}
+		}
+	})
+}
+
+func TestClusterDrsFullyAutomated(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+
+		busy := d.hosts[0].Reference()
+		var vms []*object.VirtualMachine
+		for i := range 4 {
+			vms = append(vms, d.createVM(fmt.Sprintf("drs-%d", i), d.hosts[0], 1000))
+		}
+
+		// per-VM overrides
+		manual, disabled := vms[0].Reference(), vms[1].Reference()
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DrsVmConfigSpec: []types.ClusterDrsVmConfigSpec{
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.ClusterDrsVmConfigInfo{Key: manual, Behavior: types.DrsBehaviorManual},
+				},
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.ClusterDrsVmConfigInfo{Key: disabled, Enabled: types.NewBool(false)},
+				},
+			},
+		})
+
+		recs := d.refresh()
+		count := d.count(vms)
+		assert.Less(t, count[busy], 4, "fully automated mode migrates")
+		assert.Equal(t, busy, d.host(vms[1]), "DRS disabled for VM")
+
+		for _, r := range recs {
+			m := r.Action[0].(*types.ClusterMigrationAction).DrsMigration
+			assert.Equal(t, manual, m.Vm, "only manual VMs are recommended")
+		}
+
+		// disabling DRS clears recommendations
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DrsConfig: &types.ClusterDrsConfigInfo{Enabled: types.NewBool(false)},
+		})
+		assert.Empty(t, d.refresh())
+	})
+}
+
+func TestClusterDrsRules(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DrsConfig: &types.ClusterDrsConfigInfo{DefaultVmBehavior: types.DrsBehaviorManual},
+		})
+
+		busy := d.hosts[0].Reference()
+		var vms []*object.VirtualMachine
+		var refs []types.ManagedObjectReference
+		for i := range 4 {
+			vm := d.createVM(fmt.Sprintf("drs-%d", i), d.hosts[0], 1000)
+			vms = append(vms, vm)
+			refs = append(refs, vm.Reference())
+		}
+
+		// VMs must run on the busy host
+		d.reconfigure(types.ClusterConfigSpecEx{
+			GroupSpec: []types.ClusterGroupSpec{
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.ClusterVmGroup{ClusterGroupInfo: types.ClusterGroupInfo{Name: "vms"}, Vm: refs},
+				},
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info:            &types.ClusterHostGroup{ClusterGroupInfo: types.ClusterGroupInfo{Name: "hosts"}, Host: []types.ManagedObjectReference{busy}},
+				},
+			},
+			RulesSpec: []types.ClusterRuleSpec{{
+				ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+				Info: &types.ClusterVmHostRuleInfo{
+					ClusterRuleInfo: types.ClusterRuleInfo{
+						Name:      "pin",
+						Enabled:   types.NewBool(true),
+						Mandatory: types.NewBool(true),
+					},
+					VmGroupName:         "vms",
+					AffineHostGroupName: "hosts",
+				},
+			}},
+		})
+
+		assert.Empty(t, d.refresh(), "rule prevents load balancing")
+
+		res, err := methods.RecommendHostsForVm(ctx, c, &types.RecommendHostsForVm{This: d.cluster.Reference(), Vm: refs[0]})
+		require.NoError(t, err)
+		require.Len(t, res.Returnval, 1)
+		assert.Equal(t, busy, res.Returnval[0].Host)
+
+		// remove the pin and keep two VMs apart
+		var cluster mo.ClusterComputeResource
+		require.NoError(t, d.cluster.Properties(ctx, d.cluster.Reference(), []string{"configurationEx"}, &cluster))
+		key := cluster.ConfigurationEx.(*types.ClusterConfigInfoEx).Rule[0].GetClusterRuleInfo().Key
+
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DrsConfig: &types.ClusterDrsConfigInfo{VmotionRate: 1}, // rule enforcement only
+			RulesSpec: []types.ClusterRuleSpec{
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationRemove, RemoveKey: key},
+				},
+				{
+					ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+					Info: &types.ClusterAntiAffinityRuleSpec{
+						ClusterRuleInfo: types.ClusterRuleInfo{Name: "apart", Enabled: types.NewBool(true)},
+						Vm:              refs[:2],
+					},
+				},
+			},
+		})
+
+		recs := d.refresh()
+		require.Len(t, recs, 1)
+		assert.Equal(t, string(types.RecommendationReasonCodeAntiAffin), recs[0].Reason)
+		m := recs[0].Action[0].(*types.ClusterMigrationAction).DrsMigration
+		assert.Contains(t, refs[:2], m.Vm)
+		assert.NotEqual(t, busy, m.Destination)
+	})
+}
+
+type drsPreferHost types.ManagedObjectReference
+
+func (p drsPreferHost) Score(host *DrsHost) float64 {
+	if host.Host == types.ManagedObjectReference(p) {
+		return 0
+	}
+	return 1
+}
+
+func TestClusterDrsPlacement(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+
+		// initial placement prefers the least loaded host
+		busy := d.hosts[0].Reference()
+		_ = d.createVM("busy", d.hosts[0], 4000)
+
+		res, err := methods.RecommendHostsForVm(ctx, c, &types.RecommendHostsForVm{
+			This: d.cluster.Reference(),
+			Vm:   d.createVM("idle", d.hosts[1], 0).Reference(),
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Returnval, len(d.hosts))
+		assert.Equal(t, busy, res.Returnval[len(d.hosts)-1].Host)
+		assert.Greater(t, res.Returnval[0].Rating, res.Returnval[len(d.hosts)-1].Rating)
+
+		task, err := d.folder.CreateVM(ctx, types.VirtualMachineConfigSpec{
+			Name:    "placed",
+			GuestId: string(types.VirtualMachineGuestOsIdentifierOtherGuest),
+			Files:   &types.VirtualMachineFileInfo{VmPathName: "[LocalDS_0]"},
+		}, d.pool, nil)
+		require.NoError(t, err)
+		info, err := task.WaitForResult(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, busy, d.host(object.NewVirtualMachine(c, info.Result.(types.ManagedObjectReference))))
+
+		// custom engine
+		preferred := d.hosts[2].Reference()
+		Map(ctx).Drs = drsPreferHost(preferred)
+
+		for range 3 {
+			placement, err := d.cluster.PlaceVm(ctx, types.PlacementSpec{
+				PlacementType: string(types.PlacementSpecPlacementTypeCreate),
+				ConfigSpec:    &types.VirtualMachineConfigSpec{Name: "place", MemoryMB: 64},
+			})
+			require.NoError(t, err)
+			spec := placement.Recommendations[0].Action[0].(*types.PlacementAction).RelocateSpec
+			assert.Equal(t, preferred, *spec.Host)
+		}
+
+		// hosts in maintenance mode are not eligible
+		task, err = d.hosts[2].EnterMaintenanceMode(ctx, 0, false, nil)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		pc := property.DefaultCollector(c)
+		var hosts []mo.HostSystem
+		require.NoError(t, pc.Retrieve(ctx, []types.ManagedObjectReference{preferred}, []string{"runtime"}, &hosts))
+		require.True(t, hosts[0].Runtime.InMaintenanceMode)
+
+		res, err = methods.RecommendHostsForVm(ctx, c, &types.RecommendHostsForVm{
+			This: d.cluster.Reference(),
+			Vm:   info.Result.(types.ManagedObjectReference),
+		})
+		require.NoError(t, err)
+		for _, r := range res.Returnval {
+			assert.NotEqual(t, preferred, r.Host)
+		}
+	})
+}
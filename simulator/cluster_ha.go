@@ -0,0 +1,358 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const hostsPrefix = "/vcsim/hosts"
+
+// dasPriority orders the vSphere HA restart priorities, where disabled VMs are not restarted.
+var dasPriority = map[string]int{
+	string(types.ClusterDasVmSettingsRestartPriorityDisabled): 0,
+	string(types.ClusterDasVmSettingsRestartPriorityLowest):   1,
+	string(types.ClusterDasVmSettingsRestartPriorityLow):      2,
+	string(types.ClusterDasVmSettingsRestartPriorityMedium):   3,
+	string(types.ClusterDasVmSettingsRestartPriorityHigh):     4,
+	string(types.ClusterDasVmSettingsRestartPriorityHighest):  5,
+}
+
+func (c *ClusterComputeResource) dasEnabled() bool {
+	return isTrue(c.ConfigurationEx.(*types.ClusterConfigInfoEx).DasConfig.Enabled)
+}
+
+func (c *ClusterComputeResource) event(ctx *Context) types.ClusterEvent {
+	return types.ClusterEvent{
+		Event: types.Event{
+			Datacenter: datacenterEventArgument(ctx, c),
+			ComputeResource: &types.ComputeResourceEventArgument{
+				ComputeResource:     c.Self,
+				EntityEventArgument: types.EntityEventArgument{Name: c.Name},
+			},
+		},
+	}
+}
+
+// dasRestartPriority returns the restart priority of the given VM, applying any DasVmConfig override
+// to the cluster's dasConfig.defaultVmSettings, which defaults to medium.
+func (c *ClusterComputeResource) dasRestartPriority(vm types.ManagedObjectReference) string {
+	cfg := c.ConfigurationEx.(*types.ClusterConfigInfoEx)
+
+	priority := string(types.ClusterDasVmSettingsRestartPriorityMedium)
+	if s := cfg.DasConfig.DefaultVmSettings; s != nil && s.RestartPriority != "" {
+		priority = s.RestartPriority
+	}
+
+	for _, o := range cfg.DasVmConfig {
+		if o.Key != vm {
+			continue
+		}
+		if s := o.DasSettings; s != nil && s.RestartPriority != "" {
+			if s.RestartPriority != string(types.ClusterDasVmSettingsRestartPriorityClusterRestartPriority) {
+				priority = s.RestartPriority
+			}
+		} else if o.RestartPriority != "" {
+			priority = string(o.RestartPriority)
+		}
+	}
+
+	return priority
+}
+
+// dasProtection returns the runtime.dasVmProtection of the given vm when in the given power state,
+// which is only set (non-nil) for powered on VMs in a cluster with vSphere HA enabled.
+func (vm *VirtualMachine) dasProtection(ctx *Context, state types.VirtualMachinePowerState) types.AnyType {
+	if state != types.VirtualMachinePowerStatePoweredOn || vm.Runtime.Host == nil {
+		return nil
+	}
+
+	host, ok := ctx.Map.Get(*vm.Runtime.Host).(*HostSystem)
+	if !ok || host.Parent == nil {
+		return nil
+	}
+
+	c, ok := ctx.Map.Get(*host.Parent).(*ClusterComputeResource)
+	if !ok || !c.dasEnabled() {
+		return nil
+	}
+
+	return &types.VirtualMachineRuntimeInfoDasProtectionState{
+		DasProtected: c.dasRestartPriority(vm.Self) != string(types.ClusterDasVmSettingsRestartPriorityDisabled) &&
+			host.Runtime.ConnectionState == types.HostSystemConnectionStateConnected,
+	}
+}
+
+// dasProtect updates runtime.dasVmProtection of all VMs in the cluster.
+func (c *ClusterComputeResource) dasProtect(ctx *Context) {
+	for _, ref := range c.Host {
+		host, ok := ctx.Map.Get(ref).(*HostSystem)
+		if !ok {
+			continue
+		}
+
+		for _, vref := range host.Vm {
+			vm, ok := ctx.Map.Get(vref).(*VirtualMachine)
+			if !ok {
+				continue
+			}
+
+			ctx.WithLock(vm, func() {
+				state := vm.dasProtection(ctx, vm.Runtime.PowerState)
+				ctx.Update(vm, []types.PropertyChange{
+					{Name: "runtime.dasVmProtection", Val: state},
+					{Name: "summary.runtime.dasVmProtection", Val: state},
+				})
+			})
+		}
+	}
+}
+
+// failoverHost returns the host chosen to restart the given vm, nil if there are not enough resources.
+// When admission control is enabled, the vm is only placed on hosts with enough unused capacity,
+// and the failover hosts of a ClusterFailoverHostAdmissionControlPolicy are used first.
+func (c *ClusterComputeResource) failoverHost(d *drsCluster, vm *drsVm) *DrsHost {
+	das := d.config.DasConfig
+	candidates := d.hosts
+
+	if isTrue(das.AdmissionControlEnabled) {
+		candidates = nil
+		for _, h := range d.hosts {
+			if h.CpuDemand+vm.cpu <= h.CpuCapacity && h.MemDemand+vm.mem <= h.MemCapacity {
+				candidates = append(candidates, h)
+			}
+		}
+
+		if p, ok := das.AdmissionControlPolicy.(*types.ClusterFailoverHostAdmissionControlPolicy); ok {
+			var failover []*DrsHost
+			for _, h := range candidates {
+				if slices.Contains(p.FailoverHosts, h.Host) {
+					failover = append(failover, h)
+				}
+			}
+			if host := d.place(vm, failover); host != nil {
+				return host
+			}
+		}
+	}
+
+	return d.place(vm, candidates)
+}
+
+// restart registers the given vm with the dst host and powers it on.
+func (c *ClusterComputeResource) restart(ctx *Context, vm *VirtualMachine, src *HostSystem, dst types.ManagedObjectReference) types.BaseMethodFault {
+	host := ctx.Map.Get(dst).(*HostSystem)
+
+	var fault types.BaseMethodFault
+
+	ctx.WithLock(vm, func() {
+		ctx.Map.RemoveReference(ctx, src, &src.Vm, vm.Self)
+		ctx.Map.AddReference(ctx, host, &host.Vm, vm.Self)
+
+		ctx.Update(vm, []types.PropertyChange{
+			{Name: "runtime.host", Val: &dst},
+			{Name: "summary.runtime.host", Val: &dst},
+			{Name: "runtime.connectionState", Val: types.VirtualMachineConnectionStateConnected},
+			{Name: "summary.runtime.connectionState", Val: types.VirtualMachineConnectionStateConnected},
+		})
+
+		runner := &powerVMTask{
+			VirtualMachine: vm,
+			state:          types.VirtualMachinePowerStatePoweredOn,
+			ctx:            ctx,
+		}
+		task := ctx.Map.Get(CreateTask(runner.Reference(), "powerOn", runner.Run).Run(ctx)).(*Task)
+		task.Wait()
+		if task.Info.Error != nil {
+			fault = task.Info.Error.Fault
+		}
+	})
+
+	return fault
+}
+
+// failover restarts the given VMs of the failed host on the surviving hosts in the cluster,
+// in order of restart priority.
+func (c *ClusterComputeResource) failover(ctx *Context, failed *HostSystem, vms []*VirtualMachine) {
+	cfg := c.ConfigurationEx.(*types.ClusterConfigInfoEx)
+	if !c.dasEnabled() || cfg.DasConfig.HostMonitoring == string(types.ClusterDasConfigInfoServiceStateDisabled) {
+		return
+	}
+
+	ctx.postEvent(&types.DasHostFailedEvent{
+		ClusterEvent: c.event(ctx),
+		FailedHost:   *failed.eventArgument(),
+	})
+
+	priority := make(map[types.ManagedObjectReference]int)
+	for _, vm := range vms {
+		priority[vm.Self] = dasPriority[c.dasRestartPriority(vm.Self)]
+	}
+
+	slices.SortStableFunc(vms, func(a, b *VirtualMachine) int {
+		return priority[b.Self] - priority[a.Self]
+	})
+
+	d := newDrsCluster(ctx, c)
+
+	for _, vm := range vms {
+		if priority[vm.Self] == 0 {
+			continue
+		}
+
+		x := &drsVm{ref: vm.Self}
+		x.cpu, x.mem = drsDemand(vm)
+
+		host := c.failoverHost(d, x)
+		if host == nil {
+			ctx.postEvent(&types.NotEnoughResourcesToStartVmEvent{
+				VmEvent: vm.event(ctx),
+				Reason:  "Insufficient resources to satisfy vSphere HA failover",
+			})
+			continue
+		}
+
+		if err := c.restart(ctx, vm, failed, host.Host); err != nil {
+			ctx.postEvent(&types.VmFailoverFailed{
+				VmEvent: vm.event(ctx),
+				Reason:  &types.LocalizedMethodFault{Fault: err, LocalizedMessage: fmt.Sprintf("%T", err)},
+			})
+			continue
+		}
+
+		d.add(x, host)
+
+		ctx.postEvent(&types.VmRestartedOnAlternateHostEvent{
+			VmPoweredOnEvent: types.VmPoweredOnEvent{VmEvent: vm.event(ctx)},
+			SourceHost:       *failed.eventArgument(),
+		})
+	}
+}
+
+// Fail simulates an unexpected failure of the host, which stops responding and its running VMs
+// are lost. If the host is a member of a cluster with vSphere HA enabled, protected VMs are restarted
+// on the surviving hosts. VMs that are not restarted remain powered off and disconnected on the failed host.
+func (h *HostSystem) Fail(ctx *Context) {
+	var vms []*VirtualMachine
+
+	ctx.WithLock(h, func() {
+		for _, vm := range h.poweredOnVms(ctx) {
+			if vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn {
+				vms = append(vms, vm)
+			}
+		}
+
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.connectionState", Val: types.HostSystemConnectionStateNotResponding},
+		})
+	})
+
+	ctx.postEvent(&types.HostConnectionLostEvent{HostEvent: h.event(ctx)})
+
+	for _, vm := range vms {
+		ctx.WithLock(vm, func() {
+			_ = vm.svm.stop(ctx)
+
+			ctx.Update(vm, []types.PropertyChange{
+				{Name: "runtime.powerState", Val: types.VirtualMachinePowerStatePoweredOff},
+				{Name: "summary.runtime.powerState", Val: types.VirtualMachinePowerStatePoweredOff},
+				{Name: "runtime.connectionState", Val: types.VirtualMachineConnectionStateDisconnected},
+				{Name: "summary.runtime.connectionState", Val: types.VirtualMachineConnectionStateDisconnected},
+				{Name: "runtime.dasVmProtection", Val: nil},
+				{Name: "summary.runtime.dasVmProtection", Val: nil},
+			})
+		})
+	}
+
+	if c, ok := ctx.Map.Get(*h.Parent).(*ClusterComputeResource); ok {
+		ctx.WithLock(c, func() {
+			c.failover(ctx, h, vms)
+		})
+	}
+}
+
+// Recover reconnects a host after Fail, along with any VMs that were not restarted on another host.
+func (h *HostSystem) Recover(ctx *Context) {
+	ctx.WithLock(h, func() {
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.connectionState", Val: types.HostSystemConnectionStateConnected},
+		})
+	})
+
+	ctx.postEvent(&types.HostConnectedEvent{HostEvent: h.event(ctx)})
+
+	for _, ref := range h.Vm {
+		vm, ok := ctx.Map.Get(ref).(*VirtualMachine)
+		if !ok {
+			continue
+		}
+
+		ctx.WithLock(vm, func() {
+			if vm.Runtime.ConnectionState == types.VirtualMachineConnectionStateDisconnected {
+				ctx.Update(vm, []types.PropertyChange{
+					{Name: "runtime.connectionState", Val: types.VirtualMachineConnectionStateConnected},
+					{Name: "summary.runtime.connectionState", Val: types.VirtualMachineConnectionStateConnected},
+				})
+			}
+		})
+	}
+}
+
+// ServeHosts handles requests to fail or recover a host, by name or moref ID:
+// POST /vcsim/hosts/{host}/fail
+// POST /vcsim/hosts/{host}/recover
+func (s *Service) ServeHosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, hostsPrefix), "/"), "/")
+
+	ctx := s.newContext()
+
+	var host *HostSystem
+	for _, obj := range ctx.Map.All("HostSystem") {
+		h := obj.(*HostSystem)
+		if h.Self.Value == id || h.Name == id {
+			host = h
+			break
+		}
+	}
+	if host == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var state types.HostSystemConnectionState
+	ctx.WithLock(host, func() {
+		state = host.Runtime.ConnectionState
+	})
+
+	switch action {
+	case "fail":
+		if state != types.HostSystemConnectionStateConnected {
+			http.Error(w, fmt.Sprintf("host %s is %s", host.Name, state), http.StatusConflict)
+			return
+		}
+		host.Fail(ctx)
+	case "recover":
+		if state != types.HostSystemConnectionStateNotResponding {
+			http.Error(w, fmt.Sprintf("host %s is %s", host.Name, state), http.StatusConflict)
+			return
+		}
+		host.Recover(ctx)
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
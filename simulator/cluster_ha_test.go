@@ -0,0 +1,178 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func (d *drsTest) runtime(vm *object.VirtualMachine) types.VirtualMachineRuntimeInfo {
+	var props mo.VirtualMachine
+	require.NoError(d.t, vm.Properties(d.ctx, vm.Reference(), []string{"runtime"}, &props))
+	return props.Runtime
+}
+
+func (d *drsTest) events(kind ...string) []types.BaseEvent {
+	events, err := event.NewManager(d.c).QueryEvents(d.ctx, types.EventFilterSpec{EventTypeId: kind})
+	require.NoError(d.t, err)
+	return events
+}
+
+func (d *drsTest) restartPriority(vm *object.VirtualMachine, priority types.ClusterDasVmSettingsRestartPriority) types.ClusterDasVmConfigSpec {
+	return types.ClusterDasVmConfigSpec{
+		ArrayUpdateSpec: types.ArrayUpdateSpec{Operation: types.ArrayUpdateOperationAdd},
+		Info: &types.ClusterDasVmConfigInfo{
+			Key:         vm.Reference(),
+			DasSettings: &types.ClusterDasVmSettings{RestartPriority: string(priority)},
+		},
+	}
+}
+
+func TestClusterHAFailover(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+
+		high := d.createVM("ha-high", d.hosts[0], 100)
+		low := d.createVM("ha-low", d.hosts[0], 100)
+		off := d.createVM("ha-disabled", d.hosts[0], 100)
+
+		assert.Nil(t, d.runtime(high).DasVmProtection, "HA is not enabled")
+
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DasConfig: &types.ClusterDasConfigInfo{
+				Enabled:           types.NewBool(true),
+				DefaultVmSettings: &types.ClusterDasVmSettings{RestartPriority: string(types.ClusterDasVmSettingsRestartPriorityLow)},
+			},
+			DasVmConfigSpec: []types.ClusterDasVmConfigSpec{
+				d.restartPriority(high, types.ClusterDasVmSettingsRestartPriorityHighest),
+				d.restartPriority(off, types.ClusterDasVmSettingsRestartPriorityDisabled),
+			},
+		})
+		require.Len(t, d.events("DasEnabledEvent"), 1)
+
+		assert.True(t, d.runtime(high).DasVmProtection.DasProtected)
+		assert.True(t, d.runtime(low).DasVmProtection.DasProtected)
+		assert.False(t, d.runtime(off).DasVmProtection.DasProtected)
+
+		failed := Map(ctx).Get(d.hosts[0].Reference()).(*HostSystem)
+		failed.Fail(ctx.(*Context))
+
+		for _, vm := range []*object.VirtualMachine{high, low} {
+			rt := d.runtime(vm)
+			assert.NotEqual(t, failed.Self, *rt.Host)
+			assert.Equal(t, types.VirtualMachinePowerStatePoweredOn, rt.PowerState)
+			assert.Equal(t, types.VirtualMachineConnectionStateConnected, rt.ConnectionState)
+			assert.True(t, rt.DasVmProtection.DasProtected)
+		}
+
+		rt := d.runtime(off)
+		assert.Equal(t, failed.Self, *rt.Host)
+		assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, rt.PowerState)
+		assert.Equal(t, types.VirtualMachineConnectionStateDisconnected, rt.ConnectionState)
+		assert.Nil(t, rt.DasVmProtection)
+
+		require.Len(t, d.events("DasHostFailedEvent"), 1)
+
+		restarted := d.events("VmRestartedOnAlternateHostEvent")
+		require.NotEmpty(t, restarted)
+		var names []string
+		for _, e := range restarted {
+			r := e.(*types.VmRestartedOnAlternateHostEvent)
+			assert.Equal(t, failed.Self, r.SourceHost.Host)
+			names = append(names, r.Vm.Name)
+		}
+		assert.NotContains(t, names, "ha-disabled")
+		// events are returned in reverse order, highest priority is restarted first
+		assert.Equal(t, "ha-high", names[len(names)-1])
+
+		// no restart when HA is disabled
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DasConfig: &types.ClusterDasConfigInfo{Enabled: types.NewBool(false)},
+		})
+		assert.Nil(t, d.runtime(high).DasVmProtection)
+		require.Len(t, d.events("DasDisabledEvent"), 1)
+
+		failed.Recover(ctx.(*Context))
+		rt = d.runtime(off)
+		assert.Equal(t, types.VirtualMachineConnectionStateConnected, rt.ConnectionState)
+
+		host := Map(ctx).Get(d.host(high)).(*HostSystem)
+		host.Fail(ctx.(*Context))
+		assert.Equal(t, host.Self, d.host(high))
+		assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, d.runtime(high).PowerState)
+		require.Len(t, d.events("DasHostFailedEvent"), 1)
+	})
+}
+
+func TestClusterHAAdmissionControl(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		d := newDrsTest(ctx, t, c)
+
+		// each host has 4096MB, leaving room for a single 3000MB VM on each surviving host
+		var vms []*object.VirtualMachine
+		for _, name := range []string{"ha-0", "ha-1", "ha-2"} {
+			vm := d.createVM(name, d.hosts[0], 100)
+			d.setQuickStats(vm, types.VirtualMachineQuickStats{OverallCpuUsage: 100, HostMemoryUsage: 3000})
+			vms = append(vms, vm)
+		}
+
+		d.reconfigure(types.ClusterConfigSpecEx{
+			DasConfig: &types.ClusterDasConfigInfo{
+				Enabled:                 types.NewBool(true),
+				AdmissionControlEnabled: types.NewBool(true),
+				AdmissionControlPolicy: &types.ClusterFailoverHostAdmissionControlPolicy{
+					FailoverHosts: []types.ManagedObjectReference{d.hosts[2].Reference()},
+				},
+			},
+			DasVmConfigSpec: []types.ClusterDasVmConfigSpec{
+				d.restartPriority(vms[0], types.ClusterDasVmSettingsRestartPriorityLowest),
+				d.restartPriority(vms[2], types.ClusterDasVmSettingsRestartPriorityHigh),
+			},
+		})
+
+		s := ServiceFromContext(ctx)
+		call := func(method, path string) int {
+			w := httptest.NewRecorder()
+			s.ServeHosts(w, httptest.NewRequest(method, path, nil))
+			return w.Code
+		}
+
+		assert.Equal(t, http.StatusNotFound, call(http.MethodPost, "/vcsim/hosts/enoent/fail"))
+		assert.Equal(t, http.StatusNotFound, call(http.MethodPost, "/vcsim/hosts/"+d.hosts[0].Reference().Value+"/enoent"))
+		assert.Equal(t, http.StatusMethodNotAllowed, call(http.MethodGet, "/vcsim/hosts/"+d.hosts[0].Reference().Value+"/fail"))
+		assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/vcsim/hosts/"+d.hosts[0].Reference().Value+"/recover"))
+
+		name := Map(ctx).Get(d.hosts[0].Reference()).(*HostSystem).Name
+		require.Equal(t, http.StatusNoContent, call(http.MethodPost, "/vcsim/hosts/"+name+"/fail"))
+		assert.Equal(t, http.StatusConflict, call(http.MethodPost, "/vcsim/hosts/"+name+"/fail"))
+
+		// high priority VM is restarted on the failover host
+		assert.Equal(t, d.hosts[2].Reference(), d.host(vms[2]))
+		assert.Equal(t, d.hosts[1].Reference(), d.host(vms[1]))
+		assert.Equal(t, d.hosts[0].Reference(), d.host(vms[0]))
+		assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, d.runtime(vms[0]).PowerState)
+
+		events := d.events("NotEnoughResourcesToStartVmEvent")
+		require.Len(t, events, 1)
+		assert.Equal(t, vms[0].Reference(), events[0].GetEvent().Vm.Vm)
+
+		require.Equal(t, http.StatusNoContent, call(http.MethodPost, "/vcsim/hosts/"+name+"/recover"))
+		var host mo.HostSystem
+		require.NoError(t, d.hosts[0].Properties(ctx, d.hosts[0].Reference(), []string{"runtime"}, &host))
+		assert.Equal(t, types.HostSystemConnectionStateConnected, host.Runtime.ConnectionState)
+	})
+}
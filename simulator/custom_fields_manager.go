@@ -192,16 +192,16 @@ func (c *CustomFieldsManager) SetField(ctx *Context, req *types.SetField) soap.H
 		return s
 	}
 
-	entity := ctx.Map.Get(req.Entity).(mo.Entity).Entity()
+	obj := ctx.Map.Get(req.Entity)
+	entity := obj.(mo.Entity).Entity()
 
-	ctx.WithLock(entity, func() {
+	ctx.WithLock(obj, func() {
 		// Check if custom value and value are already set. If so, remove them.
-		entity.CustomValue = removeExistingValues(entity.CustomValue)
-		entity.Value = removeExistingValues(entity.Value)
-
-		// Add the new value
-		entity.CustomValue = append(entity.CustomValue, newValue)
-		entity.Value = append(entity.Value, newValue)
+		// Then add the new value
+		ctx.Update(obj, []types.PropertyChange{
+			{Name: "customValue", Val: append(removeExistingValues(entity.CustomValue), newValue)},
+			{Name: "value", Val: append(removeExistingValues(entity.Value), newValue)},
+		})
 	})
 
 	body.Res = &types.SetFieldResponse{}
@@ -7,6 +7,9 @@ package simulator
 import (
 	"encoding/pem"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
 )
 
 var DefaultCustomizationSpec = []types.CustomizationSpecItem{
@@ -238,7 +242,7 @@ type CustomizationSpecManager struct {
 }
 
 func (m *CustomizationSpecManager) init(r *Registry) {
-	m.items = DefaultCustomizationSpec
+	m.items = slices.Clone(DefaultCustomizationSpec)
 
 	// Real VC is different DN, X509v3 extensions, etc.
 	// This is still useful for testing []byte of DER encoded cert over SOAP
@@ -313,7 +317,9 @@ func (m *CustomizationSpecManager) CreateCustomizationSpec(ctx *Context, req *ty
 		}
 	}
 
-	m.items = append(m.items, req.Item)
+	item := req.Item
+	m.updated(&item.Info)
+	m.items = append(m.items, item)
 	body.Res = new(types.CreateCustomizationSpecResponse)
 
 	return body
@@ -324,7 +330,13 @@ func (m *CustomizationSpecManager) OverwriteCustomizationSpec(ctx *Context, req
 
 	for i, item := range m.items {
 		if item.Info.Name == req.Item.Info.Name {
+			version := req.Item.Info.ChangeVersion
+			if version != "" && version != item.Info.ChangeVersion {
+				body.Fault_ = Fault("", new(types.ConcurrentAccess))
+				return body
+			}
 			m.items[i] = req.Item
+			m.updated(&m.items[i].Info)
 			body.Res = new(types.OverwriteCustomizationSpecResponse)
 			return body
 		}
@@ -335,6 +347,80 @@ func (m *CustomizationSpecManager) OverwriteCustomizationSpec(ctx *Context, req
 	return body
 }
 
+// updated sets the change version and update time of a created or overwritten spec
+func (m *CustomizationSpecManager) updated(info *types.CustomizationSpecInfo) {
+	now := time.Now()
+	version := now.Unix()
+	if v, err := strconv.ParseInt(info.ChangeVersion, 10, 64); err == nil && v >= version {
+		version = v + 1
+	}
+	info.ChangeVersion = strconv.FormatInt(version, 10)
+	info.LastUpdateTime = &now
+}
+
+func (m *CustomizationSpecManager) DeleteCustomizationSpec(ctx *Context, req *types.DeleteCustomizationSpec) soap.HasFault {
+	body := new(methods.DeleteCustomizationSpecBody)
+
+	for i, item := range m.items {
+		if item.Info.Name == req.Name {
+			m.items = slices.Delete(m.items, i, i+1)
+			body.Res = new(types.DeleteCustomizationSpecResponse)
+			return body
+		}
+	}
+
+	body.Fault_ = Fault("", new(types.NotFound))
+
+	return body
+}
+
+// customizationSpecItemXML sets the xsi namespace on the root element of an encoded CustomizationSpecItem
+type customizationSpecItemXML struct {
+	XMLName xml.Name `xml:"CustomizationSpecItem"`
+	XSI     string   `xml:"xmlns:xsi,attr"`
+	types.CustomizationSpecItem
+}
+
+func (m *CustomizationSpecManager) CustomizationSpecItemToXml(ctx *Context, req *types.CustomizationSpecItemToXml) soap.HasFault {
+	body := new(methods.CustomizationSpecItemToXmlBody)
+
+	item := customizationSpecItemXML{
+		XSI:                   "http://www.w3.org/2001/XMLSchema-instance",
+		CustomizationSpecItem: req.Item,
+	}
+
+	b, err := xml.Marshal(item)
+	if err != nil {
+		body.Fault_ = Fault(err.Error(), new(types.CustomizationFault))
+		return body
+	}
+
+	body.Res = &types.CustomizationSpecItemToXmlResponse{
+		Returnval: string(b),
+	}
+
+	return body
+}
+
+func (m *CustomizationSpecManager) XmlToCustomizationSpecItem(ctx *Context, req *types.XmlToCustomizationSpecItem) soap.HasFault {
+	body := new(methods.XmlToCustomizationSpecItemBody)
+
+	var item types.CustomizationSpecItem
+	dec := xml.NewDecoder(strings.NewReader(req.SpecItemXml))
+	dec.TypeFunc = types.TypeFunc()
+
+	if err := dec.Decode(&item); err != nil {
+		body.Fault_ = Fault(err.Error(), new(types.CustomizationFault))
+		return body
+	}
+
+	body.Res = &types.XmlToCustomizationSpecItemResponse{
+		Returnval: item,
+	}
+
+	return body
+}
+
 func (m *CustomizationSpecManager) Get() mo.Reference {
 	clone := *m
 
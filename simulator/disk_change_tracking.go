@@ -0,0 +1,444 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// changeTrackingBlockSize is the granularity of changed areas reported by QueryChangedDiskAreas.
+const changeTrackingBlockSize = 64 * 1024
+
+// diskChangeTracker is persisted as the disk's "-ctk.vmdk" file.
+// Blocks contains the sha256 of every non-zero block of the disk's flat backing file
+// at the time the current epoch's change ID was assigned.
+// Changes contains the blocks changed in each epoch, the oldest entry includes the changes of all pruned epochs.
+type diskChangeTracker struct {
+	UUID    string           `json:"uuid"`
+	Epoch   int              `json:"epoch"`
+	Blocks  map[int64]string `json:"blocks"`
+	Changes map[int][]int64  `json:"changes"`
+}
+
+// epoch returns the epoch of the given change ID, which must be of the form "$uuid/$epoch"
+func (ctk *diskChangeTracker) epoch(id string) (int, bool) {
+	u, e, ok := strings.Cut(id, "/")
+	if !ok || u != ctk.UUID {
+		return 0, false
+	}
+	n, err := strconv.Atoi(e)
+	if err != nil || n < 1 || n > ctk.Epoch {
+		return 0, false
+	}
+	return n, true
+}
+
+// changed returns the blocks changed after epoch "from" up to and including epoch "to".
+// When "from" is 0 or was pruned, all blocks changed up to epoch "to" are returned,
+// a superset of the changes since "from".
+func (ctk *diskChangeTracker) changed(from, to int) map[int64]bool {
+	oldest := to
+	for e := range ctk.Changes {
+		oldest = min(oldest, e)
+	}
+	if from < oldest {
+		from = 0
+	}
+
+	blocks := make(map[int64]bool)
+	for e, changes := range ctk.Changes {
+		if e > from && e <= to {
+			for _, i := range changes {
+				blocks[i] = true
+			}
+		}
+	}
+	return blocks
+}
+
+// prune merges the changes of epochs before the given epoch into a single entry.
+func (ctk *diskChangeTracker) prune(oldest int) {
+	var merged []int64
+	for e, changes := range ctk.Changes {
+		if e <= oldest {
+			merged = append(merged, changes...)
+			delete(ctk.Changes, e)
+		}
+	}
+	slices.Sort(merged)
+	ctk.Changes[oldest] = slices.Compact(merged)
+}
+
+// diffBlocks returns the blocks that differ between the given block hashes
+func diffBlocks(base, current map[int64]string) []int64 {
+	var blocks []int64
+	for i, sum := range current {
+		if base[i] != sum {
+			blocks = append(blocks, i)
+		}
+	}
+	for i := range base {
+		if _, ok := current[i]; !ok {
+			blocks = append(blocks, i)
+		}
+	}
+	slices.Sort(blocks)
+	return blocks
+}
+
+func changeTrackingFileName(name string) string {
+	return strings.Replace(name, ".vmdk", "-ctk.vmdk", 1)
+}
+
+// newChangeTrackingUUID returns a UUID in the format ESX uses for change IDs,
+// for example: "52 4a 17 8b 3c 2c b1 b0-40 07 2d 99 e5 4c 8f 2a"
+func newChangeTrackingUUID() string {
+	id := uuid.New()
+	s := make([]string, len(id))
+	for i, b := range id {
+		s[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(s[:8], " ") + "-" + strings.Join(s[8:], " ")
+}
+
+// changeTrackingBacking returns the change ID field and file name of a disk backing that supports CBT.
+func changeTrackingBacking(disk *types.VirtualDisk) (*string, string, bool) {
+	switch b := disk.Backing.(type) {
+	case *types.VirtualDiskFlatVer2BackingInfo:
+		return &b.ChangeId, b.FileName, true
+	case *types.VirtualDiskSeSparseBackingInfo:
+		return &b.ChangeId, b.FileName, true
+	case *types.VirtualDiskSparseVer2BackingInfo:
+		return &b.ChangeId, b.FileName, true
+	case *types.VirtualDiskRawDiskMappingVer1BackingInfo:
+		return &b.ChangeId, b.FileName, true
+	}
+	return nil, "", false
+}
+
+// diskBlockHashes reads the given file in changeTrackingBlockSize chunks,
+// returning the hash of each block that contains non-zero data.
+func diskBlockHashes(name string) (map[int64]string, error) {
+	blocks := make(map[int64]string)
+
+	f, err := os.Open(name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return blocks, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	zero := make([]byte, changeTrackingBlockSize)
+	buf := make([]byte, changeTrackingBlockSize)
+
+	for i := int64(0); ; i++ {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 && !bytes.Equal(buf[:n], zero[:n]) {
+			sum := sha256.Sum256(buf[:n])
+			blocks[i] = hex.EncodeToString(sum[:])
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return blocks, nil
+			}
+			return nil, err
+		}
+	}
+}
+
+func (vm *VirtualMachine) resolveDiskFile(ctx *Context, name string) (string, types.BaseMethodFault) {
+	dc := ctx.Map.getEntityDatacenter(vm)
+	return ctx.Map.FileManager().resolve(ctx, &dc.Self, name)
+}
+
+func (vm *VirtualMachine) loadChangeTracker(ctx *Context, name string) (*diskChangeTracker, string, types.BaseMethodFault) {
+	file, fault := vm.resolveDiskFile(ctx, changeTrackingFileName(name))
+	if fault != nil {
+		return nil, "", fault
+	}
+
+	fm := ctx.Map.FileManager()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, file, fm.fault(changeTrackingFileName(name), err, new(types.FileFault))
+	}
+
+	var ctk diskChangeTracker
+	if err = json.Unmarshal(data, &ctk); err != nil {
+		return nil, file, fm.fault(changeTrackingFileName(name), err, new(types.FileFault))
+	}
+
+	return &ctk, file, nil
+}
+
+// newChangeID records the changes to the disk since the previous change ID as a new epoch and returns its ID.
+// Epochs older than the oldest of the given change IDs that are still in use are pruned.
+func (vm *VirtualMachine) newChangeID(ctx *Context, name string, inUse []string) (string, types.BaseMethodFault) {
+	fm := ctx.Map.FileManager()
+
+	ctk, file, fault := vm.loadChangeTracker(ctx, name)
+	if fault != nil {
+		if _, ok := fault.(*types.FileNotFound); !ok {
+			return "", fault
+		}
+		ctk = &diskChangeTracker{
+			UUID:    newChangeTrackingUUID(),
+			Blocks:  make(map[int64]string),
+			Changes: make(map[int][]int64),
+		}
+	}
+
+	flat, fault := vm.resolveDiskFile(ctx, VirtualDiskBackingFileName(name))
+	if fault != nil {
+		return "", fault
+	}
+
+	blocks, err := diskBlockHashes(flat)
+	if err != nil {
+		return "", fm.fault(name, err, new(types.FileFault))
+	}
+
+	ctk.Epoch++
+	ctk.Changes[ctk.Epoch] = diffBlocks(ctk.Blocks, blocks)
+	ctk.Blocks = blocks
+
+	oldest := ctk.Epoch
+	for _, id := range inUse {
+		if e, ok := ctk.epoch(id); ok {
+			oldest = min(oldest, e)
+		}
+	}
+	ctk.prune(oldest)
+
+	data, err := json.Marshal(ctk)
+	if err == nil {
+		err = os.WriteFile(file, data, 0600)
+	}
+	if err != nil {
+		return "", fm.fault(changeTrackingFileName(name), err, new(types.FileFault))
+	}
+
+	return fmt.Sprintf("%s/%d", ctk.UUID, ctk.Epoch), nil
+}
+
+// changeIDsInUse returns the change IDs of the VM's snapshot disks
+func (vm *VirtualMachine) changeIDsInUse(ctx *Context) []string {
+	var ids []string
+
+	if vm.Snapshot == nil {
+		return nil
+	}
+
+	for _, ref := range allSnapshotsInTree(vm.Snapshot.RootSnapshotList) {
+		snapshot, ok := ctx.Map.Get(ref).(*VirtualMachineSnapshot)
+		if !ok {
+			continue
+		}
+		for _, device := range snapshot.Config.Hardware.Device {
+			if disk, ok := device.(*types.VirtualDisk); ok {
+				if id, _, ok := changeTrackingBacking(disk); ok && *id != "" {
+					ids = append(ids, *id)
+				}
+			}
+		}
+	}
+
+	return ids
+}
+
+// updateChangeTracking assigns a new change ID to each disk when change tracking is enabled,
+// otherwise clears the change IDs and removes the tracking files.
+// Called when ChangeTrackingEnabled is reconfigured and when a snapshot is taken.
+func (vm *VirtualMachine) updateChangeTracking(ctx *Context) types.BaseMethodFault {
+	enabled := isTrue(vm.Config.ChangeTrackingEnabled)
+	devices := vm.cloneDevice()
+	changed := false
+
+	var inUse []string
+	if enabled {
+		inUse = vm.changeIDsInUse(ctx)
+	}
+
+	for _, device := range devices {
+		disk, ok := device.(*types.VirtualDisk)
+		if !ok {
+			continue
+		}
+		changeID, name, ok := changeTrackingBacking(disk)
+		if !ok {
+			continue
+		}
+
+		if enabled {
+			id, fault := vm.newChangeID(ctx, name, inUse)
+			if fault != nil {
+				return fault
+			}
+			*changeID = id
+			changed = true
+			continue
+		}
+
+		if *changeID != "" {
+			if file, fault := vm.resolveDiskFile(ctx, changeTrackingFileName(name)); fault == nil {
+				_ = os.Remove(file)
+			}
+			*changeID = ""
+			changed = true
+		}
+	}
+
+	if changed {
+		ctx.Update(vm, []types.PropertyChange{{Name: "config.hardware.device", Val: devices}})
+	}
+
+	return nil
+}
+
+func (vm *VirtualMachine) queryChangedDiskAreas(ctx *Context, req *types.QueryChangedDiskAreas) (*types.DiskChangeInfo, types.BaseMethodFault) {
+	devices := vm.Config.Hardware.Device
+	if req.Snapshot != nil {
+		snapshot, ok := ctx.Map.Get(*req.Snapshot).(*VirtualMachineSnapshot)
+		if !ok || snapshot.Vm != vm.Self {
+			return nil, &types.ManagedObjectNotFound{Obj: *req.Snapshot}
+		}
+		devices = snapshot.Config.Hardware.Device
+	}
+
+	var disk *types.VirtualDisk
+	for _, device := range devices {
+		if d, ok := device.(*types.VirtualDisk); ok && d.Key == req.DeviceKey {
+			disk = d
+			break
+		}
+	}
+	if disk == nil {
+		return nil, &types.InvalidArgument{InvalidProperty: "deviceKey"}
+	}
+
+	changeID, name, ok := changeTrackingBacking(disk)
+	if !ok || *changeID == "" {
+		return nil, &types.FileNotFound{FileFault: types.FileFault{File: changeTrackingFileName(name)}}
+	}
+
+	if req.StartOffset < 0 || req.StartOffset > disk.CapacityInBytes {
+		return nil, &types.InvalidArgument{InvalidProperty: "startOffset"}
+	}
+
+	ctk, _, fault := vm.loadChangeTracker(ctx, name)
+	if fault != nil {
+		return nil, fault
+	}
+
+	// The state to compare against: the disk at the time of the snapshot or its current content.
+	to, ok := ctk.epoch(*changeID)
+	if !ok {
+		return nil, &types.InvalidArgument{InvalidProperty: "snapshot"}
+	}
+
+	var current map[int64]string
+	if req.Snapshot == nil {
+		flat, fault := vm.resolveDiskFile(ctx, VirtualDiskBackingFileName(name))
+		if fault != nil {
+			return nil, fault
+		}
+		var err error
+		current, err = diskBlockHashes(flat)
+		if err != nil {
+			return nil, ctx.Map.FileManager().fault(name, err, new(types.FileFault))
+		}
+	}
+
+	var changed map[int64]bool
+	if req.ChangeId == "*" {
+		// all allocated areas of the disk
+		if current != nil {
+			changed = make(map[int64]bool)
+			for i := range current {
+				changed[i] = true
+			}
+		} else {
+			changed = ctk.changed(0, to)
+		}
+	} else {
+		from, ok := ctk.epoch(req.ChangeId)
+		if !ok || from > to {
+			return nil, &types.InvalidArgument{InvalidProperty: "changeId"}
+		}
+		changed = ctk.changed(from, to)
+		if current != nil {
+			// changes since the current epoch's change ID was assigned
+			for _, i := range diffBlocks(ctk.Blocks, current) {
+				changed[i] = true
+			}
+		}
+	}
+
+	blocks := slices.Sorted(maps.Keys(changed))
+
+	info := &types.DiskChangeInfo{
+		StartOffset: req.StartOffset,
+		Length:      disk.CapacityInBytes - req.StartOffset,
+	}
+
+	for _, i := range blocks {
+		start := max(i*changeTrackingBlockSize, req.StartOffset)
+		end := min((i+1)*changeTrackingBlockSize, disk.CapacityInBytes)
+		if start >= end {
+			continue
+		}
+
+		if n := len(info.ChangedArea); n != 0 {
+			last := &info.ChangedArea[n-1]
+			if last.Start+last.Length == start {
+				last.Length += end - start
+				continue
+			}
+		}
+
+		info.ChangedArea = append(info.ChangedArea, types.DiskChangeExtent{
+			Start:  start,
+			Length: end - start,
+		})
+	}
+
+	return info, nil
+}
+
+func (vm *VirtualMachine) QueryChangedDiskAreas(ctx *Context, req *types.QueryChangedDiskAreas) soap.HasFault {
+	body := new(methods.QueryChangedDiskAreasBody)
+
+	info, err := vm.queryChangedDiskAreas(ctx, req)
+	if err != nil {
+		body.Fault_ = Fault("", err)
+		return body
+	}
+
+	body.Res = &types.QueryChangedDiskAreasResponse{
+		Returnval: *info,
+	}
+
+	return body
+}
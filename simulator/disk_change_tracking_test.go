@@ -0,0 +1,170 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestQueryChangedDiskAreas(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		const block = changeTrackingBlockSize
+
+		finder := find.NewFinder(c)
+		vm, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+
+		reconfigure := func(enabled bool) {
+			task, err := vm.Reconfigure(ctx, types.VirtualMachineConfigSpec{ChangeTrackingEnabled: &enabled})
+			require.NoError(t, err)
+			require.NoError(t, task.Wait(ctx))
+		}
+
+		disk := func(snapshot *types.ManagedObjectReference) *types.VirtualDisk {
+			devices, err := vm.Device(ctx)
+			require.NoError(t, err)
+			if snapshot != nil {
+				s := Map(ctx).Get(*snapshot).(*VirtualMachineSnapshot)
+				devices = s.Config.Hardware.Device
+			}
+			return devices.SelectByType((*types.VirtualDisk)(nil))[0].(*types.VirtualDisk)
+		}
+
+		backing := func(d *types.VirtualDisk) *types.VirtualDiskFlatVer2BackingInfo {
+			return d.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		}
+
+		write := func(data []byte) {
+			var p object.DatastorePath
+			p.FromString(backing(disk(nil)).FileName)
+			name := VirtualDiskBackingFileName(p.Path)
+			require.NoError(t, ds.Upload(ctx, bytes.NewReader(data), name, &soap.DefaultUpload))
+		}
+
+		snapshot := func(name string) *types.ManagedObjectReference {
+			task, err := vm.CreateSnapshot(ctx, name, "", false, false)
+			require.NoError(t, err)
+			info, err := task.WaitForResult(ctx)
+			require.NoError(t, err)
+			ref := info.Result.(types.ManagedObjectReference)
+			return &ref
+		}
+
+		query := func(snapshot *types.ManagedObjectReference, changeID string) ([]types.DiskChangeExtent, error) {
+			res, err := methods.QueryChangedDiskAreas(ctx, c, &types.QueryChangedDiskAreas{
+				This:      vm.Reference(),
+				Snapshot:  snapshot,
+				DeviceKey: disk(nil).Key,
+				ChangeId:  changeID,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return res.Returnval.ChangedArea, nil
+		}
+
+		assert.Empty(t, backing(disk(nil)).ChangeId)
+		_, err = query(nil, "*")
+		assert.True(t, fault.Is(err, &types.FileNotFound{}))
+
+		reconfigure(true)
+		assert.NotEmpty(t, backing(disk(nil)).ChangeId)
+
+		data := make([]byte, 3*block)
+		copy(data, bytes.Repeat([]byte("a"), block))
+		write(data)
+
+		s1 := snapshot("s1")
+		id1 := backing(disk(s1)).ChangeId
+		assert.NotEmpty(t, id1)
+
+		areas, err := query(s1, "*")
+		require.NoError(t, err)
+		assert.Equal(t, []types.DiskChangeExtent{{Start: 0, Length: block}}, areas)
+
+		copy(data[block:], bytes.Repeat([]byte("b"), 2*block))
+		write(data)
+
+		s2 := snapshot("s2")
+		id2 := backing(disk(s2)).ChangeId
+		assert.NotEqual(t, id1, id2)
+
+		// object.VirtualMachine helper uses the base snapshot's changeId
+		info, err := vm.QueryChangedDiskAreas(ctx, s1, s2, disk(s2), 0)
+		require.NoError(t, err)
+		assert.Equal(t, []types.DiskChangeExtent{{Start: block, Length: 2 * block}}, info.ChangedArea)
+		assert.Equal(t, disk(s2).CapacityInBytes, info.Length)
+
+		// no changes since s2
+		areas, err = query(nil, id2)
+		require.NoError(t, err)
+		assert.Empty(t, areas)
+
+		_, err = query(nil, "52 00 00 00 00 00 00 00-00 00 00 00 00 00 00 00/1")
+		assert.True(t, fault.Is(err, &types.InvalidArgument{}))
+
+		tracker := func() *diskChangeTracker {
+			var p object.DatastorePath
+			p.FromString(backing(disk(nil)).FileName)
+			f, _, err := ds.Download(ctx, changeTrackingFileName(p.Path), nil)
+			require.NoError(t, err)
+			defer f.Close()
+			var ctk diskChangeTracker
+			require.NoError(t, json.NewDecoder(f).Decode(&ctk))
+			return &ctk
+		}
+
+		// epochs are retained while referenced by a snapshot, the epoch assigned when enabled is merged into s1's
+		assert.Len(t, tracker().Changes, 2)
+
+		task, err := vm.RemoveAllSnapshot(ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		copy(data[2*block:], bytes.Repeat([]byte("c"), block))
+		write(data)
+		s3 := snapshot("s3")
+
+		// epochs no longer in use are merged
+		ctk := tracker()
+		assert.Len(t, ctk.Changes, 1)
+		assert.Len(t, ctk.Blocks, 3)
+
+		// changes since a pruned changeId include all blocks changed up to the oldest retained epoch
+		areas, err = query(s3, id1)
+		require.NoError(t, err)
+		assert.Equal(t, []types.DiskChangeExtent{{Start: 0, Length: 3 * block}}, areas)
+
+		copy(data, bytes.Repeat([]byte("d"), block))
+		write(data)
+		_ = snapshot("s4")
+		assert.Len(t, tracker().Changes, 2)
+
+		areas, err = query(nil, backing(disk(s3)).ChangeId)
+		require.NoError(t, err)
+		assert.Equal(t, []types.DiskChangeExtent{{Start: 0, Length: block}}, areas)
+
+		reconfigure(false)
+		assert.Empty(t, backing(disk(nil)).ChangeId)
+		_, err = query(nil, id2)
+		assert.True(t, fault.Is(err, &types.FileNotFound{}))
+	})
+}
@@ -71,6 +71,42 @@ var EventInfo = []types.EventDescriptionEventDetail{
 		FullFormat:  "Removed host {{.Host.Name}} in {{.Datacenter.Name}}",
 		Category:    "info",
 	},
+	{
+		Key:         "HostConnectedEvent",
+		Description: "Host connected",
+		Category:    "info",
+		FullFormat:  "Connected to {{.Host.Name}} in {{.Datacenter.Name}}",
+	},
+	{
+		Key:         "HostShutdownEvent",
+		Description: "Host shut down",
+		Category:    "info",
+		FullFormat:  "Shut down of {{.Host.Name}} in {{.Datacenter.Name}}: {{.Reason}}",
+	},
+	{
+		Key:         "EnteringStandbyModeEvent",
+		Description: "Entering standby mode",
+		Category:    "info",
+		FullFormat:  "The host {{.Host.Name}} is entering standby mode",
+	},
+	{
+		Key:         "EnteredStandbyModeEvent",
+		Description: "Entered standby mode",
+		Category:    "info",
+		FullFormat:  "The host {{.Host.Name}} is in standby mode",
+	},
+	{
+		Key:         "ExitingStandbyModeEvent",
+		Description: "Exiting standby mode",
+		Category:    "info",
+		FullFormat:  "The host {{.Host.Name}} is exiting standby mode",
+	},
+	{
+		Key:         "ExitedStandbyModeEvent",
+		Description: "Exited standby mode",
+		Category:    "info",
+		FullFormat:  "The host {{.Host.Name}} is no longer in standby mode",
+	},
 	{
 		Key:         "VmSuspendedEvent",
 		Description: "VM suspended",
@@ -299,4 +335,46 @@ var EventInfo = []types.EventDescriptionEventDetail{
 		Category:    "info",
 		FullFormat:  "dvPort group {{.Net.Name}} in {{.Datacenter.Name}} was deleted.",
 	},
+	{
+		Key:         "HostConnectionLostEvent",
+		Description: "Host connection lost",
+		Category:    "error",
+		FullFormat:  "Host {{.Host.Name}} in {{.Datacenter.Name}} is not responding",
+	},
+	{
+		Key:         "DasEnabledEvent",
+		Description: "vSphere HA enabled for cluster",
+		Category:    "info",
+		FullFormat:  "vSphere HA enabled in cluster {{.ComputeResource.Name}} in {{.Datacenter.Name}}",
+	},
+	{
+		Key:         "DasDisabledEvent",
+		Description: "vSphere HA disabled for cluster",
+		Category:    "info",
+		FullFormat:  "vSphere HA disabled in cluster {{.ComputeResource.Name}} in {{.Datacenter.Name}}",
+	},
+	{
+		Key:         "DasHostFailedEvent",
+		Description: "vSphere HA host failure",
+		Category:    "error",
+		FullFormat:  "A possible host failure has been detected by vSphere HA on {{.FailedHost.Name}} in cluster {{.ComputeResource.Name}} in {{.Datacenter.Name}}",
+	},
+	{
+		Key:         "VmRestartedOnAlternateHostEvent",
+		Description: "VM restarted on alternate host",
+		Category:    "info",
+		FullFormat:  "Virtual machine {{.Vm.Name}} was restarted on {{.Host.Name}} since {{.SourceHost.Name}} failed",
+	},
+	{
+		Key:         "NotEnoughResourcesToStartVmEvent",
+		Description: "Insufficient resources for vSphere HA to start VM",
+		Category:    "warning",
+		FullFormat:  "vSphere HA cannot fail over {{.Vm.Name}} in {{.ComputeResource.Name}} in {{.Datacenter.Name}}. Reason: {{.Reason}}",
+	},
+	{
+		Key:         "VmFailoverFailed",
+		Description: "vSphere HA virtual machine failover unsuccessful",
+		Category:    "warning",
+		FullFormat:  "vSphere HA unsuccessfully failed over {{.Vm.Name}} in cluster {{.ComputeResource.Name}} in {{.Datacenter.Name}}",
+	},
 }
@@ -35,7 +35,7 @@ func (vm *BusyVM) PowerOffVMTask(ctx *simulator.Context, req *types.PowerOffVM_T
 	}
 }
 
-// Add AcquireTicket method, not implemented by simulator.VirtualMachine
+// Override simulator.VirtualMachine.AcquireTicket to return a fixed ticket
 func (vm *BusyVM) AcquireTicket(req *types.AcquireTicket) soap.HasFault {
 	body := &methods.AcquireTicketBody{}
 
@@ -168,6 +168,7 @@ type FaultInjectionRule struct {
 	count     int64
 	skipCount int64
 	rng       *rand.Rand
+	spec      *FaultRuleSpec
 }
 
 // FaultType represents the type of fault to inject
@@ -220,6 +221,10 @@ func (f *FaultInjector) AddRule(rule *FaultInjectionRule) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	f.addRule(rule)
+}
+
+func (f *FaultInjector) addRule(rule *FaultInjectionRule) {
 	// Initialize rule's RNG if not set
 	if rule.rng == nil {
 		rule.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -241,6 +246,19 @@ func (f *FaultInjector) RemoveRule(index int) bool {
 	return true
 }
 
+// EnableRule enables or disables a fault injection rule by index
+func (f *FaultInjector) EnableRule(index int, enabled bool) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if index < 0 || index >= len(f.rules) {
+		return false
+	}
+
+	f.rules[index].Enabled = enabled
+	return true
+}
+
 // ClearRules removes all fault injection rules
 func (f *FaultInjector) ClearRules() {
 	f.mu.Lock()
@@ -260,8 +278,8 @@ func (f *FaultInjector) GetRules() []*FaultInjectionRule {
 
 // ShouldInjectFault determines if a fault should be injected for the given method call
 func (f *FaultInjector) ShouldInjectFault(method *Method, handler mo.Reference) *FaultInjectionRule {
-	f.mu.RLock()
-	defer f.mu.RUnlock()
+	f.mu.Lock() // rule counters are updated below
+	defer f.mu.Unlock()
 
 	for _, rule := range f.rules {
 		if !rule.Enabled {
@@ -0,0 +1,419 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// faultsPrefix is the path of the fault injection HTTP endpoint, see Service.ServeFaults
+const faultsPrefix = "/vcsim/faults"
+
+var faultTypeNames = map[FaultType]string{
+	FaultTypeGeneric:                    "Generic",
+	FaultTypeNotAuthenticated:           "NotAuthenticated",
+	FaultTypeNoPermission:               "NoPermission",
+	FaultTypeInvalidArgument:            "InvalidArgument",
+	FaultTypeMethodNotFound:             "MethodNotFound",
+	FaultTypeManagedObjectNotFound:      "ManagedObjectNotFound",
+	FaultTypeInvalidState:               "InvalidState",
+	FaultTypeResourceInUse:              "ResourceInUse",
+	FaultTypeInsufficientResourcesFault: "InsufficientResourcesFault",
+	FaultTypeCustom:                     "Custom",
+	FaultTypeNetworkFailure:             "NetworkFailure",
+	FaultTypeTimeout:                    "Timeout",
+}
+
+func (t FaultType) String() string {
+	if name, ok := faultTypeNames[t]; ok {
+		return name
+	}
+	return "FaultType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ParseFaultType returns the FaultType for the given name, such as "NotAuthenticated" or "Timeout".
+func ParseFaultType(name string) (FaultType, bool) {
+	for t, s := range faultTypeNames {
+		if s == name {
+			return t, true
+		}
+	}
+	return FaultTypeGeneric, false
+}
+
+// FaultRuleSpec is the declarative form of a FaultInjectionRule,
+// as used by FaultScenario files and the vcsim fault injection HTTP endpoint.
+type FaultRuleSpec struct {
+	// Method is the SOAP method name, defaults to "*"
+	Method string `json:"method,omitempty" yaml:"method,omitempty"`
+	// ObjectType is the managed object type, defaults to "*"
+	ObjectType string `json:"objectType,omitempty" yaml:"objectType,omitempty"`
+	// ObjectName is the managed entity name, defaults to "*"
+	ObjectName string `json:"objectName,omitempty" yaml:"objectName,omitempty"`
+	// Include filters, see FaultFilterAll
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Exclude filters, see FaultFilterAny
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+	// Probability of injecting the fault, defaults to 1.0
+	Probability *float64 `json:"probability,omitempty" yaml:"probability,omitempty"`
+	// Fault is the name of a FaultType, such as "NotAuthenticated",
+	// or the name of a vim25 fault type, such as "InvalidPowerState". Defaults to "Generic".
+	Fault string `json:"fault,omitempty" yaml:"fault,omitempty"`
+	// Message is the fault message
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// Delay in milliseconds before returning the fault
+	Delay int `json:"delay,omitempty" yaml:"delay,omitempty"`
+	// MaxCount limits the number of times the rule is triggered (0 = unlimited)
+	MaxCount int `json:"maxCount,omitempty" yaml:"maxCount,omitempty"`
+	// SkipCount is the number of matches to skip before the rule is triggered
+	SkipCount int `json:"skipCount,omitempty" yaml:"skipCount,omitempty"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+}
+
+// FaultScenario is a set of fault injection rules, which can be loaded from a YAML or JSON file.
+// Example:
+//
+//	rules:
+//	  - method: PowerOnVM_Task
+//	    objectName: DC0_H0_VM0
+//	    fault: InvalidPowerState
+//	    maxCount: 1
+//	  - method: RetrievePropertiesEx
+//	    fault: NotAuthenticated
+//	    skipCount: 10
+type FaultScenario struct {
+	Rules []FaultRuleSpec `json:"rules" yaml:"rules"`
+}
+
+// FaultRuleStatus is the FaultRuleSpec and injection count of a rule registered with a FaultInjector.
+type FaultRuleStatus struct {
+	Index int `json:"index"`
+	FaultRuleSpec
+	Count int64 `json:"count"`
+}
+
+func faultFilter(filter func(...string) FaultFilterCallback, spec []string) (cb FaultFilterCallback, err error) {
+	if len(spec) == 0 {
+		return nil, nil
+	}
+
+	// FaultFilter{All,Any} panic on invalid input, which is an error here rather than a usage bug
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid filter %q: %v", spec, r)
+		}
+	}()
+
+	return filter(spec...), nil
+}
+
+// Rule returns a FaultInjectionRule for the given spec.
+func (spec *FaultRuleSpec) Rule() (*FaultInjectionRule, error) {
+	wildcard := func(s string) string {
+		if s == "" {
+			return "*"
+		}
+		return s
+	}
+
+	rule := &FaultInjectionRule{
+		MethodName:  wildcard(spec.Method),
+		ObjectType:  wildcard(spec.ObjectType),
+		ObjectName:  wildcard(spec.ObjectName),
+		Probability: 1.0,
+		Message:     spec.Message,
+		Delay:       spec.Delay,
+		MaxCount:    spec.MaxCount,
+		SkipCount:   spec.SkipCount,
+		Enabled:     true,
+		spec:        spec,
+	}
+
+	if spec.Probability != nil {
+		rule.Probability = *spec.Probability
+		if rule.Probability < 0 || rule.Probability > 1 {
+			return nil, fmt.Errorf("invalid probability: %v", rule.Probability)
+		}
+	}
+
+	if spec.Enabled != nil {
+		rule.Enabled = *spec.Enabled
+	}
+
+	if spec.Delay < 0 || spec.MaxCount < 0 || spec.SkipCount < 0 {
+		return nil, errors.New("delay, maxCount and skipCount must not be negative")
+	}
+
+	var err error
+	if rule.InclusionPropertyFilter, err = faultFilter(FaultFilterAll, spec.Include); err != nil {
+		return nil, err
+	}
+	if rule.ExclusionPropertyFilter, err = faultFilter(FaultFilterAny, spec.Exclude); err != nil {
+		return nil, err
+	}
+
+	if spec.Fault == "" {
+		return rule, nil
+	}
+
+	var ok bool
+	if rule.FaultType, ok = ParseFaultType(spec.Fault); ok {
+		if rule.FaultType == FaultTypeCustom {
+			return nil, errors.New("fault type Custom requires a vim25 fault name")
+		}
+		return rule, nil
+	}
+
+	if kind, ok := types.TypeFunc()(spec.Fault); ok {
+		if fault, ok := reflect.New(kind).Interface().(types.BaseMethodFault); ok {
+			rule.FaultType = FaultTypeCustom
+			rule.Fault = fault
+			return rule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown fault: %q", spec.Fault)
+}
+
+// ruleSpec returns the FaultRuleSpec for the given rule, which may have been created from Go code
+// in which case filter callbacks are not included.
+func ruleSpec(rule *FaultInjectionRule) FaultRuleSpec {
+	probability := rule.Probability
+
+	spec := FaultRuleSpec{
+		Method:      rule.MethodName,
+		ObjectType:  rule.ObjectType,
+		ObjectName:  rule.ObjectName,
+		Probability: &probability,
+		Fault:       rule.FaultType.String(),
+		Message:     rule.Message,
+		Delay:       rule.Delay,
+		MaxCount:    rule.MaxCount,
+		SkipCount:   rule.SkipCount,
+		Enabled:     types.NewBool(rule.Enabled),
+	}
+
+	if rule.spec != nil {
+		spec.Include = rule.spec.Include
+		spec.Exclude = rule.spec.Exclude
+	}
+
+	if rule.FaultType == FaultTypeCustom && rule.Fault != nil {
+		spec.Fault = reflect.TypeOf(rule.Fault).Elem().Name()
+	}
+
+	return spec
+}
+
+// ParseFaultScenario decodes a FaultScenario in YAML or JSON format,
+// returning an error if any of its rules are invalid.
+func ParseFaultScenario(r io.Reader) (*FaultScenario, error) {
+	var scenario FaultScenario
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&scenario); err != nil {
+		if err == io.EOF {
+			return &scenario, nil
+		}
+		return nil, err
+	}
+
+	for i := range scenario.Rules {
+		if _, err := scenario.Rules[i].Rule(); err != nil {
+			return nil, fmt.Errorf("rule %d: %s", i, err)
+		}
+	}
+
+	return &scenario, nil
+}
+
+// AddScenario adds the rules in the given scenario, returning their indices.
+// No rules are added if any rule is invalid.
+func (f *FaultInjector) AddScenario(scenario *FaultScenario) ([]int, error) {
+	rules := make([]*FaultInjectionRule, len(scenario.Rules))
+
+	for i := range scenario.Rules {
+		rule, err := scenario.Rules[i].Rule()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %s", i, err)
+		}
+		rules[i] = rule
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]int, len(rules))
+	for i, rule := range rules {
+		ids[i] = len(f.rules)
+		f.addRule(rule)
+	}
+
+	return ids, nil
+}
+
+// LoadScenario parses a FaultScenario from r and adds its rules.
+func (f *FaultInjector) LoadScenario(r io.Reader) error {
+	scenario, err := ParseFaultScenario(r)
+	if err != nil {
+		return err
+	}
+	_, err = f.AddScenario(scenario)
+	return err
+}
+
+// GetRuleStatus returns the spec and injection count of all rules.
+func (f *FaultInjector) GetRuleStatus() []FaultRuleStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	status := make([]FaultRuleStatus, len(f.rules))
+	for i, rule := range f.rules {
+		status[i] = FaultRuleStatus{
+			Index:         i,
+			FaultRuleSpec: ruleSpec(rule),
+			Count:         rule.count,
+		}
+	}
+
+	return status
+}
+
+func faultsResponse(w http.ResponseWriter, status int, val any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(val)
+}
+
+func faultsError(w http.ResponseWriter, status int, err error) {
+	faultsResponse(w, status, struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+// ServeFaults is the HTTP endpoint for managing fault injection rules at runtime:
+//
+//	GET    /vcsim/faults           list rules and stats
+//	POST   /vcsim/faults           add rules from a FaultScenario (YAML or JSON) request body
+//	DELETE /vcsim/faults           remove all rules
+//	GET    /vcsim/faults/stats     get stats, see FaultInjector.GetStats
+//	DELETE /vcsim/faults/stats     reset stats
+//	GET    /vcsim/faults/{index}   get a rule
+//	PATCH  /vcsim/faults/{index}   enable or disable a rule, with request body {"enabled": bool}
+//	DELETE /vcsim/faults/{index}   remove a rule
+func (s *Service) ServeFaults(w http.ResponseWriter, r *http.Request) {
+	f := s.FaultInjector()
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, faultsPrefix), "/")
+
+	switch {
+	case id == "":
+		s.serveFaultRules(w, r)
+	case id == "stats":
+		switch r.Method {
+		case http.MethodGet:
+			faultsResponse(w, http.StatusOK, f.GetStats())
+		case http.MethodDelete:
+			f.ResetStats()
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	default:
+		index, err := strconv.Atoi(id)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		s.serveFaultRule(w, r, index)
+	}
+}
+
+func (s *Service) serveFaultRules(w http.ResponseWriter, r *http.Request) {
+	f := s.FaultInjector()
+
+	switch r.Method {
+	case http.MethodGet:
+		faultsResponse(w, http.StatusOK, struct {
+			Rules []FaultRuleStatus `json:"rules"`
+			Stats map[string]any    `json:"stats"`
+		}{f.GetRuleStatus(), f.GetStats()})
+	case http.MethodPost:
+		scenario, err := ParseFaultScenario(r.Body)
+		if err != nil {
+			faultsError(w, http.StatusBadRequest, err)
+			return
+		}
+		ids, err := f.AddScenario(scenario)
+		if err != nil {
+			faultsError(w, http.StatusBadRequest, err)
+			return
+		}
+		faultsResponse(w, http.StatusCreated, ids)
+	case http.MethodDelete:
+		f.ClearRules()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Service) serveFaultRule(w http.ResponseWriter, r *http.Request, index int) {
+	f := s.FaultInjector()
+
+	switch r.Method {
+	case http.MethodGet:
+		status := f.GetRuleStatus()
+		if index < 0 || index >= len(status) {
+			http.NotFound(w, r)
+			return
+		}
+		faultsResponse(w, http.StatusOK, status[index])
+	case http.MethodPatch:
+		var spec struct {
+			Enabled *bool `json:"enabled"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+			faultsError(w, http.StatusBadRequest, err)
+			return
+		}
+		if spec.Enabled == nil {
+			faultsError(w, http.StatusBadRequest, errors.New("missing field: enabled"))
+			return
+		}
+		if !f.EnableRule(index, *spec.Enabled) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		if !f.RemoveRule(index) {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,266 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/session"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestParseFaultScenario(t *testing.T) {
+	yamlScenario := `
+rules:
+  - method: PowerOnVM_Task
+    objectName: DC0_H0_VM0
+    fault: InvalidPowerState
+    maxCount: 1
+  - method: RetrievePropertiesEx
+    fault: Timeout
+    include: ["name == DC0*"]
+    skipCount: 2
+    enabled: false
+`
+	jsonScenario := `{"rules": [{"method": "PowerOffVM_Task", "fault": "NotAuthenticated", "probability": 0.5}]}`
+
+	scenario, err := ParseFaultScenario(strings.NewReader(yamlScenario))
+	require.NoError(t, err)
+	require.Len(t, scenario.Rules, 2)
+
+	rule, err := scenario.Rules[0].Rule()
+	require.NoError(t, err)
+	assert.Equal(t, "PowerOnVM_Task", rule.MethodName)
+	assert.Equal(t, "*", rule.ObjectType)
+	assert.Equal(t, FaultTypeCustom, rule.FaultType)
+	assert.IsType(t, new(types.InvalidPowerState), rule.Fault)
+	assert.Equal(t, 1.0, rule.Probability)
+	assert.True(t, rule.Enabled)
+
+	rule, err = scenario.Rules[1].Rule()
+	require.NoError(t, err)
+	assert.Equal(t, FaultTypeTimeout, rule.FaultType)
+	assert.NotNil(t, rule.InclusionPropertyFilter)
+	assert.Equal(t, 2, rule.SkipCount)
+	assert.False(t, rule.Enabled)
+
+	scenario, err = ParseFaultScenario(strings.NewReader(jsonScenario))
+	require.NoError(t, err)
+	require.Len(t, scenario.Rules, 1)
+	rule, err = scenario.Rules[0].Rule()
+	require.NoError(t, err)
+	assert.Equal(t, FaultTypeNotAuthenticated, rule.FaultType)
+	assert.Equal(t, 0.5, rule.Probability)
+
+	invalid := []string{
+		`rules: [{fault: NoSuchFault}]`,
+		`rules: [{fault: Custom}]`,
+		`rules: [{fault: VirtualMachineConfigSpec}]`,
+		`rules: [{probability: 2}]`,
+		`rules: [{delay: -1}]`,
+		`rules: [{include: ["name"]}]`,
+		`rules: [{exclude: [""]}]`,
+		`rules: [{method: PowerOnVM_Task, unknown: field}]`,
+		`rules: {}`,
+	}
+
+	for _, s := range invalid {
+		_, err = ParseFaultScenario(strings.NewReader(s))
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFaultInjectorScenario(t *testing.T) {
+	f := NewFaultInjector()
+
+	scenario := &FaultScenario{Rules: []FaultRuleSpec{{Method: "A"}, {Fault: "Invalid"}}}
+	_, err := f.AddScenario(scenario)
+	require.Error(t, err)
+	assert.Empty(t, f.GetRules(), "no rules added when any rule is invalid")
+
+	err = f.LoadScenario(strings.NewReader(`rules: [{method: A}, {method: B, fault: InvalidState}]`))
+	require.NoError(t, err)
+
+	f.AddRule(&FaultInjectionRule{
+		MethodName: "C",
+		FaultType:  FaultTypeCustom,
+		Fault:      new(types.HostConfigFault),
+	})
+
+	status := f.GetRuleStatus()
+	require.Len(t, status, 3)
+	assert.Equal(t, "A", status[0].Method)
+	assert.Equal(t, "Generic", status[0].Fault)
+	assert.Equal(t, "InvalidState", status[1].Fault)
+	assert.Equal(t, "HostConfigFault", status[2].Fault)
+	assert.False(t, *status[2].Enabled)
+
+	assert.True(t, f.EnableRule(2, true))
+	assert.True(t, f.GetRules()[2].Enabled)
+	assert.False(t, f.EnableRule(3, true))
+}
+
+func TestServeFaults(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		s := ServiceFromContext(ctx)
+
+		call := func(method, path, body string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(method, path, strings.NewReader(body))
+			s.ServeFaults(w, r)
+			return w
+		}
+
+		var list struct {
+			Rules []FaultRuleStatus `json:"rules"`
+			Stats map[string]any    `json:"stats"`
+		}
+
+		get := func() {
+			w := call(http.MethodGet, "/vcsim/faults", "")
+			require.Equal(t, http.StatusOK, w.Code)
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+		}
+
+		w := call(http.MethodPost, "/vcsim/faults", `{"rules": [{"fault": "Unknown"}]}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		scenario := `
+rules:
+  - method: PowerOnVM_Task
+    objectName: DC0_H0_VM0
+    fault: NotAuthenticated
+  - method: PowerOffVM_Task
+    fault: InvalidPowerState
+    enabled: false
+`
+		w = call(http.MethodPost, "/vcsim/faults", scenario)
+		require.Equal(t, http.StatusCreated, w.Code)
+		var ids []int
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&ids))
+		assert.Equal(t, []int{0, 1}, ids)
+
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		_, err = vm.PowerOn(ctx)
+		require.Error(t, err)
+		assert.True(t, fault.Is(err, &types.NotAuthenticated{}))
+
+		// rule 1 is disabled
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		w = call(http.MethodPatch, "/vcsim/faults/1", `{"enabled": true}`)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		_, err = vm.PowerOff(ctx)
+		require.Error(t, err)
+		assert.True(t, fault.Is(err, &types.InvalidPowerState{}))
+
+		get()
+		require.Len(t, list.Rules, 2)
+		assert.Equal(t, int64(1), list.Rules[0].Count)
+		assert.Equal(t, int64(1), list.Rules[1].Count)
+		assert.True(t, *list.Rules[1].Enabled)
+		assert.EqualValues(t, 2, list.Stats["total_injections"])
+
+		w = call(http.MethodGet, "/vcsim/faults/1", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var rule FaultRuleStatus
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&rule))
+		assert.Equal(t, "PowerOffVM_Task", rule.Method)
+		assert.Equal(t, "InvalidPowerState", rule.Fault)
+
+		w = call(http.MethodDelete, "/vcsim/faults/stats", "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = call(http.MethodGet, "/vcsim/faults/stats", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		var stats map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&stats))
+		assert.EqualValues(t, 0, stats["total_injections"])
+
+		w = call(http.MethodDelete, "/vcsim/faults/0", "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+		get()
+		require.Len(t, list.Rules, 1)
+		assert.Equal(t, "PowerOffVM_Task", list.Rules[0].Method)
+
+		for _, path := range []string{"/vcsim/faults/1", "/vcsim/faults/-1", "/vcsim/faults/x"} {
+			w = call(http.MethodDelete, path, "")
+			assert.Equal(t, http.StatusNotFound, w.Code, path)
+		}
+
+		w = call(http.MethodPatch, "/vcsim/faults/0", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = call(http.MethodPut, "/vcsim/faults", "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+		w = call(http.MethodDelete, "/vcsim/faults", "")
+		require.Equal(t, http.StatusNoContent, w.Code)
+		get()
+		assert.Empty(t, list.Rules)
+
+		task, err = vm.PowerOn(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+	})
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	get := func(ctx context.Context, client *http.Client, u *url.URL) int {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		return res.StatusCode
+	}
+
+	for _, enabled := range []bool{false, true} {
+		m := VPX()
+		require.NoError(t, m.Create())
+		m.Service.AdminEndpoints = enabled
+
+		Test(func(ctx context.Context, c *vim25.Client) {
+			u := *c.URL()
+			u.Path = "/vcsim/faults"
+
+			if !enabled {
+				assert.Equal(t, http.StatusNotFound, get(ctx, &c.Client.Client, &u))
+				return
+			}
+
+			hosts := u
+			hosts.Path = "/vcsim/hosts/DC0_H0/fail"
+
+			// no session cookie
+			assert.Equal(t, http.StatusUnauthorized, get(ctx, new(http.Client), &u))
+			assert.Equal(t, http.StatusUnauthorized, get(ctx, new(http.Client), &hosts))
+			assert.Equal(t, http.StatusMethodNotAllowed, get(ctx, &c.Client.Client, &hosts))
+
+			// session cookie from the SOAP login
+			assert.Equal(t, http.StatusOK, get(ctx, &c.Client.Client, &u))
+
+			require.NoError(t, session.NewManager(c).Logout(ctx))
+			assert.Equal(t, http.StatusUnauthorized, get(ctx, &c.Client.Client, &u))
+		}, m)
+	}
+}
@@ -374,14 +374,25 @@ func (c *createVM) Run(task *Task) (types.AnyType, types.BaseMethodFault) {
 
 		c.ctx.WithLock(cr, func() {
 			var hosts []types.ManagedObjectReference
+			var cluster *ClusterComputeResource
 			switch cr := cr.(type) {
 			case *mo.ComputeResource:
 				hosts = cr.Host
 			case *ClusterComputeResource:
 				hosts = cr.Host
+				cluster = cr
 			}
 
 			hosts = hostsWithDatastore(c.ctx, hosts, c.req.Config.Files.VmPathName)
+
+			if cluster != nil && cluster.drsEnabled() {
+				_, mem := drsDemand(vm)
+				if host := cluster.placeHost(c.ctx, 0, mem, hosts); host != nil {
+					vm.Runtime.Host = host
+					return
+				}
+			}
+
 			host := hosts[rand.Intn(len(hosts))]
 			vm.Runtime.Host = &host
 		})
@@ -585,6 +585,240 @@ func (h *HostSystem) ReconnectHostTask(ctx *Context, spec *types.ReconnectHost_T
 	}
 }
 
+// poweredOnVms returns the VMs on this host that are not powered off.
+func (h *HostSystem) poweredOnVms(ctx *Context) []*VirtualMachine {
+	var vms []*VirtualMachine
+
+	for _, ref := range h.Vm {
+		vm, ok := ctx.Map.Get(ref).(*VirtualMachine)
+		if ok && vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+			vms = append(vms, vm)
+		}
+	}
+
+	return vms
+}
+
+// powerOp validates a host power operation and, when forced, powers off
+// any VMs that are still running on the host.
+func (h *HostSystem) powerOp(ctx *Context, force bool) types.BaseMethodFault {
+	if h.Runtime.PowerState != types.HostSystemPowerStatePoweredOn {
+		return &types.InvalidState{}
+	}
+
+	vms := h.poweredOnVms(ctx)
+	if len(vms) == 0 {
+		return nil
+	}
+
+	if !force && !h.Runtime.InMaintenanceMode {
+		return &types.InvalidState{}
+	}
+
+	for _, vm := range vms {
+		runner := &powerVMTask{
+			VirtualMachine: vm,
+			state:          types.VirtualMachinePowerStatePoweredOff,
+			ctx:            ctx,
+		}
+		task := ctx.Map.Get(CreateTask(runner.Reference(), "powerOff", runner.Run).Run(ctx)).(*Task)
+		task.Wait()
+		if task.Info.Error != nil {
+			return task.Info.Error.Fault
+		}
+	}
+
+	return nil
+}
+
+// boot brings the host back to the poweredOn and connected state.
+func (h *HostSystem) boot(ctx *Context) {
+	ctx.Update(h, []types.PropertyChange{
+		{Name: "runtime.powerState", Val: types.HostSystemPowerStatePoweredOn},
+		{Name: "runtime.connectionState", Val: types.HostSystemConnectionStateConnected},
+		{Name: "runtime.standbyMode", Val: string(types.HostStandbyModeNone)},
+		{Name: "runtime.bootTime", Val: time.Now()},
+	})
+
+	ctx.postEvent(&types.HostConnectedEvent{HostEvent: h.event(ctx)})
+}
+
+// transition applies the TaskDelay for the given task while the host is between power states,
+// releasing the host lock such that the intermediate state can be observed.
+func (h *HostSystem) transition(t *Task) {
+	unlock := func() {}
+	if enableLocker {
+		l := t.ctx.Map.locker(h)
+		l.Release(t.ctx)
+		unlock = func() { l.Acquire(t.ctx) }
+	}
+
+	TaskDelay.delay(t.Info.Name)
+
+	unlock()
+}
+
+// evacuatePoweredOff moves the powered off VMs on this host to another host in the same cluster.
+func (h *HostSystem) evacuatePoweredOff(ctx *Context) types.BaseMethodFault {
+	var vms []*VirtualMachine
+	for _, ref := range h.Vm {
+		if vm, ok := ctx.Map.Get(ref).(*VirtualMachine); ok && vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOff {
+			vms = append(vms, vm)
+		}
+	}
+	if len(vms) == 0 {
+		return nil
+	}
+
+	var dst *types.ManagedObjectReference
+	for _, ref := range hostParent(ctx, &h.HostSystem).Host {
+		host, ok := ctx.Map.Get(ref).(*HostSystem)
+		if ok && ref != h.Self && host.Runtime.PowerState == types.HostSystemPowerStatePoweredOn &&
+			host.Runtime.ConnectionState == types.HostSystemConnectionStateConnected && !host.Runtime.InMaintenanceMode {
+			dst = &ref
+			break
+		}
+	}
+	if dst == nil {
+		return &types.InvalidState{}
+	}
+
+	for _, vm := range vms {
+		res := vm.RelocateVMTask(ctx, &types.RelocateVM_Task{This: vm.Self, Spec: types.VirtualMachineRelocateSpec{Host: dst}})
+		task := ctx.Map.Get(res.(*methods.RelocateVM_TaskBody).Res.Returnval).(*Task)
+		task.Wait()
+		if task.Info.Error != nil {
+			return task.Info.Error.Fault
+		}
+	}
+
+	return nil
+}
+
+// RebootHostTask takes the host down and brings it back before the task completes.
+// The time the host is down can be configured using TaskDelay.MethodDelay["Reboot"],
+// which also delays the start of the task.
+func (h *HostSystem) RebootHostTask(ctx *Context, req *types.RebootHost_Task) soap.HasFault {
+	task := CreateTask(h, "reboot", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if err := h.powerOp(ctx, req.Force); err != nil {
+			return nil, err
+		}
+
+		ctx.postEvent(&types.HostShutdownEvent{HostEvent: h.event(ctx), Reason: "Reboot requested"})
+
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.powerState", Val: types.HostSystemPowerStateUnknown},
+			{Name: "runtime.connectionState", Val: types.HostSystemConnectionStateNotResponding},
+		})
+
+		h.transition(t)
+
+		h.boot(ctx)
+
+		return nil, nil
+	})
+
+	return &methods.RebootHost_TaskBody{
+		Res: &types.RebootHost_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+func (h *HostSystem) ShutdownHostTask(ctx *Context, req *types.ShutdownHost_Task) soap.HasFault {
+	task := CreateTask(h, "shutdown", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if err := h.powerOp(ctx, req.Force); err != nil {
+			return nil, err
+		}
+
+		ctx.postEvent(&types.HostShutdownEvent{HostEvent: h.event(ctx), Reason: "Shutdown requested"})
+
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.powerState", Val: types.HostSystemPowerStatePoweredOff},
+			{Name: "runtime.connectionState", Val: types.HostSystemConnectionStateNotResponding},
+		})
+
+		return nil, nil
+	})
+
+	return &methods.ShutdownHost_TaskBody{
+		Res: &types.ShutdownHost_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// PowerDownHostToStandByTask enters standby mode, the host is in the entering state for
+// TaskDelay.MethodDelay["PowerDownHostToStandBy"], which also delays the start of the task.
+// Powered off VMs are moved to another host in the cluster if EvacuatePoweredOffVms is true.
+func (h *HostSystem) PowerDownHostToStandByTask(ctx *Context, req *types.PowerDownHostToStandBy_Task) soap.HasFault {
+	task := CreateTask(h, "powerDownHostToStandBy", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		// There is no DRS to evacuate running VMs, so they must be powered off or moved first.
+		if err := h.powerOp(ctx, false); err != nil {
+			return nil, err
+		}
+
+		if isTrue(req.EvacuatePoweredOffVms) {
+			if err := h.evacuatePoweredOff(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		ctx.postEvent(&types.EnteringStandbyModeEvent{HostEvent: h.event(ctx)})
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.standbyMode", Val: string(types.HostStandbyModeEntering)},
+		})
+
+		h.transition(t)
+
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.powerState", Val: types.HostSystemPowerStateStandBy},
+			{Name: "runtime.standbyMode", Val: string(types.HostStandbyModeIn)},
+		})
+		ctx.postEvent(&types.EnteredStandbyModeEvent{HostEvent: h.event(ctx)})
+
+		return nil, nil
+	})
+
+	return &methods.PowerDownHostToStandBy_TaskBody{
+		Res: &types.PowerDownHostToStandBy_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// PowerUpHostFromStandByTask exits standby mode, the host is in the exiting state for
+// TaskDelay.MethodDelay["PowerUpHostFromStandBy"], which also delays the start of the task.
+func (h *HostSystem) PowerUpHostFromStandByTask(ctx *Context, req *types.PowerUpHostFromStandBy_Task) soap.HasFault {
+	task := CreateTask(h, "powerUpHostFromStandBy", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if h.Runtime.PowerState != types.HostSystemPowerStateStandBy {
+			return nil, &types.InvalidState{}
+		}
+
+		ctx.postEvent(&types.ExitingStandbyModeEvent{HostEvent: h.event(ctx)})
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.standbyMode", Val: string(types.HostStandbyModeExiting)},
+		})
+
+		h.transition(t)
+
+		ctx.Update(h, []types.PropertyChange{
+			{Name: "runtime.powerState", Val: types.HostSystemPowerStatePoweredOn},
+			{Name: "runtime.standbyMode", Val: string(types.HostStandbyModeNone)},
+			{Name: "runtime.bootTime", Val: time.Now()},
+		})
+		ctx.postEvent(&types.ExitedStandbyModeEvent{HostEvent: h.event(ctx)})
+
+		return nil, nil
+	})
+
+	return &methods.PowerUpHostFromStandBy_TaskBody{
+		Res: &types.PowerUpHostFromStandBy_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
 func (s *HostSystem) QueryTpmAttestationReport(ctx *Context, req *types.QueryTpmAttestationReport) soap.HasFault {
 	body := new(methods.QueryTpmAttestationReportBody)
 
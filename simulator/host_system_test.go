@@ -7,14 +7,20 @@ package simulator
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/event"
+	"github.com/vmware/govmomi/fault"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/simulator/esx"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -260,3 +266,129 @@ func TestDisconnect(t *testing.T) {
 			types.HostSystemConnectionStateConnected, hs.Runtime.ConnectionState)
 	}
 }
+
+func TestHostPowerLifecycle(t *testing.T) {
+	delay := TaskDelay
+	TaskDelay = DelayConfig{MethodDelay: map[string]int{"Reboot": 200, "PowerDownHostToStandBy": 200}}
+	defer func() { TaskDelay = delay }()
+
+	Test(func(ctx context.Context, c *vim25.Client) {
+		finder := find.NewFinder(c)
+		host, err := finder.HostSystem(ctx, "DC0_C0_H0")
+		require.NoError(t, err)
+		vms, err := finder.VirtualMachineList(ctx, "DC0_C0_RP0_VM*")
+		require.NoError(t, err)
+
+		self := host.Reference()
+
+		runtime := func() types.HostRuntimeInfo {
+			var props mo.HostSystem
+			require.NoError(t, host.Properties(ctx, self, []string{"runtime"}, &props))
+			return props.Runtime
+		}
+
+		wait := func(ref types.ManagedObjectReference) error {
+			return object.NewTask(c, ref).Wait(ctx)
+		}
+
+		reboot := func(force bool) types.ManagedObjectReference {
+			res, err := methods.RebootHost_Task(ctx, c, &types.RebootHost_Task{This: self, Force: force})
+			require.NoError(t, err)
+			return res.Returnval
+		}
+
+		// powered on VMs on this host without maintenance mode or force
+		var running []*object.VirtualMachine
+		for _, vm := range vms {
+			var props mo.VirtualMachine
+			require.NoError(t, vm.Properties(ctx, vm.Reference(), []string{"runtime"}, &props))
+			if *props.Runtime.Host == self {
+				running = append(running, vm)
+			}
+		}
+		require.NotEmpty(t, running)
+
+		err = wait(reboot(false))
+		if !fault.Is(err, &types.InvalidState{}) {
+			t.Fatalf("expected InvalidState, got %v", err)
+		}
+		assert.Equal(t, types.HostSystemConnectionStateConnected, runtime().ConnectionState)
+
+		// the host is down while the task is running
+		task := reboot(true)
+		assert.Eventually(t, func() bool {
+			return runtime().ConnectionState == types.HostSystemConnectionStateNotResponding
+		}, time.Second, 10*time.Millisecond)
+		for _, vm := range running {
+			state, err := vm.PowerState(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, state)
+		}
+
+		require.NoError(t, wait(task))
+		assert.Equal(t, types.HostSystemConnectionStateConnected, runtime().ConnectionState)
+		assert.Equal(t, types.HostSystemPowerStatePoweredOn, runtime().PowerState)
+
+		res, err := methods.PowerDownHostToStandBy_Task(ctx, c, &types.PowerDownHostToStandBy_Task{This: self})
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool {
+			return runtime().StandbyMode == string(types.HostStandbyModeEntering)
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, wait(res.Returnval))
+		assert.Equal(t, types.HostSystemPowerStateStandBy, runtime().PowerState)
+		assert.Equal(t, string(types.HostStandbyModeIn), runtime().StandbyMode)
+
+		// VMs cannot be powered on while the host is in standby
+		ptask, err := running[0].PowerOn(ctx)
+		require.NoError(t, err)
+		if err = ptask.Wait(ctx); !fault.Is(err, &types.InvalidState{}) {
+			t.Fatalf("expected InvalidState, got %v", err)
+		}
+
+		up, err := methods.PowerUpHostFromStandBy_Task(ctx, c, &types.PowerUpHostFromStandBy_Task{This: self})
+		require.NoError(t, err)
+		require.NoError(t, wait(up.Returnval))
+		assert.Equal(t, types.HostSystemPowerStatePoweredOn, runtime().PowerState)
+
+		// not in standby
+		up, err = methods.PowerUpHostFromStandBy_Task(ctx, c, &types.PowerUpHostFromStandBy_Task{This: self})
+		require.NoError(t, err)
+		if err = wait(up.Returnval); !fault.Is(err, &types.InvalidState{}) {
+			t.Fatalf("expected InvalidState, got %v", err)
+		}
+
+		// powered off VMs are moved to another host in the cluster
+		res, err = methods.PowerDownHostToStandBy_Task(ctx, c, &types.PowerDownHostToStandBy_Task{
+			This:                  self,
+			EvacuatePoweredOffVms: types.NewBool(true),
+		})
+		require.NoError(t, err)
+		require.NoError(t, wait(res.Returnval))
+
+		for _, vm := range running {
+			var props mo.VirtualMachine
+			require.NoError(t, vm.Properties(ctx, vm.Reference(), []string{"runtime"}, &props))
+			assert.NotEqual(t, self, *props.Runtime.Host)
+		}
+
+		up, err = methods.PowerUpHostFromStandBy_Task(ctx, c, &types.PowerUpHostFromStandBy_Task{This: self})
+		require.NoError(t, err)
+		require.NoError(t, wait(up.Returnval))
+
+		down, err := methods.ShutdownHost_Task(ctx, c, &types.ShutdownHost_Task{This: self})
+		require.NoError(t, err)
+		require.NoError(t, wait(down.Returnval))
+		assert.Equal(t, types.HostSystemPowerStatePoweredOff, runtime().PowerState)
+		assert.Equal(t, types.HostSystemConnectionStateNotResponding, runtime().ConnectionState)
+
+		events, err := event.NewManager(c).QueryEvents(ctx, types.EventFilterSpec{
+			Entity: &types.EventFilterSpecByEntity{
+				Entity:    self,
+				Recursion: types.EventFilterSpecRecursionOptionSelf,
+			},
+			EventTypeId: []string{"HostShutdownEvent", "EnteredStandbyModeEvent", "ExitedStandbyModeEvent"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, events, 6) // reboot, standby, exit standby, standby, exit standby, shutdown
+	})
+}
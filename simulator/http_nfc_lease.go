@@ -6,6 +6,7 @@ package simulator
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -14,24 +15,29 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 	"sync"
 
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vmdk"
 )
 
 type metadata struct {
-	sha1 []byte
-	size int64
+	sha1   []byte
+	sha256 []byte
+	size   int64
 }
 
 type HttpNfcLease struct {
 	mo.HttpNfcLease
 	files    map[string]string
 	metadata map[string]metadata
+	disks    map[string]int64 // export disk capacity, served as streamOptimized
 }
 
 var (
@@ -59,17 +65,23 @@ func ServeNFC(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status := http.StatusOK
-	var sum hash.Hash
+	var sum, sum256 hash.Hash
 	var dst io.Writer = w
 	var src io.ReadCloser
 
 	switch r.Method {
 	case http.MethodPut, http.MethodPost:
-		sum = sha1.New()
-		dst = sum
+		sum, sum256 = sha1.New(), sha256.New()
+		dst = io.MultiWriter(sum, sum256)
 		src = r.Body
 	case http.MethodGet:
-		f, err := os.Open(file)
+		var f io.ReadCloser
+		var err error
+		if capacity, ok := lease.disks[name]; ok {
+			f, err = streamOptimized(file, capacity)
+		} else {
+			f, err = os.Open(file)
+		}
 		if err != nil {
 			http.NotFound(w, r)
 			return
@@ -83,8 +95,9 @@ func ServeNFC(w http.ResponseWriter, r *http.Request) {
 	_ = src.Close()
 	if sum != nil {
 		lease.metadata[name] = metadata{
-			sha1: sum.Sum(nil),
-			size: n,
+			sha1:   sum.Sum(nil),
+			sha256: sum256.Sum(nil),
+			size:   n,
 		}
 	}
 
@@ -114,6 +127,10 @@ func (l *HttpNfcLease) ready(ctx *Context, entity types.ManagedObjectReference,
 		LeaseTimeout: 300,
 	}
 
+	for _, capacity := range l.disks {
+		info.TotalDiskCapacityInKB += capacity / 1024
+	}
+
 	ctx.WithLock(l, func() {
 		ctx.Update(l, []types.PropertyChange{
 			{Name: "state", Val: types.HttpNfcLeaseStateReady},
@@ -129,6 +146,7 @@ func newHttpNfcLease(ctx *Context) *HttpNfcLease {
 		},
 		files:    make(map[string]string),
 		metadata: make(map[string]metadata),
+		disks:    make(map[string]int64),
 	}
 
 	ctx.Session.Put(lease)
@@ -153,6 +171,89 @@ func leaseURL(ctx *Context) *url.URL {
 	return u
 }
 
+// export adds a device URL for each of the VM's disks, to be downloaded as a streamOptimized VMDK.
+func (l *HttpNfcLease) export(ctx *Context, vm *VirtualMachine) ([]types.HttpNfcLeaseDeviceUrl, types.BaseMethodFault) {
+	var urls []types.HttpNfcLeaseDeviceUrl
+	u := leaseURL(ctx)
+
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))
+
+	for n, d := range devices {
+		disk := d.(*types.VirtualDisk)
+		info, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo)
+		if !ok {
+			continue
+		}
+
+		var file object.DatastorePath
+		file.FromString(info.GetVirtualDeviceFileBackingInfo().FileName)
+		host := ctx.Map.Get(*vm.Runtime.Host).(*HostSystem)
+		ds, ok := ctx.Map.FindByName(file.Datastore, host.Datastore).(*Datastore)
+		if !ok {
+			return nil, &types.InvalidDatastore{Name: file.Datastore}
+		}
+
+		name := fmt.Sprintf("%s-disk-%d.vmdk", vm.Name, n)
+		l.files[name] = VirtualDiskBackingFileName(ds.resolve(ctx, file.Path))
+		l.disks[name] = disk.CapacityInBytes
+
+		u.Path = nfcPrefix + path.Join(l.Reference().Value, name)
+		urls = append(urls, types.HttpNfcLeaseDeviceUrl{
+			Key:       fmt.Sprintf("/%s/%s:%d", vm.Self.Value, devices.Type(d), n),
+			ImportKey: fmt.Sprintf("/%s/%s:%d", vm.Name, devices.Type(d), n),
+			Url:       u.String(),
+			Disk:      types.NewBool(true),
+			TargetId:  name,
+		})
+	}
+
+	return urls, nil
+}
+
+// streamOptimized converts the given flat disk file to a temporary streamOptimized VMDK,
+// which is removed when the returned ReadCloser is closed.
+func streamOptimized(name string, capacity int64) (io.ReadCloser, error) {
+	var src io.Reader = strings.NewReader("") // a disk that has never been written to
+	flat, err := os.Open(name)
+	if err == nil {
+		defer flat.Close()
+		src = flat
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp("", "vcsim-export-*.vmdk")
+	if err != nil {
+		return nil, err
+	}
+	_ = f.Close()
+
+	w, err := vmdk.NewStreamOptimizedWriter(f.Name(), capacity)
+	if err == nil {
+		if err = w.Write(src); err == nil {
+			err = w.Close()
+		}
+	}
+	if err == nil {
+		f, err = os.Open(f.Name())
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+
+	return &tempFile{f}, nil
+}
+
+type tempFile struct {
+	*os.File
+}
+
+func (f *tempFile) Close() error {
+	_ = f.File.Close()
+	return os.Remove(f.Name())
+}
+
 func (l *HttpNfcLease) HttpNfcLeaseComplete(ctx *Context, req *types.HttpNfcLeaseComplete) soap.HasFault {
 	ctx.Session.Remove(ctx, req.This)
 	nfcLease.Delete(req.This)
@@ -192,9 +293,11 @@ func (l *HttpNfcLease) HttpNfcLeaseGetManifest(ctx *Context, req *types.HttpNfcL
 	entries := []types.HttpNfcLeaseManifestEntry{}
 	for name, md := range l.metadata {
 		entries = append(entries, types.HttpNfcLeaseManifestEntry{
-			Key:  l.getDeviceKey(name),
-			Sha1: hex.EncodeToString(md.sha1),
-			Size: md.size,
+			Key:          l.getDeviceKey(name),
+			Sha1:         hex.EncodeToString(md.sha1),
+			ChecksumType: "sha256",
+			Checksum:     hex.EncodeToString(md.sha256),
+			Size:         md.size,
 		})
 	}
 	return &methods.HttpNfcLeaseGetManifestBody{
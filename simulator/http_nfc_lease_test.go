@@ -0,0 +1,128 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vmdk"
+)
+
+func TestExportVm(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		_, err = vm.Export(ctx)
+		assert.True(t, fault.Is(err, &types.InvalidPowerState{}))
+
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		// disk on a datastore the host cannot access
+		sim := Map(ctx).Get(vm.Reference()).(*VirtualMachine)
+		backing := object.VirtualDeviceList(sim.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))[0].
+			GetVirtualDevice().Backing.(types.BaseVirtualDeviceFileBackingInfo).GetVirtualDeviceFileBackingInfo()
+		fileName := backing.FileName
+		backing.FileName = "[enoent] DC0_H0_VM0/disk1.vmdk"
+		_, err = vm.Export(ctx)
+		assert.True(t, fault.Is(err, &types.InvalidDatastore{}))
+		backing.FileName = fileName
+
+		lease, err := vm.Export(ctx)
+		require.NoError(t, err)
+
+		info, err := lease.Wait(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, info.Items, 1)
+		assert.Equal(t, "DC0_H0_VM0-disk-0.vmdk", info.Items[0].Path)
+
+		u := lease.StartUpdater(ctx, info)
+		dir := t.TempDir()
+		var cdp types.OvfCreateDescriptorParams
+
+		for _, item := range info.Items {
+			name := filepath.Join(dir, item.Path)
+			require.NoError(t, lease.DownloadFile(ctx, name, item, soap.DefaultDownload))
+
+			disk, err := vmdk.Stat(name)
+			require.NoError(t, err)
+			assert.Equal(t, info.TotalDiskCapacityInKB*1024, disk.Capacity)
+
+			cdp.OvfFiles = append(cdp.OvfFiles, item.File())
+		}
+
+		u.Done()
+		require.NoError(t, lease.Complete(ctx))
+
+		// lease is removed on Complete
+		_, err = lease.Properties(ctx, "state")
+		assert.Error(t, err)
+
+		desc, err := ovf.NewManager(c).CreateDescriptor(ctx, vm, cdp)
+		require.NoError(t, err)
+
+		env, err := ovf.Unmarshal(strings.NewReader(desc.OvfDescriptor))
+		require.NoError(t, err)
+		assert.Equal(t, "DC0_H0_VM0", env.VirtualSystem.ID)
+		require.Len(t, env.References, 1)
+		assert.Equal(t, "DC0_H0_VM0-disk-0.vmdk", env.References[0].Href)
+		require.Len(t, env.Disk.Disks, 1)
+		assert.Equal(t, env.References[0].ID, *env.Disk.Disks[0].FileRef)
+	})
+}
+
+func TestExportVApp(t *testing.T) {
+	m := VPX()
+	m.App = 1
+	defer m.Remove()
+
+	Test(func(ctx context.Context, c *vim25.Client) {
+		obj := Map(ctx).Any("VirtualApp").(*VirtualApp)
+		require.NotEmpty(t, obj.Vm)
+		vapp := object.NewVirtualApp(c, obj.Self)
+
+		req := types.ExportVApp{This: vapp.Reference()}
+		_, err := methods.ExportVApp(ctx, c, &req)
+		assert.True(t, fault.Is(err, &types.InvalidPowerState{}))
+
+		for _, ref := range obj.Vm {
+			task, err := object.NewVirtualMachine(c, ref).PowerOff(ctx)
+			require.NoError(t, err)
+			require.NoError(t, task.Wait(ctx))
+		}
+
+		res, err := methods.ExportVApp(ctx, c, &req)
+		require.NoError(t, err)
+
+		lease := nfc.NewLease(c, res.Returnval)
+		info, err := lease.Wait(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, obj.Self, info.Entity)
+		assert.Len(t, info.Items, len(obj.Vm))
+
+		require.NoError(t, lease.Abort(ctx, nil))
+
+		desc, err := ovf.NewManager(c).CreateDescriptor(ctx, vapp, types.OvfCreateDescriptorParams{})
+		require.NoError(t, err)
+		assert.Contains(t, desc.OvfDescriptor, "<VirtualSystemCollection ovf:id=\""+obj.Name+"\">")
+	}, m)
+}
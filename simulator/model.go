@@ -269,6 +269,7 @@ var kinds = map[string]reflect.Type{
 	"VirtualApp":                         reflect.TypeOf((*VirtualApp)(nil)).Elem(),
 	"VirtualDiskManager":                 reflect.TypeOf((*VirtualDiskManager)(nil)).Elem(),
 	"VirtualMachine":                     reflect.TypeOf((*VirtualMachine)(nil)).Elem(),
+	"VirtualMachineSnapshot":             reflect.TypeOf((*VirtualMachineSnapshot)(nil)).Elem(),
 	"VirtualMachineCompatibilityChecker": reflect.TypeOf((*VmCompatibilityChecker)(nil)).Elem(),
 	"VirtualMachineProvisioningChecker":  reflect.TypeOf((*VmProvisioningChecker)(nil)).Elem(),
 	"VmwareDistributedVirtualSwitch":     reflect.TypeOf((*DistributedVirtualSwitch)(nil)).Elem(),
@@ -843,6 +844,11 @@ func (m *Model) createRootTempDir(opt *OptionManager) error {
 		return err
 	}
 
+	if home := opt.find("vcsim.home"); home != nil {
+		home.Value = m.dir // Model.Load of a saved vcsim instance
+		return nil
+	}
+
 	opt.Setting = append(opt.Setting, &types.OptionValue{
 		Key:   "vcsim.home",
 		Value: m.dir,
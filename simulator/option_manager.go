@@ -61,6 +61,9 @@ func (m *OptionManager) init(r *Registry) {
 }
 
 func (m *OptionManager) model(model *Model) error {
+	if model.dir != "" {
+		return nil // HostSystem OptionManager
+	}
 	return model.createRootTempDir(m)
 }
 
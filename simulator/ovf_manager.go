@@ -5,11 +5,16 @@
 package simulator
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
 	"log"
 	"math"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/ovf"
@@ -355,3 +360,315 @@ func (m *OvfManager) CreateImportSpec(ctx *Context, req *types.CreateImportSpec)
 
 	return body
 }
+
+// ovfDescriptor is the template used by CreateDescriptor, describing one or more VMs.
+var ovfDescriptor = template.Must(template.New("ovf").Funcs(template.FuncMap{"xml": xmlEscape}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"
+          xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+          xmlns:cim="http://schemas.dmtf.org/wbem/wscim/1/common"
+          xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData"
+          xmlns:vmw="http://www.vmware.com/schema/ovf"
+          xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData"
+          xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <References>
+{{- range .Files }}
+    <File ovf:href="{{ xml .Href }}" ovf:id="{{ .ID }}" ovf:size="{{ .Size }}"/>
+{{- end }}
+  </References>
+  <DiskSection>
+    <Info>Virtual disk information</Info>
+{{- range .Systems }}{{ range .Disks }}
+    <Disk ovf:capacity="{{ .Capacity }}" ovf:capacityAllocationUnits="byte" ovf:diskId="{{ .ID }}"{{ if .FileRef }} ovf:fileRef="{{ .FileRef }}"{{ end }} ovf:format="http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized"/>
+{{- end }}{{ end }}
+  </DiskSection>
+  <NetworkSection>
+    <Info>The list of logical networks</Info>
+{{- range .Networks }}
+    <Network ovf:name="{{ xml . }}">
+      <Description>The {{ xml . }} network</Description>
+    </Network>
+{{- end }}
+  </NetworkSection>
+{{- if .Collection }}
+  <VirtualSystemCollection ovf:id="{{ xml .Name }}">
+    <Info>A vApp</Info>
+    <Name>{{ xml .Name }}</Name>
+{{- end }}
+{{- range .Systems }}
+  <VirtualSystem ovf:id="{{ xml .Name }}">
+    <Info>A virtual machine</Info>
+    <Name>{{ xml .Name }}</Name>
+{{- if .Annotation }}
+    <AnnotationSection>
+      <Info>A human-readable annotation</Info>
+      <Annotation>{{ xml .Annotation }}</Annotation>
+    </AnnotationSection>
+{{- end }}
+    <OperatingSystemSection ovf:id="1" vmw:osType="{{ xml .GuestID }}">
+      <Info>The kind of installed guest operating system</Info>
+    </OperatingSystemSection>
+    <VirtualHardwareSection>
+      <Info>Virtual hardware requirements</Info>
+      <System>
+        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>
+        <vssd:InstanceID>0</vssd:InstanceID>
+        <vssd:VirtualSystemIdentifier>{{ xml .Name }}</vssd:VirtualSystemIdentifier>
+        <vssd:VirtualSystemType>{{ .Version }}</vssd:VirtualSystemType>
+      </System>
+      <Item>
+        <rasd:AllocationUnits>hertz * 10^6</rasd:AllocationUnits>
+        <rasd:Description>Number of Virtual CPUs</rasd:Description>
+        <rasd:ElementName>{{ .NumCPUs }} virtual CPU(s)</rasd:ElementName>
+        <rasd:InstanceID>1</rasd:InstanceID>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{ .NumCPUs }}</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:Description>Memory Size</rasd:Description>
+        <rasd:ElementName>{{ .MemoryMB }}MB of memory</rasd:ElementName>
+        <rasd:InstanceID>2</rasd:InstanceID>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>{{ .MemoryMB }}</rasd:VirtualQuantity>
+      </Item>
+{{- range .Items }}
+      <Item>
+{{- if .Address }}
+        <rasd:Address>{{ .Address }}</rasd:Address>
+{{- end }}
+{{- if .AddressOnParent }}
+        <rasd:AddressOnParent>{{ .AddressOnParent }}</rasd:AddressOnParent>
+{{- end }}
+{{- if .Connection }}
+        <rasd:Connection>{{ xml .Connection }}</rasd:Connection>
+{{- end }}
+        <rasd:ElementName>{{ xml .ElementName }}</rasd:ElementName>
+{{- if .HostResource }}
+        <rasd:HostResource>{{ .HostResource }}</rasd:HostResource>
+{{- end }}
+        <rasd:InstanceID>{{ .InstanceID }}</rasd:InstanceID>
+{{- if .Parent }}
+        <rasd:Parent>{{ .Parent }}</rasd:Parent>
+{{- end }}
+{{- if .ResourceSubType }}
+        <rasd:ResourceSubType>{{ .ResourceSubType }}</rasd:ResourceSubType>
+{{- end }}
+        <rasd:ResourceType>{{ .ResourceType }}</rasd:ResourceType>
+      </Item>
+{{- end }}
+    </VirtualHardwareSection>
+  </VirtualSystem>
+{{- end }}
+{{- if .Collection }}
+  </VirtualSystemCollection>
+{{- end }}
+</Envelope>
+`))
+
+func xmlEscape(s string) string {
+	var buf strings.Builder
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+type ovfDescriptorFile struct {
+	ID   string
+	Href string
+	Size int64
+}
+
+type ovfDescriptorDisk struct {
+	ID       string
+	FileRef  string
+	Capacity int64
+}
+
+type ovfDescriptorItem struct {
+	InstanceID      int32
+	ResourceType    int
+	ResourceSubType string
+	ElementName     string
+	Address         string
+	AddressOnParent string
+	Parent          int32
+	HostResource    string
+	Connection      string
+}
+
+type ovfDescriptorSystem struct {
+	Name       string
+	Annotation string
+	GuestID    string
+	Version    string
+	NumCPUs    int32
+	MemoryMB   int32
+	Disks      []ovfDescriptorDisk
+	Items      []ovfDescriptorItem
+}
+
+type ovfDescriptorEnvelope struct {
+	Name       string
+	Collection bool
+	Files      []ovfDescriptorFile
+	Networks   []string
+	Systems    []*ovfDescriptorSystem
+
+	ndisk int
+}
+
+// ovfNetworkName returns the name of the network backing the given ethernet card.
+func ovfNetworkName(ctx *Context, vm *VirtualMachine, card *types.VirtualEthernetCard) string {
+	switch b := card.Backing.(type) {
+	case *types.VirtualEthernetCardNetworkBackingInfo:
+		return b.DeviceName
+	case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+		for _, ref := range vm.Network {
+			if pg, ok := ctx.Map.Get(ref).(*DistributedVirtualPortgroup); ok && pg.Key == b.Port.PortgroupKey {
+				return pg.Name
+			}
+		}
+	}
+	return card.DeviceInfo.GetDescription().Summary
+}
+
+// ovfSystem adds the given VM to the envelope, referencing any of the cdp.OvfFiles that belong to its disks.
+func (e *ovfDescriptorEnvelope) ovfSystem(ctx *Context, vm *VirtualMachine, name string, cdp *types.OvfCreateDescriptorParams) {
+	system := &ovfDescriptorSystem{
+		Name:       name,
+		Annotation: vm.Config.Annotation,
+		GuestID:    vm.Config.GuestId,
+		Version:    vm.Config.Version,
+		NumCPUs:    vm.Config.Hardware.NumCPU,
+		MemoryMB:   vm.Config.Hardware.MemoryMB,
+	}
+
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+	instance := make(map[int32]int32) // device key -> InstanceID
+	id := int32(3)
+	ndisk := 0
+
+	item := func(d types.BaseVirtualDevice, kind int, subtype string) *ovfDescriptorItem {
+		dev := d.GetVirtualDevice()
+		instance[dev.Key] = id
+		system.Items = append(system.Items, ovfDescriptorItem{
+			InstanceID:      id,
+			ResourceType:    kind,
+			ResourceSubType: subtype,
+			ElementName:     dev.DeviceInfo.GetDescription().Label,
+		})
+		id++
+		i := &system.Items[len(system.Items)-1]
+		if dev.UnitNumber != nil {
+			i.AddressOnParent = strconv.Itoa(int(*dev.UnitNumber))
+		}
+		return i
+	}
+
+	// controllers first, so children can reference their InstanceID
+	for _, d := range devices {
+		switch c := d.(type) {
+		case *types.VirtualIDEController:
+			item(d, 5, "PIIX4").Address = strconv.Itoa(int(c.BusNumber))
+		case types.BaseVirtualSCSIController:
+			subtype := devices.Type(d)
+			switch subtype {
+			case "pvscsi":
+				subtype = "VirtualSCSI"
+			case "lsilogic-sas":
+				subtype = "lsilogicsas"
+			}
+			i := item(d, 6, subtype)
+			i.Address = strconv.Itoa(int(c.GetVirtualSCSIController().BusNumber))
+			i.AddressOnParent = ""
+		}
+	}
+
+	for _, d := range devices {
+		dev := d.GetVirtualDevice()
+		switch x := d.(type) {
+		case *types.VirtualDisk:
+			disk := ovfDescriptorDisk{
+				ID:       fmt.Sprintf("vmdisk%d", e.ndisk+1),
+				Capacity: x.CapacityInBytes,
+			}
+			suffix := fmt.Sprintf(":%d", ndisk)
+			for _, f := range cdp.OvfFiles {
+				if strings.HasPrefix(f.DeviceId, "/"+vm.Self.Value+"/") && strings.HasSuffix(f.DeviceId, suffix) {
+					file := ovfDescriptorFile{
+						ID:   fmt.Sprintf("file%d", len(e.Files)+1),
+						Href: f.Path,
+						Size: f.Size,
+					}
+					e.Files = append(e.Files, file)
+					disk.FileRef = file.ID
+					break
+				}
+			}
+			system.Disks = append(system.Disks, disk)
+			i := item(d, 17, "")
+			i.Parent = instance[dev.ControllerKey]
+			i.HostResource = "ovf:/disk/" + disk.ID
+			e.ndisk++
+			ndisk++
+		case *types.VirtualCdrom:
+			if _, ok := devices.FindByKey(dev.ControllerKey).(*types.VirtualIDEController); ok {
+				item(d, 15, "").Parent = instance[dev.ControllerKey]
+			}
+		case types.BaseVirtualEthernetCard:
+			card := x.GetVirtualEthernetCard()
+			net := ovfNetworkName(ctx, vm, card)
+			if !slices.Contains(e.Networks, net) {
+				e.Networks = append(e.Networks, net)
+			}
+			kind := reflect.TypeOf(d).Elem().Name()
+			i := item(d, 10, strings.TrimPrefix(kind, "Virtual"))
+			i.AddressOnParent = ""
+			i.Connection = net
+		}
+	}
+
+	e.Systems = append(e.Systems, system)
+}
+
+func (m *OvfManager) CreateDescriptor(ctx *Context, req *types.CreateDescriptor) soap.HasFault {
+	body := new(methods.CreateDescriptorBody)
+
+	env := &ovfDescriptorEnvelope{Name: req.Cdp.Name}
+
+	switch obj := ctx.Map.Get(req.Obj).(type) {
+	case *VirtualMachine:
+		if env.Name == "" {
+			env.Name = obj.Name
+		}
+		env.ovfSystem(ctx, obj, env.Name, &req.Cdp)
+		if req.Cdp.Description != "" {
+			env.Systems[0].Annotation = req.Cdp.Description
+		}
+	case *VirtualApp:
+		if env.Name == "" {
+			env.Name = obj.Name
+		}
+		env.Collection = true
+		for _, ref := range obj.Vm {
+			vm := ctx.Map.Get(ref).(*VirtualMachine)
+			env.ovfSystem(ctx, vm, vm.Name, &req.Cdp)
+		}
+	default:
+		body.Fault_ = Fault("", &types.ManagedObjectNotFound{Obj: req.Obj})
+		return body
+	}
+
+	var buf bytes.Buffer
+	if err := ovfDescriptor.Execute(&buf, env); err != nil {
+		body.Fault_ = Fault(err.Error(), &types.RuntimeFault{})
+		return body
+	}
+
+	body.Res = &types.CreateDescriptorResponse{
+		Returnval: types.OvfCreateDescriptorResult{
+			OvfDescriptor: buf.String(),
+		},
+	}
+
+	return body
+}
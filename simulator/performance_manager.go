@@ -5,7 +5,6 @@
 package simulator
 
 import (
-	"math/rand"
 	"strconv"
 	"strings"
 	"time"
@@ -99,26 +98,42 @@ func (p *PerformanceManager) QueryPerfProviderSummary(ctx *Context, req *types.Q
 	return body
 }
 
-func (p *PerformanceManager) buildAvailablePerfMetricsQueryResponse(ids []types.PerfMetricId, numCPU int, datastoreURL string) *types.QueryAvailablePerfMetricResponse {
+func (p *PerformanceManager) buildAvailablePerfMetricsQueryResponse(ids []types.PerfMetricId, instances perfInstances) *types.QueryAvailablePerfMetricResponse {
 	r := new(types.QueryAvailablePerfMetricResponse)
 	r.Returnval = make([]types.PerfMetricId, 0, len(ids))
-	for _, id := range ids {
-		switch id.Instance {
-		case "$cpu":
-			for i := range numCPU {
-				r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: strconv.Itoa(i)})
+
+	seen := make(map[types.PerfMetricId]bool, len(ids))
+	add := func(counter int32, instance ...string) {
+		for _, i := range instance {
+			id := types.PerfMetricId{CounterId: counter, Instance: i}
+			if !seen[id] {
+				seen[id] = true
+				r.Returnval = append(r.Returnval, id)
 			}
-		case "$physDisk":
-			if datastoreURL != "" {
-				r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: datastoreURL})
+		}
+	}
+
+	for _, id := range ids {
+		group := ""
+		if c, ok := p.perfCounterIndex[id.CounterId]; ok {
+			group = c.GroupInfo.GetElementDescription().Key
+		}
+
+		switch {
+		case id.Instance == "$cpu":
+			for i := range instances.cpu {
+				add(id.CounterId, strconv.Itoa(i))
 			}
-		case "$file":
-			r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: "DISKFILE"})
-			r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: "DELTAFILE"})
-			r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: "SWAPFILE"})
-			r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: "OTHERFILE"})
+		case id.Instance == "$physDisk":
+			add(id.CounterId, instances.datastore...)
+		case id.Instance == "$file":
+			add(id.CounterId, "DISKFILE", "DELTAFILE", "SWAPFILE", "OTHERFILE")
+		case group == "net" && id.Instance != "" && instances.nic != nil:
+			add(id.CounterId, instances.nic...)
+		case group == "virtualDisk" && instances.disk != nil:
+			add(id.CounterId, instances.disk...)
 		default:
-			r.Returnval = append(r.Returnval, types.PerfMetricId{CounterId: id.CounterId, Instance: id.Instance})
+			add(id.CounterId, id.Instance)
 		}
 	}
 	// Add a CounterId without a corresponding PerfCounterInfo entry. See issue #2835
@@ -130,27 +145,23 @@ func (p *PerformanceManager) queryAvailablePerfMetric(ctx *Context, entity types
 	switch entity.Type {
 	case "VirtualMachine":
 		vm := ctx.Map.Get(entity).(*VirtualMachine)
-		ds := ""
-		if len(vm.Datastore) != 0 {
-			ds = vm.Datastore[0].Value
-		}
-		return p.buildAvailablePerfMetricsQueryResponse(p.vmMetrics, int(vm.Summary.Config.NumCpu), ds)
+		return p.buildAvailablePerfMetricsQueryResponse(p.vmMetrics, vmPerfInstances(vm))
 	case "HostSystem":
 		host := ctx.Map.Get(entity).(*HostSystem)
-		return p.buildAvailablePerfMetricsQueryResponse(p.hostMetrics, int(host.Hardware.CpuInfo.NumCpuThreads), host.Datastore[0].Value)
+		return p.buildAvailablePerfMetricsQueryResponse(p.hostMetrics, hostPerfInstances(host))
 	case "ResourcePool":
-		return p.buildAvailablePerfMetricsQueryResponse(p.rpMetrics, 0, "")
+		return p.buildAvailablePerfMetricsQueryResponse(p.rpMetrics, perfInstances{})
 	case "ClusterComputeResource":
 		if interval != 20 {
-			return p.buildAvailablePerfMetricsQueryResponse(p.clusterMetrics, 0, "")
+			return p.buildAvailablePerfMetricsQueryResponse(p.clusterMetrics, perfInstances{})
 		}
 	case "Datastore":
 		if interval != 20 {
-			return p.buildAvailablePerfMetricsQueryResponse(p.datastoreMetrics, 0, "")
+			return p.buildAvailablePerfMetricsQueryResponse(p.datastoreMetrics, perfInstances{})
 		}
 	case "Datacenter":
 		if interval != 20 {
-			return p.buildAvailablePerfMetricsQueryResponse(p.datacenterMetrics, 0, "")
+			return p.buildAvailablePerfMetricsQueryResponse(p.datacenterMetrics, perfInstances{})
 		}
 	}
 
@@ -165,79 +176,122 @@ func (p *PerformanceManager) QueryAvailablePerfMetric(ctx *Context, req *types.Q
 	return body
 }
 
-func (p *PerformanceManager) QueryPerf(ctx *Context, req *types.QueryPerf) soap.HasFault {
-	body := new(methods.QueryPerfBody)
-	body.Res = new(types.QueryPerfResponse)
-	body.Res.Returnval = make([]types.BasePerfEntityMetricBase, len(req.QuerySpec))
-
-	for i, qs := range req.QuerySpec {
-		// Get metric data for this entity type
-		metricData, ok := p.metricData[qs.Entity.Type]
-		if !ok {
-			body.Fault_ = Fault("", &types.InvalidArgument{
-				InvalidProperty: "Entity",
-			})
+// retention returns how long samples of the given interval are kept
+func (p *PerformanceManager) retention(interval int32) time.Duration {
+	if interval == 20 {
+		return time.Hour
+	}
+	for _, i := range p.HistoricalInterval {
+		if i.SamplingPeriod == interval {
+			return time.Duration(i.Length) * time.Second
 		}
-		var start, end time.Time
-		if qs.StartTime == nil {
-			start = time.Now().Add(time.Duration(-365*24) * time.Hour) // Assume we have data for a year
-		} else {
-			start = *qs.StartTime
+	}
+	return 24 * time.Hour
+}
+
+// metricIds expands the "*" instance of the requested ids to all of the entity's instances of the counter.
+// When available is true, ids for counters the entity does not provide are dropped.
+func (e *perfEntity) metricIds(ids []types.PerfMetricId, available bool) []types.PerfMetricId {
+	var res []types.PerfMetricId
+
+	for _, id := range ids {
+		found := false
+		for _, a := range e.available {
+			if a.CounterId != id.CounterId {
+				continue
+			}
+			found = true
+			if id.Instance == "*" {
+				res = append(res, a)
+			}
 		}
-		if qs.EndTime == nil {
-			end = time.Now()
-		} else {
-			end = *qs.EndTime
+		if id.Instance != "*" && (found || !available) {
+			res = append(res, id)
 		}
+	}
 
-		// Generate metric series. Divide into n buckets of interval seconds
-		interval := qs.IntervalId
-		if interval == -1 || interval == 0 {
-			interval = 20 // TODO: Determine from entity type
+	return res
+}
+
+func (p *PerformanceManager) queryPerf(ctx *Context, qs types.PerfQuerySpec, available bool) (*types.PerfEntityMetric, types.BaseMethodFault) {
+	interval := qs.IntervalId
+	if interval == -1 || interval == 0 {
+		switch qs.Entity.Type {
+		case "VirtualMachine", "HostSystem", "ResourcePool":
+			interval = 20
+		default:
+			interval = 300
 		}
-		n := 1 + int32(end.Sub(start).Seconds())/interval
-		if qs.MaxSample > 0 && n > qs.MaxSample {
-			n = qs.MaxSample
+	}
+
+	e := p.perfEntity(ctx, qs.Entity, interval)
+	if e == nil {
+		return nil, &types.InvalidArgument{InvalidProperty: "Entity"}
+	}
+
+	// Samples are aligned to the interval, the sample with timestamp T covers the interval ending at T.
+	step := time.Duration(interval) * time.Second
+
+	end := time.Now()
+	if qs.EndTime != nil {
+		end = *qs.EndTime
+	}
+	last := end.Truncate(step)
+
+	first := last.Add(-p.retention(interval)).Add(step)
+	if qs.StartTime != nil {
+		first = qs.StartTime.Truncate(step).Add(step)
+	}
+
+	n := max(int32(last.Sub(first)/step)+1, 1)
+	if qs.MaxSample > 0 && n > qs.MaxSample {
+		n = qs.MaxSample
+	}
+	first = last.Add(-step * time.Duration(n-1))
+
+	metrics := &types.PerfEntityMetric{
+		PerfEntityMetricBase: types.PerfEntityMetricBase{Entity: qs.Entity},
+		SampleInfo:           make([]types.PerfSampleInfo, n),
+	}
+
+	for tick := range n {
+		metrics.SampleInfo[tick] = types.PerfSampleInfo{
+			Timestamp: first.Add(step * time.Duration(tick)),
+			Interval:  interval,
 		}
+	}
 
-		metrics := new(types.PerfEntityMetric)
-		metrics.Entity = qs.Entity
+	points := p.metricData[qs.Entity.Type]
 
-		// Loop through each interval "tick"
-		metrics.SampleInfo = make([]types.PerfSampleInfo, n)
-		metrics.Value = make([]types.BasePerfMetricSeries, len(qs.MetricId))
-		for tick := int32(0); tick < n; tick++ {
-			metrics.SampleInfo[tick] = types.PerfSampleInfo{Timestamp: end.Add(time.Duration(-interval*tick) * time.Second), Interval: interval}
+	for _, id := range e.metricIds(qs.MetricId, available) {
+		series := &types.PerfMetricIntSeries{
+			PerfMetricSeries: types.PerfMetricSeries{Id: id},
+			Value:            make([]int64, n),
 		}
 
-		series := make([]*types.PerfMetricIntSeries, len(qs.MetricId))
-		for j, mid := range qs.MetricId {
-			// Create list of metrics for this tick
-			series[j] = &types.PerfMetricIntSeries{Value: make([]int64, n)}
-			series[j].Id = mid
-			points := metricData[mid.CounterId]
-			offset := int64(start.Unix()) / int64(interval)
-
-			for tick := int32(0); tick < n; tick++ {
-				var p int64
-
-				// Use sample data if we have it. Otherwise, just send 0.
-				if len(points) > 0 {
-					p = points[(offset+int64(tick))%int64(len(points))]
-					scale := p / 5
-					if scale > 0 {
-						// Add some gaussian noise to make the data look more "real"
-						p += int64(rand.NormFloat64() * float64(scale))
-						if p < 0 {
-							p = 0
-						}
-					}
-				} else {
-					p = 0
-				}
-				series[j].Value[tick] = p
+		if c, ok := p.perfCounterIndex[id.CounterId]; ok {
+			for tick, info := range metrics.SampleInfo {
+				series.Value[tick] = e.sample(&c, id.Instance, info.Timestamp, interval, points[id.CounterId])
 			}
-			metrics.Value[j] = series[j]
+		}
+
+		metrics.Value = append(metrics.Value, series)
+	}
+
+	return metrics, nil
+}
+
+func (p *PerformanceManager) QueryPerf(ctx *Context, req *types.QueryPerf) soap.HasFault {
+	body := new(methods.QueryPerfBody)
+	body.Res = new(types.QueryPerfResponse)
+	body.Res.Returnval = make([]types.BasePerfEntityMetricBase, len(req.QuerySpec))
+
+	for i, qs := range req.QuerySpec {
+		metrics, fault := p.queryPerf(ctx, qs, false)
+		if fault != nil {
+			body.Fault_ = Fault("", fault)
+			body.Res = nil
+			return body
 		}
 
 		if qs.Format == string(types.PerfFormatCsv) {
@@ -246,13 +300,14 @@ func (p *PerformanceManager) QueryPerf(ctx *Context, req *types.QueryPerf) soap.
 
 			//PerfSampleInfo encoded in the following CSV format: [interval1], [date1], [interval2], [date2], and so on.
 			metricsCsv.SampleInfoCSV = sampleInfoCSV(metrics)
-			metricsCsv.Value = make([]types.PerfMetricSeriesCSV, len(qs.MetricId))
+			metricsCsv.Value = make([]types.PerfMetricSeriesCSV, len(metrics.Value))
 
-			for j, mid := range qs.MetricId {
-				seriesCsv := &types.PerfMetricSeriesCSV{Value: ""}
-				seriesCsv.Id = mid
-				seriesCsv.Value = valueCSV(series[j])
-				metricsCsv.Value[j] = *seriesCsv
+			for j, val := range metrics.Value {
+				series := val.(*types.PerfMetricIntSeries)
+				metricsCsv.Value[j] = types.PerfMetricSeriesCSV{
+					PerfMetricSeries: types.PerfMetricSeries{Id: series.Id},
+					Value:            valueCSV(series),
+				}
 			}
 
 			body.Res.Returnval[i] = metricsCsv
@@ -263,6 +318,45 @@ func (p *PerformanceManager) QueryPerf(ctx *Context, req *types.QueryPerf) soap.
 	return body
 }
 
+// QueryPerfComposite returns the metrics of a host along with the same metrics of its virtual machines.
+func (p *PerformanceManager) QueryPerfComposite(ctx *Context, req *types.QueryPerfComposite) soap.HasFault {
+	body := new(methods.QueryPerfCompositeBody)
+
+	host, ok := ctx.Map.Get(req.QuerySpec.Entity).(*HostSystem)
+	if !ok {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "querySpec.entity"})
+		return body
+	}
+
+	if req.QuerySpec.Format == string(types.PerfFormatCsv) {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "querySpec.format"})
+		return body
+	}
+
+	metrics, fault := p.queryPerf(ctx, req.QuerySpec, false)
+	if fault != nil {
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	res := types.PerfCompositeMetric{Entity: metrics}
+
+	for _, ref := range host.Vm {
+		spec := req.QuerySpec
+		spec.Entity = ref
+
+		child, fault := p.queryPerf(ctx, spec, true)
+		if fault != nil {
+			continue
+		}
+		res.ChildEntity = append(res.ChildEntity, child)
+	}
+
+	body.Res = &types.QueryPerfCompositeResponse{Returnval: res}
+
+	return body
+}
+
 // sampleInfoCSV converts the SampleInfo field to a CSV string
 func sampleInfoCSV(m *types.PerfEntityMetric) string {
 	values := make([]string, len(m.SampleInfo)*2)
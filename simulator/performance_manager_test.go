@@ -8,12 +8,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/performance"
 	"github.com/vmware/govmomi/simulator/esx"
 	"github.com/vmware/govmomi/simulator/vpx"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/mo"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -403,3 +413,166 @@ func TestQueryPerf(t *testing.T) {
 		}
 	}
 }
+
+func TestQueryPerfInventory(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		p := performance.NewManager(c)
+
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		svm := Map(ctx).Get(vm.Reference()).(*VirtualMachine)
+
+		sample := func(spec types.PerfQuerySpec, names ...string) map[string][]int64 {
+			res, err := p.SampleByName(ctx, spec, names, []types.ManagedObjectReference{vm.Reference()})
+			require.NoError(t, err)
+			series, err := p.ToMetricSeries(ctx, res)
+			require.NoError(t, err)
+			require.Len(t, series, 1)
+
+			values := make(map[string][]int64)
+			for _, s := range series[0].Value {
+				values[s.Name+"/"+s.Instance] = s.Value
+			}
+			return values
+		}
+
+		spec := types.PerfQuerySpec{IntervalId: 20, MaxSample: 6}
+
+		values := sample(spec, "mem.granted.average", "cpu.usagemhz.average", "cpu.entitlement.latest",
+			"virtualDisk.read.average", "net.usage.average")
+
+		assert.Equal(t, int64(svm.Summary.Config.MemorySizeMB)*1024, values["mem.granted.average/"][0])
+		entitlement := values["cpu.entitlement.latest/"][0]
+		for _, v := range values["cpu.usagemhz.average/"] {
+			assert.True(t, v > 0 && v < entitlement, v)
+		}
+
+		devices := object.VirtualDeviceList(svm.Config.Hardware.Device)
+		disk := devices.SelectByType((*types.VirtualDisk)(nil))[0].(*types.VirtualDisk)
+		assert.Len(t, values["virtualDisk.read.average/"+perfDiskInstance(devices, disk)], 6)
+		assert.NotContains(t, values, "virtualDisk.read.average/")
+
+		nic := devices.SelectByType((*types.VirtualEthernetCard)(nil))[0]
+		assert.Len(t, values["net.usage.average/"+strconv.Itoa(int(nic.GetVirtualDevice().Key))], 6)
+		assert.Len(t, values["net.usage.average/"], 6)
+
+		// samples are deterministic and aligned to the interval
+		assert.Equal(t, values, sample(spec, "mem.granted.average", "cpu.usagemhz.average", "cpu.entitlement.latest",
+			"virtualDisk.read.average", "net.usage.average"))
+
+		res, err := p.Query(ctx, []types.PerfQuerySpec{{
+			Entity:     vm.Reference(),
+			IntervalId: 300,
+			MaxSample:  3,
+			MetricId:   []types.PerfMetricId{{CounterId: 6}},
+		}})
+		require.NoError(t, err)
+		info := res[0].(*types.PerfEntityMetric).SampleInfo
+		require.Len(t, info, 3)
+		for i := range info {
+			assert.Equal(t, int32(300), info[i].Interval)
+			assert.Zero(t, info[i].Timestamp.Unix()%300)
+			if i > 0 {
+				assert.Equal(t, 300*time.Second, info[i].Timestamp.Sub(info[i-1].Timestamp))
+			}
+		}
+
+		// values follow the configuration
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		values = sample(spec, "mem.granted.average", "cpu.usagemhz.average")
+		for _, v := range values {
+			for i := range v {
+				assert.Zero(t, v[i])
+			}
+		}
+
+		task, err = vm.Reconfigure(ctx, types.VirtualMachineConfigSpec{NumCPUs: 2, MemoryMB: 2048})
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		task, err = vm.PowerOn(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		values = sample(spec, "mem.granted.average", "cpu.entitlement.latest", "cpu.usagemhz.average")
+		assert.Equal(t, int64(2048*1024), values["mem.granted.average/"][0])
+		assert.Equal(t, 2*entitlement, values["cpu.entitlement.latest/"][0])
+		assert.Len(t, values["cpu.usagemhz.average/1"], 6)
+	})
+}
+
+func TestQueryPerfRollup(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		p := performance.NewManager(c)
+		host := Map(ctx).Any("HostSystem")
+
+		counters, err := p.CounterInfoByName(ctx)
+		require.NoError(t, err)
+
+		var ids []types.PerfMetricId
+		for _, name := range []string{"cpu.usagemhz.minimum", "cpu.usagemhz.average", "cpu.usagemhz.maximum"} {
+			ids = append(ids, types.PerfMetricId{CounterId: counters[name].Key})
+		}
+
+		for _, interval := range []int32{300, 7200} {
+			res, err := p.Query(ctx, []types.PerfQuerySpec{{
+				Entity:     host.Reference(),
+				IntervalId: interval,
+				MaxSample:  12,
+				MetricId:   ids,
+			}})
+			require.NoError(t, err)
+
+			series := res[0].(*types.PerfEntityMetric).Value
+			require.Len(t, series, 3)
+			lo := series[0].(*types.PerfMetricIntSeries).Value
+			avg := series[1].(*types.PerfMetricIntSeries).Value
+			hi := series[2].(*types.PerfMetricIntSeries).Value
+			require.Len(t, avg, 12)
+
+			for i := range avg {
+				assert.Less(t, lo[i], avg[i])
+				assert.Less(t, avg[i], hi[i])
+			}
+		}
+	})
+}
+
+func TestQueryPerfComposite(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		host := Map(ctx).Any("HostSystem").(*HostSystem)
+		perf := *c.ServiceContent.PerfManager
+
+		spec := types.PerfQuerySpec{
+			Entity:     host.Reference(),
+			IntervalId: 20,
+			MaxSample:  3,
+			MetricId: []types.PerfMetricId{
+				{CounterId: 6, Instance: "*"}, // cpu.usagemhz.average
+				{CounterId: 24},               // mem.usage.average
+			},
+		}
+
+		res, err := methods.QueryPerfComposite(ctx, c, &types.QueryPerfComposite{This: perf, QuerySpec: spec})
+		require.NoError(t, err)
+
+		entity := res.Returnval.Entity.(*types.PerfEntityMetric)
+		assert.Equal(t, host.Reference(), entity.Entity)
+		assert.Len(t, entity.SampleInfo, 3)
+		require.Len(t, res.Returnval.ChildEntity, len(host.Vm))
+
+		for _, child := range res.Returnval.ChildEntity {
+			m := child.(*types.PerfEntityMetric)
+			assert.Equal(t, "VirtualMachine", m.Entity.Type)
+			assert.Equal(t, entity.SampleInfo, m.SampleInfo)
+			assert.NotEmpty(t, m.Value)
+		}
+
+		spec.Entity = Map(ctx).Any("VirtualMachine").Reference()
+		_, err = methods.QueryPerfComposite(ctx, c, &types.QueryPerfComposite{This: perf, QuerySpec: spec})
+		assert.True(t, fault.Is(err, &types.InvalidArgument{}))
+	})
+}
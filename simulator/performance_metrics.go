@@ -0,0 +1,535 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// perfInstances are the instances of an entity's per-device counters.
+// A nil nic or disk list leaves the instances of the canned metric ids as-is.
+type perfInstances struct {
+	cpu       int
+	datastore []string
+	nic       []string
+	disk      []string
+}
+
+// perfEntity is a snapshot of the inventory state used to generate the performance metrics of an entity.
+// Aggregate entities (hosts, pools, clusters, datastores and datacenters) sum the load of their members.
+type perfEntity struct {
+	ref  types.ManagedObjectReference
+	on   bool
+	vm   bool
+	boot *time.Time
+
+	cpus       float64 // virtual CPUs or host CPU threads
+	cpuMHz     float64 // CPU capacity
+	cpuUsed    float64 // CPU usage in MHz
+	memKB      float64 // configured or physical memory
+	activeKB   float64
+	consumedKB float64
+	overheadKB float64
+	netKBps    float64
+	diskKBps   float64
+	watts      float64
+
+	space *types.DatastoreSummary
+
+	nics  int
+	disks int
+
+	available []types.PerfMetricId
+	instances map[int32]int // number of non-empty instances per counter
+}
+
+// perfSteady counters describe capacity or configuration and do not vary between samples.
+var perfSteady = map[string]bool{
+	"cpu.entitlement":      true,
+	"cpu.totalmhz":         true,
+	"cpu.effectivemhz":     true,
+	"mem.entitlement":      true,
+	"mem.granted":          true,
+	"mem.totalmb":          true,
+	"mem.effectivemem":     true,
+	"disk.capacity":        true,
+	"disk.provisioned":     true,
+	"disk.used":            true,
+	"sys.heartbeat":        true,
+	"sys.uptime":           true,
+	"sys.osUptime":         true,
+	"power.powerCap":       true,
+	"cpu.reservedCapacity": true,
+}
+
+// perfRandom returns a value in the range [0, 1) derived from the given key,
+// such that repeated queries for the same sample return the same value.
+func perfRandom(key ...any) float64 {
+	h := fnv.New64a()
+	for _, k := range key {
+		_, _ = fmt.Fprint(h, k, "|")
+	}
+	return float64(h.Sum64()>>11) / (1 << 53)
+}
+
+func perfRatio(n, d float64) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return math.Min(n/d, 1)
+}
+
+func perfCounterName(c *types.PerfCounterInfo) string {
+	return c.GroupInfo.GetElementDescription().Key + "." + c.NameInfo.GetElementDescription().Key
+}
+
+// perfDiskInstance returns the virtualDisk counter instance of a disk, such as "scsi0:0"
+func perfDiskInstance(devices object.VirtualDeviceList, disk *types.VirtualDisk) string {
+	kind := "scsi"
+	bus := int32(0)
+
+	c := devices.FindByKey(disk.ControllerKey)
+	switch c.(type) {
+	case *types.VirtualIDEController:
+		kind = "ide"
+	case types.BaseVirtualSATAController:
+		kind = "sata"
+	case *types.VirtualNVMEController:
+		kind = "nvme"
+	}
+	if vc, ok := c.(types.BaseVirtualController); ok {
+		bus = vc.GetVirtualController().BusNumber
+	}
+
+	unit := int32(0)
+	if disk.UnitNumber != nil {
+		unit = *disk.UnitNumber
+	}
+
+	return fmt.Sprintf("%s%d:%d", kind, bus, unit)
+}
+
+func vmPerfInstances(vm *VirtualMachine) perfInstances {
+	in := perfInstances{
+		cpu:  int(vm.Summary.Config.NumCpu),
+		nic:  []string{},
+		disk: []string{},
+	}
+
+	for _, ds := range vm.Datastore {
+		in.datastore = append(in.datastore, ds.Value)
+	}
+
+	if vm.Config == nil {
+		return in
+	}
+
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+	for _, d := range devices {
+		switch x := d.(type) {
+		case types.BaseVirtualEthernetCard:
+			in.nic = append(in.nic, strconv.Itoa(int(d.GetVirtualDevice().Key)))
+		case *types.VirtualDisk:
+			in.disk = append(in.disk, perfDiskInstance(devices, x))
+		}
+	}
+
+	return in
+}
+
+func hostPerfInstances(host *HostSystem) perfInstances {
+	in := perfInstances{cpu: int(host.Hardware.CpuInfo.NumCpuThreads)}
+
+	for _, ds := range host.Datastore {
+		in.datastore = append(in.datastore, ds.Value)
+	}
+
+	if host.Config != nil && host.Config.Network != nil {
+		in.nic = []string{}
+		for _, pnic := range host.Config.Network.Pnic {
+			in.nic = append(in.nic, pnic.Device)
+		}
+	}
+
+	return in
+}
+
+// addLoad adds the resource usage of o to e
+func (e *perfEntity) addLoad(o *perfEntity) {
+	e.cpuUsed += o.cpuUsed
+	e.activeKB += o.activeKB
+	e.consumedKB += o.consumedKB
+	e.overheadKB += o.overheadKB
+	e.netKBps += o.netKBps
+	e.diskKBps += o.diskKBps
+	e.watts += o.watts
+}
+
+// add adds the capacity and resource usage of member o to aggregate entity e
+func (e *perfEntity) add(o *perfEntity) {
+	e.on = e.on || o.on
+	e.cpus += o.cpus
+	e.cpuMHz += o.cpuMHz
+	e.memKB += o.memKB
+	e.nics += o.nics
+	e.disks += o.disks
+	e.addLoad(o)
+}
+
+func vmPerfEntity(ctx *Context, vm *VirtualMachine) *perfEntity {
+	in := vmPerfInstances(vm)
+
+	e := &perfEntity{
+		ref:   vm.Self,
+		vm:    true,
+		on:    vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn,
+		boot:  vm.Summary.Runtime.BootTime,
+		cpus:  float64(vm.Summary.Config.NumCpu),
+		memKB: float64(vm.Summary.Config.MemorySizeMB) * 1024,
+		nics:  len(in.nic),
+		disks: len(in.disk),
+	}
+
+	mhz := 2000.0
+	if ref := vm.Runtime.Host; ref != nil {
+		if h, ok := ctx.Map.Get(*ref).(*HostSystem); ok && h.Summary.Hardware.CpuMhz > 0 {
+			mhz = float64(h.Summary.Hardware.CpuMhz)
+		}
+	}
+	e.cpuMHz = e.cpus * mhz
+
+	if !e.on {
+		return e
+	}
+
+	// Usage follows quickStats when set (via VirtualMachine.SetQuickStats for example),
+	// otherwise each VM gets its own stable utilization.
+	stats := vm.Summary.QuickStats
+
+	e.cpuUsed = float64(stats.OverallCpuUsage)
+	if e.cpuUsed == 0 {
+		e.cpuUsed = e.cpuMHz * (0.05 + 0.3*perfRandom(vm.Self.Value, "cpu"))
+	}
+
+	e.activeKB = float64(stats.GuestMemoryUsage) * 1024
+	if e.activeKB == 0 {
+		e.activeKB = e.memKB * (0.1 + 0.4*perfRandom(vm.Self.Value, "mem"))
+	}
+
+	e.consumedKB = float64(stats.HostMemoryUsage) * 1024
+	if e.consumedKB == 0 {
+		e.consumedKB = math.Min(e.memKB, e.activeKB+0.3*e.memKB)
+	}
+
+	util := perfRatio(e.cpuUsed, e.cpuMHz)
+
+	e.overheadKB = 30*1024 + 0.01*e.memKB
+	e.netKBps = float64(e.nics) * (10 + 500*util)
+	e.diskKBps = float64(e.disks) * (20 + 300*util)
+	e.watts = 5 + 20*e.cpus*util
+
+	return e
+}
+
+func hostPerfEntity(ctx *Context, host *HostSystem) *perfEntity {
+	hw := host.Summary.Hardware
+
+	e := &perfEntity{
+		ref:    host.Self,
+		on:     host.Runtime.ConnectionState == types.HostSystemConnectionStateConnected && host.Runtime.PowerState == types.HostSystemPowerStatePoweredOn,
+		boot:   host.Summary.Runtime.BootTime,
+		cpus:   float64(hw.NumCpuThreads),
+		cpuMHz: float64(hw.CpuMhz) * float64(hw.NumCpuCores),
+		memKB:  float64(hw.MemorySize) / 1024,
+	}
+
+	if host.Config != nil && host.Config.Network != nil {
+		e.nics = len(host.Config.Network.Pnic)
+	}
+
+	if !e.on {
+		return e
+	}
+
+	for _, ref := range host.Vm {
+		if vm, ok := ctx.Map.Get(ref).(*VirtualMachine); ok {
+			e.addLoad(vmPerfEntity(ctx, vm))
+		}
+	}
+
+	// hypervisor overhead
+	e.cpuUsed = math.Min(e.cpuUsed+0.02*e.cpuMHz, e.cpuMHz)
+	e.activeKB += 512 * 1024
+	e.consumedKB = math.Min(e.consumedKB+e.overheadKB+1024*1024, e.memKB)
+	e.watts = 100 + 200*perfRatio(e.cpuUsed, e.cpuMHz)
+
+	return e
+}
+
+func poolPerfEntity(ctx *Context, e *perfEntity, pool types.ManagedObjectReference) {
+	rp, ok := asResourcePoolMO(ctx.Map.Get(pool))
+	if !ok {
+		return
+	}
+
+	for _, ref := range rp.Vm {
+		if vm, ok := ctx.Map.Get(ref).(*VirtualMachine); ok {
+			e.add(vmPerfEntity(ctx, vm))
+		}
+	}
+
+	for _, ref := range rp.ResourcePool {
+		poolPerfEntity(ctx, e, ref)
+	}
+}
+
+// perfEntity returns a snapshot of the given entity's state, nil if the entity type has no performance provider.
+func (p *PerformanceManager) perfEntity(ctx *Context, ref types.ManagedObjectReference, interval int32) *perfEntity {
+	e := &perfEntity{ref: ref}
+
+	switch obj := ctx.Map.Get(ref).(type) {
+	case *VirtualMachine:
+		e = vmPerfEntity(ctx, obj)
+	case *HostSystem:
+		e = hostPerfEntity(ctx, obj)
+	case *ResourcePool, *VirtualApp:
+		poolPerfEntity(ctx, e, ref)
+	case *ClusterComputeResource:
+		for _, host := range obj.Host {
+			if h, ok := ctx.Map.Get(host).(*HostSystem); ok {
+				e.add(hostPerfEntity(ctx, h))
+			}
+		}
+	case *Datacenter:
+		for _, h := range ctx.Map.All("HostSystem") {
+			if ctx.Map.getEntityDatacenter(h).Self == ref {
+				e.add(hostPerfEntity(ctx, h.(*HostSystem)))
+			}
+		}
+	case *Datastore:
+		e.on = obj.Summary.Accessible
+		e.space = &obj.Summary
+		for _, ref := range obj.Vm {
+			if vm, ok := ctx.Map.Get(ref).(*VirtualMachine); ok && len(vm.Datastore) != 0 {
+				e.diskKBps += vmPerfEntity(ctx, vm).diskKBps / float64(len(vm.Datastore))
+			}
+		}
+	default:
+		return nil
+	}
+
+	e.available = p.queryAvailablePerfMetric(ctx, ref, interval).Returnval
+	e.instances = make(map[int32]int)
+	for _, id := range e.available {
+		if id.Instance != "" {
+			e.instances[id.CounterId]++
+		}
+	}
+
+	return e
+}
+
+// level returns the expected value of a counter instance for a sample of the given interval.
+// False is returned if the counter is not modeled.
+func (e *perfEntity) level(c *types.PerfCounterInfo, instance string, interval int32) (float64, bool) {
+	ms := float64(interval) * 1000 // milliseconds per sample
+	util := perfRatio(e.cpuUsed, e.cpuMHz)
+	ready := 0.005 + 0.02*util
+
+	var v float64
+	split := true // divide the aggregate value between the counter's instances
+
+	switch perfCounterName(c) {
+	case "cpu.usage", "cpu.utilization", "cpu.coreUtilization",
+		"rescpu.actav1", "rescpu.actav5", "rescpu.actav15",
+		"rescpu.actpk1", "rescpu.actpk5", "rescpu.actpk15",
+		"rescpu.runav1", "rescpu.runav5", "rescpu.runav15",
+		"rescpu.runpk1", "rescpu.runpk5", "rescpu.runpk15":
+		v, split = util*10000, false
+	case "cpu.usagemhz", "cpu.demand":
+		v = e.cpuUsed
+	case "cpu.entitlement", "cpu.totalmhz":
+		v = e.cpuMHz
+	case "cpu.effectivemhz":
+		v = 0.95 * e.cpuMHz
+	case "cpu.used", "cpu.run":
+		v = util * ms * e.cpus
+	case "cpu.idle", "cpu.wait":
+		v = (1 - util) * ms * e.cpus
+	case "cpu.ready":
+		v = ready * ms * e.cpus
+	case "cpu.costop":
+		v = ready / 10 * ms * e.cpus
+	case "cpu.readiness":
+		v, split = ready*10000, false
+	case "cpu.latency":
+		v, split = 1.5*ready*10000, false
+	case "cpu.swapwait", "cpu.overlap", "cpu.maxlimited", "cpu.reservedCapacity":
+		v = 0
+	case "mem.granted":
+		if e.vm {
+			v = e.memKB
+		} else {
+			v = e.consumedKB
+		}
+	case "mem.entitlement":
+		v = e.memKB
+	case "mem.active":
+		v = e.activeKB
+	case "mem.consumed":
+		v = e.consumedKB
+	case "mem.usage":
+		if e.vm {
+			v = perfRatio(e.activeKB, e.memKB) * 10000
+		} else {
+			v = perfRatio(e.consumedKB, e.memKB) * 10000
+		}
+		split = false
+	case "mem.overhead":
+		v = e.overheadKB
+	case "mem.shared", "mem.sharedcommon":
+		v = 0.05 * e.consumedKB
+	case "mem.zero":
+		v = 0.02 * e.consumedKB
+	case "mem.totalmb":
+		v = e.memKB / 1024
+	case "mem.effectivemem":
+		v = 0.95 * e.memKB / 1024
+	case "mem.swapped", "mem.swapin", "mem.swapout", "mem.swaptarget",
+		"mem.swapinRate", "mem.swapoutRate", "mem.vmmemctl", "mem.vmmemctltarget",
+		"mem.compressed", "mem.compressionRate", "mem.decompressionRate", "mem.llSwapUsed":
+		v = 0
+	case "net.usage":
+		v = e.netKBps
+	case "net.received", "net.bytesRx":
+		v = 0.6 * e.netKBps
+	case "net.transmitted", "net.bytesTx":
+		v = 0.4 * e.netKBps
+	case "net.packetsRx":
+		v = 0.6 * e.netKBps * float64(interval)
+	case "net.packetsTx":
+		v = 0.4 * e.netKBps * float64(interval)
+	case "net.broadcastRx", "net.multicastRx":
+		v = 0.006 * e.netKBps * float64(interval)
+	case "net.broadcastTx", "net.multicastTx":
+		v = 0.004 * e.netKBps * float64(interval)
+	case "net.droppedRx", "net.droppedTx", "net.errorsRx", "net.errorsTx", "net.unknownProtos":
+		v = 0
+	case "disk.usage":
+		v = e.diskKBps
+	case "virtualDisk.read", "datastore.read", "disk.read":
+		v = 0.6 * e.diskKBps
+	case "virtualDisk.write", "datastore.write", "disk.write":
+		v = 0.4 * e.diskKBps
+	case "virtualDisk.numberReadAveraged", "datastore.numberReadAveraged":
+		v = 0.6 * e.diskKBps / 16
+	case "virtualDisk.numberWriteAveraged", "datastore.numberWriteAveraged":
+		v = 0.4 * e.diskKBps / 16
+	case "disk.commandsAveraged", "datastore.datastoreIops":
+		v = e.diskKBps / 16
+	case "disk.numberRead":
+		v = 0.6 * e.diskKBps / 16 * float64(interval)
+	case "disk.numberWrite":
+		v = 0.4 * e.diskKBps / 16 * float64(interval)
+	case "disk.commands":
+		v = e.diskKBps / 16 * float64(interval)
+	case "virtualDisk.totalReadLatency", "datastore.totalReadLatency", "disk.totalReadLatency":
+		v, split = 2+3*util, false
+	case "virtualDisk.totalWriteLatency", "datastore.totalWriteLatency", "disk.totalWriteLatency":
+		v, split = 3+4*util, false
+	case "disk.maxTotalLatency", "datastore.maxTotalLatency", "disk.totalLatency":
+		v, split = 5+5*util, false
+	case "disk.capacity":
+		if e.space == nil {
+			return 0, false
+		}
+		v = float64(e.space.Capacity) / 1024
+	case "disk.used":
+		if e.space == nil {
+			return 0, false
+		}
+		v = float64(e.space.Capacity-e.space.FreeSpace) / 1024
+	case "disk.provisioned":
+		if e.space == nil {
+			return 0, false
+		}
+		v = float64(e.space.Capacity-e.space.FreeSpace+e.space.Uncommitted) / 1024
+	case "sys.heartbeat":
+		v, split = float64(interval), false
+	case "power.power":
+		v = e.watts
+	case "power.energy":
+		v = e.watts * float64(interval)
+	default:
+		return 0, false
+	}
+
+	if split && instance != "" {
+		if n := e.instances[c.Key]; n > 1 {
+			v /= float64(n)
+		}
+	}
+
+	return v, true
+}
+
+// sample returns the value of a counter instance for the sample ending at ts.
+// Counters that are not modeled use the canned data points, if any.
+// Values vary with a daily cycle and noise, which is smoothed out by the longer historical intervals.
+// Powered off virtual machines and disconnected hosts report zero for all counters.
+func (e *perfEntity) sample(c *types.PerfCounterInfo, instance string, ts time.Time, interval int32, points []int64) int64 {
+	if !e.on {
+		return 0
+	}
+
+	name := perfCounterName(c)
+
+	v, ok := e.level(c, instance, interval)
+	if (name == "sys.uptime" || name == "sys.osUptime") && e.boot != nil {
+		v, ok = math.Max(ts.Sub(*e.boot).Seconds(), 1), true
+	}
+	if !ok {
+		if len(points) == 0 {
+			return 0
+		}
+		v = float64(points[(ts.Unix()/int64(interval))%int64(len(points))])
+	}
+
+	if v <= 0 {
+		return 0
+	}
+
+	if !perfSteady[name] {
+		phase := 2 * math.Pi * perfRandom(e.ref.Value, "phase")
+		v *= 1 + 0.1*math.Sin(2*math.Pi*float64(ts.Unix())/86400+phase)
+
+		smooth := math.Sqrt(20 / float64(interval))
+		spread := 0.15 * smooth
+		noise := 2*perfRandom(e.ref.Value, c.Key, instance, ts.Unix()) - 1
+
+		switch c.RollupType {
+		case types.PerfSummaryTypeMaximum:
+			v *= 1 + 0.2*(1-smooth) + spread*math.Abs(noise)
+		case types.PerfSummaryTypeMinimum:
+			v *= 1 - 0.2*(1-smooth) - spread*math.Abs(noise)
+		default:
+			v *= 1 + spread*noise
+		}
+	}
+
+	if c.UnitInfo.GetElementDescription().Key == string(types.PerformanceManagerUnitPercent) {
+		v = math.Min(v, 10000)
+	}
+
+	// Zero values are omitted when encoding the series, which would then no longer line up with its SampleInfo
+	return max(int64(math.Round(v)), 1)
+}
@@ -81,6 +81,10 @@ type Registry struct {
 	// without requiring modifications to the code under test.
 	// See ContainerImageRegistry for details and usage examples.
 	ContainerImages ContainerImageRegistry
+
+	// Drs scores hosts for ClusterComputeResource VM placement and load balancing,
+	// defaults to DrsUtilization when nil. See DrsEngine for details.
+	Drs DrsEngine
 }
 
 // tagManager is an interface to simplify internal interaction with the vapi tag manager simulator.
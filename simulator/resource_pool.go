@@ -457,6 +457,48 @@ func (a *VirtualApp) CloneVAppTask(ctx *Context, req *types.CloneVApp_Task) soap
 	}
 }
 
+func (a *VirtualApp) ExportVApp(ctx *Context, req *types.ExportVApp) soap.HasFault {
+	body := new(methods.ExportVAppBody)
+
+	for _, ref := range a.Vm {
+		vm := ctx.Map.Get(ref).(*VirtualMachine)
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+			body.Fault_ = Fault("", &types.InvalidPowerState{
+				RequestedState: types.VirtualMachinePowerStatePoweredOff,
+				ExistingState:  vm.Runtime.PowerState,
+			})
+			return body
+		}
+	}
+
+	lease := newHttpNfcLease(ctx)
+	lease.InitializeProgress = 100
+	lease.Mode = string(types.HttpNfcLeaseModePull)
+	lease.Capabilities = types.HttpNfcLeaseCapabilities{
+		CorsSupported:     true,
+		PullModeSupported: true,
+	}
+
+	var urls []types.HttpNfcLeaseDeviceUrl
+	for _, ref := range a.Vm {
+		vmURLs, fault := lease.export(ctx, ctx.Map.Get(ref).(*VirtualMachine))
+		if fault != nil {
+			lease.HttpNfcLeaseAbort(ctx, &types.HttpNfcLeaseAbort{This: lease.Self})
+			body.Fault_ = Fault("", fault)
+			return body
+		}
+		urls = append(urls, vmURLs...)
+	}
+
+	lease.ready(ctx, a.Self, urls)
+
+	body.Res = &types.ExportVAppResponse{
+		Returnval: lease.Reference(),
+	}
+
+	return body
+}
+
 func (a *VirtualApp) CreateVApp(ctx *Context, req *types.CreateVApp) soap.HasFault {
 	return (&ResourcePool{ResourcePool: a.ResourcePool}).CreateVApp(ctx, req)
 }
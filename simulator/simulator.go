@@ -63,6 +63,8 @@ type Service struct {
 	funcs         []handleFunc
 	delay         *DelayConfig
 	faultInjector *FaultInjector
+	checkpointers []Checkpointer
+	restore       string
 
 	readAll func(io.Reader) ([]byte, error)
 
@@ -72,6 +74,10 @@ type Service struct {
 	ServeMux *http.ServeMux
 	// RegisterEndpoints will initialize any endpoints added via RegisterEndpoint
 	RegisterEndpoints bool
+	// AdminEndpoints enables the /vcsim endpoints for managing the simulator at runtime, such as fault injection
+	// and host failure.
+	// Requests to these endpoints require a valid session cookie.
+	AdminEndpoints bool
 }
 
 // Server provides a simulator Service over HTTP
@@ -95,6 +101,16 @@ func New(ctx *Context, instance *ServiceInstance) *Service {
 	return s
 }
 
+// newContext returns a Context for use by HTTP handlers outside of the SDK endpoint, such as /vcsim/hosts
+func (s *Service) newContext() *Context {
+	return &Context{
+		Context: context.Background(),
+		Session: s.Context.Session,
+		Map:     s.Context.Map,
+		svc:     s,
+	}
+}
+
 func (s *Service) client() *vim25.Client {
 	c, _ := vim25.NewClient(context.Background(), s)
 	return c
@@ -120,6 +136,14 @@ func (s *Service) RemoveFaultRule(index int) bool {
 	return false
 }
 
+// EnableFaultRule enables or disables a fault injection rule by index
+func (s *Service) EnableFaultRule(index int, enabled bool) bool {
+	if s.faultInjector != nil {
+		return s.faultInjector.EnableRule(index, enabled)
+	}
+	return false
+}
+
 // ClearFaultRules removes all fault injection rules
 func (s *Service) ClearFaultRules() {
 	if s.faultInjector != nil {
@@ -476,6 +500,9 @@ func (s *Service) Handle(pattern string, handler http.Handler) {
 	if m, ok := handler.(tagManager); ok {
 		s.sdk[vim25.Path].tagManager = m
 	}
+	if c, ok := handler.(Checkpointer); ok {
+		s.addCheckpointer(c)
+	}
 }
 
 type muxHandleFunc interface {
@@ -673,7 +700,18 @@ func (s *Service) ServeDatastore(w http.ResponseWriter, r *http.Request) {
 		dir := path.Dir(p)
 		_ = os.MkdirAll(dir, 0700)
 
-		f, err := os.Create(p)
+		// Content-Range can be used to resume an upload, such as soap.Upload.Resume
+		var start int64
+		flag := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+		if cr := r.Header.Get("Content-Range"); cr != "" && r.Method == http.MethodPut {
+			if _, err := fmt.Sscanf(cr, "bytes %d-", &start); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			flag &^= os.O_TRUNC
+		}
+
+		f, err := os.OpenFile(p, flag, 0666)
 		if err != nil {
 			log.Printf("failed to %s '%s': %s", r.Method, p, err)
 			w.WriteHeader(http.StatusInternalServerError)
@@ -681,7 +719,14 @@ func (s *Service) ServeDatastore(w http.ResponseWriter, r *http.Request) {
 		}
 		defer f.Close()
 
-		_, _ = io.Copy(f, r.Body)
+		if start != 0 {
+			if err = f.Truncate(start); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		_, _ = io.Copy(io.NewOffsetWriter(f, start), r.Body)
 	default:
 		// ds.resolve() may have translated vsan friendly name to uuid,
 		// apply the same to the Request.URL.Path
@@ -756,6 +801,22 @@ func defaultIP(addr *net.TCPAddr) string {
 	return addr.IP.String()
 }
 
+// authenticated wraps the given handler, requiring the request to have a valid session cookie
+func (s *Service) authenticated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(soap.SessionCookieName)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if _, ok := s.Context.sessionManager().getSession(cookie.Value); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 // NewServer returns an http Server instance for the given service
 func (s *Service) NewServer() *Server {
 	ctx := s.Context
@@ -768,6 +829,12 @@ func (s *Service) NewServer() *Server {
 	mux.HandleFunc(guestPrefix, ServeGuest)
 	mux.HandleFunc(nfcPrefix, ServeNFC)
 	mux.HandleFunc("/about", s.About)
+	if s.AdminEndpoints {
+		mux.HandleFunc(faultsPrefix, s.authenticated(s.ServeFaults))
+		mux.HandleFunc(faultsPrefix+"/", s.authenticated(s.ServeFaults))
+		mux.HandleFunc(hostsPrefix+"/", s.authenticated(s.ServeHosts))
+	}
+	mux.HandleFunc(screenPrefix, s.authenticated(s.ServeScreen))
 
 	if s.Listen == nil {
 		s.Listen = new(url.URL)
@@ -802,12 +869,18 @@ func (s *Service) NewServer() *Server {
 			}
 		}
 	}
-	m.UpdateOptions(&types.UpdateOptions{
-		ChangedValue: []types.BaseOptionValue{&types.OptionValue{
-			Key:   "vcsim.server.url",
-			Value: u.String(),
-		}},
-	})
+	settings := []types.BaseOptionValue{&types.OptionValue{
+		Key:   "vcsim.server.url",
+		Value: u.String(),
+	}}
+	if ctx.Map.IsVPX() {
+		// Used to generate console URLs, see govc vm.console -h5
+		settings = append(settings, &types.OptionValue{
+			Key:   "VirtualCenter.FQDN",
+			Value: u.Hostname(),
+		})
+	}
+	m.UpdateOptions(&types.UpdateOptions{ChangedValue: settings})
 
 	u.User = s.Listen.User
 	if u.User == nil {
@@ -815,6 +888,16 @@ func (s *Service) NewServer() *Server {
 	}
 	s.Listen = u
 
+	if s.TLS != nil && len(s.TLS.Certificates) == 0 {
+		// Same default as httptest.StartTLS, set here so endpoints can access the initial certificate,
+		// see vapi/vcenter/certificatemanagement/simulator for example.
+		cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+		if err != nil {
+			panic(err)
+		}
+		s.TLS.Certificates = []tls.Certificate{cert}
+	}
+
 	if s.RegisterEndpoints {
 		for i := range endpoints {
 			endpoints[i](s, ctx.Map)
@@ -835,7 +918,7 @@ func (s *Service) NewServer() *Server {
 	if s.TLS != nil {
 		ts.TLS = s.TLS
 		ts.TLS.ClientAuth = tls.RequestClientCert // Used by SessionManager.LoginExtensionByCertificate
-		ctx.Map.SessionManager().TLS = func() *tls.Config { return ts.TLS }
+		ctx.Map.SessionManager().TLS = func() *tls.Config { return serverTLS(ts.TLS) }
 		ts.StartTLS()
 	} else {
 		ts.Start()
@@ -847,11 +930,23 @@ func (s *Service) NewServer() *Server {
 	}
 }
 
+// serverTLS returns the TLS config used for new connections,
+// which may be replaced via GetConfigForClient after the server is started.
+func serverTLS(config *tls.Config) *tls.Config {
+	if config.GetConfigForClient != nil {
+		c, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+		if err == nil && c != nil {
+			return c
+		}
+	}
+	return config
+}
+
 // Certificate returns the TLS certificate for the Server if started with TLS enabled.
 // This method will panic if TLS is not enabled for the server.
 func (s *Server) Certificate() *x509.Certificate {
 	// By default httptest.StartTLS uses http/internal.LocalhostCert, which we can access here:
-	cert, _ := x509.ParseCertificate(s.TLS.Certificates[0].Certificate[0])
+	cert, _ := x509.ParseCertificate(serverTLS(s.TLS).Certificates[0].Certificate[0])
 	return cert
 }
 
@@ -303,6 +303,10 @@ func (vm *VirtualMachine) hostInMM(ctx *Context) bool {
 	return ctx.Map.Get(*vm.Runtime.Host).(*HostSystem).Runtime.InMaintenanceMode
 }
 
+func (vm *VirtualMachine) hostPoweredOn(ctx *Context) bool {
+	return ctx.Map.Get(*vm.Runtime.Host).(*HostSystem).Runtime.PowerState == types.HostSystemPowerStatePoweredOn
+}
+
 func (vm *VirtualMachine) apply(spec *types.VirtualMachineConfigSpec) {
 	if spec.Files == nil {
 		spec.Files = new(types.VirtualMachineFileInfo)
@@ -533,6 +537,14 @@ func extraConfigKey(key string) string {
 	return key
 }
 
+// SetQuickStats sets the VM's summary.quickStats, such as the simulated CPU and memory usage
+// used by DRS and the performance manager.
+func (vm *VirtualMachine) SetQuickStats(ctx *Context, stats types.VirtualMachineQuickStats) {
+	ctx.WithLock(vm, func() {
+		ctx.Update(vm, []types.PropertyChange{{Name: "summary.quickStats", Val: stats}})
+	})
+}
+
 func (vm *VirtualMachine) applyExtraConfig(ctx *Context, spec *types.VirtualMachineConfigSpec) types.BaseMethodFault {
 	if len(spec.ExtraConfig) == 0 {
 		return nil
@@ -709,7 +721,15 @@ func (vm *VirtualMachine) configure(ctx *Context, spec *types.VirtualMachineConf
 		return err
 	}
 
-	return vm.configureDevices(ctx, spec)
+	if err := vm.configureDevices(ctx, spec); err != nil {
+		return err
+	}
+
+	if spec.ChangeTrackingEnabled != nil {
+		return vm.updateChangeTracking(ctx)
+	}
+
+	return nil
 }
 
 func getVMFileType(fileName string) types.VirtualMachineFileLayoutExFileType {
@@ -2288,7 +2308,7 @@ func (c *powerVMTask) Run(task *Task) (types.AnyType, types.BaseMethodFault) {
 	var customizationFault types.BaseMethodFault
 	switch c.state {
 	case types.VirtualMachinePowerStatePoweredOn:
-		if c.VirtualMachine.hostInMM(c.ctx) {
+		if c.VirtualMachine.hostInMM(c.ctx) || !c.VirtualMachine.hostPoweredOn(c.ctx) {
 			return nil, new(types.InvalidState)
 		}
 
@@ -2344,10 +2364,14 @@ func (c *powerVMTask) Run(task *Task) (types.AnyType, types.BaseMethodFault) {
 		}
 	}
 
+	das := c.VirtualMachine.dasProtection(c.ctx, c.state)
+
 	c.ctx.Update(c.VirtualMachine, []types.PropertyChange{
 		{Name: "runtime.powerState", Val: c.state},
 		{Name: "summary.runtime.powerState", Val: c.state},
 		{Name: "summary.runtime.bootTime", Val: boot},
+		{Name: "runtime.dasVmProtection", Val: das},
+		{Name: "summary.runtime.dasVmProtection", Val: das},
 		{Name: "config.hardware.device", Val: devices},
 	})
 
@@ -2897,6 +2921,178 @@ func copyNonEmptyValue[T comparable](dst, src *T) {
 	*dst = *src
 }
 
+func (vm *VirtualMachine) InstantCloneTask(ctx *Context, req *types.InstantClone_Task) soap.HasFault {
+	spec := req.Spec
+
+	folderRef := spec.Location.Folder
+	if folderRef == nil {
+		folderRef = vm.Parent
+	}
+
+	folder, ok := asFolderMO(ctx.Map.Get(*folderRef))
+	if !ok {
+		return &methods.InstantClone_TaskBody{
+			Fault_: Fault("Invalid folder", &types.RuntimeFault{}),
+		}
+	}
+
+	pool := spec.Location.Pool
+	if pool == nil {
+		pool = vm.ResourcePool
+	}
+
+	hostRef := vm.Runtime.Host
+	if spec.Location.Host != nil {
+		hostRef = spec.Location.Host
+	}
+	host, ok := ctx.Map.Get(*hostRef).(*HostSystem)
+	if !ok {
+		return &methods.InstantClone_TaskBody{
+			Fault_: Fault("", &types.InvalidArgument{InvalidProperty: "spec.location.host"}),
+		}
+	}
+	event := vm.event(ctx)
+
+	vmx := vm.vmx(nil)
+	vmx.Path = spec.Name
+	if ref := spec.Location.Datastore; ref != nil {
+		ds, ok := ctx.Map.Get(*ref).(*Datastore)
+		if !ok {
+			return &methods.InstantClone_TaskBody{
+				Fault_: Fault("", &types.InvalidDatastore{Datastore: ref}),
+			}
+		}
+		vmx.Datastore = ds.Name
+	}
+
+	task := CreateTask(vm, "instantClone", func(t *Task) (types.AnyType, types.BaseMethodFault) {
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			return nil, &types.InvalidPowerState{
+				RequestedState: types.VirtualMachinePowerStatePoweredOn,
+				ExistingState:  vm.Runtime.PowerState,
+			}
+		}
+		if pool == nil {
+			return nil, &types.InvalidArgument{InvalidProperty: "spec.location.pool"}
+		}
+		if obj := ctx.Map.FindByName(spec.Name, folder.ChildEntity); obj != nil {
+			return nil, &types.DuplicateName{
+				Name:   spec.Name,
+				Object: obj.Reference(),
+			}
+		}
+
+		ctx.postEvent(&types.VmBeingClonedEvent{
+			VmCloneEvent: types.VmCloneEvent{
+				VmEvent: event,
+			},
+			DestFolder: folderEventArgument(folder),
+			DestName:   spec.Name,
+			DestHost:   *host.eventArgument(),
+		})
+
+		config := types.VirtualMachineConfigSpec{
+			Name:                spec.Name,
+			Version:             vm.Config.Version,
+			GuestId:             vm.Config.GuestId,
+			Uuid:                spec.BiosUuid,
+			NumCPUs:             vm.Config.Hardware.NumCPU,
+			MemoryMB:            int64(vm.Config.Hardware.MemoryMB),
+			NumCoresPerSocket:   vm.Config.Hardware.NumCoresPerSocket,
+			VirtualICH7MPresent: vm.Config.Hardware.VirtualICH7MPresent,
+			VirtualSMCPresent:   vm.Config.Hardware.VirtualSMCPresent,
+			ExtraConfig:         spec.Config,
+			Files: &types.VirtualMachineFileInfo{
+				VmPathName: vmx.String(),
+			},
+		}
+
+		defaultDevices := object.VirtualDeviceList(esx.VirtualDevice)
+		devices := vm.cloneDevice()
+
+		for _, device := range devices {
+			var fop types.VirtualDeviceConfigSpecFileOperation
+
+			if defaultDevices.Find(object.VirtualDeviceList(devices).Name(device)) != nil {
+				// Default devices are added during CreateVMTask
+				continue
+			}
+
+			switch x := device.(type) {
+			case *types.VirtualDisk:
+				// The child gets a delta disk on top of the parent's running disk chain
+				fop = types.VirtualDeviceConfigSpecFileOperationCreate
+
+				if backing, ok := x.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+					parent := *backing
+					backing.Parent = &parent
+					backing.FileName = ""
+					backing.Uuid = ""
+					backing.ChangeId = ""
+				}
+			case types.BaseVirtualEthernetCard:
+				// The child is assigned a new MAC address
+				x.GetVirtualEthernetCard().MacAddress = ""
+			}
+
+			config.DeviceChange = append(config.DeviceChange, &types.VirtualDeviceConfigSpec{
+				Operation:     types.VirtualDeviceConfigSpecOperationAdd,
+				Device:        device,
+				FileOperation: fop,
+			})
+		}
+
+		res := ctx.Map.Get(folder.Self).(vmFolder).CreateVMTask(ctx, &types.CreateVM_Task{
+			This:   folder.Self,
+			Config: config,
+			Pool:   *pool,
+			Host:   &host.Self,
+		})
+
+		ctask := ctx.Map.Get(res.(*methods.CreateVM_TaskBody).Res.Returnval).(*Task)
+		ctask.Wait()
+		if ctask.Info.Error != nil {
+			return nil, ctask.Info.Error.Fault
+		}
+
+		ref := ctask.Info.Result.(types.ManagedObjectReference)
+		clone := ctx.Map.Get(ref).(*VirtualMachine)
+		if err := clone.configureDevices(ctx, &types.VirtualMachineConfigSpec{DeviceChange: spec.Location.DeviceChange}); err != nil {
+			return nil, err
+		}
+		clone.DataSets = copyDataSetsForVmClone(vm.DataSets)
+
+		ctx.postEvent(&types.VmClonedEvent{
+			VmCloneEvent: types.VmCloneEvent{VmEvent: clone.event(ctx)},
+			SourceVm:     *event.Vm,
+		})
+
+		// The child resumes from the parent's running state
+		runner := &powerVMTask{
+			VirtualMachine: clone,
+			state:          types.VirtualMachinePowerStatePoweredOn,
+			ctx:            ctx,
+		}
+		ptask := ctx.Map.Get(CreateTask(runner.Reference(), "powerOn", runner.Run).Run(ctx)).(*Task)
+		ptask.Wait()
+		if ptask.Info.Error != nil {
+			return nil, ptask.Info.Error.Fault
+		}
+
+		ctx.WithLock(clone, func() {
+			clone.Runtime.InstantCloneFrozen = types.NewBool(false)
+		})
+
+		return ref, nil
+	})
+
+	return &methods.InstantClone_TaskBody{
+		Res: &types.InstantClone_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
 func (vm *VirtualMachine) RelocateVMTask(ctx *Context, req *types.RelocateVM_Task) soap.HasFault {
 	task := CreateTask(vm, "relocateVm", func(t *Task) (types.AnyType, types.BaseMethodFault) {
 		var changes []types.PropertyChange
@@ -2918,8 +3114,13 @@ func (vm *VirtualMachine) RelocateVMTask(ctx *Context, req *types.RelocateVM_Tas
 		}
 
 		if ref := req.Spec.Host; ref != nil {
+			if src := vm.Runtime.Host; src != nil && *src != *ref {
+				if host, ok := ctx.Map.Get(*src).(*HostSystem); ok {
+					ctx.Map.RemoveReference(ctx, host, &host.Vm, vm.Self)
+				}
+			}
 			host := ctx.Map.Get(*ref).(*HostSystem)
-			ctx.Map.RemoveReference(ctx, host, &host.Vm, *ref)
+			ctx.Map.AddReference(ctx, host, &host.Vm, vm.Self)
 
 			changes = append(changes,
 				types.PropertyChange{Name: "runtime.host", Val: ref},
@@ -3296,6 +3497,13 @@ func (vm *VirtualMachine) CreateSnapshotExTask(ctx *Context, req *types.CreateSn
 			vm.Snapshot = &types.VirtualMachineSnapshotInfo{}
 		}
 
+		if isTrue(vm.Config.ChangeTrackingEnabled) {
+			// The snapshot's change IDs identify the state of each disk at this point in time
+			if err := vm.updateChangeTracking(ctx); err != nil {
+				return nil, err
+			}
+		}
+
 		snapshot := &VirtualMachineSnapshot{}
 		snapshot.Vm = vm.Reference()
 		snapshot.Config = copyConfigFromVmConfig(vm.Config)
@@ -3642,6 +3850,41 @@ func (vm *VirtualMachine) MarkAsVirtualMachine(req *types.MarkAsVirtualMachine)
 	return r
 }
 
+func (vm *VirtualMachine) ExportVm(ctx *Context, req *types.ExportVm) soap.HasFault {
+	body := new(methods.ExportVmBody)
+
+	if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOff {
+		body.Fault_ = Fault("", &types.InvalidPowerState{
+			RequestedState: types.VirtualMachinePowerStatePoweredOff,
+			ExistingState:  vm.Runtime.PowerState,
+		})
+		return body
+	}
+
+	lease := newHttpNfcLease(ctx)
+	lease.InitializeProgress = 100
+	lease.Mode = string(types.HttpNfcLeaseModePull)
+	lease.Capabilities = types.HttpNfcLeaseCapabilities{
+		CorsSupported:     true,
+		PullModeSupported: true,
+	}
+
+	urls, fault := lease.export(ctx, vm)
+	if fault != nil {
+		lease.HttpNfcLeaseAbort(ctx, &types.HttpNfcLeaseAbort{This: lease.Self})
+		body.Fault_ = Fault("", fault)
+		return body
+	}
+
+	lease.ready(ctx, vm.Self, urls)
+
+	body.Res = &types.ExportVmResponse{
+		Returnval: lease.Reference(),
+	}
+
+	return body
+}
+
 func findSnapshotInTree(tree []types.VirtualMachineSnapshotTree, ref types.ManagedObjectReference) *types.VirtualMachineSnapshotTree {
 	if tree == nil {
 		return nil
@@ -0,0 +1,245 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+const screenPrefix = "/screen"
+
+// consoleEndpoint returns the host, port and certificate used for VM console connections,
+// which are served by the same listener as the SDK.
+func (vm *VirtualMachine) consoleEndpoint(ctx *Context) (string, int32, *x509.Certificate) {
+	var (
+		host string
+		port int32
+		cert *x509.Certificate
+	)
+
+	if h, ok := ctx.Map.Get(*vm.Runtime.Host).(*HostSystem); ok {
+		host = h.Name
+		port = h.Summary.Config.Port
+	}
+
+	m := ctx.Map.SessionManager()
+
+	if name, p, err := net.SplitHostPort(m.ServiceHostName); err == nil {
+		host = name
+		if n, err := strconv.Atoi(p); err == nil {
+			port = int32(n)
+		}
+	}
+
+	if m.TLS != nil {
+		cert, _ = x509.ParseCertificate(m.TLS().Certificates[0].Certificate[0])
+	}
+
+	return host, port, cert
+}
+
+func (vm *VirtualMachine) AcquireTicket(ctx *Context, req *types.AcquireTicket) soap.HasFault {
+	body := new(methods.AcquireTicketBody)
+
+	kind := types.VirtualMachineTicketType(req.TicketType)
+	if !slices.Contains(kind.Values(), kind) {
+		body.Fault_ = Fault("", &types.InvalidArgument{InvalidProperty: "ticketType"})
+		return body
+	}
+
+	if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		body.Fault_ = Fault("", &types.InvalidPowerState{
+			RequestedState: types.VirtualMachinePowerStatePoweredOn,
+			ExistingState:  vm.Runtime.PowerState,
+		})
+		return body
+	}
+
+	host, port, cert := vm.consoleEndpoint(ctx)
+
+	ticket := types.VirtualMachineTicket{
+		Ticket:  uuid.New().String(),
+		CfgFile: vm.Config.Files.VmPathName,
+		Host:    host,
+		Port:    port,
+	}
+
+	if cert != nil {
+		ticket.SslThumbprint = soap.ThumbprintSHA1(cert)
+		ticket.CertThumbprintList = []types.VirtualMachineCertThumbprint{{
+			Thumbprint:    soap.ThumbprintSHA256(cert),
+			HashAlgorithm: string(types.VirtualMachineCertThumbprintHashAlgorithmSha256),
+		}}
+	}
+
+	body.Res = &types.AcquireTicketResponse{Returnval: ticket}
+
+	return body
+}
+
+func (vm *VirtualMachine) AcquireMksTicket(ctx *Context, req *types.AcquireMksTicket) soap.HasFault {
+	body := new(methods.AcquireMksTicketBody)
+
+	res := vm.AcquireTicket(ctx, &types.AcquireTicket{
+		This:       req.This,
+		TicketType: string(types.VirtualMachineTicketTypeMks),
+	}).(*methods.AcquireTicketBody)
+
+	if res.Fault_ != nil {
+		body.Fault_ = res.Fault_
+		return body
+	}
+
+	ticket := res.Res.Returnval
+	body.Res = &types.AcquireMksTicketResponse{
+		Returnval: types.VirtualMachineMksTicket{
+			Ticket:        ticket.Ticket,
+			CfgFile:       ticket.CfgFile,
+			Host:          ticket.Host,
+			Port:          ticket.Port,
+			SslThumbprint: ticket.SslThumbprint,
+		},
+	}
+
+	return body
+}
+
+// screen renders a simulated console screen for the given VM.
+// The image has a title bar colored by the VM's uuid, making screenshots of different VMs distinguishable.
+func (vm *VirtualMachine) screen() ([]byte, error) {
+	const width, height, bar = 640, 480, 24
+
+	sum := sha256.Sum256([]byte(vm.Config.Uuid))
+	title := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 0xff}
+	background := color.RGBA{R: 0x20, G: 0x20, B: 0x20, A: 0xff}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		c := background
+		if y < bar {
+			c = title
+		}
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// screenshotPath returns the datastore path for the next screenshot of the VM, named {vm.Name}-{N}.png
+func (vm *VirtualMachine) screenshotPath() object.DatastorePath {
+	var n int
+	for _, f := range vm.LayoutEx.File {
+		if f.Type == string(types.VirtualMachineFileLayoutExFileTypeScreenshot) {
+			n++
+		}
+	}
+
+	p := vm.vmx(nil)
+	if path.Ext(p.Path) == ".vmx" {
+		p.Path = path.Dir(p.Path)
+	}
+	p.Path = path.Join(p.Path, fmt.Sprintf("%s-%d.png", vm.Name, n+1))
+
+	return p
+}
+
+func (vm *VirtualMachine) CreateScreenshotTask(ctx *Context, req *types.CreateScreenshot_Task) soap.HasFault {
+	task := CreateTask(vm, "createScreenshot", func(*Task) (types.AnyType, types.BaseMethodFault) {
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			return nil, &types.InvalidPowerState{
+				RequestedState: types.VirtualMachinePowerStatePoweredOn,
+				ExistingState:  vm.Runtime.PowerState,
+			}
+		}
+
+		data, err := vm.screen()
+		if err != nil {
+			return nil, &types.SystemError{Reason: err.Error()}
+		}
+
+		p := vm.screenshotPath()
+
+		f, fault := vm.createFile(ctx, p.String(), "", false)
+		if fault != nil {
+			return nil, fault
+		}
+
+		_, err = f.Write(data)
+		_ = f.Close()
+		if err != nil {
+			return nil, &types.FileFault{File: p.String()}
+		}
+
+		vm.addFileLayoutEx(ctx, p, int64(len(data)))
+
+		return p.String(), nil
+	})
+
+	return &methods.CreateScreenshot_TaskBody{
+		Res: &types.CreateScreenshot_TaskResponse{
+			Returnval: task.Run(ctx),
+		},
+	}
+}
+
+// ServeScreen handles requests for a VM console screen shot, as used by `govc vm.console -capture`:
+// GET /screen?id={vm}
+func (s *Service) ServeScreen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: r.URL.Query().Get("id")}
+
+	ctx := s.newContext()
+
+	vm, ok := ctx.Map.Get(ref).(*VirtualMachine)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var data []byte
+	var err error
+
+	ctx.WithLock(vm, func() {
+		if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			err = fmt.Errorf("%s is %s", vm.Name, vm.Runtime.PowerState)
+			return
+		}
+		data, err = vm.screen()
+	})
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,146 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVirtualMachineTicket(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		ticket, err := vm.AcquireTicket(ctx, string(types.VirtualMachineTicketTypeWebmks))
+		require.NoError(t, err)
+
+		u := c.URL()
+		assert.NotEmpty(t, ticket.Ticket)
+		assert.Equal(t, u.Hostname(), ticket.Host)
+		assert.Equal(t, u.Port(), fmt.Sprint(ticket.Port))
+		assert.Contains(t, ticket.CfgFile, "DC0_H0_VM0")
+
+		var info object.HostCertificateInfo
+		require.NoError(t, info.FromURL(u, nil))
+		assert.Equal(t, info.ThumbprintSHA1, ticket.SslThumbprint)
+		require.Len(t, ticket.CertThumbprintList, 1)
+		assert.Equal(t, info.ThumbprintSHA256, ticket.CertThumbprintList[0].Thumbprint)
+
+		mks, err := methods.AcquireMksTicket(ctx, c, &types.AcquireMksTicket{This: vm.Reference()})
+		require.NoError(t, err)
+		assert.Equal(t, ticket.Host, mks.Returnval.Host)
+		assert.NotEqual(t, ticket.Ticket, mks.Returnval.Ticket)
+
+		_, err = vm.AcquireTicket(ctx, "pks")
+		assert.True(t, fault.Is(err, &types.InvalidArgument{}))
+
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		_, err = vm.AcquireTicket(ctx, string(types.VirtualMachineTicketTypeMks))
+		assert.True(t, fault.Is(err, &types.InvalidPowerState{}))
+	})
+}
+
+func TestVirtualMachineScreenshot(t *testing.T) {
+	Test(func(ctx context.Context, c *vim25.Client) {
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		screenshot := func() (string, error) {
+			res, err := methods.CreateScreenshot_Task(ctx, c, &types.CreateScreenshot_Task{This: vm.Reference()})
+			require.NoError(t, err)
+			info, err := object.NewTask(c, res.Returnval).WaitForResult(ctx)
+			if err != nil {
+				return "", err
+			}
+			return info.Result.(string), nil
+		}
+
+		for i, name := range []string{"DC0_H0_VM0-1.png", "DC0_H0_VM0-2.png"} {
+			p, err := screenshot()
+			require.NoError(t, err)
+
+			var path object.DatastorePath
+			require.True(t, path.FromString(p))
+			assert.Equal(t, "DC0_H0_VM0/"+name, path.Path, i)
+
+			ds := Map(ctx).Get(vm.Reference()).(*VirtualMachine).useDatastore(ctx.(*Context), path.Datastore)
+			f, err := os.Open(ds.resolve(ctx.(*Context), path.Path))
+			require.NoError(t, err)
+			img, err := png.Decode(f)
+			_ = f.Close()
+			require.NoError(t, err)
+			assert.Equal(t, 640, img.Bounds().Dx())
+		}
+
+		var files []string
+		for _, f := range Map(ctx).Get(vm.Reference()).(*VirtualMachine).LayoutEx.File {
+			if f.Type == string(types.VirtualMachineFileLayoutExFileTypeScreenshot) {
+				files = append(files, f.Name)
+			}
+		}
+		assert.Len(t, files, 2)
+
+		s := ServiceFromContext(ctx)
+		get := func(id string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			s.ServeScreen(w, httptest.NewRequest(http.MethodGet, "/screen?id="+id, nil))
+			return w
+		}
+
+		w := get(vm.Reference().Value)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+		_, err = png.Decode(w.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNotFound, get("vm-enoent").Code)
+
+		// download via the same path as govc vm.console -capture
+		u := c.URL()
+		u.Path = "/screen"
+		u.RawQuery = "id=" + vm.Reference().Value
+		r, _, err := c.Download(ctx, u, &soap.DefaultDownload)
+		require.NoError(t, err)
+		_, err = png.Decode(r)
+		_ = r.Close()
+		require.NoError(t, err)
+
+		// a session is required
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		require.NoError(t, err)
+		res, err := c.Client.Transport.RoundTrip(req)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		_, err = screenshot()
+		assert.True(t, fault.Is(err, &types.InvalidPowerState{}))
+		assert.Equal(t, http.StatusConflict, get(vm.Reference().Value).Code)
+	})
+}
@@ -1185,6 +1185,142 @@ func TestCloneVmExtraConfig(t *testing.T) {
 	}, m)
 }
 
+func TestInstantCloneVm(t *testing.T) {
+	m := VPX()
+	defer m.Remove()
+
+	Test(func(ctx context.Context, c *vim25.Client) {
+		vmm := m.Map().Any("VirtualMachine").(*VirtualMachine)
+		vm := object.NewVirtualMachine(c, vmm.Reference())
+
+		spec := types.VirtualMachineInstantCloneSpec{
+			Name:     "instant-clone-vm",
+			BiosUuid: "12345678-abcd-1234-cdef-123456789abc",
+			Config: []types.BaseOptionValue{
+				&types.OptionValue{Key: "guestinfo.ic.hostname", Value: "child"},
+			},
+		}
+
+		task, err := vm.PowerOff(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		task, err = vm.InstantClone(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = task.WaitForResult(ctx, nil)
+		if !fault.Is(err, &types.InvalidPowerState{}) {
+			t.Fatalf("expected InvalidPowerState, got %v", err)
+		}
+
+		task, err = vm.PowerOn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = task.Wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		task, err = vm.InstantClone(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := task.WaitForResult(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		clone := m.Map().Get(info.Result.(types.ManagedObjectReference)).(*VirtualMachine)
+
+		if clone.Parent.Value != vmm.Parent.Value {
+			t.Errorf("folder=%s", clone.Parent)
+		}
+		if clone.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+			t.Errorf("power state=%s", clone.Runtime.PowerState)
+		}
+		if clone.Config.Uuid != spec.BiosUuid {
+			t.Errorf("uuid=%s", clone.Config.Uuid)
+		}
+
+		var found bool
+		for _, ov := range clone.Config.ExtraConfig {
+			if ov.GetOptionValue().Key == "guestinfo.ic.hostname" {
+				found = ov.GetOptionValue().Value == "child"
+			}
+		}
+		if !found {
+			t.Error("extraConfig not applied")
+		}
+
+		parentDisks := object.VirtualDeviceList(vmm.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))
+		disks := object.VirtualDeviceList(clone.Config.Hardware.Device).SelectByType((*types.VirtualDisk)(nil))
+		if len(disks) != len(parentDisks) || len(disks) == 0 {
+			t.Fatalf("disks=%d, parent disks=%d", len(disks), len(parentDisks))
+		}
+		for i := range disks {
+			backing := disks[i].(*types.VirtualDisk).Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			parent := parentDisks[i].(*types.VirtualDisk).Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+			if backing.Parent == nil || backing.Parent.FileName != parent.FileName {
+				t.Errorf("disk %d is not a delta of %s", i, parent.FileName)
+			}
+			if backing.FileName == parent.FileName {
+				t.Errorf("disk %d shares the parent's file", i)
+			}
+		}
+
+		task, err = vm.InstantClone(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = task.WaitForResult(ctx, nil)
+		if !fault.Is(err, &types.DuplicateName{}) {
+			t.Fatalf("expected DuplicateName, got %v", err)
+		}
+
+		// the child is placed on Location.Host
+		var host types.ManagedObjectReference
+		for _, h := range m.Map().All("HostSystem") {
+			if h.Reference() != *vmm.Runtime.Host {
+				host = h.Reference()
+				break
+			}
+		}
+		spec.Name = "instant-clone-host"
+		spec.Location.Host = &host
+		task, err = vm.InstantClone(ctx, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err = task.WaitForResult(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clone = m.Map().Get(info.Result.(types.ManagedObjectReference)).(*VirtualMachine)
+		if *clone.Runtime.Host != host {
+			t.Errorf("host=%s", clone.Runtime.Host)
+		}
+
+		spec.Name = "instant-clone-invalid"
+		spec.Location.Host = &types.ManagedObjectReference{Type: "HostSystem", Value: "enoent"}
+		_, err = vm.InstantClone(ctx, spec)
+		if !fault.Is(err, &types.InvalidArgument{}) {
+			t.Fatalf("expected InvalidArgument, got %v", err)
+		}
+
+		spec.Location.Host = nil
+		spec.Location.Datastore = &types.ManagedObjectReference{Type: "Datastore", Value: "enoent"}
+		_, err = vm.InstantClone(ctx, spec)
+		if !fault.Is(err, &types.InvalidDatastore{}) {
+			t.Fatalf("expected InvalidDatastore, got %v", err)
+		}
+	}, m)
+}
+
 func TestReconfigVmDevice(t *testing.T) {
 	ctx := context.Background()
 
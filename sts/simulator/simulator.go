@@ -18,6 +18,7 @@ import (
 	"github.com/vmware/govmomi/sts/internal"
 	"github.com/vmware/govmomi/vim25/soap"
 	vim "github.com/vmware/govmomi/vim25/types"
+	"github.com/vmware/govmomi/vim25/xml"
 )
 
 func init() {
@@ -54,7 +55,7 @@ func (s *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	lifetime := &internal.Lifetime{
 		Created: now.Format(internal.Time),
-		Expires: now.Add(5 * time.Minute).Format(internal.Time),
+		Expires: now.Add(requestLifetime(r)).Format(internal.Time),
 	}
 
 	switch path.Base(action) {
@@ -90,6 +91,26 @@ func (s *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, internal.Marshal(env))
 }
 
+// requestLifetime returns the duration of the requested token Lifetime, defaulting to 5 minutes.
+func requestLifetime(r *http.Request) time.Duration {
+	var env struct {
+		Lifetime struct {
+			Created string
+			Expires string
+		} `xml:"Body>RequestSecurityToken>Lifetime"`
+	}
+
+	if err := xml.NewDecoder(r.Body).Decode(&env); err == nil {
+		created, cerr := time.Parse(internal.Time, env.Lifetime.Created)
+		expires, eerr := time.Parse(internal.Time, env.Lifetime.Expires)
+		if cerr == nil && eerr == nil && expires.After(created) {
+			return expires.Sub(created)
+		}
+	}
+
+	return 5 * time.Minute
+}
+
 // Currently simulator.SessionManager.LoginByToken() only checks for a non-empty Assertion.Subject.NameID field,
 // so the token below is returned by Issue and Renew requests for now.
 var token = `<saml2:Assertion xmlns:saml2="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ID="_1881a9ba-4a76-4baa-839b-36e2cba10743" IssueInstant="2018-03-04T00:27:56.409Z" Version="2.0"><saml2:Issuer Format="urn:oasis:names:tc:SAML:2.0:nameid-format:entity">https://office1-sfo2-dhcp221.eng.vmware.com/websso/SAML2/Metadata/vsphere.local</saml2:Issuer><ds:Signature xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:SignedInfo><ds:CanonicalizationMethod Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"/><ds:SignatureMethod Algorithm="http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"/><ds:Reference URI="#_1881a9ba-4a76-4baa-839b-36e2cba10743"><ds:Transforms><ds:Transform Algorithm="http://www.w3.org/2000/09/xmldsig#enveloped-signature"/><ds:Transform Algorithm="http://www.w3.org/2001/10/xml-exc-c14n#"><ec:InclusiveNamespaces xmlns:ec="http://www.w3.org/2001/10/xml-exc-c14n#" PrefixList="xs xsi"/></ds:Transform></ds:Transforms><ds:DigestMethod Algorithm="http://www.w3.org/2001/04/xmlenc#sha256"/><ds:DigestValue>l/0AzCGiPB69oTstUdrCkihBIDtwb83A93zAe10tG3k=</ds:DigestValue></ds:Reference></ds:SignedInfo><ds:SignatureValue>EKHf14V0CHctwqXRlhYSYNyID5lNJLimbw57eUBm/QlAMLY7GJ1wth44oeQPSj3eMpJaXKHEYYtn
@@ -8,6 +8,7 @@ import (
 	"context"
 
 	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/telemetry"
 	"github.com/vmware/govmomi/vim25/progress"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -107,6 +108,11 @@ func WaitEx(
 	pc *property.Collector,
 	s progress.Sinker) (*types.TaskInfo, error) {
 
+	ctx, span := telemetry.Start(ctx, pc.Telemetry(), "task.Wait",
+		telemetry.String(telemetry.AttrObjectType, ref.Type),
+		telemetry.String(telemetry.AttrObjectValue, ref.Value))
+	defer span.End()
+
 	cb := &taskCallback{}
 
 	// Include progress sink if specified
@@ -138,8 +144,14 @@ func WaitEx(
 			return false
 		}); err != nil {
 
+		span.RecordError(err)
 		return nil, err
 	}
 
+	if cb.info != nil {
+		span.SetAttributes(telemetry.String(telemetry.AttrTaskState, string(cb.info.State)))
+	}
+	span.RecordError(cb.err)
+
 	return cb.info, cb.err
 }
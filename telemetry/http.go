@@ -0,0 +1,115 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type transport struct {
+	p  *Provider
+	rt http.RoundTripper
+}
+
+// Transport returns an http.RoundTripper that starts a span for each request sent via rt.
+// If rt is nil, http.DefaultTransport is used.
+func Transport(p *Provider, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &transport{p: p, rt: rt}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := Route(req)
+	name := req.Method + " " + route
+
+	ctx, span := t.p.start(req.Context(), KindREST, name,
+		String(AttrSystem, "vmware.vapi"),
+		String(AttrHTTPMethod, req.Method),
+		String(AttrHTTPRoute, route),
+	)
+	defer span.End()
+
+	res, err := t.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	span.SetAttributes(Int(AttrHTTPStatus, res.StatusCode))
+
+	if res.StatusCode >= http.StatusBadRequest {
+		span.RecordError(&statusError{res.Status})
+		span.setFault(errorType(res))
+	}
+
+	return res, nil
+}
+
+type statusError struct {
+	status string
+}
+
+func (e *statusError) Error() string {
+	return e.status
+}
+
+// Route returns the request URL path, with path segments that identify an object replaced by "{id}",
+// such that it can be used as a low cardinality span name. The "action" query parameter is retained.
+func Route(req *http.Request) string {
+	segs := strings.Split(req.URL.Path, "/")
+
+	for i, seg := range segs {
+		if strings.ContainsAny(seg, "0123456789:") {
+			segs[i] = "{id}"
+		}
+	}
+
+	route := strings.Join(segs, "/")
+	if action := req.URL.Query().Get("~action"); action != "" {
+		route += "?~action=" + action
+	} else if action := req.URL.Query().Get("action"); action != "" {
+		route += "?action=" + action
+	}
+
+	return route
+}
+
+// errorType returns the vAPI error type of the response body, if any.
+// The /api endpoint uses "error_type", the /rest endpoint uses "type".
+func errorType(res *http.Response) string {
+	if res.Body == nil {
+		return ""
+	}
+
+	// error responses are small, but don't read more than needed if that's not the case
+	b, err := io.ReadAll(io.LimitReader(res.Body, 64*1024))
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(b), res.Body), res.Body}
+	if err != nil {
+		return ""
+	}
+
+	var e struct {
+		ErrorType string `json:"error_type"`
+		Type      string `json:"type"`
+	}
+	if json.Unmarshal(b, &e) != nil {
+		return ""
+	}
+
+	if e.ErrorType != "" {
+		return e.ErrorType
+	}
+
+	return e.Type[strings.LastIndex(e.Type, ".")+1:]
+}
@@ -0,0 +1,90 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type roundTripper struct {
+	p  *Provider
+	rt soap.RoundTripper
+}
+
+// RoundTripper returns a soap.RoundTripper that starts a span for each method call sent via rt.
+func RoundTripper(p *Provider, rt soap.RoundTripper) soap.RoundTripper {
+	return &roundTripper{p: p, rt: rt}
+}
+
+func (t *roundTripper) Telemetry() *Provider {
+	return t.p
+}
+
+func (t *roundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	name, this := method(req)
+
+	attrs := []Attribute{String(AttrSystem, "vmware.vim"), String(AttrMethod, name)}
+	if this != nil {
+		attrs = append(attrs, String(AttrObjectType, this.Type), String(AttrObjectValue, this.Value))
+	}
+
+	ctx, span := t.p.start(ctx, KindSOAP, name, attrs...)
+	defer span.End()
+
+	err := t.rt.RoundTrip(ctx, req, res)
+	if err != nil {
+		span.RecordError(err)
+		span.setFault(faultName(err))
+	}
+
+	return err
+}
+
+// method returns the method name and target object of the given request,
+// using the Req field of the generated methods.*Body types.
+func method(req soap.HasFault) (string, *types.ManagedObjectReference) {
+	v := reflect.ValueOf(req)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Struct {
+		if r := v.FieldByName("Req"); r.IsValid() && r.Kind() == reflect.Pointer && !r.IsNil() {
+			r = r.Elem()
+			var this *types.ManagedObjectReference
+			if f := r.FieldByName("This"); f.IsValid() {
+				if ref, ok := f.Interface().(types.ManagedObjectReference); ok {
+					this = &ref
+				}
+			}
+			return r.Type().Name(), this
+		}
+	}
+
+	return strings.TrimSuffix(v.Type().Name(), "Body"), nil
+}
+
+// faultName returns the vim fault type name of err, if any.
+func faultName(err error) string {
+	var f any
+
+	switch {
+	case soap.IsSoapFault(err):
+		f = soap.ToSoapFault(err).VimFault()
+	case soap.IsVimFault(err):
+		f = soap.ToVimFault(err)
+	}
+
+	if f == nil {
+		return ""
+	}
+
+	return reflect.Indirect(reflect.ValueOf(f)).Type().Name()
+}
@@ -0,0 +1,76 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+)
+
+// Counter aggregates the calls recorded for a given name.
+type Counter struct {
+	Count    int64
+	Errors   int64
+	Duration time.Duration    // Duration is the total duration of all calls
+	Max      time.Duration    // Max is the longest duration of any call
+	Faults   map[string]int64 // Faults counts errors by fault name
+}
+
+// Stats is a Meter that keeps per-call counters in memory.
+type Stats struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+// Record implements the Meter interface.
+func (s *Stats) Record(_ context.Context, call Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counters == nil {
+		s.counters = make(map[string]*Counter)
+	}
+
+	c, ok := s.counters[call.Name]
+	if !ok {
+		c = &Counter{Faults: make(map[string]int64)}
+		s.counters[call.Name] = c
+	}
+
+	c.Count++
+	c.Duration += call.Duration
+	c.Max = max(c.Max, call.Duration)
+
+	if call.Err != nil {
+		c.Errors++
+		if call.Fault != "" {
+			c.Faults[call.Fault]++
+		}
+	}
+}
+
+// Counters returns a copy of the counters, keyed by call name.
+func (s *Stats) Counters() map[string]Counter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counters := make(map[string]Counter, len(s.counters))
+	for name, c := range s.counters {
+		cc := *c
+		cc.Faults = maps.Clone(c.Faults)
+		counters[name] = cc
+	}
+
+	return counters
+}
+
+// Reset clears all counters.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters = nil
+}
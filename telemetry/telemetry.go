@@ -0,0 +1,215 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+/*
+Package telemetry provides opt-in tracing and metrics instrumentation for
+vim25 SOAP and vAPI REST clients.
+
+The Tracer and Meter interfaces are intentionally small, such that they can be
+implemented by an adapter for OpenTelemetry or any other observability
+library, without govmomi depending on one.
+
+	p := &telemetry.Provider{Tracer: myTracer, Meter: new(telemetry.Stats)}
+	telemetry.Instrument(c, p) // c is a *vim25.Client
+
+Once instrumented, a span is started for each SOAP method call and REST
+request, including rest.Client instances created from c. Waiting on a task or
+property collector updates starts a parent span for the polling calls it makes,
+propagated via the Context.
+*/
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// Attribute keys
+const (
+	AttrSystem      = "rpc.system"
+	AttrMethod      = "rpc.method"
+	AttrObjectType  = "vim.object.type"
+	AttrObjectValue = "vim.object.value"
+	AttrFault       = "vim.fault"
+	AttrHTTPMethod  = "http.request.method"
+	AttrHTTPRoute   = "http.route"
+	AttrHTTPStatus  = "http.response.status_code"
+	AttrTaskState   = "vim.task.state"
+	AttrPollCount   = "vim.poll.count"
+)
+
+// Call kinds
+const (
+	KindSOAP = "soap"
+	KindREST = "rest"
+	KindWait = "wait"
+)
+
+// Attribute is a key value pair attached to a Span or Call.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// String returns an Attribute with a string value.
+func String(key, val string) Attribute {
+	return Attribute{Key: key, Value: val}
+}
+
+// Int returns an Attribute with an int value.
+func Int(key string, val int) Attribute {
+	return Attribute{Key: key, Value: val}
+}
+
+// Span represents an operation, such as a SOAP method call or REST request.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans, an implementation is expected to propagate the span via the returned Context,
+// such that spans started with that Context are children of the span.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Call is passed to Meter.Record when a SOAP method call, REST request or wait operation completes.
+type Call struct {
+	Kind       string
+	Name       string
+	Duration   time.Duration
+	Err        error
+	Fault      string // Fault is the vim fault or vAPI error type name, if any
+	Attributes []Attribute
+}
+
+// Meter records call latency and errors.
+type Meter interface {
+	Record(ctx context.Context, call Call)
+}
+
+// Provider combines the Tracer and Meter used for instrumentation, either of which may be nil.
+type Provider struct {
+	Tracer Tracer
+	Meter  Meter
+}
+
+// start starts a span, recording the Call when the span is ended.
+func (p *Provider) start(ctx context.Context, kind, name string, attrs ...Attribute) (context.Context, *span) {
+	s := &span{p: p, kind: kind, name: name, attrs: attrs, start: time.Now()}
+	if p.Tracer != nil {
+		ctx, s.Span = p.Tracer.Start(ctx, name, attrs...)
+	}
+	return ctx, s
+}
+
+// span wraps the Tracer Span, also recording the Call when ended.
+type span struct {
+	Span
+
+	p     *Provider
+	kind  string
+	name  string
+	attrs []Attribute
+	err   error
+	fault string
+	start time.Time
+}
+
+func (s *span) SetAttributes(attrs ...Attribute) {
+	s.attrs = append(s.attrs, attrs...)
+	if s.Span != nil {
+		s.Span.SetAttributes(attrs...)
+	}
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.err = err
+	if s.Span != nil {
+		s.Span.RecordError(err)
+	}
+}
+
+func (s *span) End() {
+	if s.Span != nil {
+		s.Span.End()
+	}
+	if s.p.Meter != nil {
+		s.p.Meter.Record(context.Background(), Call{
+			Kind:       s.kind,
+			Name:       s.name,
+			Duration:   time.Since(s.start),
+			Err:        s.err,
+			Fault:      s.fault,
+			Attributes: s.attrs,
+		})
+	}
+}
+
+func (s *span) setFault(name string) {
+	if name != "" {
+		s.fault = name
+		s.SetAttributes(String(AttrFault, name))
+	}
+}
+
+type noop struct{}
+
+func (noop) SetAttributes(...Attribute) {}
+func (noop) RecordError(error)          {}
+func (noop) End()                       {}
+
+// instrumented is implemented by the RoundTripper returned by the RoundTripper func.
+type instrumented interface {
+	Telemetry() *Provider
+}
+
+// unwrapper is implemented by soap.RoundTripper wrappers, such as vim25.Throttler and keepalive.HandlerSOAP,
+// returning the RoundTripper they wrap.
+type unwrapper interface {
+	Unwrap() soap.RoundTripper
+}
+
+// FromRoundTripper returns the Provider used to instrument rt, or nil if rt is not instrumented.
+// If rt is a *vim25.Client, its RoundTripper field is checked.
+// Wrappers of the instrumented RoundTripper are unwrapped via their Unwrap() soap.RoundTripper method.
+func FromRoundTripper(rt soap.RoundTripper) *Provider {
+	if c, ok := rt.(*vim25.Client); ok {
+		rt = c.RoundTripper
+	}
+	for rt != nil {
+		switch t := rt.(type) {
+		case instrumented:
+			return t.Telemetry()
+		case unwrapper:
+			rt = t.Unwrap()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// Start starts a span for an operation that spans multiple calls, such as waiting for a task.
+// If p is nil, a no-op Span is returned.
+func Start(ctx context.Context, p *Provider, name string, attrs ...Attribute) (context.Context, Span) {
+	if p == nil {
+		return ctx, noop{}
+	}
+	return p.start(ctx, KindWait, name, attrs...)
+}
+
+// Instrument wraps the vim25.Client RoundTripper with instrumentation using the given Provider.
+// rest.NewClient and the task and property wait functions find the Provider via the client's RoundTripper field,
+// any RoundTripper that wraps it further must implement an Unwrap() soap.RoundTripper method.
+func Instrument(c *vim25.Client, p *Provider) {
+	c.RoundTripper = RoundTripper(p, c.RoundTripper)
+}
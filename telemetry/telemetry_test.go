@@ -0,0 +1,215 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/telemetry"
+	"github.com/vmware/govmomi/vapi/rest"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+type span struct {
+	name   string
+	parent *span
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+// tracer records spans, propagating the parent via Context
+type tracer struct {
+	sync.Mutex
+	spans []*span
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...telemetry.Attribute) (context.Context, telemetry.Span) {
+	t.Lock()
+	defer t.Unlock()
+
+	s := &span{name: name, attrs: make(map[string]any)}
+	s.parent, _ = ctx.Value(spanKey{}).(*span)
+	s.SetAttributes(attrs...)
+	t.spans = append(t.spans, s)
+
+	return context.WithValue(ctx, spanKey{}, s), &tracedSpan{t, s}
+}
+
+func (t *tracer) find(name string) []*span {
+	t.Lock()
+	defer t.Unlock()
+
+	var spans []*span
+	for _, s := range t.spans {
+		if s.name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func (s *span) SetAttributes(attrs ...telemetry.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+type tracedSpan struct {
+	t *tracer
+	s *span
+}
+
+func (s *tracedSpan) SetAttributes(attrs ...telemetry.Attribute) {
+	s.t.Lock()
+	defer s.t.Unlock()
+	s.s.SetAttributes(attrs...)
+}
+
+func (s *tracedSpan) RecordError(err error) {
+	s.t.Lock()
+	defer s.t.Unlock()
+	s.s.err = err
+}
+
+func (s *tracedSpan) End() {
+	s.t.Lock()
+	defer s.t.Unlock()
+	s.s.ended = true
+}
+
+func TestInstrument(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		traces := new(tracer)
+		stats := new(telemetry.Stats)
+		telemetry.Instrument(c, &telemetry.Provider{Tracer: traces, Meter: stats})
+
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		spans := traces.find("PowerOffVM_Task")
+		require.Len(t, spans, 1)
+		assert.True(t, spans[0].ended)
+		assert.Equal(t, "VirtualMachine", spans[0].attrs[telemetry.AttrObjectType])
+		assert.Equal(t, vm.Reference().Value, spans[0].attrs[telemetry.AttrObjectValue])
+
+		// task wait -> property collector polls -> WaitForUpdatesEx calls
+		waits := traces.find("task.Wait")
+		require.Len(t, waits, 1)
+		assert.Equal(t, string(types.TaskInfoStateSuccess), waits[0].attrs[telemetry.AttrTaskState])
+
+		polls := traces.find("property.WaitForUpdatesEx")
+		require.Len(t, polls, 1)
+		assert.Equal(t, waits[0], polls[0].parent)
+		assert.NotZero(t, polls[0].attrs[telemetry.AttrPollCount])
+
+		calls := traces.find("WaitForUpdatesEx")
+		require.NotEmpty(t, calls)
+		for _, call := range calls {
+			assert.Equal(t, polls[0], call.parent)
+		}
+
+		// fault
+		ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-enoent"}
+		_, err = object.NewVirtualMachine(c, ref).PowerOn(ctx)
+		require.Error(t, err)
+
+		spans = traces.find("PowerOnVM_Task")
+		require.Len(t, spans, 1)
+		assert.Error(t, spans[0].err)
+		assert.Equal(t, "ManagedObjectNotFound", spans[0].attrs[telemetry.AttrFault])
+
+		counters := stats.Counters()
+		assert.Equal(t, int64(1), counters["PowerOffVM_Task"].Count)
+		assert.Equal(t, int64(1), counters["PowerOnVM_Task"].Errors)
+		assert.Equal(t, int64(1), counters["PowerOnVM_Task"].Faults["ManagedObjectNotFound"])
+		assert.Equal(t, int64(len(calls)), counters["WaitForUpdatesEx"].Count)
+
+		// rest.Client inherits instrumentation
+		rc := rest.NewClient(c)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+
+		m := tags.NewManager(rc)
+		for range 2 {
+			_, err = m.CreateCategory(ctx, &tags.Category{Name: "dup"})
+		}
+		require.Error(t, err)
+
+		name := "POST /rest/com/vmware/cis/tagging/category"
+		spans = traces.find(name)
+		require.Len(t, spans, 2)
+		assert.Equal(t, http.StatusBadRequest, spans[1].attrs[telemetry.AttrHTTPStatus])
+		assert.Equal(t, "already_exists", spans[1].attrs[telemetry.AttrFault])
+		assert.Equal(t, int64(1), stats.Counters()[name].Faults["already_exists"])
+
+		// the body can still be read by the client
+		assert.ErrorContains(t, err, "already_exists")
+	})
+}
+
+func TestInstrumentWrapped(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		traces := new(tracer)
+		p := &telemetry.Provider{Tracer: traces}
+		telemetry.Instrument(c, p)
+		c.RoundTripper = vim25.Throttle(c.RoundTripper, vim25.ThrottleOptions{MaxInFlight: 4})
+
+		assert.Equal(t, p, telemetry.FromRoundTripper(c))
+
+		vm, err := find.NewFinder(c).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+
+		task, err := vm.PowerOff(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		waits := traces.find("task.Wait")
+		require.Len(t, waits, 1)
+		polls := traces.find("property.WaitForUpdatesEx")
+		require.Len(t, polls, 1)
+		assert.Equal(t, waits[0], polls[0].parent)
+
+		rc := rest.NewClient(c)
+		require.NoError(t, rc.Login(ctx, simulator.DefaultLogin))
+		assert.NotEmpty(t, traces.find("POST /rest/com/vmware/cis/session"))
+	})
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		url   string
+		route string
+	}{
+		{"/api/vcenter/vm", "/api/vcenter/vm"},
+		{"/api/vcenter/vm/vm-42/power?action=stop", "/api/vcenter/vm/{id}/power?action=stop"},
+		{"/rest/com/vmware/cis/session", "/rest/com/vmware/cis/session"},
+		{"/rest/com/vmware/cis/tagging/tag-association/id:urn:vmomi:Tag?~action=attach", "/rest/com/vmware/cis/tagging/tag-association/{id}?~action=attach"},
+	}
+
+	for _, test := range tests {
+		u, err := url.Parse(test.url)
+		require.NoError(t, err)
+		assert.Equal(t, test.route, telemetry.Route(&http.Request{URL: u}))
+	}
+}
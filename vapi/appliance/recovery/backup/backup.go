@@ -0,0 +1,204 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+const (
+	Path          = "/api/appliance/recovery/backup"
+	JobPath       = Path + "/job"
+	PartsPath     = Path + "/parts"
+	SchedulesPath = Path + "/schedules"
+	Action        = "action"
+)
+
+// Location types supported by the appliance backup service.
+const (
+	LocationTypeFTP   = "FTP"
+	LocationTypeFTPS  = "FTPS"
+	LocationTypeHTTP  = "HTTP"
+	LocationTypeHTTPS = "HTTPS"
+	LocationTypeSCP   = "SCP"
+	LocationTypeSFTP  = "SFTP"
+	LocationTypeNFS   = "NFS"
+	LocationTypeSMB   = "SMB"
+)
+
+// Job states.
+const (
+	StateFailed     = "FAILED"
+	StateInProgress = "INPROGRESS"
+	StateNone       = "NONE"
+	StateSucceeded  = "SUCCEEDED"
+)
+
+// Job types.
+const (
+	TypeManual    = "MANUAL"
+	TypeScheduled = "SCHEDULED"
+)
+
+// Validation result statuses.
+const (
+	StatusOK      = "OK"
+	StatusWarning = "WARNING"
+	StatusError   = "ERROR"
+)
+
+// LocationType returns the location type for the given backup location URL, based on the URL scheme.
+func LocationType(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+
+	kind := strings.ToUpper(u.Scheme)
+	switch kind {
+	case LocationTypeFTP, LocationTypeFTPS, LocationTypeHTTP, LocationTypeHTTPS,
+		LocationTypeSCP, LocationTypeSFTP, LocationTypeNFS, LocationTypeSMB:
+		return kind, nil
+	}
+
+	return "", fmt.Errorf("unsupported backup location scheme %q", u.Scheme)
+}
+
+// Manager provides convenience methods to backup and restore the appliance.
+type Manager struct {
+	*rest.Client
+}
+
+// NewManager creates a new Manager with the given client
+func NewManager(client *rest.Client) *Manager {
+	return &Manager{
+		Client: client,
+	}
+}
+
+// Part describes a backup part, a group of appliance data that can be included in a backup.
+type Part struct {
+	ID                string                  `json:"id"`
+	Name              rest.LocalizableMessage `json:"name"`
+	Description       rest.LocalizableMessage `json:"description"`
+	SelectedByDefault bool                    `json:"selected_by_default"`
+	Optional          bool                    `json:"optional"`
+}
+
+// Request describes a backup of the appliance.
+type Request struct {
+	Parts            []string `json:"parts"`
+	BackupPassword   string   `json:"backup_password,omitempty"`
+	LocationType     string   `json:"location_type"`
+	Location         string   `json:"location"`
+	LocationUser     string   `json:"location_user,omitempty"`
+	LocationPassword string   `json:"location_password,omitempty"`
+	Comment          string   `json:"comment,omitempty"`
+}
+
+// ReturnResult is the result of a backup validation or cancel request.
+type ReturnResult struct {
+	Status   string                    `json:"status"`
+	Messages []rest.LocalizableMessage `json:"messages"`
+}
+
+// JobStatus contains the status of a backup job.
+type JobStatus struct {
+	ID        string                    `json:"id"`
+	State     string                    `json:"state"`
+	Messages  []rest.LocalizableMessage `json:"messages"`
+	Progress  int64                     `json:"progress"`
+	StartTime time.Time                 `json:"start_time"`
+	EndTime   *time.Time                `json:"end_time,omitempty"`
+}
+
+// Err returns the first message of a FAILED job as an error, otherwise nil.
+func (s *JobStatus) Err() error {
+	if s.State != StateFailed {
+		return nil
+	}
+	if len(s.Messages) != 0 {
+		return &s.Messages[0]
+	}
+	return fmt.Errorf("backup job %s failed", s.ID)
+}
+
+// ListParts returns the backup parts supported by the appliance.
+func (m *Manager) ListParts(ctx context.Context) ([]Part, error) {
+	r := m.Resource(PartsPath)
+	var res []Part
+	return res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// PartSize returns the estimated size of the given backup part in kilobytes.
+func (m *Manager) PartSize(ctx context.Context, part string) (int64, error) {
+	r := m.Resource(PartsPath).WithSubpath(part)
+	var res int64
+	return res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// Validate checks that a backup can be created with the given request.
+func (m *Manager) Validate(ctx context.Context, req Request) (*ReturnResult, error) {
+	r := m.Resource(Path).WithParam(Action, "validate")
+	var res ReturnResult
+	return &res, m.Do(ctx, r.Request(http.MethodPost, req), &res)
+}
+
+// CreateJob starts a backup job and returns its status.
+func (m *Manager) CreateJob(ctx context.Context, req Request) (*JobStatus, error) {
+	r := m.Resource(JobPath)
+	var res JobStatus
+	return &res, m.Do(ctx, r.Request(http.MethodPost, req), &res)
+}
+
+// ListJobs returns the identifiers of the backup jobs.
+func (m *Manager) ListJobs(ctx context.Context) ([]string, error) {
+	r := m.Resource(JobPath)
+	var res []string
+	return res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// GetJob returns the status of the given backup job.
+func (m *Manager) GetJob(ctx context.Context, id string) (*JobStatus, error) {
+	r := m.Resource(JobPath).WithSubpath(id)
+	var res JobStatus
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// CancelJob cancels the given backup job.
+func (m *Manager) CancelJob(ctx context.Context, id string) (*ReturnResult, error) {
+	r := m.Resource(JobPath).WithSubpath(id).WithParam(Action, "cancel")
+	var res ReturnResult
+	return &res, m.Do(ctx, r.Request(http.MethodPost), &res)
+}
+
+// WaitForJob blocks until the given backup job is no longer in progress,
+// polling its status at the given interval.
+// An error is returned if the job FAILED.
+func (m *Manager) WaitForJob(ctx context.Context, id string, interval time.Duration) (*JobStatus, error) {
+	for {
+		s, err := m.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.State != StateInProgress {
+			return s, s.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return s, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
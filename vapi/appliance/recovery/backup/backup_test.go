@@ -0,0 +1,356 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+
+	_ "github.com/vmware/govmomi/vapi/appliance/simulator"
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+const interval = 10 * time.Millisecond
+
+// fileServer is an in-memory backup location for HTTP based backups
+type fileServer struct {
+	sync.Mutex
+	files map[string][]byte
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	if user, password, _ := r.BasicAuth(); user != "backup" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.files[r.URL.Path], _ = io.ReadAll(r.Body)
+	case http.MethodGet:
+		data, ok := s.files[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case http.MethodDelete:
+		delete(s.files, r.URL.Path)
+	}
+}
+
+func TestLocationType(t *testing.T) {
+	tests := []struct {
+		location string
+		kind     string
+	}{
+		{"sftp://backup.example.com/vcsa", backup.LocationTypeSFTP},
+		{"https://backup.example.com/vcsa", backup.LocationTypeHTTPS},
+		{"nfs://backup.example.com/exports/vcsa", backup.LocationTypeNFS},
+		{"smb://backup.example.com/vcsa", backup.LocationTypeSMB},
+	}
+
+	for _, test := range tests {
+		kind, err := backup.LocationType(test.location)
+		require.NoError(t, err)
+		assert.Equal(t, test.kind, kind)
+	}
+
+	_, err := backup.LocationType("/tmp/vcsa")
+	assert.Error(t, err)
+}
+
+func TestBackupRestore(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := backup.NewManager(c)
+
+		parts, err := m.ListParts(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, parts)
+
+		var ids []string
+		for _, part := range parts {
+			ids = append(ids, part.ID)
+			size, err := m.PartSize(ctx, part.ID)
+			require.NoError(t, err)
+			assert.NotZero(t, size)
+		}
+
+		_, err = m.PartSize(ctx, "enoent")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		dir := t.TempDir()
+		req := backup.Request{
+			Parts:          ids,
+			BackupPassword: "VMware1!",
+			LocationType:   backup.LocationTypeSFTP,
+			Location:       "sftp://backup.example.com" + filepath.ToSlash(dir),
+			Comment:        "before upgrade",
+		}
+
+		res, err := m.Validate(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StatusOK, res.Status)
+
+		_, err = m.Validate(ctx, backup.Request{Parts: []string{"enoent"}, LocationType: req.LocationType, Location: req.Location})
+		assert.ErrorContains(t, err, "INVALID_ARGUMENT")
+
+		job, err := m.CreateJob(ctx, req)
+		require.NoError(t, err)
+		assert.NotEmpty(t, job.ID)
+
+		job, err = m.WaitForJob(ctx, job.ID, interval)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StateSucceeded, job.State)
+		assert.Equal(t, int64(100), job.Progress)
+		require.NotNil(t, job.EndTime)
+
+		jobs, err := m.ListJobs(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{job.ID}, jobs)
+
+		_, err = m.CancelJob(ctx, job.ID)
+		assert.ErrorContains(t, err, "NOT_ALLOWED_IN_CURRENT_STATE")
+
+		for _, id := range ids {
+			_, err = os.Stat(filepath.Join(dir, id+".tar.gz"))
+			assert.NoError(t, err)
+		}
+
+		res, err = m.Validate(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StatusWarning, res.Status)
+
+		restore := backup.RestoreRequest{
+			BackupPassword: "invalid",
+			LocationType:   req.LocationType,
+			Location:       req.Location,
+		}
+
+		_, err = m.ValidateRestore(ctx, restore)
+		assert.ErrorContains(t, err, "INVALID_ARGUMENT")
+
+		restore.BackupPassword = req.BackupPassword
+		meta, err := m.ValidateRestore(ctx, restore)
+		require.NoError(t, err)
+		assert.True(t, meta.Applicable)
+		assert.Equal(t, req.Comment, meta.Comment)
+		assert.Equal(t, ids, meta.Parts)
+
+		status, err := m.GetRestoreJob(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StateNone, status.State)
+
+		_, err = m.Restore(ctx, restore)
+		require.NoError(t, err)
+
+		status, err = m.WaitForRestoreJob(ctx, interval)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StateSucceeded, status.State)
+
+		// a missing part fails the restore job
+		require.NoError(t, os.Remove(filepath.Join(dir, ids[0]+".tar.gz")))
+		_, err = m.Restore(ctx, restore)
+		require.NoError(t, err)
+
+		status, err = m.WaitForRestoreJob(ctx, interval)
+		require.Error(t, err)
+		assert.Equal(t, backup.StateFailed, status.State)
+	})
+}
+
+func TestBackupHTTP(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := backup.NewManager(c)
+
+		fs := &fileServer{files: make(map[string][]byte)}
+		s := httptest.NewServer(fs)
+		defer s.Close()
+
+		req := backup.Request{
+			LocationType:     backup.LocationTypeHTTP,
+			Location:         s.URL + "/vcsa",
+			LocationUser:     "backup",
+			LocationPassword: "invalid",
+		}
+
+		res, err := m.Validate(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, backup.StatusError, res.Status)
+
+		job, err := m.CreateJob(ctx, req)
+		require.NoError(t, err)
+		job, err = m.WaitForJob(ctx, job.ID, interval)
+		assert.Error(t, err)
+		assert.Equal(t, backup.StateFailed, job.State)
+
+		req.LocationPassword = "secret"
+		job, err = m.CreateJob(ctx, req)
+		require.NoError(t, err)
+		_, err = m.WaitForJob(ctx, job.ID, interval)
+		require.NoError(t, err)
+
+		fs.Lock()
+		assert.Contains(t, fs.files, "/vcsa/common.tar.gz")
+		assert.NotContains(t, fs.files, "/vcsa/seat.tar.gz") // optional part, not requested
+		fs.Unlock()
+
+		_, err = m.Restore(ctx, backup.RestoreRequest{
+			LocationType:     req.LocationType,
+			Location:         req.Location,
+			LocationUser:     req.LocationUser,
+			LocationPassword: req.LocationPassword,
+		})
+		require.NoError(t, err)
+
+		_, err = m.WaitForRestoreJob(ctx, interval)
+		require.NoError(t, err)
+	})
+}
+
+func TestSchedule(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := backup.NewManager(c)
+		dir := t.TempDir()
+
+		spec := backup.ScheduleCreateSpec{
+			Location: "nfs://backup.example.com" + filepath.ToSlash(dir),
+			RecurrenceInfo: &backup.RecurrenceInfo{
+				Hour: 2,
+				Days: []string{backup.Saturday, backup.Sunday},
+			},
+			RetentionInfo: &backup.RetentionInfo{MaxCount: 2},
+		}
+
+		require.NoError(t, m.CreateSchedule(ctx, "default", spec))
+		assert.ErrorContains(t, m.CreateSchedule(ctx, "default", spec), "ALREADY_EXISTS")
+
+		schedules, err := m.ListSchedules(ctx)
+		require.NoError(t, err)
+		require.Contains(t, schedules, "default")
+		assert.True(t, schedules["default"].Enable)
+		assert.Equal(t, []string{"common"}, schedules["default"].Parts)
+
+		disable := false
+		require.NoError(t, m.UpdateSchedule(ctx, "default", backup.ScheduleUpdateSpec{
+			Enable:        &disable,
+			RetentionInfo: &backup.RetentionInfo{MaxCount: 1},
+		}))
+
+		info, err := m.GetSchedule(ctx, "default")
+		require.NoError(t, err)
+		assert.False(t, info.Enable)
+		assert.Equal(t, int64(1), info.RetentionInfo.MaxCount)
+		assert.Equal(t, int64(2), info.RecurrenceInfo.Hour)
+
+		for i := 0; i < 3; i++ {
+			job, err := m.RunSchedule(ctx, "default", "")
+			require.NoError(t, err)
+			_, err = m.WaitForJob(ctx, job.ID, interval)
+			require.NoError(t, err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1) // retention
+		assert.True(t, strings.HasPrefix(entries[0].Name(), "S_"))
+
+		require.NoError(t, m.DeleteSchedule(ctx, "default"))
+		_, err = m.GetSchedule(ctx, "default")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		// ids are escaped once in the request path
+		require.NoError(t, m.CreateSchedule(ctx, "my schedule", spec))
+		schedules, err = m.ListSchedules(ctx)
+		require.NoError(t, err)
+		assert.Contains(t, schedules, "my schedule")
+		_, err = m.GetSchedule(ctx, "my schedule")
+		require.NoError(t, err)
+		require.NoError(t, m.DeleteSchedule(ctx, "my schedule"))
+	})
+}
+
+func TestScheduleRetention(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := backup.NewManager(c)
+
+		fs := &fileServer{files: make(map[string][]byte)}
+		s := httptest.NewServer(fs)
+		defer s.Close()
+
+		files := func() int {
+			fs.Lock()
+			defer fs.Unlock()
+			return len(fs.files)
+		}
+
+		run := func() error {
+			job, err := m.RunSchedule(ctx, "default", "")
+			require.NoError(t, err)
+			_, err = m.WaitForJob(ctx, job.ID, interval)
+			return err
+		}
+
+		require.NoError(t, m.CreateSchedule(ctx, "default", backup.ScheduleCreateSpec{
+			Location:         s.URL + "/vcsa",
+			LocationUser:     "backup",
+			LocationPassword: "secret",
+			RetentionInfo:    &backup.RetentionInfo{MaxCount: 1},
+		}))
+
+		require.NoError(t, run())
+		n := files()
+		assert.NotZero(t, n)
+
+		// a failed job does not remove previous backups
+		require.NoError(t, m.UpdateSchedule(ctx, "default", backup.ScheduleUpdateSpec{LocationPassword: "invalid"}))
+		assert.Error(t, run())
+		assert.Equal(t, n, files())
+
+		// previous backups are removed from their own location, along with their own parts
+		dir := t.TempDir()
+		require.NoError(t, m.UpdateSchedule(ctx, "default", backup.ScheduleUpdateSpec{
+			Location: "nfs://backup.example.com" + filepath.ToSlash(dir),
+			Parts:    []string{"common", "seat"},
+		}))
+		require.NoError(t, run())
+		assert.Zero(t, files())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+}
@@ -0,0 +1,110 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+const (
+	RestorePath    = "/api/appliance/recovery/restore"
+	RestoreJobPath = RestorePath + "/job"
+)
+
+// RestoreRequest describes a restore of the appliance from a backup.
+type RestoreRequest struct {
+	BackupPassword       string `json:"backup_password,omitempty"`
+	LocationType         string `json:"location_type"`
+	Location             string `json:"location"`
+	LocationUser         string `json:"location_user,omitempty"`
+	LocationPassword     string `json:"location_password,omitempty"`
+	SSOAdminUserName     string `json:"sso_admin_user_name,omitempty"`
+	SSOAdminUserPassword string `json:"sso_admin_user_password,omitempty"`
+	IgnoreWarnings       *bool  `json:"ignore_warnings,omitempty"`
+}
+
+// Metadata describes a backup, as read from the backup location.
+type Metadata struct {
+	TimeStamp  time.Time                 `json:"timestamp"`
+	Parts      []string                  `json:"parts"`
+	Version    string                    `json:"version"`
+	BoxName    string                    `json:"boxname"`
+	Comment    string                    `json:"comment"`
+	Applicable bool                      `json:"applicable"`
+	Messages   []rest.LocalizableMessage `json:"messages"`
+}
+
+// RestoreJobStatus contains the status of the restore job.
+type RestoreJobStatus struct {
+	State    string                    `json:"state"`
+	Messages []rest.LocalizableMessage `json:"messages"`
+	Progress int64                     `json:"progress"`
+}
+
+// Err returns the first message of a FAILED restore job as an error, otherwise nil.
+func (s *RestoreJobStatus) Err() error {
+	if s.State != StateFailed {
+		return nil
+	}
+	if len(s.Messages) != 0 {
+		return &s.Messages[0]
+	}
+	return fmt.Errorf("restore job failed")
+}
+
+// ValidateRestore reads the backup metadata from the request location and checks that it can be restored.
+func (m *Manager) ValidateRestore(ctx context.Context, req RestoreRequest) (*Metadata, error) {
+	r := m.Resource(RestorePath).WithParam(Action, "validate")
+	var res Metadata
+	return &res, m.Do(ctx, r.Request(http.MethodPost, req), &res)
+}
+
+// Restore starts the restore job.
+func (m *Manager) Restore(ctx context.Context, req RestoreRequest) (*RestoreJobStatus, error) {
+	r := m.Resource(RestoreJobPath)
+	var res RestoreJobStatus
+	return &res, m.Do(ctx, r.Request(http.MethodPost, req), &res)
+}
+
+// GetRestoreJob returns the status of the restore job.
+func (m *Manager) GetRestoreJob(ctx context.Context) (*RestoreJobStatus, error) {
+	r := m.Resource(RestoreJobPath)
+	var res RestoreJobStatus
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// CancelRestoreJob cancels the restore job.
+func (m *Manager) CancelRestoreJob(ctx context.Context) (*ReturnResult, error) {
+	r := m.Resource(RestoreJobPath).WithParam(Action, "cancel")
+	var res ReturnResult
+	return &res, m.Do(ctx, r.Request(http.MethodPost), &res)
+}
+
+// WaitForRestoreJob blocks until the restore job is no longer in progress,
+// polling its status at the given interval.
+// An error is returned if the job FAILED.
+func (m *Manager) WaitForRestoreJob(ctx context.Context, interval time.Duration) (*RestoreJobStatus, error) {
+	for {
+		s, err := m.GetRestoreJob(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.State != StateInProgress {
+			return s, s.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return s, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
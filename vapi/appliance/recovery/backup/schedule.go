@@ -0,0 +1,115 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package backup
+
+import (
+	"context"
+	"net/http"
+)
+
+// Days of the week for RecurrenceInfo.
+const (
+	Monday    = "MONDAY"
+	Tuesday   = "TUESDAY"
+	Wednesday = "WEDNESDAY"
+	Thursday  = "THURSDAY"
+	Friday    = "FRIDAY"
+	Saturday  = "SATURDAY"
+	Sunday    = "SUNDAY"
+)
+
+// RecurrenceInfo defines when a scheduled backup runs.
+// When Days is empty the backup runs every day.
+type RecurrenceInfo struct {
+	Minute int64    `json:"minute"`
+	Hour   int64    `json:"hour"`
+	Days   []string `json:"days,omitempty"`
+}
+
+// RetentionInfo defines how many scheduled backups are retained at the backup location.
+type RetentionInfo struct {
+	MaxCount int64 `json:"max_count"`
+}
+
+// ScheduleCreateSpec describes a backup schedule to be created.
+// The location type is derived from the Location URL scheme.
+type ScheduleCreateSpec struct {
+	Parts            []string        `json:"parts,omitempty"`
+	BackupPassword   string          `json:"backup_password,omitempty"`
+	Location         string          `json:"location"`
+	LocationUser     string          `json:"location_user,omitempty"`
+	LocationPassword string          `json:"location_password,omitempty"`
+	Enable           *bool           `json:"enable,omitempty"`
+	RecurrenceInfo   *RecurrenceInfo `json:"recurrence_info,omitempty"`
+	RetentionInfo    *RetentionInfo  `json:"retention_info,omitempty"`
+}
+
+// ScheduleUpdateSpec describes changes to a backup schedule.
+type ScheduleUpdateSpec struct {
+	Parts            []string        `json:"parts,omitempty"`
+	BackupPassword   string          `json:"backup_password,omitempty"`
+	Location         string          `json:"location,omitempty"`
+	LocationUser     string          `json:"location_user,omitempty"`
+	LocationPassword string          `json:"location_password,omitempty"`
+	Enable           *bool           `json:"enable,omitempty"`
+	RecurrenceInfo   *RecurrenceInfo `json:"recurrence_info,omitempty"`
+	RetentionInfo    *RetentionInfo  `json:"retention_info,omitempty"`
+}
+
+// ScheduleInfo contains information about a backup schedule.
+type ScheduleInfo struct {
+	Parts          []string        `json:"parts"`
+	Location       string          `json:"location"`
+	LocationUser   string          `json:"location_user,omitempty"`
+	Enable         bool            `json:"enable"`
+	RecurrenceInfo *RecurrenceInfo `json:"recurrence_info,omitempty"`
+	RetentionInfo  *RetentionInfo  `json:"retention_info,omitempty"`
+}
+
+func schedulePath(id string) string {
+	return SchedulesPath + "/" + id
+}
+
+// ListSchedules returns the backup schedules, keyed by schedule identifier.
+func (m *Manager) ListSchedules(ctx context.Context) (map[string]ScheduleInfo, error) {
+	r := m.Resource(SchedulesPath)
+	var res map[string]ScheduleInfo
+	return res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// CreateSchedule creates a backup schedule with the given identifier.
+func (m *Manager) CreateSchedule(ctx context.Context, id string, spec ScheduleCreateSpec) error {
+	r := m.Resource(schedulePath(id))
+	return m.Do(ctx, r.Request(http.MethodPost, spec), nil)
+}
+
+// GetSchedule returns the given backup schedule.
+func (m *Manager) GetSchedule(ctx context.Context, id string) (*ScheduleInfo, error) {
+	r := m.Resource(schedulePath(id))
+	var res ScheduleInfo
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// UpdateSchedule updates the given backup schedule.
+func (m *Manager) UpdateSchedule(ctx context.Context, id string, spec ScheduleUpdateSpec) error {
+	r := m.Resource(schedulePath(id))
+	return m.Do(ctx, r.Request(http.MethodPatch, spec), nil)
+}
+
+// DeleteSchedule deletes the given backup schedule.
+func (m *Manager) DeleteSchedule(ctx context.Context, id string) error {
+	r := m.Resource(schedulePath(id))
+	return m.Do(ctx, r.Request(http.MethodDelete), nil)
+}
+
+// RunSchedule starts a backup job for the given schedule, without waiting for its next recurrence.
+func (m *Manager) RunSchedule(ctx context.Context, id string, comment string) (*JobStatus, error) {
+	r := m.Resource(schedulePath(id)).WithParam(Action, "run")
+	spec := struct {
+		Comment string `json:"comment,omitempty"`
+	}{comment}
+	var res JobStatus
+	return &res, m.Do(ctx, r.Request(http.MethodPost, spec), &res)
+}
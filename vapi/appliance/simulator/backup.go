@@ -0,0 +1,864 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
+	"github.com/vmware/govmomi/vapi/rest"
+	vapi "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// backupMetadataFile is written to the backup location after all parts.
+const backupMetadataFile = "backup-metadata.json"
+
+var backupParts = []backup.Part{
+	{
+		ID:                "common",
+		Name:              rest.LocalizableMessage{DefaultMessage: "Common"},
+		Description:       rest.LocalizableMessage{DefaultMessage: "Inventory and configuration"},
+		SelectedByDefault: true,
+	},
+	{
+		ID:          "seat",
+		Name:        rest.LocalizableMessage{DefaultMessage: "Stats, Events, and Tasks"},
+		Description: rest.LocalizableMessage{DefaultMessage: "Historical data such as statistics, events and tasks"},
+		Optional:    true,
+	},
+}
+
+// backupMetadata is the simulator's backup-metadata.json format.
+// Digest is used to check the backup password on restore, in place of encrypting the parts.
+type backupMetadata struct {
+	backup.Metadata
+	Digest string `json:"digest,omitempty"`
+}
+
+// backupJob is a backup job along with its request
+type backupJob struct {
+	backup.JobStatus
+	req      backup.Request
+	canceled bool
+	done     func() // called with the recovery lock held when the job succeeds
+}
+
+// scheduledBackup is the target and parts of a completed scheduled backup
+type scheduledBackup struct {
+	target backupTarget
+	parts  []string
+}
+
+// backupSchedule is a backup schedule along with its credentials and its previous backups
+type backupSchedule struct {
+	backup.ScheduleInfo
+	password         string
+	locationPassword string
+	backups          []scheduledBackup
+}
+
+// recovery holds the state of the backup and restore simulator
+type recovery struct {
+	sync.Mutex
+	about     types.AboutInfo
+	seq       int
+	jobs      map[string]*backupJob
+	schedules map[string]*backupSchedule
+	restore   backup.RestoreJobStatus
+}
+
+func newRecovery() *recovery {
+	return &recovery{
+		jobs:      make(map[string]*backupJob),
+		schedules: make(map[string]*backupSchedule),
+		restore:   backup.RestoreJobStatus{State: backup.StateNone},
+	}
+}
+
+func digest(password string) string {
+	if password == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func message(format string, args ...any) rest.LocalizableMessage {
+	return rest.LocalizableMessage{DefaultMessage: fmt.Sprintf(format, args...)}
+}
+
+// backupTarget reads and writes the files of a backup.
+// HTTP and HTTPS locations are accessed with the PUT, GET and DELETE methods, for example against an in-process file server.
+// Any other location type is simulated using the location's URL path as a local directory.
+type backupTarget interface {
+	Put(name string, data []byte) error
+	Get(name string) ([]byte, error)
+	RemoveAll(names []string) error
+}
+
+type fileTarget struct {
+	dir string
+}
+
+func (t fileTarget) Put(name string, data []byte) error {
+	if err := os.MkdirAll(t.dir, 0750); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.dir, name), data, 0600)
+}
+
+func (t fileTarget) Get(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(t.dir, name))
+}
+
+func (t fileTarget) RemoveAll([]string) error {
+	return os.RemoveAll(t.dir)
+}
+
+type httpTarget struct {
+	u        *url.URL
+	user     string
+	password string
+	c        *http.Client
+}
+
+func (t httpTarget) do(method string, name string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, t.u.JoinPath(name).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if t.user != "" {
+		req.SetBasicAuth(t.user, t.password)
+	}
+
+	res, err := t.c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		if res.StatusCode == http.StatusNotFound {
+			return nil, os.ErrNotExist
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, req.URL, res.Status)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+func (t httpTarget) Put(name string, data []byte) error {
+	_, err := t.do(http.MethodPut, name, bytes.NewReader(data))
+	return err
+}
+
+func (t httpTarget) Get(name string) ([]byte, error) {
+	return t.do(http.MethodGet, name, nil)
+}
+
+func (t httpTarget) RemoveAll(names []string) error {
+	for _, name := range names {
+		if _, err := t.do(http.MethodDelete, name, nil); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func newBackupTarget(kind, location, user, password string) (backupTarget, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case backup.LocationTypeHTTP, backup.LocationTypeHTTPS:
+		c := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+		return httpTarget{u: u, user: user, password: password, c: c}, nil
+	case backup.LocationTypeFTP, backup.LocationTypeFTPS, backup.LocationTypeSCP,
+		backup.LocationTypeSFTP, backup.LocationTypeNFS, backup.LocationTypeSMB:
+		if u.Path == "" {
+			return nil, fmt.Errorf("backup location %q has no path", location)
+		}
+		return fileTarget{dir: filepath.FromSlash(u.Path)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported location type %q", kind)
+}
+
+// backupFiles returns the names of the files written for the given backup parts
+func backupFiles(parts []string) []string {
+	names := []string{backupMetadataFile}
+	for _, part := range parts {
+		names = append(names, part+".tar.gz")
+	}
+	return names
+}
+
+// archivePart creates the tar.gz archive for a backup part
+func archivePart(part string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	hdr := &tar.Header{
+		Name:    part + ".json",
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// extractPart reads back a tar.gz archive created by archivePart
+func extractPart(data []byte) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		_, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(io.Discard, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// validParts checks the requested parts and adds any that are not optional
+func validParts(parts []string) ([]string, bool) {
+	selected := make(map[string]bool)
+	for _, part := range parts {
+		selected[part] = true
+	}
+
+	var res []string
+	for _, part := range backupParts {
+		if selected[part.ID] || !part.Optional {
+			res = append(res, part.ID)
+			delete(selected, part.ID)
+		}
+	}
+
+	return res, len(selected) == 0
+}
+
+func (h *Handler) backupParts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	vapi.StatusOK(w, backupParts)
+}
+
+func (h *Handler) backupPart(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.PathValue("part")
+	for _, part := range backupParts {
+		if part.ID == id {
+			vapi.StatusOK(w, len(part.ID)*1024)
+			return
+		}
+	}
+
+	vapi.ApiErrorNotFound(w)
+}
+
+// backup handles backup validation.
+//
+//	POST /api/appliance/recovery/backup?action=validate
+func (h *Handler) backup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Query().Get(backup.Action) != "validate" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req backup.Request
+	if !vapi.Decode(r, w, &req) {
+		return
+	}
+
+	if _, ok := validParts(req.Parts); !ok {
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	t, err := newBackupTarget(req.LocationType, req.Location, req.LocationUser, req.LocationPassword)
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	res := backup.ReturnResult{Status: backup.StatusOK}
+
+	if _, err = t.Get(backupMetadataFile); err == nil {
+		res.Status = backup.StatusWarning
+		res.Messages = append(res.Messages, message("Backup location %s is not empty", req.Location))
+	} else if !errors.Is(err, os.ErrNotExist) {
+		res.Status = backup.StatusError
+		res.Messages = append(res.Messages, message("Backup location %s is not accessible: %s", req.Location, err))
+	}
+
+	vapi.StatusOK(w, res)
+}
+
+// jobInProgress returns true if a backup job is in progress.
+// Must be called with the recovery lock held.
+func (h *Handler) jobInProgress() bool {
+	for _, job := range h.recovery.jobs {
+		if job.State == backup.StateInProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// nextJobID returns a new backup job ID.
+// Must be called with the recovery lock held.
+func (h *Handler) nextJobID() string {
+	h.recovery.seq++
+	return fmt.Sprintf("%s-%d", time.Now().UTC().Format("20060102-150405"), h.recovery.seq)
+}
+
+// newJob creates a backup job and starts it in the background, calling done (if not nil) when the job succeeds.
+// Must be called with the recovery lock held.
+func (h *Handler) newJob(id string, req backup.Request, t backupTarget, kind string, done func()) *backupJob {
+	job := &backupJob{
+		JobStatus: backup.JobStatus{
+			ID:        id,
+			State:     backup.StateInProgress,
+			StartTime: time.Now().UTC(),
+		},
+		req:  req,
+		done: done,
+	}
+	h.recovery.jobs[job.ID] = job
+
+	go h.runJob(job, t, kind)
+
+	return job
+}
+
+func (h *Handler) runJob(job *backupJob, t backupTarget, kind string) {
+	fail := func(err error) {
+		h.recovery.Lock()
+		defer h.recovery.Unlock()
+		now := time.Now().UTC()
+		job.State = backup.StateFailed
+		job.EndTime = &now
+		job.Messages = append(job.Messages, message("%s", err))
+	}
+
+	meta := backupMetadata{
+		Metadata: backup.Metadata{
+			TimeStamp: job.StartTime,
+			Parts:     job.req.Parts,
+			Version:   h.recovery.about.Version,
+			BoxName:   h.recovery.about.InstanceUuid,
+			Comment:   job.req.Comment,
+		},
+		Digest: digest(job.req.BackupPassword),
+	}
+
+	for i, part := range job.req.Parts {
+		h.recovery.Lock()
+		canceled := job.canceled
+		h.recovery.Unlock()
+		if canceled {
+			fail(errors.New("backup job canceled"))
+			return
+		}
+
+		info, _ := json.Marshal(struct {
+			Part string    `json:"part"`
+			Type string    `json:"type"`
+			Time time.Time `json:"time"`
+		}{part, kind, job.StartTime})
+
+		data, err := archivePart(part, info)
+		if err == nil {
+			err = t.Put(part+".tar.gz", data)
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+
+		h.recovery.Lock()
+		job.Progress = int64((i + 1) * 100 / (len(job.req.Parts) + 1))
+		h.recovery.Unlock()
+	}
+
+	data, _ := json.Marshal(meta)
+	if err := t.Put(backupMetadataFile, data); err != nil {
+		fail(err)
+		return
+	}
+
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+	now := time.Now().UTC()
+	job.State = backup.StateSucceeded
+	job.Progress = 100
+	job.EndTime = &now
+	if job.done != nil {
+		job.done()
+	}
+}
+
+// backupJobs handles the backup job collection.
+//
+//	GET  /api/appliance/recovery/backup/job
+//	POST /api/appliance/recovery/backup/job
+func (h *Handler) backupJobs(w http.ResponseWriter, r *http.Request) {
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		ids := make([]string, 0, len(h.recovery.jobs))
+		for id := range h.recovery.jobs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		vapi.StatusOK(w, ids)
+	case http.MethodPost:
+		var req backup.Request
+		if !vapi.Decode(r, w, &req) {
+			return
+		}
+
+		parts, ok := validParts(req.Parts)
+		if !ok {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+		req.Parts = parts
+
+		t, err := newBackupTarget(req.LocationType, req.Location, req.LocationUser, req.LocationPassword)
+		if err != nil {
+			log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		if h.jobInProgress() {
+			vapi.ApiErrorNotAllowedInCurrentState(w)
+			return
+		}
+
+		job := h.newJob(h.nextJobID(), req, t, backup.TypeManual, nil)
+		vapi.StatusOK(w, job.JobStatus)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// backupJob handles a backup job.
+//
+//	GET  /api/appliance/recovery/backup/job/{id}
+//	POST /api/appliance/recovery/backup/job/{id}?action=cancel
+func (h *Handler) backupJob(w http.ResponseWriter, r *http.Request) {
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+
+	job, ok := h.recovery.jobs[r.PathValue("id")]
+	if !ok {
+		vapi.ApiErrorNotFound(w)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, job.JobStatus)
+	case http.MethodPost:
+		if r.URL.Query().Get(backup.Action) != "cancel" {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+		if job.State != backup.StateInProgress {
+			vapi.ApiErrorNotAllowedInCurrentState(w)
+			return
+		}
+		job.canceled = true
+		vapi.StatusOK(w, backup.ReturnResult{Status: backup.StatusOK})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// backupSchedules handles the backup schedule collection.
+//
+//	GET /api/appliance/recovery/backup/schedules
+func (h *Handler) backupSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+
+	res := make(map[string]backup.ScheduleInfo, len(h.recovery.schedules))
+	for id, s := range h.recovery.schedules {
+		res[id] = s.ScheduleInfo
+	}
+	vapi.StatusOK(w, res)
+}
+
+// backupSchedule handles a backup schedule.
+//
+//	POST   /api/appliance/recovery/backup/schedules/{schedule}
+//	GET    /api/appliance/recovery/backup/schedules/{schedule}
+//	PATCH  /api/appliance/recovery/backup/schedules/{schedule}
+//	DELETE /api/appliance/recovery/backup/schedules/{schedule}
+//	POST   /api/appliance/recovery/backup/schedules/{schedule}?action=run
+func (h *Handler) backupSchedule(w http.ResponseWriter, r *http.Request) {
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+
+	id := r.PathValue("schedule")
+	s, ok := h.recovery.schedules[id]
+
+	if r.Method == http.MethodPost && r.URL.Query().Get(backup.Action) == "" {
+		if ok {
+			vapi.ApiErrorAlreadyExists(w)
+			return
+		}
+
+		var spec backup.ScheduleCreateSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		parts, valid := validParts(spec.Parts)
+		if _, err := backup.LocationType(spec.Location); err != nil || !valid {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		s = &backupSchedule{
+			ScheduleInfo: backup.ScheduleInfo{
+				Parts:          parts,
+				Location:       spec.Location,
+				LocationUser:   spec.LocationUser,
+				Enable:         spec.Enable == nil || *spec.Enable,
+				RecurrenceInfo: spec.RecurrenceInfo,
+				RetentionInfo:  spec.RetentionInfo,
+			},
+			password:         spec.BackupPassword,
+			locationPassword: spec.LocationPassword,
+		}
+		h.recovery.schedules[id] = s
+		vapi.StatusOK(w)
+		return
+	}
+
+	if !ok {
+		vapi.ApiErrorNotFound(w)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, s.ScheduleInfo)
+	case http.MethodPatch:
+		var spec backup.ScheduleUpdateSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		if spec.Parts != nil {
+			parts, valid := validParts(spec.Parts)
+			if !valid {
+				vapi.ApiErrorInvalidArgument(w)
+				return
+			}
+			s.Parts = parts
+		}
+		if spec.Location != "" {
+			if _, err := backup.LocationType(spec.Location); err != nil {
+				vapi.ApiErrorInvalidArgument(w)
+				return
+			}
+			s.Location = spec.Location
+		}
+		if spec.LocationUser != "" {
+			s.LocationUser = spec.LocationUser
+		}
+		if spec.LocationPassword != "" {
+			s.locationPassword = spec.LocationPassword
+		}
+		if spec.BackupPassword != "" {
+			s.password = spec.BackupPassword
+		}
+		if spec.Enable != nil {
+			s.Enable = *spec.Enable
+		}
+		if spec.RecurrenceInfo != nil {
+			s.RecurrenceInfo = spec.RecurrenceInfo
+		}
+		if spec.RetentionInfo != nil {
+			s.RetentionInfo = spec.RetentionInfo
+		}
+		vapi.StatusOK(w)
+	case http.MethodDelete:
+		delete(h.recovery.schedules, id)
+		vapi.StatusOK(w)
+	case http.MethodPost:
+		if r.URL.Query().Get(backup.Action) != "run" {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		var spec struct {
+			Comment string `json:"comment,omitempty"`
+		}
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		h.runSchedule(w, r, s, spec.Comment)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// runSchedule starts a scheduled backup job, writing to a new directory under the schedule's location.
+// Once the job succeeds, the oldest backups of the schedule are removed when there are more than its RetentionInfo.MaxCount.
+func (h *Handler) runSchedule(w http.ResponseWriter, r *http.Request, s *backupSchedule, comment string) {
+	if h.jobInProgress() {
+		vapi.ApiErrorNotAllowedInCurrentState(w)
+		return
+	}
+
+	kind, _ := backup.LocationType(s.Location)
+	u, _ := url.Parse(s.Location)
+	id := h.nextJobID()
+	location := u.JoinPath("S_" + id).String()
+
+	t, err := newBackupTarget(kind, location, s.LocationUser, s.locationPassword)
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	req := backup.Request{
+		Parts:            s.Parts,
+		BackupPassword:   s.password,
+		LocationType:     kind,
+		Location:         location,
+		LocationUser:     s.LocationUser,
+		LocationPassword: s.locationPassword,
+		Comment:          comment,
+	}
+
+	job := h.newJob(id, req, t, backup.TypeScheduled, func() {
+		s.backups = append(s.backups, scheduledBackup{target: t, parts: req.Parts})
+		s.prune()
+	})
+
+	vapi.StatusOK(w, job.JobStatus)
+}
+
+// prune removes the oldest backups of the schedule when there are more than its RetentionInfo.MaxCount.
+// Must be called with the recovery lock held.
+func (s *backupSchedule) prune() {
+	if s.RetentionInfo == nil || s.RetentionInfo.MaxCount <= 0 {
+		return
+	}
+
+	for int64(len(s.backups)) > s.RetentionInfo.MaxCount {
+		old := s.backups[0]
+		if err := old.target.RemoveAll(backupFiles(old.parts)); err != nil {
+			log.Printf("backup schedule prune: %s", err)
+		}
+		s.backups = s.backups[1:]
+	}
+}
+
+// readBackup reads and checks the metadata at the restore location
+func readBackup(req backup.RestoreRequest) (backupTarget, *backupMetadata, error) {
+	t, err := newBackupTarget(req.LocationType, req.Location, req.LocationUser, req.LocationPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := t.Get(backupMetadataFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meta backupMetadata
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return nil, nil, err
+	}
+
+	if meta.Digest != digest(req.BackupPassword) {
+		return nil, nil, errors.New("invalid backup password")
+	}
+
+	return t, &meta, nil
+}
+
+// restore handles restore validation.
+//
+//	POST /api/appliance/recovery/restore?action=validate
+func (h *Handler) restore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Query().Get(backup.Action) != "validate" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req backup.RestoreRequest
+	if !vapi.Decode(r, w, &req) {
+		return
+	}
+
+	_, meta, err := readBackup(req)
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	meta.Applicable = meta.Version == h.recovery.about.Version
+	if !meta.Applicable {
+		meta.Messages = append(meta.Messages, message("Backup version %s does not match appliance version", meta.Version))
+	}
+
+	vapi.StatusOK(w, meta.Metadata)
+}
+
+// restoreJob handles the restore job.
+//
+//	GET  /api/appliance/recovery/restore/job
+//	POST /api/appliance/recovery/restore/job
+//	POST /api/appliance/recovery/restore/job?action=cancel
+func (h *Handler) restoreJob(w http.ResponseWriter, r *http.Request) {
+	h.recovery.Lock()
+	defer h.recovery.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, h.recovery.restore)
+	case http.MethodPost:
+		if r.URL.Query().Get(backup.Action) == "cancel" {
+			if h.recovery.restore.State != backup.StateInProgress {
+				vapi.ApiErrorNotAllowedInCurrentState(w)
+				return
+			}
+			h.recovery.restore.State = backup.StateFailed
+			h.recovery.restore.Messages = []rest.LocalizableMessage{message("restore job canceled")}
+			vapi.StatusOK(w, backup.ReturnResult{Status: backup.StatusOK})
+			return
+		}
+
+		var req backup.RestoreRequest
+		if !vapi.Decode(r, w, &req) {
+			return
+		}
+
+		if h.recovery.restore.State == backup.StateInProgress {
+			vapi.ApiErrorNotAllowedInCurrentState(w)
+			return
+		}
+
+		t, meta, err := readBackup(req)
+		if err != nil {
+			log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		h.recovery.restore = backup.RestoreJobStatus{State: backup.StateInProgress}
+		go h.runRestore(t, meta.Parts)
+
+		vapi.StatusOK(w, h.recovery.restore)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) runRestore(t backupTarget, parts []string) {
+	status := func(f func(*backup.RestoreJobStatus)) bool {
+		h.recovery.Lock()
+		defer h.recovery.Unlock()
+		if h.recovery.restore.State != backup.StateInProgress {
+			return false // canceled
+		}
+		f(&h.recovery.restore)
+		return true
+	}
+
+	for i, part := range parts {
+		data, err := t.Get(part + ".tar.gz")
+		if err == nil {
+			err = extractPart(data)
+		}
+		if err != nil {
+			status(func(s *backup.RestoreJobStatus) {
+				s.State = backup.StateFailed
+				s.Messages = append(s.Messages, message("restore %s: %s", part, err))
+			})
+			return
+		}
+
+		if !status(func(s *backup.RestoreJobStatus) { s.Progress = int64((i + 1) * 100 / len(parts)) }) {
+			return
+		}
+	}
+
+	status(func(s *backup.RestoreJobStatus) {
+		s.State = backup.StateSucceeded
+		s.Progress = 100
+	})
+}
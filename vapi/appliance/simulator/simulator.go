@@ -16,8 +16,10 @@ import (
 	"github.com/vmware/govmomi/vapi/appliance/access/dcui"
 	"github.com/vmware/govmomi/vapi/appliance/access/shell"
 	"github.com/vmware/govmomi/vapi/appliance/access/ssh"
+	"github.com/vmware/govmomi/vapi/appliance/recovery/backup"
 	"github.com/vmware/govmomi/vapi/appliance/shutdown"
 	vapi "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vim25"
 )
 
 func init() {
@@ -34,6 +36,7 @@ type Handler struct {
 	ssh            ssh.Access
 	shell          shell.Access
 	shutdownConfig shutdown.Config
+	recovery       *recovery
 }
 
 // New creates a Handler instance
@@ -45,6 +48,7 @@ func New(u *url.URL) *Handler {
 		ssh:            ssh.Access{Enabled: false},
 		shell:          shell.Access{Enabled: false, Timeout: 0},
 		shutdownConfig: shutdown.Config{},
+		recovery:       newRecovery(),
 	}
 }
 
@@ -55,6 +59,19 @@ func (h *Handler) Register(s *simulator.Service, r *simulator.Registry) {
 	s.HandleFunc(ssh.Path, h.sshAccess)
 	s.HandleFunc(shell.Path, h.shellAccess)
 	s.HandleFunc(shutdown.Path, h.shutdown)
+
+	if si, ok := r.Get(vim25.ServiceInstance).(*simulator.ServiceInstance); ok {
+		h.recovery.about = si.Content.About
+	}
+	s.HandleFunc(backup.Path, h.backup)
+	s.HandleFunc(backup.PartsPath, h.backupParts)
+	s.HandleFunc(backup.PartsPath+"/{part}", h.backupPart)
+	s.HandleFunc(backup.JobPath, h.backupJobs)
+	s.HandleFunc(backup.JobPath+"/{id}", h.backupJob)
+	s.HandleFunc(backup.SchedulesPath, h.backupSchedules)
+	s.HandleFunc(backup.SchedulesPath+"/{schedule}", h.backupSchedule)
+	s.HandleFunc(backup.RestorePath, h.restore)
+	s.HandleFunc(backup.RestoreJobPath, h.restoreJob)
 }
 
 func (h *Handler) decode(r *http.Request, w http.ResponseWriter, val any) bool {
@@ -0,0 +1,266 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package library
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+// ExportOptions configures ExportLibraryItemOVA.
+type ExportOptions struct {
+	// Certificate, if set, is used to sign the generated manifest,
+	// adding an OVF certificate (.cert) file to the package.
+	Certificate *tls.Certificate
+}
+
+// ExportLibraryItemOVA writes the files of the given OVF library item to w as an OVA (tar) stream.
+// The OVF descriptor is written first, followed by a generated SHA256 manifest (.mf) and, if ExportOptions.Certificate
+// is set, the certificate (.cert) file, replacing any manifest or certificate file stored with the item.
+// The files are written last, in the order they are referenced by the descriptor followed by any other files of the item.
+// As the manifest precedes the files, each file is downloaded twice: once to compute its digest and once to write it.
+func (c *Manager) ExportLibraryItemOVA(ctx context.Context, itemID string, w io.Writer, opts ExportOptions) error {
+	session, err := c.CreateLibraryItemDownloadSession(ctx, Session{
+		LibraryItemID: itemID,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = c.DeleteLibraryItemDownloadSession(context.WithoutCancel(ctx), session)
+	}()
+
+	files, err := c.ListLibraryItemDownloadSessionFile(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	var descriptor string
+	var names []string
+	for _, file := range files {
+		switch path.Ext(file.Name) {
+		case ".ovf":
+			if descriptor != "" {
+				return fmt.Errorf("library item %s has multiple OVF descriptors", itemID)
+			}
+			descriptor = file.Name
+		case ".mf", ".cert":
+			// generated
+		default:
+			names = append(names, file.Name)
+		}
+	}
+	if descriptor == "" {
+		return fmt.Errorf("library item %s is not an OVF template", itemID)
+	}
+	slices.Sort(names)
+
+	e := &exporter{
+		Manager: c,
+		session: session,
+		tar:     tar.NewWriter(w),
+		sums:    make(map[string][]byte),
+	}
+
+	var ovfContent bytes.Buffer
+	if err = e.download(ctx, descriptor, &ovfContent); err != nil {
+		return err
+	}
+
+	env, err := ovf.Unmarshal(bytes.NewReader(ovfContent.Bytes()))
+	if err != nil {
+		return fmt.Errorf("failed to parse ovf: %s", err)
+	}
+
+	// OVF requires the descriptor first, followed by the manifest and certificate,
+	// and the remaining files in the order of the References section
+	var order []string
+	for _, ref := range env.References {
+		if i := slices.Index(names, ref.Href); i >= 0 {
+			order = append(order, ref.Href)
+			names = slices.Delete(names, i, i+1)
+		}
+	}
+	order = append(order, names...)
+
+	sum := sha256.Sum256(ovfContent.Bytes())
+	e.add(descriptor, sum[:])
+
+	for _, name := range order {
+		if err = e.digest(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	if err = e.write(descriptor, ovfContent.Bytes()); err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(descriptor, ".ovf")
+	if err = e.write(base+".mf", e.manifest.Bytes()); err != nil {
+		return err
+	}
+
+	if opts.Certificate != nil {
+		cert, err := ovf.SignManifest(base+".mf", e.manifest.Bytes(), *opts.Certificate)
+		if err != nil {
+			return err
+		}
+		if err = e.write(base+".cert", cert); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range order {
+		if err = e.copy(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	return e.tar.Close()
+}
+
+// exporter writes the files of a library item download session to an OVA tar stream
+type exporter struct {
+	*Manager
+
+	session  string
+	tar      *tar.Writer
+	manifest bytes.Buffer
+	sums     map[string][]byte
+}
+
+// prepare waits for the given download session file to be prepared
+func (e *exporter) prepare(ctx context.Context, name string) (*DownloadFile, error) {
+	_, err := e.PrepareLibraryItemDownloadSessionFile(ctx, e.session, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		info, err := e.GetLibraryItemDownloadSessionFile(ctx, e.session, name)
+		if err != nil {
+			return nil, err
+		}
+		if info.Status == "PREPARED" {
+			return info, nil // with this status we have a DownloadEndpoint.URI
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// open prepares and opens the given download session file
+func (e *exporter) open(ctx context.Context, name string) (io.ReadCloser, int64, error) {
+	info, err := e.prepare(ctx, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	u, err := url.Parse(info.DownloadEndpoint.URI)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, size, err := e.Client.Download(ctx, u, &soap.DefaultDownload)
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.Size > 0 {
+		size = info.Size
+	}
+	return f, size, nil
+}
+
+func (e *exporter) download(ctx context.Context, name string, w io.Writer) error {
+	f, _, err := e.open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func (e *exporter) header(name string, size int64) error {
+	return e.tar.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    size,
+		ModTime: time.Now(),
+		Format:  tar.FormatUSTAR, // required by OVF
+	})
+}
+
+// add appends the given file digest to the manifest
+func (e *exporter) add(name string, sum []byte) {
+	_, _ = fmt.Fprintf(&e.manifest, "SHA256(%s)= %x\n", name, sum)
+}
+
+// write adds the given content to the archive
+func (e *exporter) write(name string, content []byte) error {
+	if err := e.header(name, int64(len(content))); err != nil {
+		return err
+	}
+	_, err := e.tar.Write(content)
+	return err
+}
+
+// digest reads the given download session file and adds its digest to the manifest
+func (e *exporter) digest(ctx context.Context, name string) error {
+	h := sha256.New()
+	if err := e.download(ctx, name, h); err != nil {
+		return err
+	}
+
+	e.sums[name] = h.Sum(nil)
+	e.add(name, e.sums[name])
+	return nil
+}
+
+// copy streams the given download session file to the archive, checking it matches the manifest digest
+func (e *exporter) copy(ctx context.Context, name string) error {
+	f, size, err := e.open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if size < 0 {
+		return fmt.Errorf("size of %s is unknown", name)
+	}
+
+	if err = e.header(name, size); err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(e.tar, h), f); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(h.Sum(nil), e.sums[name]) {
+		return fmt.Errorf("%s changed during export", name)
+	}
+	return nil
+}
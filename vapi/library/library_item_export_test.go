@@ -0,0 +1,194 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package library_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/ovf"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func uploadLibraryItem(ctx context.Context, t *testing.T, m *library.Manager, libID, name, file string) string {
+	t.Helper()
+
+	id, err := m.CreateLibraryItem(ctx, library.Item{
+		Name:      name,
+		Type:      "OVF",
+		LibraryID: libID,
+	})
+	require.NoError(t, err)
+
+	session, err := m.CreateLibraryItemUpdateSession(ctx, library.Session{LibraryItemID: id})
+	require.NoError(t, err)
+
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	fi, err := f.Stat()
+	require.NoError(t, err)
+
+	update, err := m.AddLibraryItemFile(ctx, session, library.UpdateFile{
+		Name:       filepath.Base(file),
+		SourceType: "PUSH",
+		Size:       fi.Size(),
+	})
+	require.NoError(t, err)
+
+	u, err := url.Parse(update.UploadEndpoint.URI)
+	require.NoError(t, err)
+
+	p := soap.DefaultUpload
+	p.ContentLength = fi.Size()
+	require.NoError(t, m.Client.Upload(ctx, f, u, &p))
+	require.NoError(t, m.CompleteLibraryItemUpdateSession(ctx, session))
+
+	return id
+}
+
+func signingCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "govmomi"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestExportLibraryItemOVA(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		ds, err := find.NewFinder(vc).DefaultDatastore(ctx)
+		require.NoError(t, err)
+
+		m := library.NewManager(c)
+
+		libID, err := m.CreateLibrary(ctx, library.Library{
+			Name: "export",
+			Type: "LOCAL",
+			Storage: []library.StorageBacking{{
+				DatastoreID: ds.Reference().Value,
+				Type:        "DATASTORE",
+			}},
+		})
+		require.NoError(t, err)
+
+		id := uploadLibraryItem(ctx, t, m, libID, "ttylinux", "./testdata/ttylinux-pc_i486-16.1.ova")
+		cert := signingCertificate(t)
+
+		for _, signed := range []bool{false, true} {
+			var opts library.ExportOptions
+			if signed {
+				opts.Certificate = &cert
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, m.ExportLibraryItemOVA(ctx, id, &buf, opts))
+
+			var names []string
+			content := make(map[string][]byte)
+			r := tar.NewReader(&buf)
+			for {
+				h, err := r.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tar.FormatUSTAR, h.Format)
+				b, err := io.ReadAll(r)
+				require.NoError(t, err)
+				names = append(names, h.Name)
+				content[h.Name] = b
+			}
+
+			// OVF 1.x ordering: descriptor, manifest, certificate, then the referenced files
+			expect := []string{
+				"ttylinux-pc_i486-16.1.ovf",
+				"ttylinux-pc_i486-16.1.mf",
+			}
+			if signed {
+				expect = append(expect, "ttylinux-pc_i486-16.1.cert")
+			}
+			expect = append(expect, "ttylinux-pc_i486-16.1-disk1.vmdk")
+			require.Equal(t, expect, names)
+
+			mf := content["ttylinux-pc_i486-16.1.mf"]
+			sums, err := library.ReadManifest(bytes.NewReader(mf))
+			require.NoError(t, err)
+			assert.Len(t, sums, 2)
+			for _, name := range []string{"ttylinux-pc_i486-16.1.ovf", "ttylinux-pc_i486-16.1-disk1.vmdk"} {
+				assert.Equal(t, "SHA256", sums[name].Algorithm)
+				assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(content[name])), sums[name].Checksum)
+			}
+
+			if signed {
+				roots := x509.NewCertPool()
+				roots.AddCert(cert.Leaf)
+
+				signer, err := ovf.VerifyManifest(mf, content["ttylinux-pc_i486-16.1.cert"], x509.VerifyOptions{Roots: roots})
+				require.NoError(t, err)
+				assert.Equal(t, "govmomi", signer.Subject.CommonName)
+			}
+		}
+
+		// not an OVF template
+		isoID, err := m.CreateLibraryItem(ctx, library.Item{Name: "iso", Type: "ISO", LibraryID: libID})
+		require.NoError(t, err)
+		err = m.ExportLibraryItemOVA(ctx, isoID, io.Discard, library.ExportOptions{})
+		assert.ErrorContains(t, err, "not an OVF template")
+
+		// download sessions are removed
+		sessions, err := m.ListLibraryItemDownloadSession(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+}
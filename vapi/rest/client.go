@@ -18,6 +18,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/vmware/govmomi/telemetry"
 	"github.com/vmware/govmomi/vapi/internal"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/soap"
@@ -54,6 +55,11 @@ func (m *LocalizableMessage) Error() string {
 func NewClient(c *vim25.Client) *Client {
 	sc := c.Client.NewServiceClient(Path, "")
 
+	// Inherit instrumentation of the vim25.Client, if any
+	if p := telemetry.FromRoundTripper(c); p != nil {
+		sc.Transport = telemetry.Transport(p, sc.Transport)
+	}
+
 	return &Client{Client: sc}
 }
 
@@ -0,0 +1,156 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/vmware/govmomi/vapi/internal"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// checkpointFile is the name of the file written to the simulator.Service checkpoint directory.
+const checkpointFile = "vapi.json"
+
+type checkpointItem struct {
+	Item     *library.Item                 `json:"item"`
+	File     []library.File                `json:"file,omitempty"`
+	Template *types.ManagedObjectReference `json:"template,omitempty"`
+}
+
+type checkpointLibrary struct {
+	Library *library.Library                         `json:"library"`
+	Item    []checkpointItem                         `json:"item,omitempty"`
+	Subs    map[string]*library.Subscriber           `json:"subs,omitempty"`
+	VMTX    map[string]*types.ManagedObjectReference `json:"vmtx,omitempty"`
+}
+
+// checkpoint is the persisted state of the handler, sessions are not included.
+type checkpoint struct {
+	Category     map[string]*tags.Category              `json:"category"`
+	Tag          map[string]*tags.Tag                   `json:"tag"`
+	Association  map[string][]internal.AssociatedObject `json:"association"`
+	Library      []checkpointLibrary                    `json:"library"`
+	Policies     []library.ContentSecurityPoliciesInfo  `json:"policies"`
+	Trust        map[string]library.TrustedCertificate  `json:"trust"`
+	LibraryUsage map[string]map[string]library.Usage    `json:"libraryUsage,omitempty"`
+}
+
+// Checkpoint implements simulator.Checkpointer
+func (s *handler) Checkpoint(dir string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	c := checkpoint{
+		Category:     s.Category,
+		Tag:          s.Tag,
+		Association:  make(map[string][]internal.AssociatedObject),
+		Policies:     s.Policies,
+		Trust:        s.Trust,
+		LibraryUsage: s.LibraryUsage,
+	}
+
+	for id, objs := range s.Association {
+		refs := []internal.AssociatedObject{}
+		for obj, ok := range objs {
+			if ok {
+				refs = append(refs, obj)
+			}
+		}
+		c.Association[id] = refs
+	}
+
+	for _, l := range s.Library {
+		cl := checkpointLibrary{
+			Library: l.Library,
+			Subs:    l.Subs,
+			VMTX:    l.VMTX,
+		}
+		for _, i := range l.Item {
+			cl.Item = append(cl.Item, checkpointItem{
+				Item:     i.Item,
+				File:     i.File,
+				Template: i.Template,
+			})
+		}
+		c.Library = append(c.Library, cl)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, checkpointFile), data, 0600)
+}
+
+// Restore implements simulator.Checkpointer
+func (s *handler) Restore(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, checkpointFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var c checkpoint
+	if err = json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if c.Category != nil {
+		s.Category = c.Category
+	}
+	if c.Tag != nil {
+		s.Tag = c.Tag
+	}
+	for id, refs := range c.Association {
+		s.Association[id] = make(map[internal.AssociatedObject]bool)
+		for _, ref := range refs {
+			s.Association[id][ref] = true
+		}
+	}
+	for _, cl := range c.Library {
+		l := &content{
+			Library: cl.Library,
+			Item:    make(map[string]*item),
+			Subs:    cl.Subs,
+			VMTX:    cl.VMTX,
+		}
+		if l.Subs == nil {
+			l.Subs = make(map[string]*library.Subscriber)
+		}
+		if l.VMTX == nil {
+			l.VMTX = make(map[string]*types.ManagedObjectReference)
+		}
+		for _, i := range cl.Item {
+			l.Item[i.Item.ID] = &item{
+				Item:     i.Item,
+				File:     i.File,
+				Template: i.Template,
+			}
+		}
+		s.Library[l.ID] = l
+	}
+	if c.Policies != nil {
+		s.Policies = c.Policies
+	}
+	if c.Trust != nil {
+		s.Trust = c.Trust
+	}
+	if c.LibraryUsage != nil {
+		s.LibraryUsage = c.LibraryUsage
+	}
+
+	return nil
+}
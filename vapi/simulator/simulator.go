@@ -469,6 +469,18 @@ func ApiErrorAlreadyExists(w http.ResponseWriter) {
 	apiError(w, http.StatusBadRequest, "ALREADY_EXISTS")
 }
 
+// ApiErrorAlreadyInDesiredState responds with a REST error of type "ALREADY_IN_DESIRED_STATE".
+// For use with "/api" endpoints.
+func ApiErrorAlreadyInDesiredState(w http.ResponseWriter) {
+	apiError(w, http.StatusBadRequest, "ALREADY_IN_DESIRED_STATE")
+}
+
+// ApiErrorConcurrentChange responds with a REST error of type "CONCURRENT_CHANGE".
+// For use with "/api" endpoints.
+func ApiErrorConcurrentChange(w http.ResponseWriter) {
+	apiError(w, http.StatusBadRequest, "CONCURRENT_CHANGE")
+}
+
 // ApiErrorGeneral responds with a REST error of type "ERROR".
 // For use with "/api" endpoints.
 func ApiErrorGeneral(w http.ResponseWriter) {
@@ -499,6 +511,12 @@ func ApiErrorResourceInUse(w http.ResponseWriter) {
 	apiError(w, http.StatusBadRequest, "RESOURCE_IN_USE")
 }
 
+// ApiErrorServiceUnavailable responds with a REST error of type "SERVICE_UNAVAILABLE".
+// For use with "/api" endpoints.
+func ApiErrorServiceUnavailable(w http.ResponseWriter) {
+	apiError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE")
+}
+
 // ApiErrorUnauthorized responds with a REST error of type "UNAUTHORIZED".
 // For use with "/api" endpoints.
 func ApiErrorUnauthorized(w http.ResponseWriter) {
@@ -2641,6 +2659,9 @@ func (s *handler) libraryItemDownloadSessionFileID(w http.ResponseWriter, r *htt
 					URI: u.String(),
 				},
 			}
+			if fi, err := os.Stat(path.Join(s.libraryPath(dl.Library, dl.Session.LibraryItemID), spec.File)); err == nil {
+				info.Size = fi.Size()
+			}
 			dl.File[spec.File] = info
 			OK(w, info)
 		}
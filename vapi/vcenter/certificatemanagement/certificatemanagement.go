@@ -0,0 +1,153 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificatemanagement
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vim25/soap"
+)
+
+const (
+	Path                   = "/api/vcenter/certificate-management/vcenter"
+	TLSPath                = Path + "/tls"
+	TLSCSRPath             = Path + "/tls-csr"
+	TrustedRootChainsPath  = Path + "/trusted-root-chains"
+	SigningCertificatePath = Path + "/signing-certificate"
+	Action                 = "action"
+)
+
+// Manager provides convenience methods to manage the vCenter certificates.
+type Manager struct {
+	*rest.Client
+}
+
+// NewManager creates a new Manager with the given client
+func NewManager(client *rest.Client) *Manager {
+	return &Manager{
+		Client: client,
+	}
+}
+
+// X509CertChain is a chain of PEM encoded certificates, leaf certificate first.
+type X509CertChain struct {
+	CertChain []string `json:"cert_chain"`
+}
+
+// Certificates decodes the PEM encoded certificates of the chain.
+func (c *X509CertChain) Certificates() ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for _, s := range c.CertChain {
+		data := []byte(s)
+		for {
+			var block *pem.Block
+			block, data = pem.Decode(data)
+			if block == nil {
+				break
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM encoded certificates found")
+	}
+
+	return certs, nil
+}
+
+// EncodeCertificate returns the given certificate PEM encoded.
+func EncodeCertificate(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// TLSInfo contains information about the vCenter machine (TLS) certificate.
+type TLSInfo struct {
+	Version                       int64     `json:"version"`
+	SerialNumber                  string    `json:"serial_number"`
+	SignatureAlgorithm            string    `json:"signature_algorithm"`
+	IssuerDN                      string    `json:"issuer_dn"`
+	ValidFrom                     time.Time `json:"valid_from"`
+	ValidTo                       time.Time `json:"valid_to"`
+	SubjectDN                     string    `json:"subject_dn"`
+	Thumbprint                    string    `json:"thumbprint"`
+	IsCA                          bool      `json:"is_CA"`
+	PathLengthConstraint          int64     `json:"path_length_constraint"`
+	KeyUsage                      []string  `json:"key_usage"`
+	ExtendedKeyUsage              []string  `json:"extended_key_usage"`
+	SubjectAlternativeName        []string  `json:"subject_alternative_name"`
+	AuthorityInformationAccessURI []string  `json:"authority_information_access_uri"`
+	Cert                          string    `json:"cert"`
+}
+
+var keyUsage = []string{
+	"DigitalSignature",
+	"ContentCommitment",
+	"KeyEncipherment",
+	"DataEncipherment",
+	"KeyAgreement",
+	"KeyCertSign",
+	"CRLSign",
+	"EncipherOnly",
+	"DecipherOnly",
+}
+
+var extKeyUsage = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:             "Any",
+	x509.ExtKeyUsageServerAuth:      "ServerAuth",
+	x509.ExtKeyUsageClientAuth:      "ClientAuth",
+	x509.ExtKeyUsageCodeSigning:     "CodeSigning",
+	x509.ExtKeyUsageEmailProtection: "EmailProtection",
+	x509.ExtKeyUsageTimeStamping:    "TimeStamping",
+	x509.ExtKeyUsageOCSPSigning:     "OCSPSigning",
+}
+
+// FromCertificate populates TLSInfo from the given certificate.
+func (info *TLSInfo) FromCertificate(cert *x509.Certificate) *TLSInfo {
+	*info = TLSInfo{
+		Version:                       int64(cert.Version),
+		SerialNumber:                  fmt.Sprintf("%X", cert.SerialNumber),
+		SignatureAlgorithm:            cert.SignatureAlgorithm.String(),
+		IssuerDN:                      cert.Issuer.String(),
+		ValidFrom:                     cert.NotBefore,
+		ValidTo:                       cert.NotAfter,
+		SubjectDN:                     cert.Subject.String(),
+		Thumbprint:                    soap.ThumbprintSHA256(cert),
+		IsCA:                          cert.IsCA,
+		PathLengthConstraint:          int64(cert.MaxPathLen),
+		AuthorityInformationAccessURI: cert.IssuingCertificateURL,
+		Cert:                          EncodeCertificate(cert),
+	}
+
+	for i, name := range keyUsage {
+		if cert.KeyUsage&(1<<i) != 0 {
+			info.KeyUsage = append(info.KeyUsage, name)
+		}
+	}
+
+	for _, usage := range cert.ExtKeyUsage {
+		if name, ok := extKeyUsage[usage]; ok {
+			info.ExtendedKeyUsage = append(info.ExtendedKeyUsage, name)
+		}
+	}
+
+	info.SubjectAlternativeName = append(info.SubjectAlternativeName, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		info.SubjectAlternativeName = append(info.SubjectAlternativeName, ip.String())
+	}
+	info.SubjectAlternativeName = append(info.SubjectAlternativeName, cert.EmailAddresses...)
+
+	return info
+}
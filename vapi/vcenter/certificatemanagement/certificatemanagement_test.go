@@ -0,0 +1,253 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificatemanagement_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	cm "github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/soap"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	_ "github.com/vmware/govmomi/vapi/vcenter/certificatemanagement/simulator"
+)
+
+func newManager(ctx context.Context, t *testing.T, vc *vim25.Client) *cm.Manager {
+	c := rest.NewClient(vc)
+	require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+	return cm.NewManager(c)
+}
+
+// served returns the leaf certificate presented by a new TLS connection to the simulator
+func served(t *testing.T, vc *vim25.Client) *x509.Certificate {
+	conn, err := tls.Dial("tcp", vc.URL().Host, &tls.Config{InsecureSkipVerify: true})
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0]
+}
+
+// newCA creates a self-signed CA for signing CSRs
+func newCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return ca, key
+}
+
+func TestTLS(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		m := newManager(ctx, t, vc)
+
+		info, err := m.GetTLS(ctx)
+		require.NoError(t, err)
+		initial := served(t, vc)
+		assert.Equal(t, soap.ThumbprintSHA256(initial), info.Thumbprint)
+
+		require.NoError(t, m.RenewTLS(ctx, 30))
+
+		renewed, err := m.GetTLS(ctx)
+		require.NoError(t, err)
+		assert.NotEqual(t, info.Thumbprint, renewed.Thumbprint)
+		assert.Equal(t, info.SubjectDN, renewed.SubjectDN)
+		assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), renewed.ValidTo, 2*time.Hour)
+
+		// new connections are served the renewed certificate
+		leaf := served(t, vc)
+		assert.Equal(t, renewed.Thumbprint, soap.ThumbprintSHA256(leaf))
+
+		signing, err := m.GetSigningCertificate(ctx)
+		require.NoError(t, err)
+		certs, err := signing.ActiveCertChain.Certificates()
+		require.NoError(t, err)
+		assert.NoError(t, leaf.CheckSignatureFrom(certs[0]))
+
+		spec := cm.CSRSpec{
+			KeySize:        2048,
+			CommonName:     "vcsa.example.com",
+			Organization:   "VMware",
+			Country:        "US",
+			SubjectAltName: []string{"vcsa.example.com", "127.0.0.1"},
+		}
+		require.NoError(t, m.ReplaceTLSWithVMCASigned(ctx, spec))
+
+		replaced, err := m.GetTLS(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "CN=vcsa.example.com,O=VMware,C=US", replaced.SubjectDN)
+		assert.Equal(t, []string{"vcsa.example.com", "127.0.0.1"}, replaced.SubjectAlternativeName)
+		assert.Contains(t, replaced.ExtendedKeyUsage, "ServerAuth")
+		assert.Equal(t, replaced.Thumbprint, soap.ThumbprintSHA256(served(t, vc)))
+	})
+}
+
+func TestCustomTLS(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		m := newManager(ctx, t, vc)
+
+		csr, err := m.GenerateCSR(ctx, cm.CSRSpec{CommonName: "vcsa.example.com", SubjectAltName: []string{"vcsa.example.com"}})
+		require.NoError(t, err)
+
+		block, _ := pem.Decode([]byte(csr.CSR))
+		require.NotNil(t, block)
+		req, err := x509.ParseCertificateRequest(block.Bytes)
+		require.NoError(t, err)
+		require.NoError(t, req.CheckSignature())
+		assert.Equal(t, []string{"vcsa.example.com"}, req.DNSNames)
+
+		ca, caKey := newCA(t)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      req.Subject,
+			DNSNames:     req.DNSNames,
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, req.PublicKey, caKey)
+		require.NoError(t, err)
+		cert, err := x509.ParseCertificate(der)
+		require.NoError(t, err)
+
+		// not signed by a trusted root
+		err = m.SetTLS(ctx, cm.TLSSpec{Cert: cm.EncodeCertificate(cert)})
+		assert.Error(t, err)
+
+		err = m.SetTLS(ctx, cm.TLSSpec{Cert: cm.EncodeCertificate(cert), RootCert: cm.EncodeCertificate(ca)})
+		require.NoError(t, err)
+
+		info, err := m.GetTLS(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, soap.ThumbprintSHA256(cert), info.Thumbprint)
+		assert.Equal(t, "CN=Test CA", info.IssuerDN)
+		assert.Equal(t, info.Thumbprint, soap.ThumbprintSHA256(served(t, vc)))
+
+		// root_cert was added to the trusted root chains
+		ids, err := m.ListTrustedRootChains(ctx)
+		require.NoError(t, err)
+		assert.Len(t, ids, 2)
+	})
+}
+
+func TestTrustedRootChains(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		m := newManager(ctx, t, vc)
+
+		ids, err := m.ListTrustedRootChains(ctx)
+		require.NoError(t, err)
+		require.Len(t, ids, 1) // VMCA root
+		vmca := ids[0]
+
+		ca, _ := newCA(t)
+		spec := cm.TrustedRootChainCreateSpec{
+			CertChain: cm.X509CertChain{CertChain: []string{cm.EncodeCertificate(ca)}},
+		}
+		id, err := m.CreateTrustedRootChain(ctx, spec)
+		require.NoError(t, err)
+		assert.NotEmpty(t, id)
+
+		_, err = m.CreateTrustedRootChain(ctx, spec)
+		assert.ErrorContains(t, err, "ALREADY_EXISTS")
+
+		ids, err = m.ListTrustedRootChains(ctx)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{vmca, id}, ids)
+
+		info, err := m.GetTrustedRootChain(ctx, id)
+		require.NoError(t, err)
+		certs, err := info.CertChain.Certificates()
+		require.NoError(t, err)
+		assert.True(t, ca.Equal(certs[0]))
+
+		err = m.DeleteTrustedRootChain(ctx, vmca)
+		assert.ErrorContains(t, err, "RESOURCE_IN_USE")
+
+		require.NoError(t, m.DeleteTrustedRootChain(ctx, id))
+
+		_, err = m.GetTrustedRootChain(ctx, id)
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		// ids are escaped once in the request path
+		spec.Chain = "my chain"
+		id, err = m.CreateTrustedRootChain(ctx, spec)
+		require.NoError(t, err)
+		assert.Equal(t, spec.Chain, id)
+		_, err = m.GetTrustedRootChain(ctx, id)
+		require.NoError(t, err)
+		require.NoError(t, m.DeleteTrustedRootChain(ctx, id))
+	})
+}
+
+func TestSigningCertificate(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		m := newManager(ctx, t, vc)
+
+		info, err := m.GetSigningCertificate(ctx)
+		require.NoError(t, err)
+		require.Len(t, info.SigningCertChains, 1)
+
+		root, err := m.RefreshSigningCertificate(ctx, false)
+		require.NoError(t, err)
+
+		info, err = m.GetSigningCertificate(ctx)
+		require.NoError(t, err)
+		assert.Len(t, info.SigningCertChains, 2)
+		assert.Equal(t, []string{root}, info.ActiveCertChain.CertChain)
+
+		// renewed machine certificate is signed by the new VMCA signing certificate
+		require.NoError(t, m.RenewTLS(ctx, 0))
+		signer, err := info.ActiveCertChain.Certificates()
+		require.NoError(t, err)
+		assert.NoError(t, served(t, vc).CheckSignatureFrom(signer[0]))
+
+		// custom signing certificate
+		ca, key := newCA(t)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		spec := cm.SigningCertificateSetSpec{
+			SigningCertChain: cm.X509CertChain{CertChain: []string{cm.EncodeCertificate(ca)}},
+			PrivateKey:       string(encodePEM("PRIVATE KEY", der)),
+		}
+		require.NoError(t, m.SetSigningCertificate(ctx, spec))
+
+		_, err = m.RefreshSigningCertificate(ctx, false)
+		assert.ErrorContains(t, err, "NOT_ALLOWED_IN_CURRENT_STATE")
+
+		_, err = m.RefreshSigningCertificate(ctx, true)
+		assert.NoError(t, err)
+	})
+}
+
+func encodePEM(kind string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+}
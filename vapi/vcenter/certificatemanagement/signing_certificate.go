@@ -0,0 +1,49 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificatemanagement
+
+import (
+	"context"
+	"net/http"
+)
+
+// SigningCertificateInfo contains the VMCA signing certificate chains.
+type SigningCertificateInfo struct {
+	// ActiveCertChain is the chain used to sign new certificates.
+	ActiveCertChain X509CertChain `json:"active_cert_chain"`
+	// SigningCertChains contains the active and any previous signing chains still used to verify certificates.
+	SigningCertChains []X509CertChain `json:"signing_cert_chains"`
+}
+
+// SigningCertificateSetSpec describes a custom VMCA signing certificate.
+type SigningCertificateSetSpec struct {
+	SigningCertChain X509CertChain `json:"signing_cert_chain"`
+	PrivateKey       string        `json:"private_key"`
+}
+
+// GetSigningCertificate returns the VMCA signing certificate chains.
+func (m *Manager) GetSigningCertificate(ctx context.Context) (*SigningCertificateInfo, error) {
+	r := m.Resource(SigningCertificatePath)
+	var res SigningCertificateInfo
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// SetSigningCertificate replaces the VMCA signing certificate with the given custom signing certificate.
+func (m *Manager) SetSigningCertificate(ctx context.Context, spec SigningCertificateSetSpec) error {
+	r := m.Resource(SigningCertificatePath)
+	return m.Do(ctx, r.Request(http.MethodPut, spec), nil)
+}
+
+// RefreshSigningCertificate generates a new self-signed VMCA signing certificate
+// and returns its PEM encoded chain.
+// If force is true, the signing certificate is replaced even if the current one is not self-signed.
+func (m *Manager) RefreshSigningCertificate(ctx context.Context, force bool) (string, error) {
+	r := m.Resource(SigningCertificatePath).WithParam(Action, "refresh")
+	spec := struct {
+		Force bool `json:"force,omitempty"`
+	}{force}
+	var res string
+	return res, m.Do(ctx, r.Request(http.MethodPost, spec), &res)
+}
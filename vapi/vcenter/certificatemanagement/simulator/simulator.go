@@ -0,0 +1,652 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/simulator"
+	vapi "github.com/vmware/govmomi/vapi/simulator"
+	cm "github.com/vmware/govmomi/vapi/vcenter/certificatemanagement"
+)
+
+func init() {
+	simulator.RegisterEndpoint(func(s *simulator.Service, r *simulator.Registry) {
+		New(s.Listen).Register(s, r)
+	})
+}
+
+// defaultDuration is the validity period of VMCA signed machine certificates
+const defaultDuration = 730 * 24 * time.Hour
+
+// Handler implements the vCenter certificate management API simulator.
+// The machine certificate served by the simulator's TLS listener is replaced
+// when the certificate is renewed or set via this API.
+type Handler struct {
+	sync.Mutex
+	URL *url.URL
+
+	config  *tls.Config         // the simulator's TLS config, nil if TLS is disabled
+	cert    *tls.Certificate    // the machine certificate
+	served  bool                // true once cert has replaced the initial certificate
+	csrKey  crypto.Signer       // private key of the last generated CSR
+	vmca    *tls.Certificate    // the VMCA signing certificate
+	custom  bool                // true if vmca was set via the API rather than generated
+	signing []*x509.Certificate // active and previous VMCA signing certificates
+	roots   map[string][]string // trusted root chains, chain ID -> PEM encoded certificates
+}
+
+// New creates a Handler instance
+func New(u *url.URL) *Handler {
+	return &Handler{
+		URL:   u,
+		roots: make(map[string][]string),
+	}
+}
+
+// Register certificate management API paths with the vapi simulator's http.ServeMux
+func (h *Handler) Register(s *simulator.Service, r *simulator.Registry) {
+	if !r.IsVPX() {
+		return
+	}
+
+	if s.TLS != nil && len(s.TLS.Certificates) != 0 {
+		h.config = s.TLS
+		h.cert = &s.TLS.Certificates[0]
+		if h.cert.Leaf == nil {
+			h.cert.Leaf, _ = x509.ParseCertificate(h.cert.Certificate[0])
+		}
+		h.config.GetConfigForClient = h.configForClient
+	}
+
+	s.HandleFunc(cm.TLSPath, h.tls)
+	s.HandleFunc(cm.TLSCSRPath, h.tlsCSR)
+	s.HandleFunc(cm.TrustedRootChainsPath, h.trustedRootChains)
+	s.HandleFunc(cm.TrustedRootChainsPath+"/{chain}", h.trustedRootChain)
+	s.HandleFunc(cm.SigningCertificatePath, h.signingCertificate)
+}
+
+// configForClient implements tls.Config.GetConfigForClient, serving the current machine certificate
+func (h *Handler) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if !h.served {
+		return nil, nil // initial config
+	}
+
+	c := h.config.Clone()
+	c.GetConfigForClient = nil
+	c.Certificates = []tls.Certificate{*h.cert}
+	if c.NextProtos == nil {
+		c.NextProtos = []string{"http/1.1"}
+	}
+	return c, nil
+}
+
+// setCert replaces the machine certificate.
+// Must be called with the Handler lock held.
+func (h *Handler) setCert(cert *tls.Certificate) {
+	h.cert = cert
+	h.served = h.config != nil
+}
+
+func chainID(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:20]))
+}
+
+// addRoot adds the root of the given chain to the trusted root chains.
+// Must be called with the Handler lock held.
+func (h *Handler) addRoot(chain []*x509.Certificate) {
+	root := chain[len(chain)-1]
+	h.roots[chainID(root)] = []string{cm.EncodeCertificate(root)}
+}
+
+func newKey(size int64) (crypto.Signer, error) {
+	if size > 0 {
+		return rsa.GenerateKey(rand.Reader, int(size))
+	}
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+func serialNumber() *big.Int {
+	n, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return n
+}
+
+// newSigner creates a self-signed VMCA signing certificate.
+// Must be called with the Handler lock held.
+func (h *Handler) newSigner() error {
+	key, err := newKey(0)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "CA", Organization: []string{"vcsim"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	h.setSigner(&tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert}, []*x509.Certificate{cert})
+	h.custom = false
+	return nil
+}
+
+// setSigner replaces the VMCA signing certificate.
+// Must be called with the Handler lock held.
+func (h *Handler) setSigner(signer *tls.Certificate, chain []*x509.Certificate) {
+	h.vmca = signer
+	h.signing = append([]*x509.Certificate{signer.Leaf}, h.signing...)
+	h.addRoot(chain)
+}
+
+// signer returns the VMCA signing certificate, creating one if needed.
+// Must be called with the Handler lock held.
+func (h *Handler) signer() (*tls.Certificate, error) {
+	if h.vmca == nil {
+		if err := h.newSigner(); err != nil {
+			return nil, err
+		}
+	}
+	return h.vmca, nil
+}
+
+// subjectAltNames sets the SANs of the template
+func subjectAltNames(template *x509.Certificate, names []string) {
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if strings.Contains(name, "@") {
+			template.EmailAddresses = append(template.EmailAddresses, name)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+}
+
+// subject returns the certificate subject for the given spec
+func subject(spec cm.CSRSpec) pkix.Name {
+	name := pkix.Name{CommonName: spec.CommonName}
+	add := func(field *[]string, val string) {
+		if val != "" {
+			*field = []string{val}
+		}
+	}
+	add(&name.Organization, spec.Organization)
+	add(&name.OrganizationalUnit, spec.OrganizationUnit)
+	add(&name.Locality, spec.Locality)
+	add(&name.Province, spec.StateOrProvince)
+	add(&name.Country, spec.Country)
+	return name
+}
+
+// issue creates a new VMCA signed machine certificate.
+// Must be called with the Handler lock held.
+func (h *Handler) issue(template *x509.Certificate, keySize int64, duration time.Duration) (*tls.Certificate, error) {
+	signer, err := h.signer()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := newKey(keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template.SerialNumber = serialNumber()
+	template.NotBefore = now.Add(-time.Hour)
+	template.NotAfter = now.Add(duration)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	template.BasicConstraintsValid = true
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.Leaf, key.Public(), signer.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Certificate{
+		Certificate: [][]byte{der, signer.Certificate[0]},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
+
+// current returns the machine certificate, issuing a VMCA signed certificate if TLS is disabled.
+// Must be called with the Handler lock held.
+func (h *Handler) current() (*tls.Certificate, error) {
+	if h.cert == nil {
+		template := &x509.Certificate{Subject: pkix.Name{CommonName: h.URL.Hostname()}}
+		subjectAltNames(template, []string{h.URL.Hostname()})
+		cert, err := h.issue(template, 0, defaultDuration)
+		if err != nil {
+			return nil, err
+		}
+		h.setCert(cert)
+	}
+	return h.cert, nil
+}
+
+func (h *Handler) error(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+	vapi.ApiErrorInvalidArgument(w)
+}
+
+// tls handles the machine certificate.
+//
+//	GET  /api/vcenter/certificate-management/vcenter/tls
+//	PUT  /api/vcenter/certificate-management/vcenter/tls
+//	POST /api/vcenter/certificate-management/vcenter/tls?action=renew
+//	POST /api/vcenter/certificate-management/vcenter/tls?action=replace-vmca-signed
+func (h *Handler) tls(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	cert, err := h.current()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, new(cm.TLSInfo).FromCertificate(cert.Leaf))
+	case http.MethodPut:
+		var spec cm.TLSSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+		if err = h.setTLS(spec); err != nil {
+			h.error(w, r, err)
+			return
+		}
+		vapi.StatusOK(w)
+	case http.MethodPost:
+		template := &x509.Certificate{}
+		var keySize int64
+		duration := defaultDuration
+
+		switch r.URL.Query().Get(cm.Action) {
+		case "renew":
+			var spec struct {
+				Duration int64 `json:"duration,omitempty"`
+			}
+			if r.ContentLength != 0 && !vapi.Decode(r, w, &spec) {
+				return
+			}
+			if spec.Duration > 0 {
+				duration = time.Duration(spec.Duration) * 24 * time.Hour
+			}
+			template.Subject = cert.Leaf.Subject
+			template.DNSNames = cert.Leaf.DNSNames
+			template.IPAddresses = cert.Leaf.IPAddresses
+			template.EmailAddresses = cert.Leaf.EmailAddresses
+		case "replace-vmca-signed":
+			var spec cm.CSRSpec
+			if !vapi.Decode(r, w, &spec) {
+				return
+			}
+			keySize = spec.KeySize
+			template.Subject = subject(spec)
+			if len(spec.SubjectAltName) == 0 {
+				template.DNSNames = cert.Leaf.DNSNames
+				template.IPAddresses = cert.Leaf.IPAddresses
+			}
+			subjectAltNames(template, spec.SubjectAltName)
+		default:
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		cert, err = h.issue(template, keySize, duration)
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+		h.setCert(cert)
+		vapi.StatusOK(w)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// setTLS replaces the machine certificate with a custom certificate, which must chain to a trusted root.
+// Must be called with the Handler lock held.
+func (h *Handler) setTLS(spec cm.TLSSpec) error {
+	chain, err := (&cm.X509CertChain{CertChain: []string{spec.Cert}}).Certificates()
+	if err != nil {
+		return err
+	}
+	leaf := chain[0]
+
+	var key crypto.PrivateKey = h.csrKey
+	if spec.Key != "" {
+		block, _ := pem.Decode([]byte(spec.Key))
+		if block == nil {
+			return errors.New("invalid private key")
+		}
+		if key, err = x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			if key, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+				if key, err = x509.ParseECPrivateKey(block.Bytes); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return errors.New("no private key for certificate")
+	}
+	if pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(leaf.PublicKey) {
+		return errors.New("private key does not match certificate")
+	}
+
+	var root []*x509.Certificate
+	if spec.RootCert != "" {
+		root, err = (&cm.X509CertChain{CertChain: []string{spec.RootCert}}).Certificates()
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, c := range append(chain[1:], root...) {
+		opts.Intermediates.AddCert(c)
+	}
+	for _, c := range root {
+		opts.Roots.AddCert(c)
+	}
+	for _, pems := range h.roots {
+		certs, _ := (&cm.X509CertChain{CertChain: pems}).Certificates()
+		for _, c := range certs {
+			opts.Roots.AddCert(c)
+		}
+	}
+
+	if _, err = leaf.Verify(opts); err != nil {
+		return err
+	}
+
+	cert := &tls.Certificate{PrivateKey: signer, Leaf: leaf}
+	for _, c := range chain {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+
+	if len(root) != 0 {
+		h.addRoot(root)
+	}
+	if key == h.csrKey {
+		h.csrKey = nil
+	}
+	h.setCert(cert)
+
+	return nil
+}
+
+// tlsCSR generates a certificate signing request for the machine certificate.
+//
+//	POST /api/vcenter/certificate-management/vcenter/tls-csr
+func (h *Handler) tlsCSR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var spec cm.CSRSpec
+	if !vapi.Decode(r, w, &spec) {
+		return
+	}
+
+	key, err := newKey(spec.KeySize)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	template := &x509.CertificateRequest{Subject: subject(spec)}
+	if spec.EmailAddress != "" {
+		template.EmailAddresses = []string{spec.EmailAddress}
+	}
+	tmp := &x509.Certificate{}
+	subjectAltNames(tmp, spec.SubjectAltName)
+	template.DNSNames = tmp.DNSNames
+	template.IPAddresses = tmp.IPAddresses
+	template.EmailAddresses = append(template.EmailAddresses, tmp.EmailAddresses...)
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	h.Lock()
+	h.csrKey = key
+	h.Unlock()
+
+	csr := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+	vapi.StatusOK(w, cm.CSR{CSR: string(csr)})
+}
+
+// trustedRootChains handles the trusted root chain collection.
+//
+//	GET  /api/vcenter/certificate-management/vcenter/trusted-root-chains
+//	POST /api/vcenter/certificate-management/vcenter/trusted-root-chains
+func (h *Handler) trustedRootChains(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	if _, err := h.signer(); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		type item struct {
+			Chain string `json:"chain"`
+		}
+		var res []item
+		for id := range h.roots {
+			res = append(res, item{id})
+		}
+		slices.SortFunc(res, func(a, b item) int { return strings.Compare(a.Chain, b.Chain) })
+		vapi.StatusOK(w, res)
+	case http.MethodPost:
+		var spec cm.TrustedRootChainCreateSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		certs, err := spec.CertChain.Certificates()
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+
+		id := spec.Chain
+		if id == "" {
+			id = chainID(certs[0])
+		}
+		if _, ok := h.roots[id]; ok {
+			vapi.ApiErrorAlreadyExists(w)
+			return
+		}
+
+		h.roots[id] = spec.CertChain.CertChain
+		vapi.StatusOK(w, id)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// trustedRootChain handles a trusted root chain.
+//
+//	GET    /api/vcenter/certificate-management/vcenter/trusted-root-chains/{chain}
+//	DELETE /api/vcenter/certificate-management/vcenter/trusted-root-chains/{chain}
+func (h *Handler) trustedRootChain(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	if _, err := h.signer(); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	id := r.PathValue("chain")
+	chain, ok := h.roots[id]
+	if !ok {
+		vapi.ApiErrorNotFound(w)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, cm.TrustedRootChainInfo{CertChain: cm.X509CertChain{CertChain: chain}})
+	case http.MethodDelete:
+		certs, _ := (&cm.X509CertChain{CertChain: chain}).Certificates()
+		if len(certs) != 0 && h.vmca.Leaf.CheckSignatureFrom(certs[len(certs)-1]) == nil {
+			vapi.ApiErrorResourceInUse(w) // the active VMCA signing chain
+			return
+		}
+		delete(h.roots, id)
+		vapi.StatusOK(w)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// signingCertificate handles the VMCA signing certificate.
+//
+//	GET  /api/vcenter/certificate-management/vcenter/signing-certificate
+//	PUT  /api/vcenter/certificate-management/vcenter/signing-certificate
+//	POST /api/vcenter/certificate-management/vcenter/signing-certificate?action=refresh
+func (h *Handler) signingCertificate(w http.ResponseWriter, r *http.Request) {
+	h.Lock()
+	defer h.Unlock()
+
+	signer, err := h.signer()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var res cm.SigningCertificateInfo
+		for _, cert := range signer.Certificate {
+			res.ActiveCertChain.CertChain = append(res.ActiveCertChain.CertChain,
+				string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})))
+		}
+		for _, cert := range h.signing {
+			res.SigningCertChains = append(res.SigningCertChains, cm.X509CertChain{CertChain: []string{cm.EncodeCertificate(cert)}})
+		}
+		vapi.StatusOK(w, res)
+	case http.MethodPut:
+		var spec cm.SigningCertificateSetSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		chain, err := spec.SigningCertChain.Certificates()
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+
+		var pems []byte
+		for _, cert := range chain {
+			pems = append(pems, cm.EncodeCertificate(cert)...)
+		}
+
+		cert, err := tls.X509KeyPair(pems, []byte(spec.PrivateKey))
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+		if !chain[0].IsCA {
+			h.error(w, r, errors.New("signing certificate is not a CA"))
+			return
+		}
+		cert.Leaf = chain[0]
+
+		h.setSigner(&cert, chain)
+		h.custom = true
+		vapi.StatusOK(w)
+	case http.MethodPost:
+		if r.URL.Query().Get(cm.Action) != "refresh" {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+
+		var spec struct {
+			Force bool `json:"force,omitempty"`
+		}
+		if r.ContentLength != 0 && !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		if h.custom && !spec.Force {
+			vapi.ApiErrorNotAllowedInCurrentState(w) // custom signing certificate
+			return
+		}
+
+		if err := h.newSigner(); err != nil {
+			h.error(w, r, err)
+			return
+		}
+
+		vapi.StatusOK(w, cm.EncodeCertificate(h.vmca.Leaf))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,74 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificatemanagement
+
+import (
+	"context"
+	"net/http"
+)
+
+// TLSSpec describes a custom machine certificate to replace the current one.
+// Key can be omitted if the certificate was signed from a CSR created by GenerateCSR.
+// RootCert, if specified, is added to the trusted root chains.
+type TLSSpec struct {
+	Cert     string `json:"cert"`
+	Key      string `json:"key,omitempty"`
+	RootCert string `json:"root_cert,omitempty"`
+}
+
+// CSRSpec describes the subject of a certificate signing request or a VMCA signed certificate.
+type CSRSpec struct {
+	KeySize          int64    `json:"key_size,omitempty"`
+	CommonName       string   `json:"common_name,omitempty"`
+	Organization     string   `json:"organization"`
+	OrganizationUnit string   `json:"organization_unit"`
+	Locality         string   `json:"locality"`
+	StateOrProvince  string   `json:"state_or_province"`
+	Country          string   `json:"country"`
+	EmailAddress     string   `json:"email_address"`
+	SubjectAltName   []string `json:"subject_alt_name,omitempty"`
+}
+
+// CSR is a PEM encoded certificate signing request.
+type CSR struct {
+	CSR string `json:"csr"`
+}
+
+// GetTLS returns information about the vCenter machine certificate.
+func (m *Manager) GetTLS(ctx context.Context) (*TLSInfo, error) {
+	r := m.Resource(TLSPath)
+	var res TLSInfo
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// SetTLS replaces the vCenter machine certificate with the given custom certificate.
+func (m *Manager) SetTLS(ctx context.Context, spec TLSSpec) error {
+	r := m.Resource(TLSPath)
+	return m.Do(ctx, r.Request(http.MethodPut, spec), nil)
+}
+
+// RenewTLS renews the VMCA signed vCenter machine certificate, valid for the given number of days.
+// If duration is 0, the default validity period is used.
+func (m *Manager) RenewTLS(ctx context.Context, duration int64) error {
+	r := m.Resource(TLSPath).WithParam(Action, "renew")
+	spec := struct {
+		Duration int64 `json:"duration,omitempty"`
+	}{duration}
+	return m.Do(ctx, r.Request(http.MethodPost, spec), nil)
+}
+
+// ReplaceTLSWithVMCASigned replaces the vCenter machine certificate with a new VMCA signed certificate.
+func (m *Manager) ReplaceTLSWithVMCASigned(ctx context.Context, spec CSRSpec) error {
+	r := m.Resource(TLSPath).WithParam(Action, "replace-vmca-signed")
+	return m.Do(ctx, r.Request(http.MethodPost, spec), nil)
+}
+
+// GenerateCSR generates a private key and a certificate signing request for the vCenter machine certificate.
+// The private key is kept by vCenter, for use by SetTLS once the CSR is signed.
+func (m *Manager) GenerateCSR(ctx context.Context, spec CSRSpec) (*CSR, error) {
+	r := m.Resource(TLSCSRPath)
+	var res CSR
+	return &res, m.Do(ctx, r.Request(http.MethodPost, spec), &res)
+}
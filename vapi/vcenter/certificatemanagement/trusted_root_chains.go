@@ -0,0 +1,58 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificatemanagement
+
+import (
+	"context"
+	"net/http"
+)
+
+// TrustedRootChainCreateSpec describes a trusted root chain to be added.
+type TrustedRootChainCreateSpec struct {
+	CertChain X509CertChain `json:"cert_chain"`
+	// Chain is the identifier of the new chain, generated by vCenter if not specified.
+	Chain string `json:"chain,omitempty"`
+}
+
+// TrustedRootChainInfo contains a trusted root chain.
+type TrustedRootChainInfo struct {
+	CertChain X509CertChain `json:"cert_chain"`
+}
+
+// ListTrustedRootChains returns the identifiers of the trusted root chains.
+func (m *Manager) ListTrustedRootChains(ctx context.Context) ([]string, error) {
+	r := m.Resource(TrustedRootChainsPath)
+	var res []struct {
+		Chain string `json:"chain"`
+	}
+	if err := m.Do(ctx, r.Request(http.MethodGet), &res); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(res))
+	for i := range res {
+		ids[i] = res[i].Chain
+	}
+	return ids, nil
+}
+
+// CreateTrustedRootChain adds a trusted root chain and returns its identifier.
+func (m *Manager) CreateTrustedRootChain(ctx context.Context, spec TrustedRootChainCreateSpec) (string, error) {
+	r := m.Resource(TrustedRootChainsPath)
+	var res string
+	return res, m.Do(ctx, r.Request(http.MethodPost, spec), &res)
+}
+
+// GetTrustedRootChain returns the given trusted root chain.
+func (m *Manager) GetTrustedRootChain(ctx context.Context, chain string) (*TrustedRootChainInfo, error) {
+	r := m.Resource(TrustedRootChainsPath).WithSubpath(chain)
+	var res TrustedRootChainInfo
+	return &res, m.Do(ctx, r.Request(http.MethodGet), &res)
+}
+
+// DeleteTrustedRootChain removes the given trusted root chain.
+func (m *Manager) DeleteTrustedRootChain(ctx context.Context, chain string) error {
+	r := m.Resource(TrustedRootChainsPath).WithSubpath(chain)
+	return m.Do(ctx, r.Request(http.MethodDelete), nil)
+}
@@ -0,0 +1,517 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package guest
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Conversion between the REST representation of a customization specification and the vim25 types,
+// as used by object.CustomizationSpecManager and the VirtualMachine.Customize and Clone methods.
+//
+// The REST API does not expose encrypted passwords, such passwords are omitted when converting from vim25 types.
+// Passwords are always converted to plain text vim25 passwords.
+
+var rebootOptions = map[types.CustomizationSysprepRebootOption]string{
+	types.CustomizationSysprepRebootOptionReboot:   RebootReboot,
+	types.CustomizationSysprepRebootOptionNoreboot: RebootNoReboot,
+	types.CustomizationSysprepRebootOptionShutdown: RebootShutdown,
+}
+
+var netBIOSModes = map[types.CustomizationNetBIOSMode]string{
+	types.CustomizationNetBIOSModeEnableNetBIOSViaDhcp: NetBIOSModeUseDHCP,
+	types.CustomizationNetBIOSModeEnableNetBIOS:        NetBIOSModeEnable,
+	types.CustomizationNetBIOSModeDisableNetBIOS:       NetBIOSModeDisable,
+}
+
+// reverse returns the inverse of the given enum mapping
+func reverse[K, V comparable](m map[K]V) map[V]K {
+	r := make(map[V]K, len(m))
+	for k, v := range m {
+		r[v] = k
+	}
+	return r
+}
+
+// OSType returns the guest operating system type of the specification, OSTypeLinux or OSTypeWindows.
+func (s *CustomizationSpec) OSType() string {
+	if s.ConfigurationSpec.WindowsConfig != nil {
+		return OSTypeWindows
+	}
+	return OSTypeLinux
+}
+
+// Summary returns the summary of the given customization specification.
+func (info *CustomizationSpecInfo) Summary() CustomizationSpecSummary {
+	return CustomizationSpecSummary{
+		Name:         info.Name,
+		Description:  info.Description,
+		OSType:       info.Spec.OSType(),
+		LastModified: info.LastModified,
+	}
+}
+
+// FromCustomizationSpecItem converts the given vim25 customization specification item.
+// The item's change version is used as the fingerprint.
+func FromCustomizationSpecItem(item *types.CustomizationSpecItem) (*CustomizationSpecInfo, error) {
+	spec, err := FromCustomizationSpec(&item.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &CustomizationSpecInfo{
+		Name:        item.Info.Name,
+		Description: item.Info.Description,
+		Fingerprint: item.Info.ChangeVersion,
+		Spec:        *spec,
+	}
+	if item.Info.LastUpdateTime != nil {
+		info.LastModified = *item.Info.LastUpdateTime
+	}
+
+	return info, nil
+}
+
+// ToCustomizationSpecItem converts the given specification to a vim25 customization specification item.
+func ToCustomizationSpecItem(name, description string, spec *CustomizationSpec) (*types.CustomizationSpecItem, error) {
+	s, err := spec.ToCustomizationSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	kind := "Linux"
+	if spec.OSType() == OSTypeWindows {
+		kind = "Windows"
+	}
+
+	now := time.Now()
+
+	return &types.CustomizationSpecItem{
+		Info: types.CustomizationSpecInfo{
+			Name:           name,
+			Description:    description,
+			Type:           kind,
+			LastUpdateTime: &now,
+		},
+		Spec: *s,
+	}, nil
+}
+
+// FromCustomizationSpec converts the given vim25 customization specification.
+func FromCustomizationSpec(spec *types.CustomizationSpec) (*CustomizationSpec, error) {
+	var res CustomizationSpec
+	var err error
+
+	switch identity := spec.Identity.(type) {
+	case *types.CustomizationLinuxPrep:
+		res.ConfigurationSpec.LinuxConfig, err = fromLinuxPrep(identity)
+	case *types.CustomizationSysprep:
+		res.ConfigurationSpec.WindowsConfig, err = fromSysprep(identity)
+	case *types.CustomizationSysprepText:
+		res.ConfigurationSpec.WindowsConfig = &WindowsConfiguration{SysprepXML: identity.Value}
+	case *types.CustomizationCloudinitPrep:
+		res.ConfigurationSpec.CloudConfig = &CloudConfiguration{
+			Type: CloudConfigCloudinit,
+			Cloudinit: &CloudinitConfiguration{
+				Metadata: identity.Metadata,
+				Userdata: identity.Userdata,
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported customization identity %T", spec.Identity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if options, ok := spec.Options.(*types.CustomizationWinOptions); ok && res.ConfigurationSpec.WindowsConfig != nil {
+		res.ConfigurationSpec.WindowsConfig.Reboot = rebootOptions[options.Reboot]
+	}
+
+	res.GlobalDNSSettings = GlobalDNSSettings{
+		DNSSuffixList: spec.GlobalIPSettings.DnsSuffixList,
+		DNSServers:    spec.GlobalIPSettings.DnsServerList,
+	}
+
+	res.Interfaces = []AdapterMapping{}
+	for _, nic := range spec.NicSettingMap {
+		adapter, err := fromIPSettings(&nic.Adapter)
+		if err != nil {
+			return nil, err
+		}
+		res.Interfaces = append(res.Interfaces, AdapterMapping{MacAddress: nic.MacAddress, Adapter: *adapter})
+	}
+
+	return &res, nil
+}
+
+func fromName(name types.BaseCustomizationName) (*HostnameGenerator, error) {
+	switch name := name.(type) {
+	case nil:
+		return nil, nil
+	case *types.CustomizationFixedName:
+		return &HostnameGenerator{Type: HostnameFixed, FixedName: name.Name}, nil
+	case *types.CustomizationPrefixName:
+		return &HostnameGenerator{Type: HostnamePrefix, Prefix: name.Base}, nil
+	case *types.CustomizationVirtualMachineName:
+		return &HostnameGenerator{Type: HostnameVirtualMachine}, nil
+	case *types.CustomizationUnknownName:
+		return &HostnameGenerator{Type: HostnameUserInputRequired}, nil
+	default:
+		return nil, fmt.Errorf("unsupported customization name %T", name)
+	}
+}
+
+func fromPassword(p *types.CustomizationPassword) string {
+	if p == nil || !p.PlainText {
+		return ""
+	}
+	return p.Value
+}
+
+func fromLinuxPrep(identity *types.CustomizationLinuxPrep) (*LinuxConfiguration, error) {
+	name, err := fromName(identity.HostName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LinuxConfiguration{
+		Hostname:   name,
+		Domain:     identity.Domain,
+		TimeZone:   identity.TimeZone,
+		ScriptText: identity.ScriptText,
+	}, nil
+}
+
+func fromSysprep(identity *types.CustomizationSysprep) (*WindowsConfiguration, error) {
+	name, err := fromName(identity.UserData.ComputerName)
+	if err != nil {
+		return nil, err
+	}
+
+	sysprep := &WindowsSysprep{
+		UserData: UserData{
+			FullName:     identity.UserData.FullName,
+			Organization: identity.UserData.OrgName,
+			ProductKey:   identity.UserData.ProductId,
+		},
+		GuiUnattended: GuiUnattended{
+			AutoLogon:      identity.GuiUnattended.AutoLogon,
+			AutoLogonCount: int64(identity.GuiUnattended.AutoLogonCount),
+			Password:       fromPassword(identity.GuiUnattended.Password),
+			TimeZone:       int64(identity.GuiUnattended.TimeZone),
+		},
+	}
+	if name != nil {
+		sysprep.UserData.ComputerName = *name
+	}
+	if identity.GuiRunOnce != nil {
+		sysprep.GuiRunOnceCommands = identity.GuiRunOnce.CommandList
+	}
+
+	id := identity.Identification
+	switch {
+	case id.JoinDomain != "":
+		sysprep.Domain = &DomainConfiguration{
+			Type:           DomainDomain,
+			Domain:         id.JoinDomain,
+			DomainUsername: id.DomainAdmin,
+			DomainPassword: fromPassword(id.DomainAdminPassword),
+			DomainOU:       id.DomainOU,
+		}
+	case id.JoinWorkgroup != "":
+		sysprep.Domain = &DomainConfiguration{
+			Type:      DomainWorkgroup,
+			Workgroup: id.JoinWorkgroup,
+		}
+	}
+
+	return &WindowsConfiguration{Sysprep: sysprep}, nil
+}
+
+// prefixLength returns the prefix length of the given IPv4 subnet mask
+func prefixLength(mask string) (int64, error) {
+	if mask == "" {
+		return 0, nil
+	}
+	ip := net.ParseIP(mask).To4()
+	if ip == nil {
+		return 0, fmt.Errorf("invalid subnet mask %q", mask)
+	}
+	ones, bits := net.IPMask(ip).Size()
+	if bits == 0 {
+		return 0, fmt.Errorf("invalid subnet mask %q", mask)
+	}
+	return int64(ones), nil
+}
+
+func fromIPSettings(settings *types.CustomizationIPSettings) (*IPSettings, error) {
+	var res IPSettings
+
+	prefix, err := prefixLength(settings.SubnetMask)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ip := settings.Ip.(type) {
+	case nil:
+	case *types.CustomizationDhcpIpGenerator:
+		res.IPv4 = &IPv4{Type: IPTypeDHCP}
+	case *types.CustomizationFixedIp:
+		res.IPv4 = &IPv4{Type: IPTypeStatic, IPAddress: ip.IpAddress, Prefix: prefix, Gateways: settings.Gateway}
+	case *types.CustomizationUnknownIpGenerator:
+		res.IPv4 = &IPv4{Type: IPTypeUserInputRequired, Prefix: prefix, Gateways: settings.Gateway}
+	default:
+		return nil, fmt.Errorf("unsupported IP generator %T", settings.Ip)
+	}
+
+	if spec := settings.IpV6Spec; spec != nil {
+		res.IPv6 = &IPv6{Gateways: spec.Gateway}
+		for _, ip := range spec.Ip {
+			switch ip := ip.(type) {
+			case *types.CustomizationDhcpIpV6Generator:
+				res.IPv6.Type = IPTypeDHCP
+			case *types.CustomizationFixedIpV6:
+				res.IPv6.Type = IPTypeStatic
+				res.IPv6.IPv6 = append(res.IPv6.IPv6, IPv6Address{IPAddress: ip.IpAddress, Prefix: int64(ip.SubnetMask)})
+			case *types.CustomizationUnknownIpV6Generator:
+				res.IPv6.Type = IPTypeUserInputRequired
+			default:
+				return nil, fmt.Errorf("unsupported IPv6 generator %T", ip)
+			}
+		}
+	}
+
+	if len(settings.DnsServerList) != 0 || settings.DnsDomain != "" ||
+		settings.PrimaryWINS != "" || settings.SecondaryWINS != "" || settings.NetBIOS != "" {
+		res.Windows = &WindowsAdapterSettings{
+			DNSServers:  settings.DnsServerList,
+			DNSDomain:   settings.DnsDomain,
+			NetBIOSMode: netBIOSModes[settings.NetBIOS],
+		}
+		for _, wins := range []string{settings.PrimaryWINS, settings.SecondaryWINS} {
+			if wins != "" {
+				res.Windows.WINSServers = append(res.Windows.WINSServers, wins)
+			}
+		}
+	}
+
+	return &res, nil
+}
+
+// ToCustomizationSpec converts the specification to a vim25 customization specification.
+func (s *CustomizationSpec) ToCustomizationSpec() (*types.CustomizationSpec, error) {
+	var res types.CustomizationSpec
+
+	config := s.ConfigurationSpec
+
+	switch {
+	case config.LinuxConfig != nil:
+		identity := &types.CustomizationLinuxPrep{
+			Domain:     config.LinuxConfig.Domain,
+			TimeZone:   config.LinuxConfig.TimeZone,
+			ScriptText: config.LinuxConfig.ScriptText,
+		}
+		name, err := config.LinuxConfig.Hostname.toName()
+		if err != nil {
+			return nil, err
+		}
+		identity.HostName = name
+		res.Identity = identity
+		res.Options = &types.CustomizationLinuxOptions{}
+	case config.WindowsConfig != nil:
+		options := &types.CustomizationWinOptions{ChangeSID: true}
+		if config.WindowsConfig.Reboot != "" {
+			reboot, ok := reverse(rebootOptions)[config.WindowsConfig.Reboot]
+			if !ok {
+				return nil, fmt.Errorf("invalid reboot option %q", config.WindowsConfig.Reboot)
+			}
+			options.Reboot = reboot
+		}
+		res.Options = options
+
+		switch {
+		case config.WindowsConfig.Sysprep != nil:
+			identity, err := config.WindowsConfig.Sysprep.toSysprep()
+			if err != nil {
+				return nil, err
+			}
+			res.Identity = identity
+		case config.WindowsConfig.SysprepXML != "":
+			res.Identity = &types.CustomizationSysprepText{Value: config.WindowsConfig.SysprepXML}
+		default:
+			return nil, fmt.Errorf("windows configuration requires sysprep or sysprep_xml")
+		}
+	case config.CloudConfig != nil:
+		if config.CloudConfig.Type != CloudConfigCloudinit || config.CloudConfig.Cloudinit == nil {
+			return nil, fmt.Errorf("unsupported cloud configuration type %q", config.CloudConfig.Type)
+		}
+		res.Identity = &types.CustomizationCloudinitPrep{
+			Metadata: config.CloudConfig.Cloudinit.Metadata,
+			Userdata: config.CloudConfig.Cloudinit.Userdata,
+		}
+		res.Options = &types.CustomizationLinuxOptions{}
+	default:
+		return nil, fmt.Errorf("configuration spec requires linux_config, windows_config or cloud_config")
+	}
+
+	res.GlobalIPSettings = types.CustomizationGlobalIPSettings{
+		DnsSuffixList: s.GlobalDNSSettings.DNSSuffixList,
+		DnsServerList: s.GlobalDNSSettings.DNSServers,
+	}
+
+	for _, nic := range s.Interfaces {
+		adapter, err := nic.Adapter.toIPSettings()
+		if err != nil {
+			return nil, err
+		}
+		res.NicSettingMap = append(res.NicSettingMap, types.CustomizationAdapterMapping{
+			MacAddress: nic.MacAddress,
+			Adapter:    *adapter,
+		})
+	}
+
+	return &res, nil
+}
+
+func (g *HostnameGenerator) toName() (types.BaseCustomizationName, error) {
+	if g == nil {
+		return &types.CustomizationVirtualMachineName{}, nil
+	}
+
+	switch g.Type {
+	case HostnameFixed:
+		return &types.CustomizationFixedName{Name: g.FixedName}, nil
+	case HostnamePrefix:
+		return &types.CustomizationPrefixName{Base: g.Prefix}, nil
+	case HostnameVirtualMachine:
+		return &types.CustomizationVirtualMachineName{}, nil
+	case HostnameUserInputRequired:
+		return &types.CustomizationUnknownName{}, nil
+	default:
+		return nil, fmt.Errorf("invalid hostname generator type %q", g.Type)
+	}
+}
+
+func toPassword(p string) *types.CustomizationPassword {
+	if p == "" {
+		return nil
+	}
+	return &types.CustomizationPassword{Value: p, PlainText: true}
+}
+
+func (s *WindowsSysprep) toSysprep() (*types.CustomizationSysprep, error) {
+	name, err := s.UserData.ComputerName.toName()
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &types.CustomizationSysprep{
+		GuiUnattended: types.CustomizationGuiUnattended{
+			Password:       toPassword(s.GuiUnattended.Password),
+			TimeZone:       int32(s.GuiUnattended.TimeZone),
+			AutoLogon:      s.GuiUnattended.AutoLogon,
+			AutoLogonCount: int32(s.GuiUnattended.AutoLogonCount),
+		},
+		UserData: types.CustomizationUserData{
+			FullName:     s.UserData.FullName,
+			OrgName:      s.UserData.Organization,
+			ComputerName: name,
+			ProductId:    s.UserData.ProductKey,
+		},
+	}
+
+	if len(s.GuiRunOnceCommands) != 0 {
+		identity.GuiRunOnce = &types.CustomizationGuiRunOnce{CommandList: s.GuiRunOnceCommands}
+	}
+
+	if d := s.Domain; d != nil {
+		switch d.Type {
+		case DomainDomain:
+			identity.Identification = types.CustomizationIdentification{
+				JoinDomain:          d.Domain,
+				DomainAdmin:         d.DomainUsername,
+				DomainAdminPassword: toPassword(d.DomainPassword),
+				DomainOU:            d.DomainOU,
+			}
+		case DomainWorkgroup:
+			identity.Identification = types.CustomizationIdentification{JoinWorkgroup: d.Workgroup}
+		default:
+			return nil, fmt.Errorf("invalid domain configuration type %q", d.Type)
+		}
+	}
+
+	return identity, nil
+}
+
+func (s *IPSettings) toIPSettings() (*types.CustomizationIPSettings, error) {
+	var res types.CustomizationIPSettings
+
+	if ip := s.IPv4; ip != nil {
+		switch ip.Type {
+		case IPTypeDHCP:
+			res.Ip = &types.CustomizationDhcpIpGenerator{}
+		case IPTypeStatic:
+			res.Ip = &types.CustomizationFixedIp{IpAddress: ip.IPAddress}
+		case IPTypeUserInputRequired:
+			res.Ip = &types.CustomizationUnknownIpGenerator{}
+		default:
+			return nil, fmt.Errorf("invalid IPv4 type %q", ip.Type)
+		}
+		if ip.Prefix > 0 {
+			if ip.Prefix > 32 {
+				return nil, fmt.Errorf("invalid IPv4 prefix %d", ip.Prefix)
+			}
+			res.SubnetMask = net.IP(net.CIDRMask(int(ip.Prefix), 32)).String()
+		}
+		res.Gateway = ip.Gateways
+	} else {
+		res.Ip = &types.CustomizationDhcpIpGenerator{}
+	}
+
+	if ip := s.IPv6; ip != nil {
+		spec := &types.CustomizationIPSettingsIpV6AddressSpec{Gateway: ip.Gateways}
+		switch ip.Type {
+		case IPTypeDHCP:
+			spec.Ip = []types.BaseCustomizationIpV6Generator{&types.CustomizationDhcpIpV6Generator{}}
+		case IPTypeStatic:
+			for _, addr := range ip.IPv6 {
+				spec.Ip = append(spec.Ip, &types.CustomizationFixedIpV6{IpAddress: addr.IPAddress, SubnetMask: int32(addr.Prefix)})
+			}
+		case IPTypeUserInputRequired:
+			spec.Ip = []types.BaseCustomizationIpV6Generator{&types.CustomizationUnknownIpV6Generator{}}
+		default:
+			return nil, fmt.Errorf("invalid IPv6 type %q", ip.Type)
+		}
+		res.IpV6Spec = spec
+	}
+
+	if w := s.Windows; w != nil {
+		res.DnsServerList = w.DNSServers
+		res.DnsDomain = w.DNSDomain
+		if len(w.WINSServers) > 2 {
+			return nil, fmt.Errorf("at most 2 WINS servers are supported")
+		}
+		if len(w.WINSServers) > 0 {
+			res.PrimaryWINS = w.WINSServers[0]
+		}
+		if len(w.WINSServers) > 1 {
+			res.SecondaryWINS = w.WINSServers[1]
+		}
+		if w.NetBIOSMode != "" {
+			mode, ok := reverse(netBIOSModes)[w.NetBIOSMode]
+			if !ok {
+				return nil, fmt.Errorf("invalid NetBIOS mode %q", w.NetBIOSMode)
+			}
+			res.NetBIOS = mode
+		}
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,154 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package guest
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+// CustomizationSpecsPath is the REST endpoint for the guest customization specifications API
+const CustomizationSpecsPath = "/api/vcenter/guest/customization-specs"
+
+// Manager extends rest.Client, adding guest customization specification related methods.
+//
+// See https://developer.broadcom.com/xapis/vsphere-automation-api/latest/vcenter/guest/customization-specs/
+type Manager struct {
+	*rest.Client
+}
+
+// NewManager creates a new Manager instance with the given client.
+func NewManager(client *rest.Client) *Manager {
+	return &Manager{
+		Client: client,
+	}
+}
+
+// specPath returns the path of the given customization specification.
+// The path is not escaped, as rest.Client.Resource sets URL.Path.
+func specPath(name string) string {
+	return path.Join(CustomizationSpecsPath, name)
+}
+
+// Guest operating system types of a customization specification.
+const (
+	OSTypeLinux   = "LINUX"
+	OSTypeWindows = "WINDOWS"
+)
+
+// Export formats of a customization specification.
+const (
+	FormatJSON = "JSON"
+	FormatXML  = "XML"
+)
+
+// CustomizationSpecFilter contains the properties used to filter the result of ListCustomizationSpecs.
+// An empty field matches all specifications.
+type CustomizationSpecFilter struct {
+	Names  []string
+	OSType string
+}
+
+// CustomizationSpecSummary contains commonly used information about a customization specification.
+type CustomizationSpecSummary struct {
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	OSType       string    `json:"os_type"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// CustomizationSpecCreateSpec describes a customization specification to be created.
+type CustomizationSpecCreateSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Spec        CustomizationSpec `json:"spec"`
+}
+
+// CustomizationSpecSetSpec describes the new content of an existing customization specification.
+// If Fingerprint is set, the update fails unless it matches the fingerprint of the current content.
+type CustomizationSpecSetSpec struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
+	Spec        CustomizationSpec `json:"spec"`
+}
+
+// CustomizationSpecInfo contains information about a customization specification.
+type CustomizationSpecInfo struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	Fingerprint  string            `json:"fingerprint"`
+	LastModified time.Time         `json:"last_modified"`
+	Spec         CustomizationSpec `json:"spec"`
+}
+
+func (f *CustomizationSpecFilter) params(r *rest.Resource) *rest.Resource {
+	for _, name := range f.Names {
+		r.WithParam("names", name)
+	}
+	if f.OSType != "" {
+		r.WithParam("OS_type", f.OSType)
+	}
+	return r
+}
+
+// ListCustomizationSpecs returns the customization specifications that match the given filter.
+func (c *Manager) ListCustomizationSpecs(ctx context.Context, filter CustomizationSpecFilter) ([]CustomizationSpecSummary, error) {
+	url := filter.params(c.Resource(CustomizationSpecsPath))
+	var res []CustomizationSpecSummary
+	return res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// CreateCustomizationSpec creates a customization specification, returning its name.
+func (c *Manager) CreateCustomizationSpec(ctx context.Context, spec CustomizationSpecCreateSpec) (string, error) {
+	url := c.Resource(CustomizationSpecsPath)
+	var res string
+	return res, c.Do(ctx, url.Request(http.MethodPost, spec), &res)
+}
+
+// GetCustomizationSpec returns the given customization specification.
+func (c *Manager) GetCustomizationSpec(ctx context.Context, name string) (*CustomizationSpecInfo, error) {
+	url := c.Resource(specPath(name))
+	var res CustomizationSpecInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// SetCustomizationSpec replaces the content of the given customization specification.
+func (c *Manager) SetCustomizationSpec(ctx context.Context, name string, spec CustomizationSpecSetSpec) error {
+	url := c.Resource(specPath(name))
+	return c.Do(ctx, url.Request(http.MethodPut, spec), nil)
+}
+
+// DeleteCustomizationSpec deletes the given customization specification.
+func (c *Manager) DeleteCustomizationSpec(ctx context.Context, name string) error {
+	url := c.Resource(specPath(name))
+	return c.Do(ctx, url.Request(http.MethodDelete), nil)
+}
+
+// ExportCustomizationSpec returns the given customization specification in the given format,
+// FormatJSON or FormatXML, for use with ImportCustomizationSpec.
+func (c *Manager) ExportCustomizationSpec(ctx context.Context, name string, format string) (string, error) {
+	url := c.Resource(specPath(name)).WithParam("action", "export")
+	spec := struct {
+		Format string `json:"format"`
+	}{format}
+	var res string
+	return res, c.Do(ctx, url.Request(http.MethodPost, spec), &res)
+}
+
+// ImportCustomizationSpec converts the given exported customization specification, in JSON or XML format,
+// to a CustomizationSpecCreateSpec. The specification is not created, see CreateCustomizationSpec.
+func (c *Manager) ImportCustomizationSpec(ctx context.Context, content string) (*CustomizationSpecCreateSpec, error) {
+	url := c.Resource(CustomizationSpecsPath).WithParam("action", "import")
+	spec := struct {
+		Spec string `json:"customization_spec"`
+	}{content}
+	var res CustomizationSpecCreateSpec
+	return &res, c.Do(ctx, url.Request(http.MethodPost, spec), &res)
+}
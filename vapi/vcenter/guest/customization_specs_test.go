@@ -0,0 +1,269 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package guest_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/vcenter/guest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	_ "github.com/vmware/govmomi/vapi/vcenter/guest/simulator"
+)
+
+func linuxSpec() guest.CustomizationSpec {
+	return guest.CustomizationSpec{
+		ConfigurationSpec: guest.ConfigurationSpec{
+			LinuxConfig: &guest.LinuxConfiguration{
+				Hostname: &guest.HostnameGenerator{Type: guest.HostnamePrefix, Prefix: "web"},
+				Domain:   "example.com",
+				TimeZone: "UTC",
+			},
+		},
+		GlobalDNSSettings: guest.GlobalDNSSettings{
+			DNSSuffixList: []string{"example.com"},
+			DNSServers:    []string{"10.0.0.2"},
+		},
+		Interfaces: []guest.AdapterMapping{
+			{
+				Adapter: guest.IPSettings{
+					IPv4: &guest.IPv4{
+						Type:      guest.IPTypeStatic,
+						IPAddress: "10.0.0.10",
+						Prefix:    22,
+						Gateways:  []string{"10.0.0.1"},
+					},
+					IPv6: &guest.IPv6{
+						Type: guest.IPTypeStatic,
+						IPv6: []guest.IPv6Address{{IPAddress: "fd00::10", Prefix: 64}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func windowsSpec() guest.CustomizationSpec {
+	return guest.CustomizationSpec{
+		ConfigurationSpec: guest.ConfigurationSpec{
+			WindowsConfig: &guest.WindowsConfiguration{
+				Reboot: guest.RebootNoReboot,
+				Sysprep: &guest.WindowsSysprep{
+					GuiRunOnceCommands: []string{"cmd /c echo hello"},
+					UserData: guest.UserData{
+						ComputerName: guest.HostnameGenerator{Type: guest.HostnameFixed, FixedName: "win01"},
+						FullName:     "govc",
+						Organization: "VMware",
+					},
+					Domain: &guest.DomainConfiguration{
+						Type:           guest.DomainDomain,
+						Domain:         "corp.example.com",
+						DomainUsername: "admin",
+						DomainPassword: "secret",
+					},
+					GuiUnattended: guest.GuiUnattended{
+						AutoLogon:      true,
+						AutoLogonCount: 2,
+						Password:       "secret",
+						TimeZone:       85,
+					},
+				},
+			},
+		},
+		Interfaces: []guest.AdapterMapping{
+			{
+				MacAddress: "00:50:56:00:00:01",
+				Adapter: guest.IPSettings{
+					IPv4: &guest.IPv4{Type: guest.IPTypeDHCP},
+					Windows: &guest.WindowsAdapterSettings{
+						DNSServers:  []string{"10.0.0.2"},
+						DNSDomain:   "corp.example.com",
+						WINSServers: []string{"10.0.0.3", "10.0.0.4"},
+						NetBIOSMode: guest.NetBIOSModeDisable,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	for _, spec := range []guest.CustomizationSpec{linuxSpec(), windowsSpec()} {
+		s, err := spec.ToCustomizationSpec()
+		require.NoError(t, err)
+
+		res, err := guest.FromCustomizationSpec(s)
+		require.NoError(t, err)
+		assert.Equal(t, spec, *res)
+	}
+
+	linux, windows := linuxSpec(), windowsSpec()
+
+	s, err := linux.ToCustomizationSpec()
+	require.NoError(t, err)
+	adapter := s.NicSettingMap[0].Adapter
+	assert.Equal(t, "255.255.252.0", adapter.SubnetMask)
+	assert.Equal(t, "10.0.0.10", adapter.Ip.(*types.CustomizationFixedIp).IpAddress)
+
+	s, err = windows.ToCustomizationSpec()
+	require.NoError(t, err)
+	sysprep := s.Identity.(*types.CustomizationSysprep)
+	assert.True(t, sysprep.GuiUnattended.Password.PlainText)
+	assert.Equal(t, types.CustomizationSysprepRebootOptionNoreboot, s.Options.(*types.CustomizationWinOptions).Reboot)
+
+	// encrypted passwords are not exposed
+	sysprep.GuiUnattended.Password.PlainText = false
+	res, err := guest.FromCustomizationSpec(s)
+	require.NoError(t, err)
+	assert.Empty(t, res.ConfigurationSpec.WindowsConfig.Sysprep.GuiUnattended.Password)
+
+	invalid := linuxSpec()
+	invalid.Interfaces[0].Adapter.IPv4.Type = "enoent"
+	_, err = invalid.ToCustomizationSpec()
+	assert.Error(t, err)
+
+	_, err = guest.FromCustomizationSpec(&types.CustomizationSpec{
+		Identity: &types.CustomizationLinuxPrep{HostName: &types.CustomizationCustomName{}},
+	})
+	assert.Error(t, err)
+}
+
+func TestCustomizationSpecs(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+		m := guest.NewManager(c)
+		csm := object.NewCustomizationSpecManager(vc)
+
+		specs, err := m.ListCustomizationSpecs(ctx, guest.CustomizationSpecFilter{})
+		require.NoError(t, err)
+		assert.Len(t, specs, len(simulator.DefaultCustomizationSpec))
+
+		specs, err = m.ListCustomizationSpecs(ctx, guest.CustomizationSpecFilter{OSType: guest.OSTypeLinux})
+		require.NoError(t, err)
+		require.NotEmpty(t, specs)
+		for _, s := range specs {
+			assert.Equal(t, guest.OSTypeLinux, s.OSType)
+		}
+
+		info, err := m.GetCustomizationSpec(ctx, "vcsim-linux")
+		require.NoError(t, err)
+		assert.Equal(t, guest.HostnameVirtualMachine, info.Spec.ConfigurationSpec.LinuxConfig.Hostname.Type)
+		assert.Equal(t, guest.IPTypeDHCP, info.Spec.Interfaces[0].Adapter.IPv4.Type)
+
+		_, err = m.GetCustomizationSpec(ctx, "enoent")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		// create via REST, visible via SOAP
+		name, err := m.CreateCustomizationSpec(ctx, guest.CustomizationSpecCreateSpec{
+			Name:        "web",
+			Description: "web servers",
+			Spec:        linuxSpec(),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "web", name)
+
+		_, err = m.CreateCustomizationSpec(ctx, guest.CustomizationSpecCreateSpec{Name: "web", Spec: linuxSpec()})
+		assert.ErrorContains(t, err, "ALREADY_EXISTS")
+
+		item, err := csm.GetCustomizationSpec(ctx, "web")
+		require.NoError(t, err)
+		assert.Equal(t, "Linux", item.Info.Type)
+		assert.Equal(t, "255.255.252.0", item.Spec.NicSettingMap[0].Adapter.SubnetMask)
+
+		info, err = m.GetCustomizationSpec(ctx, "web")
+		require.NoError(t, err)
+		assert.Equal(t, linuxSpec(), info.Spec)
+		assert.Equal(t, item.Info.ChangeVersion, info.Fingerprint)
+
+		// update with fingerprint
+		spec := guest.CustomizationSpecSetSpec{
+			Name:        "web",
+			Description: "updated",
+			Fingerprint: info.Fingerprint,
+			Spec:        info.Spec,
+		}
+		spec.Spec.GlobalDNSSettings.DNSServers = []string{"10.0.0.53"}
+		require.NoError(t, m.SetCustomizationSpec(ctx, "web", spec))
+
+		err = m.SetCustomizationSpec(ctx, "web", spec)
+		assert.ErrorContains(t, err, "CONCURRENT_CHANGE")
+
+		item, err = csm.GetCustomizationSpec(ctx, "web")
+		require.NoError(t, err)
+		assert.Equal(t, "updated", item.Info.Description)
+		assert.Equal(t, []string{"10.0.0.53"}, item.Spec.GlobalIPSettings.DnsServerList)
+
+		specs, err = m.ListCustomizationSpecs(ctx, guest.CustomizationSpecFilter{Names: []string{"web"}})
+		require.NoError(t, err)
+		require.Len(t, specs, 1)
+		assert.Equal(t, "updated", specs[0].Description)
+
+		// export and import, in both formats
+		for _, format := range []string{guest.FormatJSON, guest.FormatXML} {
+			data, err := m.ExportCustomizationSpec(ctx, "web", format)
+			require.NoError(t, err)
+
+			imported, err := m.ImportCustomizationSpec(ctx, data)
+			require.NoError(t, err, format)
+			assert.Equal(t, "web", imported.Name)
+			assert.Equal(t, "updated", imported.Description)
+			assert.Equal(t, []string{"10.0.0.53"}, imported.Spec.GlobalDNSSettings.DNSServers)
+
+			imported.Name = "web-" + format
+			_, err = m.CreateCustomizationSpec(ctx, *imported)
+			require.NoError(t, err)
+		}
+
+		_, err = m.ExportCustomizationSpec(ctx, "web", "YAML")
+		assert.ErrorContains(t, err, "INVALID_ARGUMENT")
+
+		_, err = m.ImportCustomizationSpec(ctx, "<invalid")
+		assert.ErrorContains(t, err, "INVALID_ARGUMENT")
+
+		// created via SOAP, visible via REST
+		windows := windowsSpec()
+		s, err := windows.ToCustomizationSpec()
+		require.NoError(t, err)
+		err = csm.CreateCustomizationSpec(ctx, types.CustomizationSpecItem{
+			Info: types.CustomizationSpecInfo{Name: "win", Type: "Windows"},
+			Spec: *s,
+		})
+		require.NoError(t, err)
+
+		info, err = m.GetCustomizationSpec(ctx, "win")
+		require.NoError(t, err)
+		assert.Equal(t, windowsSpec(), info.Spec)
+
+		require.NoError(t, m.DeleteCustomizationSpec(ctx, "web"))
+
+		err = m.DeleteCustomizationSpec(ctx, "web")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		exists, err := csm.DoesCustomizationSpecExist(ctx, "web")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		// names are escaped once in the request path
+		_, err = m.CreateCustomizationSpec(ctx, guest.CustomizationSpecCreateSpec{Name: "My Spec", Spec: linuxSpec()})
+		require.NoError(t, err)
+
+		info, err = m.GetCustomizationSpec(ctx, "My Spec")
+		require.NoError(t, err)
+		assert.Equal(t, "My Spec", info.Name)
+
+		require.NoError(t, m.DeleteCustomizationSpec(ctx, "My Spec"))
+	})
+}
@@ -0,0 +1,376 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/vmware/govmomi/simulator"
+	vapi "github.com/vmware/govmomi/vapi/simulator"
+	"github.com/vmware/govmomi/vapi/vcenter/guest"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func init() {
+	simulator.RegisterEndpoint(func(s *simulator.Service, r *simulator.Registry) {
+		New(s.Listen).Register(s, r)
+	})
+}
+
+// Handler implements the guest customization specifications API simulator.
+// Specifications are stored by the vim25 simulator's CustomizationSpecManager,
+// such that changes made via either API are visible to both.
+type Handler struct {
+	URL      *url.URL
+	registry *simulator.Registry
+	manager  *simulator.CustomizationSpecManager
+}
+
+// New creates a Handler instance
+func New(u *url.URL) *Handler {
+	return &Handler{
+		URL: u,
+	}
+}
+
+// Register guest customization specification API paths with the vapi simulator's http.ServeMux
+func (h *Handler) Register(s *simulator.Service, r *simulator.Registry) {
+	if !r.IsVPX() {
+		return
+	}
+
+	si := r.Get(vim25.ServiceInstance).(*simulator.ServiceInstance)
+	h.registry = r
+	h.manager = r.Get(*si.Content.CustomizationSpecManager).(*simulator.CustomizationSpecManager)
+
+	s.HandleFunc(guest.CustomizationSpecsPath, h.specs)
+	s.HandleFunc(guest.CustomizationSpecsPath+"/{name}", h.spec)
+}
+
+func (h *Handler) newContext() *simulator.Context {
+	return &simulator.Context{
+		Context: context.Background(),
+		Session: &simulator.Session{
+			UserSession: types.UserSession{
+				Key: uuid.New().String(),
+			},
+			Registry: h.registry,
+		},
+		Map: h.registry,
+	}
+}
+
+// call invokes a CustomizationSpecManager method with the manager locked
+func (h *Handler) call(f func(*simulator.Context) soap.HasFault) (soap.HasFault, types.BaseMethodFault) {
+	ctx := h.newContext()
+	var body soap.HasFault
+
+	h.registry.WithLock(ctx, h.manager.Reference(), func() {
+		body = f(ctx)
+	})
+
+	if fault := body.Fault(); fault != nil {
+		return nil, fault.VimFault().(types.BaseMethodFault)
+	}
+
+	return body, nil
+}
+
+// fault responds with the REST error corresponding to the given vim25 fault
+func (h *Handler) fault(w http.ResponseWriter, r *http.Request, fault types.BaseMethodFault) {
+	switch fault.(type) {
+	case *types.NotFound:
+		vapi.ApiErrorNotFound(w)
+	case *types.AlreadyExists:
+		vapi.ApiErrorAlreadyExists(w)
+	case *types.ConcurrentAccess:
+		vapi.ApiErrorConcurrentChange(w)
+	case *types.InvalidArgument, *types.CustomizationFault:
+		vapi.ApiErrorInvalidArgument(w)
+	default:
+		log.Printf("%s %s: %#v", r.Method, r.RequestURI, fault)
+		vapi.ApiErrorGeneral(w)
+	}
+}
+
+// error responds with INVALID_ARGUMENT for a specification that cannot be converted
+func (h *Handler) error(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+	vapi.ApiErrorInvalidArgument(w)
+}
+
+// get returns the given specification item
+func (h *Handler) get(name string) (*types.CustomizationSpecItem, types.BaseMethodFault) {
+	body, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+		return h.manager.GetCustomizationSpec(ctx, &types.GetCustomizationSpec{This: h.manager.Self, Name: name})
+	})
+	if fault != nil {
+		return nil, fault
+	}
+	return &body.(*methods.GetCustomizationSpecBody).Res.Returnval, nil
+}
+
+func osType(kind string) string {
+	return strings.ToUpper(kind)
+}
+
+// specs handles the customization specification collection.
+//
+//	GET  /api/vcenter/guest/customization-specs
+//	POST /api/vcenter/guest/customization-specs
+//	POST /api/vcenter/guest/customization-specs?action=import
+func (h *Handler) specs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPost:
+		switch r.URL.Query().Get("action") {
+		case "":
+			h.create(w, r)
+		case "import":
+			h.importSpec(w, r)
+		default:
+			vapi.ApiErrorInvalidArgument(w)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	names := query["names"]
+	kind := query.Get("OS_type")
+
+	var info []types.CustomizationSpecInfo
+	h.registry.WithLock(h.newContext(), h.manager.Reference(), func() {
+		info = h.manager.Get().(*simulator.CustomizationSpecManager).Info
+	})
+
+	res := []guest.CustomizationSpecSummary{}
+	for _, item := range info {
+		if len(names) != 0 && !slices.Contains(names, item.Name) {
+			continue
+		}
+		if kind != "" && kind != osType(item.Type) {
+			continue
+		}
+		s := guest.CustomizationSpecSummary{
+			Name:        item.Name,
+			Description: item.Description,
+			OSType:      osType(item.Type),
+		}
+		if item.LastUpdateTime != nil {
+			s.LastModified = *item.LastUpdateTime
+		}
+		res = append(res, s)
+	}
+
+	vapi.StatusOK(w, res)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var spec guest.CustomizationSpecCreateSpec
+	if !vapi.Decode(r, w, &spec) {
+		return
+	}
+
+	if spec.Name == "" {
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	item, err := guest.ToCustomizationSpecItem(spec.Name, spec.Description, &spec.Spec)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	_, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+		return h.manager.CreateCustomizationSpec(ctx, &types.CreateCustomizationSpec{This: h.manager.Self, Item: *item})
+	})
+	if fault != nil {
+		h.fault(w, r, fault)
+		return
+	}
+
+	vapi.StatusOK(w, spec.Name)
+}
+
+func (h *Handler) importSpec(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Spec string `json:"customization_spec"`
+	}
+	if !vapi.Decode(r, w, &req) {
+		return
+	}
+
+	var spec guest.CustomizationSpecCreateSpec
+
+	if content := strings.TrimSpace(req.Spec); strings.HasPrefix(content, "<") {
+		body, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+			return h.manager.XmlToCustomizationSpecItem(ctx, &types.XmlToCustomizationSpecItem{This: h.manager.Self, SpecItemXml: content})
+		})
+		if fault != nil {
+			h.fault(w, r, fault)
+			return
+		}
+
+		info, err := guest.FromCustomizationSpecItem(&body.(*methods.XmlToCustomizationSpecItemBody).Res.Returnval)
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+		spec.Name = info.Name
+		spec.Description = info.Description
+		spec.Spec = info.Spec
+	} else {
+		if err := json.Unmarshal([]byte(content), &spec); err != nil {
+			h.error(w, r, err)
+			return
+		}
+		if _, err := spec.Spec.ToCustomizationSpec(); err != nil {
+			h.error(w, r, err)
+			return
+		}
+	}
+
+	vapi.StatusOK(w, spec)
+}
+
+// spec handles a customization specification.
+//
+//	GET    /api/vcenter/guest/customization-specs/{name}
+//	PUT    /api/vcenter/guest/customization-specs/{name}
+//	DELETE /api/vcenter/guest/customization-specs/{name}
+//	POST   /api/vcenter/guest/customization-specs/{name}?action=export
+func (h *Handler) spec(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	switch r.Method {
+	case http.MethodGet:
+		item, fault := h.get(name)
+		if fault != nil {
+			h.fault(w, r, fault)
+			return
+		}
+
+		info, err := guest.FromCustomizationSpecItem(item)
+		if err != nil {
+			log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+			vapi.ApiErrorUnsupported(w)
+			return
+		}
+
+		vapi.StatusOK(w, info)
+	case http.MethodPut:
+		var spec guest.CustomizationSpecSetSpec
+		if !vapi.Decode(r, w, &spec) {
+			return
+		}
+
+		if spec.Name != "" && spec.Name != name {
+			vapi.ApiErrorInvalidArgument(w) // rename is not supported
+			return
+		}
+
+		item, err := guest.ToCustomizationSpecItem(name, spec.Description, &spec.Spec)
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+		item.Info.ChangeVersion = spec.Fingerprint
+
+		_, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+			return h.manager.OverwriteCustomizationSpec(ctx, &types.OverwriteCustomizationSpec{This: h.manager.Self, Item: *item})
+		})
+		if fault != nil {
+			h.fault(w, r, fault)
+			return
+		}
+
+		vapi.StatusOK(w)
+	case http.MethodDelete:
+		_, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+			return h.manager.DeleteCustomizationSpec(ctx, &types.DeleteCustomizationSpec{This: h.manager.Self, Name: name})
+		})
+		if fault != nil {
+			h.fault(w, r, fault)
+			return
+		}
+
+		vapi.StatusOK(w)
+	case http.MethodPost:
+		if r.URL.Query().Get("action") != "export" {
+			vapi.ApiErrorInvalidArgument(w)
+			return
+		}
+		h.export(w, r, name)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) export(w http.ResponseWriter, r *http.Request, name string) {
+	var req struct {
+		Format string `json:"format"`
+	}
+	if !vapi.Decode(r, w, &req) {
+		return
+	}
+
+	item, fault := h.get(name)
+	if fault != nil {
+		h.fault(w, r, fault)
+		return
+	}
+
+	switch req.Format {
+	case guest.FormatJSON:
+		info, err := guest.FromCustomizationSpecItem(item)
+		if err != nil {
+			log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+			vapi.ApiErrorUnsupported(w)
+			return
+		}
+
+		spec := guest.CustomizationSpecCreateSpec{
+			Name:        info.Name,
+			Description: info.Description,
+			Spec:        info.Spec,
+		}
+
+		b, err := json.Marshal(spec)
+		if err != nil {
+			h.error(w, r, err)
+			return
+		}
+
+		vapi.StatusOK(w, string(b))
+	case guest.FormatXML:
+		body, fault := h.call(func(ctx *simulator.Context) soap.HasFault {
+			return h.manager.CustomizationSpecItemToXml(ctx, &types.CustomizationSpecItemToXml{This: h.manager.Self, Item: *item})
+		})
+		if fault != nil {
+			h.fault(w, r, fault)
+			return
+		}
+
+		vapi.StatusOK(w, body.(*methods.CustomizationSpecItemToXmlBody).Res.Returnval)
+	default:
+		vapi.ApiErrorInvalidArgument(w)
+	}
+}
@@ -0,0 +1,178 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package guest
+
+// CustomizationSpec contains the guest operating system and network settings of a customization specification.
+type CustomizationSpec struct {
+	ConfigurationSpec ConfigurationSpec `json:"configuration_spec"`
+	GlobalDNSSettings GlobalDNSSettings `json:"global_DNS_settings"`
+	Interfaces        []AdapterMapping  `json:"interfaces"`
+}
+
+// ConfigurationSpec contains the guest operating system settings, exactly one field must be set.
+type ConfigurationSpec struct {
+	LinuxConfig   *LinuxConfiguration   `json:"linux_config,omitempty"`
+	WindowsConfig *WindowsConfiguration `json:"windows_config,omitempty"`
+	CloudConfig   *CloudConfiguration   `json:"cloud_config,omitempty"`
+}
+
+// Hostname generator types.
+const (
+	HostnameFixed             = "FIXED"
+	HostnamePrefix            = "PREFIX"
+	HostnameVirtualMachine    = "VIRTUAL_MACHINE"
+	HostnameUserInputRequired = "USER_INPUT_REQUIRED"
+)
+
+// HostnameGenerator specifies how the guest host name or computer name is generated.
+type HostnameGenerator struct {
+	Type      string `json:"type"`
+	FixedName string `json:"fixed_name,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+}
+
+// LinuxConfiguration contains the Linux guest customization settings.
+type LinuxConfiguration struct {
+	Hostname   *HostnameGenerator `json:"hostname,omitempty"`
+	Domain     string             `json:"domain,omitempty"`
+	TimeZone   string             `json:"time_zone,omitempty"`
+	ScriptText string             `json:"script_text,omitempty"`
+}
+
+// Windows reboot options.
+const (
+	RebootReboot   = "REBOOT"
+	RebootNoReboot = "NO_REBOOT"
+	RebootShutdown = "SHUTDOWN"
+)
+
+// WindowsConfiguration contains the Windows guest customization settings.
+// Either Sysprep or SysprepXML must be set.
+type WindowsConfiguration struct {
+	Reboot     string          `json:"reboot,omitempty"`
+	Sysprep    *WindowsSysprep `json:"sysprep,omitempty"`
+	SysprepXML string          `json:"sysprep_xml,omitempty"`
+}
+
+// WindowsSysprep contains the settings of the Windows sysprep answer file.
+type WindowsSysprep struct {
+	GuiRunOnceCommands []string             `json:"gui_run_once_commands,omitempty"`
+	UserData           UserData             `json:"user_data"`
+	Domain             *DomainConfiguration `json:"domain,omitempty"`
+	GuiUnattended      GuiUnattended        `json:"gui_unattended"`
+}
+
+// UserData contains the Windows user and computer identity.
+type UserData struct {
+	ComputerName HostnameGenerator `json:"computer_name"`
+	FullName     string            `json:"full_name"`
+	Organization string            `json:"organization"`
+	ProductKey   string            `json:"product_key"`
+}
+
+// Windows domain configuration types.
+const (
+	DomainWorkgroup = "WORKGROUP"
+	DomainDomain    = "DOMAIN"
+)
+
+// DomainConfiguration specifies the Windows workgroup or domain to join.
+type DomainConfiguration struct {
+	Type           string `json:"type"`
+	Workgroup      string `json:"workgroup,omitempty"`
+	Domain         string `json:"domain,omitempty"`
+	DomainUsername string `json:"domain_username,omitempty"`
+	DomainPassword string `json:"domain_password,omitempty"`
+	DomainOU       string `json:"domain_OU,omitempty"`
+}
+
+// GuiUnattended contains the Windows unattended installation settings.
+// Password is only included in responses if it is stored in plain text.
+type GuiUnattended struct {
+	AutoLogon      bool   `json:"auto_logon"`
+	AutoLogonCount int64  `json:"auto_logon_count"`
+	Password       string `json:"password,omitempty"`
+	TimeZone       int64  `json:"time_zone"`
+}
+
+// Cloud configuration types.
+const (
+	CloudConfigCloudinit = "CLOUDINIT"
+)
+
+// CloudConfiguration contains the cloud-init guest customization settings.
+type CloudConfiguration struct {
+	Type      string                  `json:"type"`
+	Cloudinit *CloudinitConfiguration `json:"cloudinit,omitempty"`
+}
+
+// CloudinitConfiguration contains the cloud-init metadata and userdata.
+type CloudinitConfiguration struct {
+	Metadata string `json:"metadata"`
+	Userdata string `json:"userdata,omitempty"`
+}
+
+// GlobalDNSSettings contains the DNS settings applied to all network interfaces.
+type GlobalDNSSettings struct {
+	DNSSuffixList []string `json:"dns_suffix_list,omitempty"`
+	DNSServers    []string `json:"dns_servers,omitempty"`
+}
+
+// AdapterMapping associates the IP settings with a network interface.
+// If MacAddress is not set, interfaces are matched in the order of the virtual machine's network adapters.
+type AdapterMapping struct {
+	MacAddress string     `json:"mac_address,omitempty"`
+	Adapter    IPSettings `json:"adapter"`
+}
+
+// IPSettings contains the IP settings of a network interface.
+type IPSettings struct {
+	IPv4    *IPv4                   `json:"ipv4,omitempty"`
+	IPv6    *IPv6                   `json:"ipv6,omitempty"`
+	Windows *WindowsAdapterSettings `json:"windows,omitempty"`
+}
+
+// IP address assignment types.
+const (
+	IPTypeDHCP              = "DHCP"
+	IPTypeStatic            = "STATIC"
+	IPTypeUserInputRequired = "USER_INPUT_REQUIRED"
+)
+
+// IPv4 contains the IPv4 settings of a network interface.
+type IPv4 struct {
+	Type      string   `json:"type"`
+	IPAddress string   `json:"ip_address,omitempty"`
+	Prefix    int64    `json:"prefix,omitempty"`
+	Gateways  []string `json:"gateways,omitempty"`
+}
+
+// IPv6 contains the IPv6 settings of a network interface.
+type IPv6 struct {
+	Type     string        `json:"type"`
+	IPv6     []IPv6Address `json:"ipv6,omitempty"`
+	Gateways []string      `json:"gateways,omitempty"`
+}
+
+// IPv6Address is a static IPv6 address.
+type IPv6Address struct {
+	IPAddress string `json:"ip_address"`
+	Prefix    int64  `json:"prefix"`
+}
+
+// NetBIOS modes.
+const (
+	NetBIOSModeUseDHCP = "USE_DHCP"
+	NetBIOSModeEnable  = "ENABLE"
+	NetBIOSModeDisable = "DISABLE"
+)
+
+// WindowsAdapterSettings contains the Windows specific settings of a network interface.
+type WindowsAdapterSettings struct {
+	DNSServers  []string `json:"dns_servers,omitempty"`
+	DNSDomain   string   `json:"dns_domain,omitempty"`
+	WINSServers []string `json:"wins_servers,omitempty"`
+	NetBIOSMode string   `json:"net_bios_mode,omitempty"`
+}
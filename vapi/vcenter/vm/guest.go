@@ -0,0 +1,130 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vm
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+// Guest OS families.
+const (
+	GuestOSFamilyWindows = "WINDOWS"
+	GuestOSFamilyLinux   = "LINUX"
+	GuestOSFamilyNetware = "NETWARE"
+	GuestOSFamilySolaris = "SOLARIS"
+	GuestOSFamilyDarwin  = "DARWIN"
+	GuestOSFamilyOther   = "OTHER"
+)
+
+// GuestIdentityInfo contains information about the guest operating system, as reported by VMware Tools.
+type GuestIdentityInfo struct {
+	Name      string                  `json:"name"`
+	Family    string                  `json:"family"`
+	FullName  rest.LocalizableMessage `json:"full_name"`
+	HostName  string                  `json:"host_name"`
+	IPAddress string                  `json:"ip_address,omitempty"`
+}
+
+// GuestDNSValues contains the DNS settings of the guest operating system.
+type GuestDNSValues struct {
+	DomainName    string   `json:"domain_name,omitempty"`
+	SearchDomains []string `json:"search_domains,omitempty"`
+}
+
+// GuestNetworkingInfo contains information about the network configuration of the guest operating system.
+type GuestNetworkingInfo struct {
+	DNSValues *GuestDNSValues `json:"dns_values,omitempty"`
+}
+
+// GuestIPAddress is an IP address assigned to a guest network interface.
+type GuestIPAddress struct {
+	IPAddress    string `json:"ip_address"`
+	PrefixLength int32  `json:"prefix_length"`
+	Origin       string `json:"origin,omitempty"`
+	State        string `json:"state"`
+}
+
+// GuestIPConfig contains the IP configuration of a guest network interface.
+type GuestIPConfig struct {
+	IPAddresses []GuestIPAddress `json:"ip_addresses"`
+}
+
+// GuestInterfaceInfo contains information about a guest network interface.
+type GuestInterfaceInfo struct {
+	MacAddress string         `json:"mac_address,omitempty"`
+	Nic        string         `json:"nic,omitempty"`
+	IP         *GuestIPConfig `json:"ip,omitempty"`
+}
+
+// Guest power states.
+const (
+	GuestPowerStateRunning      = "RUNNING"
+	GuestPowerStateShuttingDown = "SHUTTING_DOWN"
+	GuestPowerStateResetting    = "RESETTING"
+	GuestPowerStateStandby      = "STANDBY"
+	GuestPowerStateNotRunning   = "NOT_RUNNING"
+	GuestPowerStateUnavailable  = "UNAVAILABLE"
+)
+
+// GuestPowerInfo contains information about the power state of the guest operating system.
+type GuestPowerInfo struct {
+	State           string `json:"state"`
+	OperationsReady bool   `json:"operations_ready"`
+}
+
+// GetGuestIdentity returns information about the guest operating system of the given virtual machine.
+// VMware Tools must be running in the guest.
+func (c *Manager) GetGuestIdentity(ctx context.Context, vm string) (*GuestIdentityInfo, error) {
+	url := c.Resource(vmPath(vm, "guest", "identity"))
+	var res GuestIdentityInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// GetGuestNetworking returns the network configuration of the guest operating system of the given virtual machine.
+func (c *Manager) GetGuestNetworking(ctx context.Context, vm string) (*GuestNetworkingInfo, error) {
+	url := c.Resource(vmPath(vm, "guest", "networking"))
+	var res GuestNetworkingInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// ListGuestNetworkingInterfaces returns the network interfaces of the guest operating system of the given virtual machine.
+func (c *Manager) ListGuestNetworkingInterfaces(ctx context.Context, vm string) ([]GuestInterfaceInfo, error) {
+	url := c.Resource(vmPath(vm, "guest", "networking", "interfaces"))
+	var res []GuestInterfaceInfo
+	return res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// GetGuestPower returns the power state of the guest operating system of the given virtual machine.
+func (c *Manager) GetGuestPower(ctx context.Context, vm string) (*GuestPowerInfo, error) {
+	url := c.Resource(vmPath(vm, "guest", "power"))
+	var res GuestPowerInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+func (c *Manager) guestPower(ctx context.Context, vm string, action string) error {
+	url := c.Resource(vmPath(vm, "guest", "power")).WithParam("action", action)
+	return c.Do(ctx, url.Request(http.MethodPost), nil)
+}
+
+// ShutdownGuest issues a request to the guest operating system to shut down.
+// VMware Tools must be running in the guest.
+func (c *Manager) ShutdownGuest(ctx context.Context, vm string) error {
+	return c.guestPower(ctx, vm, "shutdown")
+}
+
+// RebootGuest issues a request to the guest operating system to reboot.
+// VMware Tools must be running in the guest.
+func (c *Manager) RebootGuest(ctx context.Context, vm string) error {
+	return c.guestPower(ctx, vm, "reboot")
+}
+
+// StandbyGuest issues a request to the guest operating system to suspend.
+// VMware Tools must be running in the guest.
+func (c *Manager) StandbyGuest(ctx context.Context, vm string) error {
+	return c.guestPower(ctx, vm, "standby")
+}
@@ -0,0 +1,215 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vm
+
+import (
+	"context"
+	"net/http"
+)
+
+// HardwareInfo contains information about the virtual hardware of a virtual machine.
+type HardwareInfo struct {
+	// Version is the virtual hardware version, such as "VMX_19".
+	Version        string `json:"version"`
+	UpgradePolicy  string `json:"upgrade_policy"`
+	UpgradeVersion string `json:"upgrade_version,omitempty"`
+	UpgradeStatus  string `json:"upgrade_status"`
+}
+
+// CPUInfo contains information about the CPU configuration of a virtual machine.
+type CPUInfo struct {
+	Count            int64 `json:"count"`
+	CoresPerSocket   int64 `json:"cores_per_socket"`
+	HotAddEnabled    bool  `json:"hot_add_enabled"`
+	HotRemoveEnabled bool  `json:"hot_remove_enabled"`
+}
+
+// CPUUpdateSpec describes changes to the CPU configuration of a virtual machine.
+// Count can only be changed while the virtual machine is powered on if CPU hot add
+// or hot remove is enabled.
+type CPUUpdateSpec struct {
+	Count            *int64 `json:"count,omitempty"`
+	CoresPerSocket   *int64 `json:"cores_per_socket,omitempty"`
+	HotAddEnabled    *bool  `json:"hot_add_enabled,omitempty"`
+	HotRemoveEnabled *bool  `json:"hot_remove_enabled,omitempty"`
+}
+
+// MemoryInfo contains information about the memory configuration of a virtual machine.
+type MemoryInfo struct {
+	SizeMiB       int64 `json:"size_MiB"`
+	HotAddEnabled bool  `json:"hot_add_enabled"`
+}
+
+// MemoryUpdateSpec describes changes to the memory configuration of a virtual machine.
+// SizeMiB can only be increased while the virtual machine is powered on if memory hot add is enabled.
+type MemoryUpdateSpec struct {
+	SizeMiB       *int64 `json:"size_MiB,omitempty"`
+	HotAddEnabled *bool  `json:"hot_add_enabled,omitempty"`
+}
+
+// Disk host bus adapter types.
+const (
+	DiskHostBusAdapterIDE  = "IDE"
+	DiskHostBusAdapterSCSI = "SCSI"
+	DiskHostBusAdapterSATA = "SATA"
+	DiskHostBusAdapterNVME = "NVME"
+)
+
+// DiskBackingInfo describes the backing of a virtual disk.
+type DiskBackingInfo struct {
+	Type     string `json:"type"`
+	VmdkFile string `json:"vmdk_file,omitempty"`
+}
+
+// DiskAddress is the address of a virtual disk on a SCSI, SATA or NVMe adapter.
+type DiskAddress struct {
+	Bus  int32 `json:"bus"`
+	Unit int32 `json:"unit"`
+}
+
+// IDEAddress is the address of a virtual disk on an IDE adapter.
+type IDEAddress struct {
+	Primary bool `json:"primary"`
+	Master  bool `json:"master"`
+}
+
+// DiskInfo contains information about a virtual disk.
+type DiskInfo struct {
+	Label    string          `json:"label"`
+	Type     string          `json:"type"`
+	Capacity int64           `json:"capacity,omitempty"`
+	Backing  DiskBackingInfo `json:"backing"`
+	IDE      *IDEAddress     `json:"ide,omitempty"`
+	SCSI     *DiskAddress    `json:"scsi,omitempty"`
+	SATA     *DiskAddress    `json:"sata,omitempty"`
+	NVME     *DiskAddress    `json:"nvme,omitempty"`
+}
+
+// DiskVmdkCreateSpec describes a new VMDK file backing a virtual disk.
+type DiskVmdkCreateSpec struct {
+	Name     string `json:"name,omitempty"`
+	Capacity int64  `json:"capacity,omitempty"`
+}
+
+// DiskCreateSpec describes a virtual disk to be created.
+type DiskCreateSpec struct {
+	Type    string              `json:"type,omitempty"`
+	NewVmdk *DiskVmdkCreateSpec `json:"new_vmdk,omitempty"`
+	Backing *DiskBackingInfo    `json:"backing,omitempty"`
+}
+
+// Ethernet backing types.
+const (
+	EthernetBackingStandardPortgroup    = "STANDARD_PORTGROUP"
+	EthernetBackingDistributedPortgroup = "DISTRIBUTED_PORTGROUP"
+	EthernetBackingOpaqueNetwork        = "OPAQUE_NETWORK"
+)
+
+// EthernetBackingInfo describes the network backing of a virtual ethernet adapter.
+type EthernetBackingInfo struct {
+	Type    string `json:"type"`
+	Network string `json:"network,omitempty"`
+}
+
+// EthernetInfo contains information about a virtual ethernet adapter.
+type EthernetInfo struct {
+	Label             string              `json:"label"`
+	Type              string              `json:"type"`
+	MacType           string              `json:"mac_type"`
+	MacAddress        string              `json:"mac_address,omitempty"`
+	Backing           EthernetBackingInfo `json:"backing"`
+	State             string              `json:"state"`
+	StartConnected    bool                `json:"start_connected"`
+	AllowGuestControl bool                `json:"allow_guest_control"`
+}
+
+// EthernetCreateSpec describes a virtual ethernet adapter to be created.
+type EthernetCreateSpec struct {
+	// Type of the adapter, such as "VMXNET3" or "E1000E".
+	Type           string               `json:"type,omitempty"`
+	MacType        string               `json:"mac_type,omitempty"`
+	MacAddress     string               `json:"mac_address,omitempty"`
+	Backing        *EthernetBackingInfo `json:"backing,omitempty"`
+	StartConnected *bool                `json:"start_connected,omitempty"`
+}
+
+// GetHardware returns the virtual hardware settings of the given virtual machine.
+func (c *Manager) GetHardware(ctx context.Context, vm string) (*HardwareInfo, error) {
+	url := c.Resource(vmPath(vm, "hardware"))
+	var res HardwareInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// GetCPU returns the CPU configuration of the given virtual machine.
+func (c *Manager) GetCPU(ctx context.Context, vm string) (*CPUInfo, error) {
+	url := c.Resource(vmPath(vm, "hardware", "cpu"))
+	var res CPUInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// UpdateCPU updates the CPU configuration of the given virtual machine.
+func (c *Manager) UpdateCPU(ctx context.Context, vm string, spec CPUUpdateSpec) error {
+	url := c.Resource(vmPath(vm, "hardware", "cpu"))
+	return c.Do(ctx, url.Request(http.MethodPatch, spec), nil)
+}
+
+// GetMemory returns the memory configuration of the given virtual machine.
+func (c *Manager) GetMemory(ctx context.Context, vm string) (*MemoryInfo, error) {
+	url := c.Resource(vmPath(vm, "hardware", "memory"))
+	var res MemoryInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// UpdateMemory updates the memory configuration of the given virtual machine.
+func (c *Manager) UpdateMemory(ctx context.Context, vm string, spec MemoryUpdateSpec) error {
+	url := c.Resource(vmPath(vm, "hardware", "memory"))
+	return c.Do(ctx, url.Request(http.MethodPatch, spec), nil)
+}
+
+// ListDisks returns the identifiers of the virtual disks of the given virtual machine.
+func (c *Manager) ListDisks(ctx context.Context, vm string) ([]string, error) {
+	url := c.Resource(vmPath(vm, "hardware", "disk"))
+	var res []struct {
+		Disk string `json:"disk"`
+	}
+	if err := c.Do(ctx, url.Request(http.MethodGet), &res); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(res))
+	for i := range res {
+		ids[i] = res[i].Disk
+	}
+	return ids, nil
+}
+
+// GetDisk returns information about the given virtual disk.
+func (c *Manager) GetDisk(ctx context.Context, vm string, disk string) (*DiskInfo, error) {
+	url := c.Resource(vmPath(vm, "hardware", "disk", disk))
+	var res DiskInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// ListEthernet returns the identifiers of the virtual ethernet adapters of the given virtual machine.
+func (c *Manager) ListEthernet(ctx context.Context, vm string) ([]string, error) {
+	url := c.Resource(vmPath(vm, "hardware", "ethernet"))
+	var res []struct {
+		Nic string `json:"nic"`
+	}
+	if err := c.Do(ctx, url.Request(http.MethodGet), &res); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(res))
+	for i := range res {
+		ids[i] = res[i].Nic
+	}
+	return ids, nil
+}
+
+// GetEthernet returns information about the given virtual ethernet adapter.
+func (c *Manager) GetEthernet(ctx context.Context, vm string, nic string) (*EthernetInfo, error) {
+	url := c.Resource(vmPath(vm, "hardware", "ethernet", nic))
+	var res EthernetInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
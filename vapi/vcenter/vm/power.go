@@ -0,0 +1,47 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vm
+
+import (
+	"context"
+	"net/http"
+)
+
+// PowerInfo contains information about the power state of a virtual machine.
+type PowerInfo struct {
+	State PowerState `json:"state"`
+}
+
+// GetPower returns the power state of the given virtual machine.
+func (c *Manager) GetPower(ctx context.Context, vm string) (*PowerInfo, error) {
+	url := c.Resource(vmPath(vm, "power"))
+	var res PowerInfo
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+func (c *Manager) power(ctx context.Context, vm string, action string) error {
+	url := c.Resource(vmPath(vm, "power")).WithParam("action", action)
+	return c.Do(ctx, url.Request(http.MethodPost), nil)
+}
+
+// PowerOn powers on the given virtual machine.
+func (c *Manager) PowerOn(ctx context.Context, vm string) error {
+	return c.power(ctx, vm, "start")
+}
+
+// PowerOff powers off the given virtual machine, without shutting down the guest OS.
+func (c *Manager) PowerOff(ctx context.Context, vm string) error {
+	return c.power(ctx, vm, "stop")
+}
+
+// Suspend suspends the given virtual machine.
+func (c *Manager) Suspend(ctx context.Context, vm string) error {
+	return c.power(ctx, vm, "suspend")
+}
+
+// Reset resets the given virtual machine.
+func (c *Manager) Reset(ctx context.Context, vm string) error {
+	return c.power(ctx, vm, "reset")
+}
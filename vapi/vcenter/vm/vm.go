@@ -0,0 +1,164 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vm
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/vmware/govmomi/vapi/rest"
+)
+
+// Path is the REST endpoint for the virtual machine API
+const Path = "/api/vcenter/vm"
+
+// Manager extends rest.Client, adding virtual machine related methods.
+//
+// See https://developer.broadcom.com/xapis/vsphere-automation-api/latest/vcenter/vm/
+type Manager struct {
+	*rest.Client
+}
+
+// NewManager creates a new Manager instance with the given client.
+func NewManager(client *rest.Client) *Manager {
+	return &Manager{
+		Client: client,
+	}
+}
+
+// vmPath returns the path of the given virtual machine, with optional sub-resource path segments.
+// The path is not escaped, as rest.Client.Resource sets URL.Path.
+func vmPath(vm string, elem ...string) string {
+	return path.Join(append([]string{Path, vm}, elem...)...)
+}
+
+// PowerState of a virtual machine.
+type PowerState string
+
+const (
+	PowerStatePoweredOff = PowerState("POWERED_OFF")
+	PowerStatePoweredOn  = PowerState("POWERED_ON")
+	PowerStateSuspended  = PowerState("SUSPENDED")
+)
+
+// FilterSpec contains the properties used to filter the result of ListVMs.
+// An empty field matches all virtual machines.
+type FilterSpec struct {
+	VMs           []string
+	Names         []string
+	Folders       []string
+	Datacenters   []string
+	Hosts         []string
+	Clusters      []string
+	ResourcePools []string
+	PowerStates   []PowerState
+}
+
+// Summary contains commonly used information about a virtual machine.
+type Summary struct {
+	VM            string     `json:"vm"`
+	Name          string     `json:"name"`
+	PowerState    PowerState `json:"power_state"`
+	CPUCount      *int64     `json:"cpu_count,omitempty"`
+	MemorySizeMiB *int64     `json:"memory_size_MiB,omitempty"`
+}
+
+// PlacementSpec specifies where a virtual machine is created.
+// Folder and Datastore are required, along with one of ResourcePool, Host or Cluster.
+type PlacementSpec struct {
+	Folder       string `json:"folder,omitempty"`
+	ResourcePool string `json:"resource_pool,omitempty"`
+	Host         string `json:"host,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
+	Datastore    string `json:"datastore,omitempty"`
+}
+
+// CreateSpec describes a virtual machine to be created.
+type CreateSpec struct {
+	// GuestOS is the guest operating system identifier, such as "OTHER_LINUX_64".
+	GuestOS         string               `json:"guest_OS"`
+	Name            string               `json:"name,omitempty"`
+	Placement       *PlacementSpec       `json:"placement,omitempty"`
+	HardwareVersion string               `json:"hardware_version,omitempty"`
+	CPU             *CPUUpdateSpec       `json:"cpu,omitempty"`
+	Memory          *MemoryUpdateSpec    `json:"memory,omitempty"`
+	Disks           []DiskCreateSpec     `json:"disks,omitempty"`
+	Nics            []EthernetCreateSpec `json:"nics,omitempty"`
+}
+
+// Identity of a virtual machine.
+type Identity struct {
+	Name         string `json:"name"`
+	BiosUUID     string `json:"bios_uuid"`
+	InstanceUUID string `json:"instance_uuid"`
+}
+
+// Info contains information about a virtual machine.
+type Info struct {
+	GuestOS    string                  `json:"guest_OS"`
+	Name       string                  `json:"name"`
+	Identity   *Identity               `json:"identity,omitempty"`
+	PowerState PowerState              `json:"power_state"`
+	Hardware   HardwareInfo            `json:"hardware"`
+	CPU        CPUInfo                 `json:"cpu"`
+	Memory     MemoryInfo              `json:"memory"`
+	Disks      map[string]DiskInfo     `json:"disks"`
+	Nics       map[string]EthernetInfo `json:"nics"`
+}
+
+func (f *FilterSpec) params(r *rest.Resource) *rest.Resource {
+	params := []struct {
+		name   string
+		values []string
+	}{
+		{"vms", f.VMs},
+		{"names", f.Names},
+		{"folders", f.Folders},
+		{"datacenters", f.Datacenters},
+		{"hosts", f.Hosts},
+		{"clusters", f.Clusters},
+		{"resource_pools", f.ResourcePools},
+	}
+
+	for _, p := range params {
+		for _, val := range p.values {
+			r.WithParam(p.name, val)
+		}
+	}
+
+	for _, state := range f.PowerStates {
+		r.WithParam("power_states", string(state))
+	}
+
+	return r
+}
+
+// ListVMs returns information about the virtual machines that match the given filter.
+func (c *Manager) ListVMs(ctx context.Context, filter FilterSpec) ([]Summary, error) {
+	url := filter.params(c.Resource(Path))
+	var res []Summary
+	return res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// CreateVM creates a virtual machine, returning its identifier.
+func (c *Manager) CreateVM(ctx context.Context, spec CreateSpec) (string, error) {
+	url := c.Resource(Path)
+	var res string
+	return res, c.Do(ctx, url.Request(http.MethodPost, spec), &res)
+}
+
+// GetVM returns information about the given virtual machine.
+func (c *Manager) GetVM(ctx context.Context, vm string) (*Info, error) {
+	url := c.Resource(vmPath(vm))
+	var res Info
+	return &res, c.Do(ctx, url.Request(http.MethodGet), &res)
+}
+
+// DeleteVM deletes the given virtual machine, which must be powered off.
+func (c *Manager) DeleteVM(ctx context.Context, vm string) error {
+	url := c.Resource(vmPath(vm))
+	return c.Do(ctx, url.Request(http.MethodDelete), nil)
+}
@@ -0,0 +1,311 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vm_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	"github.com/vmware/govmomi/vapi/vcenter/vm"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+	_ "github.com/vmware/govmomi/vapi/vm/simulator"
+)
+
+func TestManager(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := vm.NewManager(c)
+		finder := find.NewFinder(vc)
+
+		all, err := finder.VirtualMachineList(ctx, "*")
+		require.NoError(t, err)
+
+		vms, err := m.ListVMs(ctx, vm.FilterSpec{})
+		require.NoError(t, err)
+		assert.Len(t, vms, len(all))
+
+		obj, err := finder.VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		id := obj.Reference().Value
+
+		var props mo.VirtualMachine
+		require.NoError(t, obj.Properties(ctx, obj.Reference(), []string{"config", "runtime"}, &props))
+
+		vms, err = m.ListVMs(ctx, vm.FilterSpec{Names: []string{"DC0_H0_VM0"}})
+		require.NoError(t, err)
+		require.Len(t, vms, 1)
+		assert.Equal(t, id, vms[0].VM)
+		assert.Equal(t, vm.PowerStatePoweredOn, vms[0].PowerState)
+		assert.Equal(t, int64(props.Config.Hardware.NumCPU), *vms[0].CPUCount)
+
+		vms, err = m.ListVMs(ctx, vm.FilterSpec{Hosts: []string{props.Runtime.Host.Value}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, vms)
+		for _, s := range vms {
+			assert.Contains(t, s.Name, "DC0_H0_")
+		}
+
+		cluster, err := finder.ClusterComputeResource(ctx, "DC0_C0")
+		require.NoError(t, err)
+		vms, err = m.ListVMs(ctx, vm.FilterSpec{Clusters: []string{cluster.Reference().Value}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, vms)
+		for _, s := range vms {
+			assert.Contains(t, s.Name, "DC0_C0_")
+		}
+
+		info, err := m.GetVM(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "DC0_H0_VM0", info.Name)
+		assert.Equal(t, props.Config.Uuid, info.Identity.BiosUUID)
+		assert.Equal(t, int64(props.Config.Hardware.MemoryMB), info.Memory.SizeMiB)
+		assert.NotEmpty(t, info.Disks)
+		assert.NotEmpty(t, info.Nics)
+
+		_, err = m.GetVM(ctx, "enoent")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+
+		// power state changes are observed by both APIs
+		require.Error(t, m.PowerOn(ctx, id))
+		require.NoError(t, m.PowerOff(ctx, id))
+		state, err := obj.PowerState(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, types.VirtualMachinePowerStatePoweredOff, state)
+
+		task, err := obj.PowerOn(ctx)
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+		power, err := m.GetPower(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, vm.PowerStatePoweredOn, power.State)
+
+		// CPU count cannot change while powered on without hot add
+		err = m.UpdateCPU(ctx, id, vm.CPUUpdateSpec{Count: types.NewInt64(4)})
+		assert.ErrorContains(t, err, "NOT_ALLOWED_IN_CURRENT_STATE")
+
+		require.NoError(t, m.Suspend(ctx, id))
+		require.NoError(t, m.PowerOff(ctx, id))
+		require.NoError(t, m.UpdateCPU(ctx, id, vm.CPUUpdateSpec{Count: types.NewInt64(4)}))
+		require.NoError(t, obj.Properties(ctx, obj.Reference(), []string{"config"}, &props))
+		assert.Equal(t, int32(4), props.Config.Hardware.NumCPU)
+
+		task, err = obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{MemoryMB: 2048})
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+		memory, err := m.GetMemory(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2048), memory.SizeMiB)
+
+		hw, err := m.GetHardware(ctx, id)
+		require.NoError(t, err)
+		assert.Contains(t, hw.Version, "VMX_")
+
+		disks, err := m.ListDisks(ctx, id)
+		require.NoError(t, err)
+		require.NotEmpty(t, disks)
+		disk, err := m.GetDisk(ctx, id, disks[0])
+		require.NoError(t, err)
+		assert.Equal(t, info.Disks[disks[0]], *disk)
+		assert.Equal(t, vm.DiskHostBusAdapterSCSI, disk.Type)
+		assert.NotZero(t, disk.Capacity)
+
+		nics, err := m.ListEthernet(ctx, id)
+		require.NoError(t, err)
+		require.NotEmpty(t, nics)
+		nic, err := m.GetEthernet(ctx, id, nics[0])
+		require.NoError(t, err)
+		assert.NotEmpty(t, nic.Backing.Network)
+		assert.NotEmpty(t, nic.MacAddress)
+
+		_, err = m.GetDisk(ctx, id, nics[0])
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+	})
+}
+
+func TestGuest(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := vm.NewManager(c)
+
+		obj, err := find.NewFinder(vc).VirtualMachine(ctx, "DC0_H0_VM0")
+		require.NoError(t, err)
+		id := obj.Reference().Value
+
+		power, err := m.GetGuestPower(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, vm.GuestPowerStateUnavailable, power.State)
+
+		_, err = m.GetGuestIdentity(ctx, id)
+		assert.True(t, rest.IsStatusError(err, http.StatusServiceUnavailable))
+
+		err = m.ShutdownGuest(ctx, id)
+		assert.True(t, rest.IsStatusError(err, http.StatusServiceUnavailable))
+
+		task, err := obj.Reconfigure(ctx, types.VirtualMachineConfigSpec{
+			ExtraConfig: []types.BaseOptionValue{
+				&types.OptionValue{Key: "SET.guest.toolsRunningStatus", Value: string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)},
+				&types.OptionValue{Key: "SET.guest.hostName", Value: "vcsim.local"},
+				&types.OptionValue{Key: "SET.guest.ipAddress", Value: "10.0.0.42"},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, task.Wait(ctx))
+
+		identity, err := m.GetGuestIdentity(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "vcsim.local", identity.HostName)
+		assert.Equal(t, "10.0.0.42", identity.IPAddress)
+		assert.NotEmpty(t, identity.Name)
+
+		_, err = m.GetGuestNetworking(ctx, id)
+		require.NoError(t, err)
+
+		interfaces, err := m.ListGuestNetworkingInterfaces(ctx, id)
+		require.NoError(t, err)
+		require.NotEmpty(t, interfaces)
+		require.NotNil(t, interfaces[0].IP)
+		assert.Equal(t, "10.0.0.42", interfaces[0].IP.IPAddresses[0].IPAddress)
+
+		nics, err := m.ListEthernet(ctx, id)
+		require.NoError(t, err)
+		assert.Contains(t, nics, interfaces[0].Nic)
+
+		power, err = m.GetGuestPower(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, vm.GuestPowerStateRunning, power.State)
+		assert.True(t, power.OperationsReady)
+
+		require.NoError(t, m.ShutdownGuest(ctx, id))
+		require.NoError(t, obj.WaitForPowerState(ctx, types.VirtualMachinePowerStatePoweredOff))
+
+		err = m.RebootGuest(ctx, id)
+		assert.ErrorContains(t, err, "NOT_ALLOWED_IN_CURRENT_STATE")
+	})
+}
+
+func TestCreateVM(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		m := vm.NewManager(c)
+		finder := find.NewFinder(vc)
+
+		dc, err := finder.Datacenter(ctx, "DC0")
+		require.NoError(t, err)
+		finder.SetDatacenter(dc)
+
+		folders, err := dc.Folders(ctx)
+		require.NoError(t, err)
+		cluster, err := finder.ClusterComputeResource(ctx, "DC0_C0")
+		require.NoError(t, err)
+		ds, err := finder.Datastore(ctx, "LocalDS_0")
+		require.NoError(t, err)
+		pg, err := finder.Network(ctx, "DC0_DVPG0")
+		require.NoError(t, err)
+
+		spec := vm.CreateSpec{
+			Name:    "rest-vm",
+			GuestOS: "OTHER_LINUX_64",
+			Placement: &vm.PlacementSpec{
+				Folder:    folders.VmFolder.Reference().Value,
+				Cluster:   cluster.Reference().Value,
+				Datastore: ds.Reference().Value,
+			},
+			CPU:    &vm.CPUUpdateSpec{Count: types.NewInt64(2)},
+			Memory: &vm.MemoryUpdateSpec{SizeMiB: types.NewInt64(1024)},
+			Disks: []vm.DiskCreateSpec{
+				{NewVmdk: &vm.DiskVmdkCreateSpec{Capacity: 1024 * 1024 * 1024}},
+			},
+			Nics: []vm.EthernetCreateSpec{
+				{
+					Type: "VMXNET3",
+					Backing: &vm.EthernetBackingInfo{
+						Type:    vm.EthernetBackingDistributedPortgroup,
+						Network: pg.Reference().Value,
+					},
+				},
+			},
+		}
+
+		_, err = m.CreateVM(ctx, vm.CreateSpec{Name: "invalid", GuestOS: spec.GuestOS})
+		assert.ErrorContains(t, err, "INVALID_ARGUMENT")
+
+		id, err := m.CreateVM(ctx, spec)
+		require.NoError(t, err)
+
+		obj, err := finder.VirtualMachine(ctx, "rest-vm")
+		require.NoError(t, err)
+		assert.Equal(t, id, obj.Reference().Value)
+
+		var props mo.VirtualMachine
+		require.NoError(t, obj.Properties(ctx, obj.Reference(), []string{"config"}, &props))
+		assert.Equal(t, "otherLinux64Guest", props.Config.GuestId)
+		assert.Equal(t, int32(2), props.Config.Hardware.NumCPU)
+
+		info, err := m.GetVM(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, "OTHER_LINUX_64", info.GuestOS)
+		assert.Equal(t, vm.PowerStatePoweredOff, info.PowerState)
+		assert.Equal(t, int64(1024), info.Memory.SizeMiB)
+		require.Len(t, info.Disks, 1)
+		for _, disk := range info.Disks {
+			assert.Equal(t, int64(1024*1024*1024), disk.Capacity)
+		}
+		require.Len(t, info.Nics, 1)
+		for _, nic := range info.Nics {
+			assert.Equal(t, "VMXNET3", nic.Type)
+			assert.Equal(t, vm.EthernetBackingDistributedPortgroup, nic.Backing.Type)
+		}
+
+		require.NoError(t, m.DeleteVM(ctx, id))
+		_, err = finder.VirtualMachine(ctx, "rest-vm")
+		var notFound *find.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+	})
+}
+
+// pathRecorder records the escaped path of each request
+type pathRecorder struct {
+	http.RoundTripper
+	paths []string
+}
+
+func (p *pathRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.paths = append(p.paths, req.URL.EscapedPath())
+	return p.RoundTripper.RoundTrip(req)
+}
+
+func TestPathEscape(t *testing.T) {
+	simulator.Test(func(ctx context.Context, vc *vim25.Client) {
+		c := rest.NewClient(vc)
+		require.NoError(t, c.Login(ctx, simulator.DefaultLogin))
+
+		rec := &pathRecorder{RoundTripper: c.Transport}
+		c.Transport = rec
+
+		m := vm.NewManager(c)
+
+		// the id is escaped once
+		_, err := m.GetGuestPower(ctx, "vm 1")
+		assert.True(t, rest.IsStatusError(err, http.StatusNotFound))
+		assert.Equal(t, []string{vm.Path + "/vm%201/guest/power"}, rec.paths)
+	})
+}
@@ -56,12 +56,26 @@ func (h *Handler) Register(s *simulator.Service, r *simulator.Registry) {
 	if r.IsVPX() {
 		h.registry = r
 		s.HandleFunc(restPathPrefix, h.handle)
+		s.HandleFunc(internal.VCenterVMPath, h.handle)
 		s.HandleFunc(apiPathPrefix, h.handle)
 	}
 }
 
 // path starts with "/api/vcenter/vm"
 func (h *Handler) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == internal.VCenterVMPath {
+		// "/api/vcenter/vm"
+		switch r.Method {
+		case http.MethodGet:
+			h.listVMs(w, r)
+		case http.MethodPost:
+			h.createVM(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
 	// The standard http.ServeMux does not support placeholders, so traverse the path segment by segment.
 	// 'tail' tracks the remaining path segments.
 	p := r.URL.Path
@@ -90,20 +104,13 @@ func (h *Handler) handleVm(w http.ResponseWriter, r *http.Request, tail []string
 	if vm == nil {
 		return
 	}
-	ctx := &simulator.Context{
-		Context: context.Background(),
-		Session: &simulator.Session{
-			UserSession: types.UserSession{
-				Key: uuid.New().String(),
-			},
-			Registry: h.registry,
-		},
-		Map: h.registry,
-	}
+	ctx := h.newContext()
 	h.registry.WithLock(ctx, vm.Reference(), func() {
 		if len(tail) == 0 {
 			// "/api/vcenter/vm/{}"
 			switch r.Method {
+			case http.MethodGet:
+				h.getVM(w, r, ctx, vm)
 			case http.MethodDelete:
 				h.deleteVM(w, r, ctx, vm)
 			default:
@@ -114,6 +121,12 @@ func (h *Handler) handleVm(w http.ResponseWriter, r *http.Request, tail []string
 			switch tail[0] {
 			case "data-sets":
 				h.handleVmDataSets(w, r, tail[1:], vm)
+			case "hardware":
+				h.handleVmHardware(w, r, tail[1:], ctx, vm)
+			case "power":
+				h.handleVmPower(w, r, tail[1:], ctx, vm)
+			case "guest":
+				h.handleVmGuest(w, r, tail[1:], ctx, vm)
 			default:
 				http.NotFound(w, r)
 			}
@@ -121,6 +134,19 @@ func (h *Handler) handleVm(w http.ResponseWriter, r *http.Request, tail []string
 	})
 }
 
+func (h *Handler) newContext() *simulator.Context {
+	return &simulator.Context{
+		Context: context.Background(),
+		Session: &simulator.Session{
+			UserSession: types.UserSession{
+				Key: uuid.New().String(),
+			},
+			Registry: h.registry,
+		},
+		Map: h.registry,
+	}
+}
+
 // path starts with "/api/vcenter/vm/{}/data-sets"
 func (h *Handler) handleVmDataSets(w http.ResponseWriter, r *http.Request, tail []string, vm *simulator.VirtualMachine) {
 	if !h.validateVmHardwareVersionDataSets(w, r, vm) {
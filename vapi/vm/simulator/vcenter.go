@@ -0,0 +1,1013 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/rest"
+	vapi "github.com/vmware/govmomi/vapi/simulator"
+	vcenter "github.com/vmware/govmomi/vapi/vcenter/vm"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// defaultDiskCapacity is used when a new disk is created without a capacity
+const defaultDiskCapacity = 16 * 1024 * 1024 * 1024
+
+var (
+	guestIDs     map[string]string
+	guestIDsOnce sync.Once
+
+	nicTypes = map[string]string{
+		"VirtualE1000":             "E1000",
+		"VirtualE1000e":            "E1000E",
+		"VirtualPCNet32":           "PCNET32",
+		"VirtualVmxnet":            "VMXNET",
+		"VirtualVmxnet2":           "VMXNET2",
+		"VirtualVmxnet3":           "VMXNET3",
+		"VirtualVmxnet3Vrdma":      "VMXNET3_VRDMA",
+		"VirtualSriovEthernetCard": "SRIOV",
+	}
+)
+
+// guestOS converts a vim25 guest ID, such as "otherLinux64Guest", to its REST form, such as "OTHER_LINUX_64"
+func guestOS(id string) string {
+	id = strings.Replace(id, "Guest", "", 1)
+
+	var b strings.Builder
+	var prev rune
+
+	for i, c := range id {
+		if i > 0 && prev != '_' && c != '_' {
+			if unicode.IsUpper(c) || unicode.IsDigit(c) != unicode.IsDigit(prev) {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToUpper(c))
+		prev = c
+	}
+
+	return b.String()
+}
+
+// guestID converts a REST guest OS name to its vim25 guest ID
+func guestID(name string) string {
+	guestIDsOnce.Do(func() {
+		guestIDs = make(map[string]string)
+		for _, id := range types.VirtualMachineGuestOsIdentifier("").Values() {
+			guestIDs[guestOS(string(id))] = string(id)
+		}
+	})
+
+	if id, ok := guestIDs[name]; ok {
+		return id
+	}
+	return name
+}
+
+// hardwareVersion converts a vim25 hardware version, such as "vmx-19", to its REST form, such as "VMX_19"
+func hardwareVersion(version string) string {
+	return strings.ToUpper(strings.Replace(version, "-", "_", 1))
+}
+
+func vmxVersion(version string) string {
+	return strings.ToLower(strings.Replace(version, "_", "-", 1))
+}
+
+func powerState(state types.VirtualMachinePowerState) vcenter.PowerState {
+	switch state {
+	case types.VirtualMachinePowerStatePoweredOn:
+		return vcenter.PowerStatePoweredOn
+	case types.VirtualMachinePowerStateSuspended:
+		return vcenter.PowerStateSuspended
+	default:
+		return vcenter.PowerStatePoweredOff
+	}
+}
+
+func toolsRunning(vm *simulator.VirtualMachine) bool {
+	return vm.Guest.ToolsRunningStatus == string(types.VirtualMachineToolsRunningStatusGuestToolsRunning)
+}
+
+// taskResult waits for the task started by a simulator *_Task method and returns its result
+func taskResult(ctx *simulator.Context, body soap.HasFault) (types.AnyType, types.BaseMethodFault) {
+	if f := body.Fault(); f != nil {
+		return nil, f.VimFault().(types.BaseMethodFault)
+	}
+
+	ref := reflect.ValueOf(body).Elem().FieldByName("Res").Elem().FieldByName("Returnval")
+	task := ctx.Map.Get(ref.Interface().(types.ManagedObjectReference)).(*simulator.Task)
+	task.Wait()
+
+	if task.Info.Error != nil {
+		return nil, task.Info.Error.Fault
+	}
+
+	return task.Info.Result, nil
+}
+
+// fault responds with the REST error corresponding to the given vim25 fault
+func (h *Handler) fault(w http.ResponseWriter, r *http.Request, fault types.BaseMethodFault) {
+	switch fault.(type) {
+	case *types.InvalidPowerState, *types.InvalidState:
+		vapi.ApiErrorNotAllowedInCurrentState(w)
+	case *types.InvalidArgument, *types.InvalidName, *types.InvalidDatastore:
+		vapi.ApiErrorInvalidArgument(w)
+	case *types.DuplicateName:
+		vapi.ApiErrorAlreadyExists(w)
+	case *types.ManagedObjectNotFound:
+		vapi.ApiErrorNotFound(w)
+	case *types.ToolsUnavailable:
+		vapi.ApiErrorServiceUnavailable(w)
+	default:
+		log.Printf("%s %s: %#v", r.Method, r.RequestURI, fault)
+		vapi.ApiErrorGeneral(w)
+	}
+}
+
+// ancestor returns the first parent of the given entity with the given type
+func (h *Handler) ancestor(ref types.ManagedObjectReference, kind string) *types.ManagedObjectReference {
+	for {
+		e, ok := h.registry.Get(ref).(mo.Entity)
+		if !ok {
+			return nil
+		}
+		parent := e.Entity().Parent
+		if parent == nil || parent.Type == kind {
+			return parent
+		}
+		ref = *parent
+	}
+}
+
+func (h *Handler) vmSummary(vm *simulator.VirtualMachine) vcenter.Summary {
+	return vcenter.Summary{
+		VM:            vm.Self.Value,
+		Name:          vm.Name,
+		PowerState:    powerState(vm.Runtime.PowerState),
+		CPUCount:      types.NewInt64(int64(vm.Config.Hardware.NumCPU)),
+		MemorySizeMiB: types.NewInt64(int64(vm.Config.Hardware.MemoryMB)),
+	}
+}
+
+// vmFilter returns true if the VM matches the "/api/vcenter/vm" query parameters
+func (h *Handler) vmFilter(r *http.Request, vm *simulator.VirtualMachine) bool {
+	q := r.URL.Query()
+
+	match := func(name string, ref *types.ManagedObjectReference) bool {
+		values := q[name]
+		if len(values) == 0 {
+			return true
+		}
+		return ref != nil && slices.Contains(values, ref.Value)
+	}
+
+	var cluster *types.ManagedObjectReference
+	if host := vm.Runtime.Host; host != nil {
+		if parent := h.ancestor(*host, "ClusterComputeResource"); parent != nil {
+			cluster = parent
+		}
+	}
+
+	if values := q["names"]; len(values) != 0 && !slices.Contains(values, vm.Name) {
+		return false
+	}
+
+	if values := q["power_states"]; len(values) != 0 && !slices.Contains(values, string(powerState(vm.Runtime.PowerState))) {
+		return false
+	}
+
+	return match("vms", &vm.Self) &&
+		match("folders", vm.Parent) &&
+		match("datacenters", h.ancestor(vm.Self, "Datacenter")) &&
+		match("hosts", vm.Runtime.Host) &&
+		match("clusters", cluster) &&
+		match("resource_pools", vm.ResourcePool)
+}
+
+// "/api/vcenter/vm"
+func (h *Handler) listVMs(w http.ResponseWriter, r *http.Request) {
+	ctx := h.newContext()
+	res := []vcenter.Summary{}
+
+	for _, e := range h.registry.All("VirtualMachine") {
+		vm := e.(*simulator.VirtualMachine)
+		h.registry.WithLock(ctx, vm.Reference(), func() {
+			if vm.Config.Template || !h.vmFilter(r, vm) {
+				return
+			}
+			res = append(res, h.vmSummary(vm))
+		})
+	}
+
+	slices.SortFunc(res, func(a, b vcenter.Summary) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	vapi.StatusOK(w, res)
+}
+
+// placement returns the resource pool and optional host for the given placement spec
+func (h *Handler) placement(p *vcenter.PlacementSpec) (*types.ManagedObjectReference, *types.ManagedObjectReference, error) {
+	switch {
+	case p.ResourcePool != "":
+		pool, ok := h.registry.Get(types.ManagedObjectReference{Type: "ResourcePool", Value: p.ResourcePool}).(*simulator.ResourcePool)
+		if !ok {
+			return nil, nil, fmt.Errorf("resource pool %q not found", p.ResourcePool)
+		}
+		return &pool.Self, nil, nil
+	case p.Cluster != "":
+		cluster, ok := h.registry.Get(types.ManagedObjectReference{Type: "ClusterComputeResource", Value: p.Cluster}).(*simulator.ClusterComputeResource)
+		if !ok {
+			return nil, nil, fmt.Errorf("cluster %q not found", p.Cluster)
+		}
+		return cluster.ResourcePool, nil, nil
+	case p.Host != "":
+		host, ok := h.registry.Get(types.ManagedObjectReference{Type: "HostSystem", Value: p.Host}).(*simulator.HostSystem)
+		if !ok {
+			return nil, nil, fmt.Errorf("host %q not found", p.Host)
+		}
+		switch cr := h.registry.Get(*host.Parent).(type) {
+		case *simulator.ClusterComputeResource:
+			return cr.ResourcePool, &host.Self, nil
+		case *mo.ComputeResource:
+			return cr.ResourcePool, &host.Self, nil
+		}
+		return nil, nil, fmt.Errorf("host %q has no resource pool", p.Host)
+	default:
+		return nil, nil, errors.New("one of resource_pool, cluster or host must be specified")
+	}
+}
+
+func (h *Handler) ethernetBacking(spec vcenter.EthernetBackingInfo) (types.BaseVirtualDeviceBackingInfo, error) {
+	switch spec.Type {
+	case vcenter.EthernetBackingStandardPortgroup:
+		net, ok := h.registry.Get(types.ManagedObjectReference{Type: "Network", Value: spec.Network}).(*mo.Network)
+		if !ok {
+			return nil, fmt.Errorf("network %q not found", spec.Network)
+		}
+		return &types.VirtualEthernetCardNetworkBackingInfo{
+			VirtualDeviceDeviceBackingInfo: types.VirtualDeviceDeviceBackingInfo{
+				DeviceName: net.Name,
+			},
+			Network: &net.Self,
+		}, nil
+	case vcenter.EthernetBackingDistributedPortgroup:
+		pg, ok := h.registry.Get(types.ManagedObjectReference{Type: "DistributedVirtualPortgroup", Value: spec.Network}).(*simulator.DistributedVirtualPortgroup)
+		if !ok {
+			return nil, fmt.Errorf("portgroup %q not found", spec.Network)
+		}
+		dvs := h.registry.Get(*pg.Config.DistributedVirtualSwitch).(*simulator.DistributedVirtualSwitch)
+		return &types.VirtualEthernetCardDistributedVirtualPortBackingInfo{
+			Port: types.DistributedVirtualSwitchPortConnection{
+				PortgroupKey: pg.Key,
+				SwitchUuid:   dvs.Uuid,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported backing type %q", spec.Type)
+	}
+}
+
+// devices returns the disks and nics for the given create spec
+func (h *Handler) devices(spec vcenter.CreateSpec, ds *simulator.Datastore) (object.VirtualDeviceList, error) {
+	var devices object.VirtualDeviceList
+	controllers := make(map[string]types.BaseVirtualController)
+
+	for _, d := range spec.Disks {
+		kind := d.Type
+		if kind == "" {
+			kind = vcenter.DiskHostBusAdapterSCSI
+		}
+
+		c, ok := controllers[kind]
+		if !ok {
+			var device types.BaseVirtualDevice
+			var err error
+
+			switch kind {
+			case vcenter.DiskHostBusAdapterSCSI:
+				device, err = devices.CreateSCSIController("pvscsi")
+			case vcenter.DiskHostBusAdapterSATA:
+				device, err = devices.CreateSATAController()
+			case vcenter.DiskHostBusAdapterNVME:
+				device, err = devices.CreateNVMEController()
+			case vcenter.DiskHostBusAdapterIDE:
+				device, err = devices.CreateIDEController()
+			default:
+				err = fmt.Errorf("unsupported disk type %q", kind)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			devices = append(devices, device)
+			c = device.(types.BaseVirtualController)
+			controllers[kind] = c
+		}
+
+		var disk *types.VirtualDisk
+
+		switch {
+		case d.NewVmdk != nil:
+			// the file name is chosen by CreateVM
+			disk = devices.CreateDisk(c, ds.Self, "")
+			disk.CapacityInBytes = d.NewVmdk.Capacity
+			if disk.CapacityInBytes == 0 {
+				disk.CapacityInBytes = defaultDiskCapacity
+			}
+			disk.CapacityInKB = disk.CapacityInBytes / 1024
+		case d.Backing != nil && d.Backing.VmdkFile != "":
+			disk = devices.CreateDisk(c, ds.Self, d.Backing.VmdkFile)
+		default:
+			return nil, errors.New("one of new_vmdk or backing must be specified")
+		}
+
+		devices = append(devices, disk)
+	}
+
+	for _, n := range spec.Nics {
+		if n.Backing == nil {
+			return nil, errors.New("nic backing must be specified")
+		}
+
+		backing, err := h.ethernetBacking(*n.Backing)
+		if err != nil {
+			return nil, err
+		}
+
+		var card types.BaseVirtualEthernetCard
+		for _, device := range object.EthernetCardTypes() {
+			if n.Type == "" || nicTypes[devices.TypeName(device)] == n.Type {
+				card = device.(types.BaseVirtualEthernetCard)
+				break
+			}
+		}
+		if card == nil {
+			return nil, fmt.Errorf("unsupported nic type %q", n.Type)
+		}
+
+		nic := card.GetVirtualEthernetCard()
+		nic.Backing = backing
+		if n.MacType == "MANUAL" {
+			nic.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+			nic.MacAddress = n.MacAddress
+		}
+		if n.StartConnected != nil {
+			nic.Connectable = &types.VirtualDeviceConnectInfo{
+				StartConnected: *n.StartConnected,
+				Connected:      *n.StartConnected,
+			}
+		}
+
+		devices = append(devices, card.(types.BaseVirtualDevice))
+	}
+
+	return devices, nil
+}
+
+// "/api/vcenter/vm"
+func (h *Handler) createVM(w http.ResponseWriter, r *http.Request) {
+	var spec vcenter.CreateSpec
+	if !vapi.Decode(r, w, &spec) {
+		return
+	}
+
+	p := spec.Placement
+	if spec.GuestOS == "" || p == nil || p.Folder == "" || p.Datastore == "" {
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	folder, ok := h.registry.Get(types.ManagedObjectReference{Type: "Folder", Value: p.Folder}).(*simulator.Folder)
+	if !ok {
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	ds, ok := h.registry.Get(types.ManagedObjectReference{Type: "Datastore", Value: p.Datastore}).(*simulator.Datastore)
+	if !ok {
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	pool, host, err := h.placement(p)
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	config := types.VirtualMachineConfigSpec{
+		Name:    spec.Name,
+		GuestId: guestID(spec.GuestOS),
+		Files: &types.VirtualMachineFileInfo{
+			VmPathName: fmt.Sprintf("[%s]", ds.Name),
+		},
+	}
+
+	if config.Name == "" {
+		config.Name = "Virtual machine"
+	}
+
+	if spec.HardwareVersion != "" {
+		config.Version = vmxVersion(spec.HardwareVersion)
+	}
+
+	if spec.CPU != nil {
+		cpuConfigSpec(&config, *spec.CPU)
+	}
+
+	if spec.Memory != nil {
+		memoryConfigSpec(&config, *spec.Memory)
+	}
+
+	devices, err := h.devices(spec, ds)
+	if err != nil {
+		log.Printf("%s %s: %s", r.Method, r.RequestURI, err)
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	config.DeviceChange, _ = devices.ConfigSpec(types.VirtualDeviceConfigSpecOperationAdd)
+
+	ctx := h.newContext()
+	var res types.AnyType
+	var fault types.BaseMethodFault
+
+	h.registry.WithLock(ctx, folder.Reference(), func() {
+		req := &types.CreateVM_Task{This: folder.Self, Config: config, Pool: *pool, Host: host}
+		res, fault = taskResult(ctx, folder.CreateVMTask(ctx, req))
+	})
+
+	if fault != nil {
+		h.fault(w, r, fault)
+		return
+	}
+
+	vapi.StatusOK(w, res.(types.ManagedObjectReference).Value)
+}
+
+func hardwareInfo(vm *simulator.VirtualMachine) vcenter.HardwareInfo {
+	info := vcenter.HardwareInfo{
+		Version:       hardwareVersion(vm.Config.Version),
+		UpgradePolicy: "NEVER",
+		UpgradeStatus: "NONE",
+	}
+
+	if u := vm.Config.ScheduledHardwareUpgradeInfo; u != nil {
+		switch types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicy(u.UpgradePolicy) {
+		case types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyOnSoftPowerOff:
+			info.UpgradePolicy = "AFTER_CLEAN_SHUTDOWN"
+		case types.ScheduledHardwareUpgradeInfoHardwareUpgradePolicyAlways:
+			info.UpgradePolicy = "ALWAYS"
+		}
+		if info.UpgradePolicy != "NEVER" {
+			info.UpgradeVersion = hardwareVersion(u.VersionKey)
+			info.UpgradeStatus = "PENDING"
+		}
+	}
+
+	return info
+}
+
+func cpuInfo(vm *simulator.VirtualMachine) vcenter.CPUInfo {
+	hw := vm.Config.Hardware
+	info := vcenter.CPUInfo{
+		Count:            int64(hw.NumCPU),
+		CoresPerSocket:   1,
+		HotAddEnabled:    getOrDefault(vm.Config.CpuHotAddEnabled, false),
+		HotRemoveEnabled: getOrDefault(vm.Config.CpuHotRemoveEnabled, false),
+	}
+	if hw.NumCoresPerSocket != nil {
+		info.CoresPerSocket = int64(*hw.NumCoresPerSocket)
+	}
+	return info
+}
+
+func cpuConfigSpec(config *types.VirtualMachineConfigSpec, spec vcenter.CPUUpdateSpec) {
+	if spec.Count != nil {
+		config.NumCPUs = int32(*spec.Count)
+	}
+	if spec.CoresPerSocket != nil {
+		config.NumCoresPerSocket = types.NewInt32(int32(*spec.CoresPerSocket))
+	}
+	config.CpuHotAddEnabled = spec.HotAddEnabled
+	config.CpuHotRemoveEnabled = spec.HotRemoveEnabled
+}
+
+func memoryInfo(vm *simulator.VirtualMachine) vcenter.MemoryInfo {
+	return vcenter.MemoryInfo{
+		SizeMiB:       int64(vm.Config.Hardware.MemoryMB),
+		HotAddEnabled: getOrDefault(vm.Config.MemoryHotAddEnabled, false),
+	}
+}
+
+func memoryConfigSpec(config *types.VirtualMachineConfigSpec, spec vcenter.MemoryUpdateSpec) {
+	if spec.SizeMiB != nil {
+		config.MemoryMB = *spec.SizeMiB
+	}
+	config.MemoryHotAddEnabled = spec.HotAddEnabled
+}
+
+func deviceLabel(device types.BaseVirtualDevice) string {
+	if info := device.GetVirtualDevice().DeviceInfo; info != nil {
+		return info.GetDescription().Label
+	}
+	return ""
+}
+
+func diskInfo(devices object.VirtualDeviceList, disk *types.VirtualDisk) vcenter.DiskInfo {
+	info := vcenter.DiskInfo{
+		Label:    deviceLabel(disk),
+		Capacity: disk.CapacityInBytes,
+	}
+
+	if info.Capacity == 0 {
+		info.Capacity = disk.CapacityInKB * 1024
+	}
+
+	if b, ok := disk.Backing.(types.BaseVirtualDeviceFileBackingInfo); ok {
+		info.Backing = vcenter.DiskBackingInfo{
+			Type:     "VMDK_FILE",
+			VmdkFile: b.GetVirtualDeviceFileBackingInfo().FileName,
+		}
+	}
+
+	var unit int32
+	if disk.UnitNumber != nil {
+		unit = *disk.UnitNumber
+	}
+
+	switch c := devices.FindByKey(disk.ControllerKey).(type) {
+	case types.BaseVirtualSCSIController:
+		info.Type = vcenter.DiskHostBusAdapterSCSI
+		info.SCSI = &vcenter.DiskAddress{Bus: c.GetVirtualSCSIController().BusNumber, Unit: unit}
+	case types.BaseVirtualSATAController:
+		info.Type = vcenter.DiskHostBusAdapterSATA
+		info.SATA = &vcenter.DiskAddress{Bus: c.GetVirtualSATAController().BusNumber, Unit: unit}
+	case *types.VirtualNVMEController:
+		info.Type = vcenter.DiskHostBusAdapterNVME
+		info.NVME = &vcenter.DiskAddress{Bus: c.BusNumber, Unit: unit}
+	case *types.VirtualIDEController:
+		info.Type = vcenter.DiskHostBusAdapterIDE
+		info.IDE = &vcenter.IDEAddress{Primary: c.BusNumber == 0, Master: unit == 0}
+	}
+
+	return info
+}
+
+func ethernetInfo(devices object.VirtualDeviceList, device types.BaseVirtualEthernetCard) vcenter.EthernetInfo {
+	nic := device.GetVirtualEthernetCard()
+
+	info := vcenter.EthernetInfo{
+		Label:      deviceLabel(nic),
+		Type:       nicTypes[devices.TypeName(device.(types.BaseVirtualDevice))],
+		MacType:    strings.ToUpper(nic.AddressType),
+		MacAddress: nic.MacAddress,
+		State:      "NOT_CONNECTED",
+	}
+
+	if c := nic.Connectable; c != nil {
+		if c.Connected {
+			info.State = "CONNECTED"
+		}
+		info.StartConnected = c.StartConnected
+		info.AllowGuestControl = c.AllowGuestControl
+	}
+
+	switch b := nic.Backing.(type) {
+	case *types.VirtualEthernetCardNetworkBackingInfo:
+		info.Backing.Type = vcenter.EthernetBackingStandardPortgroup
+		if b.Network != nil {
+			info.Backing.Network = b.Network.Value
+		}
+	case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+		info.Backing.Type = vcenter.EthernetBackingDistributedPortgroup
+		info.Backing.Network = b.Port.PortgroupKey
+	case *types.VirtualEthernetCardOpaqueNetworkBackingInfo:
+		info.Backing.Type = vcenter.EthernetBackingOpaqueNetwork
+		info.Backing.Network = b.OpaqueNetworkId
+	}
+
+	return info
+}
+
+// "/api/vcenter/vm/{}"
+func (h *Handler) getVM(w http.ResponseWriter, r *http.Request, ctx *simulator.Context, vm *simulator.VirtualMachine) {
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+
+	info := vcenter.Info{
+		GuestOS: guestOS(vm.Config.GuestId),
+		Name:    vm.Name,
+		Identity: &vcenter.Identity{
+			Name:         vm.Name,
+			BiosUUID:     vm.Config.Uuid,
+			InstanceUUID: vm.Config.InstanceUuid,
+		},
+		PowerState: powerState(vm.Runtime.PowerState),
+		Hardware:   hardwareInfo(vm),
+		CPU:        cpuInfo(vm),
+		Memory:     memoryInfo(vm),
+		Disks:      make(map[string]vcenter.DiskInfo),
+		Nics:       make(map[string]vcenter.EthernetInfo),
+	}
+
+	for _, device := range devices {
+		key := strconv.Itoa(int(device.GetVirtualDevice().Key))
+		switch d := device.(type) {
+		case *types.VirtualDisk:
+			info.Disks[key] = diskInfo(devices, d)
+		case types.BaseVirtualEthernetCard:
+			info.Nics[key] = ethernetInfo(devices, d)
+		}
+	}
+
+	vapi.StatusOK(w, info)
+}
+
+func (h *Handler) reconfigure(w http.ResponseWriter, r *http.Request, ctx *simulator.Context, vm *simulator.VirtualMachine, spec types.VirtualMachineConfigSpec) {
+	_, fault := taskResult(ctx, vm.ReconfigVMTask(ctx, &types.ReconfigVM_Task{This: vm.Self, Spec: spec}))
+	if fault != nil {
+		h.fault(w, r, fault)
+		return
+	}
+	vapi.StatusOK(w)
+}
+
+// path starts with "/api/vcenter/vm/{}/hardware"
+func (h *Handler) handleVmHardware(w http.ResponseWriter, r *http.Request, tail []string, ctx *simulator.Context, vm *simulator.VirtualMachine) {
+	if len(tail) == 0 {
+		// "/api/vcenter/vm/{}/hardware"
+		switch r.Method {
+		case http.MethodGet:
+			vapi.StatusOK(w, hardwareInfo(vm))
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	poweredOn := vm.Runtime.PowerState == types.VirtualMachinePowerStatePoweredOn
+
+	switch tail[0] {
+	case "cpu":
+		// "/api/vcenter/vm/{}/hardware/cpu"
+		switch r.Method {
+		case http.MethodGet:
+			vapi.StatusOK(w, cpuInfo(vm))
+		case http.MethodPatch:
+			var spec vcenter.CPUUpdateSpec
+			if !vapi.Decode(r, w, &spec) {
+				return
+			}
+			if poweredOn {
+				info := cpuInfo(vm)
+				hotplug := spec.Count == nil || *spec.Count == info.Count ||
+					(*spec.Count > info.Count && info.HotAddEnabled) ||
+					(*spec.Count < info.Count && info.HotRemoveEnabled)
+				if !hotplug || spec.CoresPerSocket != nil || spec.HotAddEnabled != nil || spec.HotRemoveEnabled != nil {
+					vapi.ApiErrorNotAllowedInCurrentState(w)
+					return
+				}
+			}
+			var config types.VirtualMachineConfigSpec
+			cpuConfigSpec(&config, spec)
+			h.reconfigure(w, r, ctx, vm, config)
+		default:
+			http.NotFound(w, r)
+		}
+	case "memory":
+		// "/api/vcenter/vm/{}/hardware/memory"
+		switch r.Method {
+		case http.MethodGet:
+			vapi.StatusOK(w, memoryInfo(vm))
+		case http.MethodPatch:
+			var spec vcenter.MemoryUpdateSpec
+			if !vapi.Decode(r, w, &spec) {
+				return
+			}
+			if poweredOn {
+				info := memoryInfo(vm)
+				hotplug := spec.SizeMiB == nil || *spec.SizeMiB == info.SizeMiB ||
+					(*spec.SizeMiB > info.SizeMiB && info.HotAddEnabled)
+				if !hotplug || spec.HotAddEnabled != nil {
+					vapi.ApiErrorNotAllowedInCurrentState(w)
+					return
+				}
+			}
+			var config types.VirtualMachineConfigSpec
+			memoryConfigSpec(&config, spec)
+			h.reconfigure(w, r, ctx, vm, config)
+		default:
+			http.NotFound(w, r)
+		}
+	case "disk":
+		// "/api/vcenter/vm/{}/hardware/disk/..."
+		h.handleVmHardwareDevice(w, r, tail[1:], vm, "disk", func(devices object.VirtualDeviceList, device types.BaseVirtualDevice) any {
+			if disk, ok := device.(*types.VirtualDisk); ok {
+				return diskInfo(devices, disk)
+			}
+			return nil
+		})
+	case "ethernet":
+		// "/api/vcenter/vm/{}/hardware/ethernet/..."
+		h.handleVmHardwareDevice(w, r, tail[1:], vm, "nic", func(devices object.VirtualDeviceList, device types.BaseVirtualDevice) any {
+			if nic, ok := device.(types.BaseVirtualEthernetCard); ok {
+				return ethernetInfo(devices, nic)
+			}
+			return nil
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleVmHardwareDevice lists devices or gets a device by key, for which info returns non-nil
+func (h *Handler) handleVmHardwareDevice(w http.ResponseWriter, r *http.Request, tail []string, vm *simulator.VirtualMachine, field string,
+	info func(object.VirtualDeviceList, types.BaseVirtualDevice) any) {
+	if r.Method != http.MethodGet || len(tail) > 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	devices := object.VirtualDeviceList(vm.Config.Hardware.Device)
+
+	if len(tail) == 0 {
+		res := []map[string]string{}
+		for _, device := range devices {
+			if info(devices, device) != nil {
+				res = append(res, map[string]string{field: strconv.Itoa(int(device.GetVirtualDevice().Key))})
+			}
+		}
+		vapi.StatusOK(w, res)
+		return
+	}
+
+	key, err := strconv.Atoi(tail[0])
+	if err == nil {
+		if device := devices.FindByKey(int32(key)); device != nil {
+			if res := info(devices, device); res != nil {
+				vapi.StatusOK(w, res)
+				return
+			}
+		}
+	}
+
+	vapi.ApiErrorNotFound(w)
+}
+
+// "/api/vcenter/vm/{}/power"
+func (h *Handler) handleVmPower(w http.ResponseWriter, r *http.Request, tail []string, ctx *simulator.Context, vm *simulator.VirtualMachine) {
+	if len(tail) != 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	state := vm.Runtime.PowerState
+
+	switch r.Method {
+	case http.MethodGet:
+		vapi.StatusOK(w, vcenter.PowerInfo{State: powerState(state)})
+		return
+	case http.MethodPost:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	var body soap.HasFault
+
+	switch r.URL.Query().Get("action") {
+	case "start":
+		if state == types.VirtualMachinePowerStatePoweredOn {
+			vapi.ApiErrorAlreadyInDesiredState(w)
+			return
+		}
+		body = vm.PowerOnVMTask(ctx, &types.PowerOnVM_Task{This: vm.Self})
+	case "stop":
+		if state == types.VirtualMachinePowerStatePoweredOff {
+			vapi.ApiErrorAlreadyInDesiredState(w)
+			return
+		}
+		body = vm.PowerOffVMTask(ctx, &types.PowerOffVM_Task{This: vm.Self})
+	case "suspend":
+		if state == types.VirtualMachinePowerStateSuspended {
+			vapi.ApiErrorAlreadyInDesiredState(w)
+			return
+		}
+		if state != types.VirtualMachinePowerStatePoweredOn {
+			vapi.ApiErrorNotAllowedInCurrentState(w)
+			return
+		}
+		body = vm.SuspendVMTask(ctx, &types.SuspendVM_Task{This: vm.Self})
+	case "reset":
+		if state != types.VirtualMachinePowerStatePoweredOn {
+			vapi.ApiErrorNotAllowedInCurrentState(w)
+			return
+		}
+		body = vm.ResetVMTask(ctx, &types.ResetVM_Task{This: vm.Self})
+	default:
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	if _, fault := taskResult(ctx, body); fault != nil {
+		h.fault(w, r, fault)
+		return
+	}
+
+	vapi.StatusOK(w)
+}
+
+func guestFamily(family string) string {
+	switch types.VirtualMachineGuestOsFamily(family) {
+	case types.VirtualMachineGuestOsFamilyWindowsGuest:
+		return vcenter.GuestOSFamilyWindows
+	case types.VirtualMachineGuestOsFamilyLinuxGuest:
+		return vcenter.GuestOSFamilyLinux
+	case types.VirtualMachineGuestOsFamilyNetwareGuest:
+		return vcenter.GuestOSFamilyNetware
+	case types.VirtualMachineGuestOsFamilySolarisGuest:
+		return vcenter.GuestOSFamilySolaris
+	case types.VirtualMachineGuestOsFamilyDarwinGuestFamily:
+		return vcenter.GuestOSFamilyDarwin
+	default:
+		return vcenter.GuestOSFamilyOther
+	}
+}
+
+func guestIdentity(vm *simulator.VirtualMachine) vcenter.GuestIdentityInfo {
+	id := vm.Guest.GuestId
+	if id == "" {
+		id = vm.Config.GuestId
+	}
+
+	name := vm.Guest.GuestFullName
+	if name == "" {
+		name = vm.Config.GuestFullName
+	}
+
+	return vcenter.GuestIdentityInfo{
+		Name:   guestOS(id),
+		Family: guestFamily(vm.Guest.GuestFamily),
+		FullName: rest.LocalizableMessage{
+			DefaultMessage: name,
+			ID:             "vmsg.guestos." + id + ".label",
+		},
+		HostName:  vm.Guest.HostName,
+		IPAddress: vm.Guest.IpAddress,
+	}
+}
+
+func guestNetworking(vm *simulator.VirtualMachine) vcenter.GuestNetworkingInfo {
+	var info vcenter.GuestNetworkingInfo
+
+	for _, stack := range vm.Guest.IpStack {
+		if dns := stack.DnsConfig; dns != nil {
+			info.DNSValues = &vcenter.GuestDNSValues{
+				DomainName:    dns.DomainName,
+				SearchDomains: dns.SearchDomain,
+			}
+			break
+		}
+	}
+
+	return info
+}
+
+func guestInterfaces(vm *simulator.VirtualMachine) []vcenter.GuestInterfaceInfo {
+	res := []vcenter.GuestInterfaceInfo{}
+
+	for _, nic := range vm.Guest.Net {
+		info := vcenter.GuestInterfaceInfo{
+			MacAddress: nic.MacAddress,
+		}
+
+		if nic.DeviceConfigId > 0 {
+			info.Nic = strconv.Itoa(int(nic.DeviceConfigId))
+		}
+
+		if c := nic.IpConfig; c != nil {
+			info.IP = &vcenter.GuestIPConfig{}
+			for _, ip := range c.IpAddress {
+				info.IP.IPAddresses = append(info.IP.IPAddresses, vcenter.GuestIPAddress{
+					IPAddress:    ip.IpAddress,
+					PrefixLength: ip.PrefixLength,
+					Origin:       strings.ToUpper(ip.Origin),
+					State:        strings.ToUpper(ip.State),
+				})
+			}
+		} else if len(nic.IpAddress) != 0 {
+			info.IP = &vcenter.GuestIPConfig{}
+			for _, ip := range nic.IpAddress {
+				info.IP.IPAddresses = append(info.IP.IPAddresses, vcenter.GuestIPAddress{
+					IPAddress: ip,
+					State:     "UNKNOWN",
+				})
+			}
+		}
+
+		res = append(res, info)
+	}
+
+	return res
+}
+
+func guestPower(vm *simulator.VirtualMachine) vcenter.GuestPowerInfo {
+	info := vcenter.GuestPowerInfo{State: vcenter.GuestPowerStateUnavailable}
+
+	switch {
+	case vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn:
+		info.State = vcenter.GuestPowerStateNotRunning
+	case toolsRunning(vm):
+		info.State = vcenter.GuestPowerStateRunning
+		info.OperationsReady = true
+	}
+
+	return info
+}
+
+// path starts with "/api/vcenter/vm/{}/guest"
+func (h *Handler) handleVmGuest(w http.ResponseWriter, r *http.Request, tail []string, ctx *simulator.Context, vm *simulator.VirtualMachine) {
+	p := strings.Join(tail, "/")
+
+	if r.Method == http.MethodGet {
+		switch p {
+		case "power":
+			vapi.StatusOK(w, guestPower(vm))
+			return
+		case "identity", "networking", "networking/interfaces":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if !toolsRunning(vm) {
+			vapi.ApiErrorServiceUnavailable(w)
+			return
+		}
+
+		switch p {
+		case "identity":
+			vapi.StatusOK(w, guestIdentity(vm))
+		case "networking":
+			vapi.StatusOK(w, guestNetworking(vm))
+		case "networking/interfaces":
+			vapi.StatusOK(w, guestInterfaces(vm))
+		}
+		return
+	}
+
+	if r.Method != http.MethodPost || p != "power" {
+		http.NotFound(w, r)
+		return
+	}
+
+	// "/api/vcenter/vm/{}/guest/power?action=..."
+	var op func() soap.HasFault
+
+	switch r.URL.Query().Get("action") {
+	case "shutdown":
+		op = func() soap.HasFault { return vm.ShutdownGuest(ctx, &types.ShutdownGuest{This: vm.Self}) }
+	case "reboot":
+		op = func() soap.HasFault { return vm.RebootGuest(ctx, &types.RebootGuest{This: vm.Self}) }
+	case "standby":
+		op = func() soap.HasFault { return vm.StandbyGuest(ctx, &types.StandbyGuest{This: vm.Self}) }
+	default:
+		vapi.ApiErrorInvalidArgument(w)
+		return
+	}
+
+	if vm.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		vapi.ApiErrorNotAllowedInCurrentState(w)
+		return
+	}
+
+	if !toolsRunning(vm) {
+		vapi.ApiErrorServiceUnavailable(w)
+		return
+	}
+
+	if f := op().Fault(); f != nil {
+		h.fault(w, r, f.VimFault().(types.BaseMethodFault))
+		return
+	}
+
+	vapi.StatusOK(w)
+}
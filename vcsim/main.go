@@ -41,7 +41,9 @@ import (
 	_ "github.com/vmware/govmomi/vapi/esx/settings/simulator"
 	_ "github.com/vmware/govmomi/vapi/namespace/simulator"
 	_ "github.com/vmware/govmomi/vapi/simulator"
+	_ "github.com/vmware/govmomi/vapi/vcenter/certificatemanagement/simulator"
 	_ "github.com/vmware/govmomi/vapi/vcenter/consumptiondomains/simulator"
+	_ "github.com/vmware/govmomi/vapi/vcenter/guest/simulator"
 	_ "github.com/vmware/govmomi/vapi/vm/simulator"
 	_ "github.com/vmware/govmomi/vsan/simulator"
 	_ "github.com/vmware/govmomi/vslm/simulator"
@@ -86,6 +88,9 @@ func main() {
 	trace := flag.String("trace-file", "", "Trace output file (defaults to stderr)")
 	stdinExit := flag.Bool("stdinexit", false, "Press any key to exit")
 	dir := flag.String("load", "", "Load model from directory")
+	checkpoint := flag.String("checkpoint", "", "Restore state from directory, if it exists, and save state to directory on exit")
+	faults := flag.String("fault-scenario", "", "Load fault injection rules from YAML or JSON file")
+	admin := flag.Bool("admin", false, "Enable /vcsim admin endpoints, requires a valid session cookie")
 
 	flag.IntVar(&model.DelayConfig.Delay, "delay", model.DelayConfig.Delay, "Method response delay across all methods")
 	methodDelayP := flag.String("method-delay", "", "Delay per method on the form 'method1:delay1,method2:delay2...'")
@@ -163,16 +168,26 @@ func main() {
 
 	esx.HostSystem.Summary.Hardware.Vendor += tag
 
-	if *dir == "" {
-		err = model.Create()
-	} else {
+	switch {
+	case hasCheckpoint(*checkpoint):
+		err = model.Restore(*checkpoint)
+	case *dir != "":
 		err = model.Load(*dir)
+	default:
+		err = model.Create()
 	}
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if *faults != "" {
+		if err = loadFaultScenario(model.Service, *faults); err != nil {
+			log.Fatalf("fault-scenario: %s", err)
+		}
+	}
+
 	model.Service.RegisterEndpoints = true
+	model.Service.AdminEndpoints = *admin
 	model.Service.Listen = u
 	if *isTLS {
 		model.Service.TLS = new(tls.Config)
@@ -241,6 +256,12 @@ func main() {
 
 	<-sig
 
+	if *checkpoint != "" {
+		if err = model.Service.Checkpoint(*checkpoint); err != nil {
+			log.Printf("checkpoint: %s", err)
+		}
+	}
+
 	model.Remove()
 
 	if *trace != "" {
@@ -248,6 +269,24 @@ func main() {
 	}
 }
 
+func hasCheckpoint(dir string) bool {
+	if dir == "" {
+		return false
+	}
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) != 0
+}
+
+func loadFaultScenario(s *simulator.Service, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return s.FaultInjector().LoadScenario(f)
+}
+
 func updateHostTemplate(ip string) error {
 	addr, port, err := net.SplitHostPort(ip)
 	if err != nil {
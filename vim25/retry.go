@@ -79,6 +79,11 @@ func Retry(roundTripper soap.RoundTripper, fn RetryFunc, retryAttempts ...int) s
 	return r
 }
 
+// Unwrap returns the wrapped soap.RoundTripper.
+func (r *retry) Unwrap() soap.RoundTripper {
+	return r.roundTripper
+}
+
 func (r *retry) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
 	var err error
 
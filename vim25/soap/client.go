@@ -28,8 +28,10 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/vmware/govmomi/internal/version"
 	"github.com/vmware/govmomi/vim25/progress"
@@ -699,6 +701,19 @@ func (e *statusError) Error() string {
 	return e.res.Status
 }
 
+// StatusCode returns the HTTP response status code
+func (e *statusError) StatusCode() int {
+	return e.res.StatusCode
+}
+
+// RetryAfter returns the HTTP response Retry-After header value in seconds, if any
+func (e *statusError) RetryAfter() time.Duration {
+	if n, err := strconv.Atoi(e.res.Header.Get("Retry-After")); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return 0
+}
+
 func newStatusError(res *http.Response) error {
 	return &url.Error{
 		Op:  res.Request.Method,
@@ -820,6 +835,13 @@ type Upload struct {
 	Ticket        *http.Cookie
 	Progress      progress.Sinker
 	Close         bool
+
+	// Retries is the number of times UploadFile retries a failed upload.
+	Retries int
+	// Resume enables UploadFile to continue from the size of the remote file, as reported by a HEAD request,
+	// using a Content-Range header, including a partial file left by a previous upload. Only enable when the endpoint supports Content-Range PUT requests.
+	// The remote file size is verified after a resumed upload, which is otherwise restarted from the beginning.
+	Resume bool
 }
 
 var DefaultUpload = Upload{
@@ -883,6 +905,10 @@ func (c *Client) UploadFile(ctx context.Context, file string, u *url.URL, param
 		param = &p
 	}
 
+	if param.Retries > 0 || param.Resume {
+		return c.uploadFile(ctx, file, u, param)
+	}
+
 	s, err := os.Stat(file)
 	if err != nil {
 		return err
@@ -906,6 +932,19 @@ type Download struct {
 	Progress progress.Sinker
 	Writer   io.Writer
 	Close    bool
+
+	// Resume enables DownloadFile to continue a partial download of an existing local file, using an HTTP Range request.
+	Resume bool
+	// Retries is the number of times DownloadFile resumes a failed download from the last byte received.
+	Retries int
+	// Parallel is the number of concurrent ranged requests used by DownloadFile, if the server supports ranges.
+	// Parallel is ignored if Writer is set.
+	Parallel int
+	// ChunkSize is the size of each ranged request when Parallel > 1, defaults to DefaultChunkSize.
+	ChunkSize int64
+	// Checksum is verified against the file written by DownloadFile, in "algorithm:hex" form.
+	// Supported algorithms are sha1, sha256 and sha512.
+	Checksum string
 }
 
 var DefaultDownload = Download{
@@ -999,6 +1038,10 @@ func (c *Client) DownloadFile(ctx context.Context, file string, u *url.URL, para
 		param = &DefaultDownload
 	}
 
+	if param.Resume || param.Retries > 0 || param.Parallel > 1 || param.Checksum != "" {
+		return c.downloadFile(ctx, file, u, param)
+	}
+
 	rc, contentLength, err := c.Download(ctx, u, param)
 	if err != nil {
 		return err
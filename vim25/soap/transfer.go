@@ -0,0 +1,423 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package soap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmware/govmomi/vim25/progress"
+)
+
+// DefaultChunkSize is the default Download.ChunkSize
+const DefaultChunkSize = 64 * 1024 * 1024
+
+// ErrChecksum is returned by DownloadFile when the file does not match Download.Checksum
+var ErrChecksum = errors.New("checksum mismatch")
+
+// transferProgress feeds the byte counts of one or more concurrent requests to a single progress.Sinker
+type transferProgress struct {
+	mu  sync.Mutex
+	pr  interface{ Done(error) }
+	r   io.Reader
+	buf []byte
+}
+
+// tick is an io.Reader that "reads" the number of bytes requested by transferProgress.add
+type tick struct{}
+
+func (tick) Read(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func newTransferProgress(ctx context.Context, s progress.Sinker, size int64) *transferProgress {
+	if s == nil {
+		return nil
+	}
+	pr := progress.NewReader(ctx, s, tick{}, size)
+	return &transferProgress{pr: pr, r: pr}
+}
+
+func (p *transferProgress) add(n int) {
+	if p == nil || n <= 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cap(p.buf) < n {
+		p.buf = make([]byte, n)
+	}
+	_, _ = p.r.Read(p.buf[:n])
+}
+
+func (p *transferProgress) done(err error) {
+	if p != nil {
+		p.pr.Done(err)
+	}
+}
+
+// progressWriter reports the number of bytes written to transferProgress
+type progressWriter struct {
+	io.Writer
+	p *transferProgress
+}
+
+func (w *progressWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	w.p.add(n)
+	return n, err
+}
+
+// parseChecksum parses a checksum in "algorithm:hex" form
+func parseChecksum(s string) (hash.Hash, []byte, error) {
+	alg, sum, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid checksum %q, expected algorithm:hex", s)
+	}
+
+	expect, err := hex.DecodeString(sum)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid checksum %q: %s", s, err)
+	}
+
+	var h hash.Hash
+	switch strings.ToLower(alg) {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, nil, fmt.Errorf("unsupported checksum algorithm %q", alg)
+	}
+
+	return h, expect, nil
+}
+
+// contentRange parses the total size from a Content-Range header value, such as "bytes 0-1023/4096" or "bytes */4096"
+func contentRange(val string) int64 {
+	_, total, ok := strings.Cut(val, "/")
+	if !ok {
+		return -1
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+type download struct {
+	c     *Client
+	u     *url.URL
+	param *Download
+	fh    *os.File
+	p     *transferProgress
+}
+
+// get requests the byte range [start, end] and writes it to the file, an end of -1 meaning the end of the file.
+// Returns the file position after the write and the size of the remote file, if known, otherwise -1.
+func (d *download) get(ctx context.Context, start, end int64) (int64, int64, error) {
+	param := *d.param
+	param.Headers = maps.Clone(param.Headers)
+	if param.Headers == nil {
+		param.Headers = make(map[string]string)
+	}
+
+	if start > 0 || end >= 0 {
+		r := fmt.Sprintf("bytes=%d-", start)
+		if end >= 0 {
+			r += strconv.FormatInt(end, 10)
+		}
+		param.Headers["Range"] = r
+	}
+
+	res, err := d.c.DownloadRequest(ctx, d.u, &param)
+	if err != nil {
+		return start, -1, err
+	}
+	defer res.Body.Close()
+
+	size := int64(-1)
+
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		size = contentRange(res.Header.Get("Content-Range"))
+	case http.StatusOK:
+		if end >= 0 && start > 0 {
+			return start, -1, fmt.Errorf("download(%s): range requests not supported", d.u)
+		}
+		// Range ignored by the server, start over
+		start = 0
+		size = res.ContentLength
+		if err = d.fh.Truncate(0); err != nil {
+			return start, -1, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		size = contentRange(res.Header.Get("Content-Range"))
+		if size == start {
+			return start, size, nil // resumed a completed download
+		}
+		fallthrough
+	default:
+		return start, size, fmt.Errorf("download(%s): %s", d.u, res.Status)
+	}
+
+	if d.p == nil && d.param.Progress != nil {
+		// the first response determines the size, any subsequent requests are for ranges within
+		d.p = newTransferProgress(ctx, d.param.Progress, size)
+		d.p.add(int(start))
+	}
+
+	var w io.Writer = io.NewOffsetWriter(d.fh, start)
+	if d.param.Writer != nil {
+		w = io.MultiWriter(w, d.param.Writer)
+	}
+
+	n, err := io.Copy(&progressWriter{w, d.p}, res.Body)
+
+	return start + n, size, err
+}
+
+// fetch calls get, resuming from the last byte received up to Download.Retries times.
+func (d *download) fetch(ctx context.Context, start, end int64) (int64, int64, error) {
+	var err error
+	size := int64(-1)
+
+	for attempt := 0; attempt <= d.param.Retries; attempt++ {
+		var pos, n int64
+		pos, n, err = d.get(ctx, start, end)
+		start = pos
+		if n >= 0 {
+			size = n
+		}
+		if err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return start, size, err
+}
+
+// parallel fetches the byte range [start, size) using concurrent ranged requests.
+func (d *download) parallel(ctx context.Context, start, size, chunk int64) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int64)
+	errs := make(chan error, d.param.Parallel)
+	var wg sync.WaitGroup
+
+	for range d.param.Parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range jobs {
+				end := min(offset+chunk, size) - 1
+				if _, _, err := d.fetch(ctx, offset, end); err != nil {
+					errs <- err
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	for offset := start; offset < size; offset += chunk {
+		select {
+		case jobs <- offset:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// verify compares the file checksum with Download.Checksum
+func (d *download) verify() error {
+	h, expect, err := parseChecksum(d.param.Checksum)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(h, io.NewSectionReader(d.fh, 0, 1<<63-1)); err != nil {
+		return err
+	}
+
+	if sum := h.Sum(nil); !bytes.Equal(sum, expect) {
+		return fmt.Errorf("%w: %s: expected %x, got %x", ErrChecksum, d.fh.Name(), expect, sum)
+	}
+
+	return nil
+}
+
+// downloadFile implements DownloadFile with Range requests
+func (c *Client) downloadFile(ctx context.Context, file string, u *url.URL, param *Download) (err error) {
+	if param.Checksum != "" {
+		if _, _, err = parseChecksum(param.Checksum); err != nil {
+			return err
+		}
+	}
+
+	flag := os.O_RDWR | os.O_CREATE
+	if !param.Resume {
+		flag |= os.O_TRUNC
+	}
+
+	fh, err := os.OpenFile(filepath.Clean(file), flag, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := fh.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	var start int64
+	if param.Resume {
+		s, err := fh.Stat()
+		if err != nil {
+			return err
+		}
+		start = s.Size()
+	}
+
+	d := &download{c: c, u: u, param: param, fh: fh}
+
+	parallel := param.Parallel > 1 && param.Writer == nil
+	chunk := param.ChunkSize
+	if chunk <= 0 {
+		chunk = DefaultChunkSize
+	}
+
+	end := int64(-1)
+	if parallel {
+		end = start + chunk - 1 // probe for Range support with the first chunk
+	}
+
+	defer func() { d.p.done(err) }()
+
+	pos, size, err := d.fetch(ctx, start, end)
+	if err != nil {
+		return err
+	}
+
+	if parallel && size > pos {
+		if err = d.parallel(ctx, pos, size, chunk); err != nil {
+			return err
+		}
+	}
+
+	if size >= 0 {
+		// in the case of a resumed download where the local file was larger than the remote file
+		if err = fh.Truncate(size); err != nil {
+			return err
+		}
+	}
+
+	if param.Checksum != "" {
+		return d.verify()
+	}
+
+	return nil
+}
+
+// remoteSize returns the size of the remote file via HEAD request, or -1 if unknown
+func (c *Client) remoteSize(ctx context.Context, u *url.URL, param *Upload) int64 {
+	res, err := c.DownloadRequest(ctx, u, &Download{Method: http.MethodHead, Ticket: param.Ticket, Close: param.Close})
+	if err != nil {
+		return -1
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return -1
+	}
+
+	return res.ContentLength
+}
+
+// uploadFile implements UploadFile with retries and optional resume via Content-Range
+func (c *Client) uploadFile(ctx context.Context, file string, u *url.URL, param *Upload) (err error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := s.Size()
+
+	p := newTransferProgress(ctx, param.Progress, size)
+	defer func() { p.done(err) }()
+
+	resume := func() int64 {
+		if param.Resume {
+			if n := c.remoteSize(ctx, u, param); n > 0 && n < size {
+				return n
+			}
+		}
+		return 0
+	}
+
+	// continue a partial remote file left by a previous upload
+	start := resume()
+
+	for attempt := 0; ; attempt++ {
+		req := *param
+		req.Progress = nil
+		req.ContentLength = size - start
+		req.Headers = maps.Clone(param.Headers)
+
+		if start > 0 {
+			if req.Headers == nil {
+				req.Headers = make(map[string]string)
+			}
+			req.Headers["Content-Range"] = fmt.Sprintf("bytes %d-%d/%d", start, size-1, size)
+		}
+
+		r := io.TeeReader(io.NewSectionReader(f, start, size-start), &progressWriter{io.Discard, p})
+
+		err = c.Upload(ctx, r, u, &req)
+		if err == nil && start > 0 && c.remoteSize(ctx, u, param) != size {
+			// the server did not apply the Content-Range, start over
+			err = fmt.Errorf("upload(%s): resumed upload size mismatch", u)
+			if attempt < param.Retries {
+				start = 0
+				continue
+			}
+		}
+
+		if err == nil || attempt >= param.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		start = resume()
+	}
+}
@@ -0,0 +1,250 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package soap
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileServer serves a single file, supporting Range requests and PUT with Content-Range
+type fileServer struct {
+	mu      sync.Mutex
+	content []byte
+	ranges  []string
+	puts    []string
+	ranged  bool
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		b, _ := io.ReadAll(r.Body)
+		var start int
+		if cr := r.Header.Get("Content-Range"); cr != "" {
+			_, _ = fmt.Sscanf(cr, "bytes %d-", &start)
+		}
+		s.puts = append(s.puts, r.Header.Get("Content-Range"))
+		s.content = append(s.content[:start], b...)
+	default:
+		if !s.ranged {
+			r.Header.Del("Range")
+		}
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		http.ServeContent(w, r, "file", time.Time{}, bytes.NewReader(s.content))
+	}
+}
+
+var errFlaky = errors.New("connection reset")
+
+// flakyReader returns errFlaky after n bytes
+type flakyReader struct {
+	io.ReadCloser
+	n int
+}
+
+func (r *flakyReader) Read(b []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, errFlaky
+	}
+	if len(b) > r.n {
+		b = b[:r.n]
+	}
+	n, err := r.ReadCloser.Read(b)
+	r.n -= n
+	return n, err
+}
+
+// flaky fails the first `fail` requests of the given method after n bytes of the body
+type flaky struct {
+	rt     http.RoundTripper
+	method string
+	fail   int
+	n      int
+}
+
+func (t *flaky) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != t.method || t.fail == 0 {
+		return t.rt.RoundTrip(req)
+	}
+	t.fail--
+
+	if req.Body != nil {
+		// forward a truncated request body
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(req.Body, int64(t.n)), req.Body}
+		req.ContentLength = int64(t.n)
+		res, err := t.rt.RoundTrip(req)
+		if err == nil {
+			_ = res.Body.Close()
+			err = errFlaky
+		}
+		return nil, err
+	}
+
+	res, err := t.rt.RoundTrip(req)
+	if err == nil {
+		res.Body = &flakyReader{ReadCloser: res.Body, n: t.n}
+	}
+	return res, err
+}
+
+func newTransferTest(t *testing.T) (*Client, *url.URL, *fileServer) {
+	content := make([]byte, 1024*64+42)
+	_, err := rand.Read(content)
+	require.NoError(t, err)
+
+	s := &fileServer{content: content, ranged: true}
+	ts := httptest.NewServer(s)
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL + "/folder/file")
+	require.NoError(t, err)
+
+	return NewClient(u, true), u, s
+}
+
+func TestDownloadFile(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Run("parallel", func(t *testing.T) {
+		c, u, s := newTransferTest(t)
+		file := filepath.Join(dir, "parallel")
+
+		p := DefaultDownload
+		p.Parallel = 4
+		p.ChunkSize = 4096
+		p.Checksum = fmt.Sprintf("sha256:%x", sha256.Sum256(s.content))
+
+		require.NoError(t, c.DownloadFile(ctx, file, u, &p))
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, s.content, b)
+		assert.Len(t, s.ranges, (len(s.content)+4095)/4096)
+		assert.Contains(t, s.ranges, "bytes=0-4095")
+
+		p.Checksum = fmt.Sprintf("sha256:%x", sha256.Sum256(nil))
+		err = c.DownloadFile(ctx, file, u, &p)
+		assert.ErrorIs(t, err, ErrChecksum)
+
+		p.Checksum = "md5:00"
+		err = c.DownloadFile(ctx, file, u, &p)
+		assert.ErrorContains(t, err, "unsupported checksum")
+	})
+
+	t.Run("parallel without range support", func(t *testing.T) {
+		c, u, s := newTransferTest(t)
+		s.ranged = false
+		file := filepath.Join(dir, "no-range")
+
+		p := DefaultDownload
+		p.Parallel = 4
+		p.ChunkSize = 4096
+
+		require.NoError(t, c.DownloadFile(ctx, file, u, &p))
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, s.content, b)
+		assert.Len(t, s.ranges, 1)
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		c, u, s := newTransferTest(t)
+		file := filepath.Join(dir, "resume")
+		require.NoError(t, os.WriteFile(file, s.content[:1000], 0600))
+
+		p := DefaultDownload
+		p.Resume = true
+
+		require.NoError(t, c.DownloadFile(ctx, file, u, &p))
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, s.content, b)
+		assert.Equal(t, []string{"bytes=1000-"}, s.ranges)
+
+		// already complete
+		require.NoError(t, c.DownloadFile(ctx, file, u, &p))
+		b, err = os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, s.content, b)
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		c, u, s := newTransferTest(t)
+		rt := &flaky{rt: c.Client.Transport, method: http.MethodGet, fail: 2, n: 5000}
+		c.Client.Transport = rt
+		file := filepath.Join(dir, "retries")
+
+		p := DefaultDownload
+		p.Retries = 1
+		err := c.DownloadFile(ctx, file, u, &p)
+		assert.ErrorIs(t, err, errFlaky)
+
+		rt.fail = 1
+		require.NoError(t, c.DownloadFile(ctx, file, u, &p))
+		b, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, s.content, b)
+		assert.Equal(t, []string{"", "bytes=5000-", "", "bytes=5000-"}, s.ranges)
+	})
+}
+
+func TestUploadFile(t *testing.T) {
+	ctx := context.Background()
+
+	c, u, s := newTransferTest(t)
+	content := s.content
+	s.content = nil
+
+	file := filepath.Join(t.TempDir(), "upload")
+	require.NoError(t, os.WriteFile(file, content, 0600))
+
+	c.Client.Transport = &flaky{rt: c.Client.Transport, method: http.MethodPut, fail: 1, n: 3000}
+
+	p := DefaultUpload
+	p.Retries = 1
+	p.Resume = true
+	require.NoError(t, c.UploadFile(ctx, file, u, &p))
+	assert.Equal(t, content, s.content)
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes 3000-%d/%d", len(content)-1, len(content))}, s.puts)
+
+	// without Resume, the retry uploads the entire file
+	s.content, s.puts = nil, nil
+	c.Client.Transport.(*flaky).fail = 1
+	p.Resume = false
+	require.NoError(t, c.UploadFile(ctx, file, u, &p))
+	assert.Equal(t, content, s.content)
+	assert.Equal(t, []string{"", ""}, s.puts)
+
+	// Resume without Retries continues a partial remote file from a previous upload
+	s.content, s.puts = content[:2000], nil
+	p.Retries = 0
+	p.Resume = true
+	require.NoError(t, c.UploadFile(ctx, file, u, &p))
+	assert.Equal(t, content, s.content)
+	assert.Equal(t, []string{fmt.Sprintf("bytes 2000-%d/%d", len(content)-1, len(content))}, s.puts)
+}
@@ -0,0 +1,369 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vim25
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Method classes used by the default ThrottleOptions.Class func
+const (
+	MethodClassSession = "session"
+	MethodClassWait    = "wait"
+	MethodClassTask    = "task"
+	MethodClassRead    = "read"
+	MethodClassWrite   = "write"
+)
+
+// Rate is a token bucket rate limit.
+type Rate struct {
+	PerSecond float64 // PerSecond is the rate tokens are added to the bucket
+	Burst     int     // Burst is the bucket size, defaults to 1
+}
+
+// Backoff configures retries of throttled requests.
+type Backoff struct {
+	Initial     time.Duration // Initial delay, defaults to 500ms
+	Max         time.Duration // Max delay, defaults to 30s
+	MaxAttempts int           // MaxAttempts including the first, defaults to 5
+}
+
+// ThrottleOptions configures the Throttle RoundTripper.
+type ThrottleOptions struct {
+	// MaxInFlight caps the number of concurrent calls, zero is unlimited.
+	// Calls in the MethodClassWait class do not count against this limit,
+	// as long polls would otherwise starve other calls.
+	MaxInFlight int
+	// Limits are per method class, classes without a limit or with a zero rate are not rate limited.
+	Limits map[string]Rate
+	// Class returns the method class of the given method name, defaults to MethodClass.
+	Class func(method string) string
+	// Backoff configures retries when IsThrottled returns true.
+	Backoff Backoff
+	// RetryClasses are the method classes that are retried when throttled,
+	// defaults to the read-only MethodClassRead and MethodClassWait classes.
+	RetryClasses []string
+	// IsThrottled reports whether err indicates the server is throttling requests, defaults to IsThrottled.
+	IsThrottled func(err error) bool
+}
+
+// ThrottleStats is a snapshot of the Throttler queue state.
+type ThrottleStats struct {
+	InFlight int            // InFlight is the number of calls sent and waiting for a response
+	Waiting  int            // Waiting is the number of calls waiting for an in-flight slot or rate limit token
+	Backoffs int64          // Backoffs is the total number of retries due to throttling
+	Classes  map[string]int // Classes is the number of waiting calls per method class
+}
+
+// Throttler is a soap.RoundTripper that limits concurrency and request rate,
+// backing off when the server is throttling requests.
+type Throttler struct {
+	roundTripper soap.RoundTripper
+	opts         ThrottleOptions
+
+	slots   chan struct{}
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	stats   ThrottleStats
+}
+
+// Throttle wraps the specified soap.RoundTripper with concurrency and rate limiting.
+func Throttle(roundTripper soap.RoundTripper, opts ThrottleOptions) *Throttler {
+	if opts.Class == nil {
+		opts.Class = MethodClass
+	}
+	if opts.IsThrottled == nil {
+		opts.IsThrottled = IsThrottled
+	}
+	if opts.Backoff.Initial <= 0 {
+		opts.Backoff.Initial = 500 * time.Millisecond
+	}
+	if opts.Backoff.Max <= 0 {
+		opts.Backoff.Max = 30 * time.Second
+	}
+	if opts.Backoff.MaxAttempts <= 0 {
+		opts.Backoff.MaxAttempts = 5
+	}
+	if opts.RetryClasses == nil {
+		opts.RetryClasses = []string{MethodClassRead, MethodClassWait}
+	}
+
+	t := &Throttler{
+		roundTripper: roundTripper,
+		opts:         opts,
+		buckets:      make(map[string]*bucket),
+		stats:        ThrottleStats{Classes: make(map[string]int)},
+	}
+
+	if opts.MaxInFlight > 0 {
+		t.slots = make(chan struct{}, opts.MaxInFlight)
+	}
+
+	for class, rate := range opts.Limits {
+		if rate.PerSecond > 0 {
+			t.buckets[class] = newBucket(rate)
+		}
+	}
+
+	return t
+}
+
+// Unwrap returns the wrapped soap.RoundTripper.
+func (t *Throttler) Unwrap() soap.RoundTripper {
+	return t.roundTripper
+}
+
+// Stats returns a snapshot of the Throttler queue state.
+func (t *Throttler) Stats() ThrottleStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.Classes = make(map[string]int, len(t.stats.Classes))
+	for class, n := range t.stats.Classes {
+		if n != 0 {
+			stats.Classes[class] = n
+		}
+	}
+
+	return stats
+}
+
+func (t *Throttler) waiting(class string, n int) {
+	t.mu.Lock()
+	t.stats.Waiting += n
+	t.stats.Classes[class] += n
+	t.mu.Unlock()
+}
+
+func (t *Throttler) inflight(n int) {
+	t.mu.Lock()
+	t.stats.InFlight += n
+	t.mu.Unlock()
+}
+
+// acquire waits for a rate limit token and in-flight slot, returning a func to release the slot.
+func (t *Throttler) acquire(ctx context.Context, class string) (func(), error) {
+	t.waiting(class, 1)
+	defer t.waiting(class, -1)
+
+	if b, ok := t.buckets[class]; ok {
+		if err := b.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.slots == nil || class == MethodClassWait {
+		return func() {}, nil
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+		return func() { <-t.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *Throttler) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	class := t.opts.Class(methodName(req))
+	retry := slices.Contains(t.opts.RetryClasses, class)
+	delay := t.opts.Backoff.Initial
+
+	for attempt := 1; ; attempt++ {
+		release, err := t.acquire(ctx, class)
+		if err != nil {
+			return err
+		}
+
+		t.inflight(1)
+		err = t.roundTripper.RoundTrip(ctx, req, res)
+		t.inflight(-1)
+		release()
+
+		if err == nil || !retry || attempt >= t.opts.Backoff.MaxAttempts || !t.opts.IsThrottled(err) {
+			return err
+		}
+
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1)) // jitter
+		if after := retryAfter(err); after > wait {
+			wait = after
+		}
+		delay = min(2*delay, t.opts.Backoff.Max)
+
+		t.mu.Lock()
+		t.stats.Backoffs++
+		t.mu.Unlock()
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+
+		// clear any fault from the previous attempt
+		if v := reflect.ValueOf(res); v.Kind() == reflect.Pointer && !v.IsNil() {
+			v.Elem().Set(reflect.Zero(v.Elem().Type()))
+		}
+	}
+}
+
+// MethodClass is the default ThrottleOptions.Class func.
+func MethodClass(method string) string {
+	switch {
+	case method == "Login", method == "Logout", method == "LoginByToken",
+		method == "LoginExtensionByCertificate", method == "SessionIsActive",
+		method == "AcquireCloneTicket", method == "CloneSession":
+		return MethodClassSession
+	case method == "WaitForUpdates", method == "WaitForUpdatesEx", method == "CheckForUpdates":
+		return MethodClassWait
+	case strings.HasSuffix(method, "_Task"):
+		return MethodClassTask
+	case method == "Fetch",
+		strings.HasPrefix(method, "Retrieve"),
+		strings.HasPrefix(method, "Query"),
+		strings.HasPrefix(method, "Find"),
+		strings.HasPrefix(method, "Read"),
+		strings.HasPrefix(method, "Current"),
+		strings.HasPrefix(method, "Continue"):
+		return MethodClassRead
+	}
+	return MethodClassWrite
+}
+
+// methodName returns the method name of the given request, such as the generated methods.*Body types.
+func methodName(req soap.HasFault) string {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	if r := v.FieldByName("Req"); r.IsValid() && r.Kind() == reflect.Pointer {
+		return r.Type().Elem().Name()
+	}
+	return strings.TrimSuffix(v.Type().Name(), "Body")
+}
+
+type statusCoder interface {
+	StatusCode() int
+}
+
+type retryAfterer interface {
+	RetryAfter() time.Duration
+}
+
+// IsThrottled returns true if err is an HTTP 429 (Too Many Requests) or 503 (Service Unavailable) response,
+// or a RequestCanceled or ServerBusy fault.
+// Such faults may also be returned by methods that are not safe to retry,
+// see ThrottleOptions.RetryClasses.
+func IsThrottled(err error) bool {
+	var sc statusCoder
+	if errors.As(err, &sc) {
+		switch sc.StatusCode() {
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return true
+		}
+		return false
+	}
+
+	var f any
+	switch {
+	case soap.IsSoapFault(err):
+		sf := soap.ToSoapFault(err)
+		if strings.Contains(sf.String, "ServerBusy") {
+			return true
+		}
+		f = sf.VimFault()
+	case soap.IsVimFault(err):
+		f = soap.ToVimFault(err)
+	}
+
+	switch f.(type) {
+	case types.RequestCanceled, *types.RequestCanceled:
+		return true
+	}
+
+	return false
+}
+
+func retryAfter(err error) time.Duration {
+	var ra retryAfterer
+	if errors.As(err, &ra) {
+		return ra.RetryAfter()
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// bucket is a token bucket rate limiter
+type bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(r Rate) *bucket {
+	burst := float64(max(r.Burst, 1))
+	return &bucket{rate: r.PerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// reserve takes a token, returning the delay until the token is available.
+func (b *bucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel returns a reserved token to the bucket
+func (b *bucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
+
+func (b *bucket) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if d := b.reserve(); d > 0 {
+		if err := sleep(ctx, d); err != nil {
+			b.cancel()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// © Broadcom. All Rights Reserved.
+// The term "Broadcom" refers to Broadcom Inc. and/or its subsidiaries.
+// SPDX-License-Identifier: Apache-2.0
+
+package vim25_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware/govmomi/fault"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestThrottleBackoff(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		throttle := vim25.Throttle(c.Client, vim25.ThrottleOptions{
+			Backoff: vim25.Backoff{Initial: time.Millisecond, MaxAttempts: 3},
+		})
+		c.RoundTripper = throttle
+
+		// Tell vcsim to respond with 503 on the 1st request
+		simulator.StatusSDK = http.StatusServiceUnavailable
+
+		_, err := methods.GetCurrentTime(ctx, c)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), throttle.Stats().Backoffs)
+
+		// methods that are not read-only are not retried by default
+		backoffs := throttle.Stats().Backoffs
+		simulator.StatusSDK = http.StatusServiceUnavailable
+		_, err = methods.CreateFilter(ctx, c, &types.CreateFilter{This: c.ServiceContent.PropertyCollector})
+		assert.True(t, vim25.IsThrottled(err))
+		assert.Equal(t, backoffs, throttle.Stats().Backoffs)
+
+		s := simulator.ServiceFromContext(ctx)
+		canceled := func(method string) {
+			s.AddFaultRule(&simulator.FaultInjectionRule{
+				MethodName:  method,
+				ObjectType:  "*",
+				ObjectName:  "*",
+				Probability: 1.0,
+				FaultType:   simulator.FaultTypeCustom,
+				Fault:       new(types.RequestCanceled),
+				MaxCount:    1,
+				Enabled:     true,
+			})
+		}
+
+		// RequestCanceled is retried for read-only methods
+		canceled("CurrentTime")
+		_, err = methods.GetCurrentTime(ctx, c)
+		require.NoError(t, err)
+		backoffs++
+		assert.Equal(t, backoffs, throttle.Stats().Backoffs)
+
+		// RequestCanceled is not retried for other methods
+		canceled("CreateFilter")
+		_, err = methods.CreateFilter(ctx, c, &types.CreateFilter{This: c.ServiceContent.PropertyCollector})
+		assert.True(t, fault.Is(err, &types.RequestCanceled{}))
+		assert.True(t, vim25.IsThrottled(err))
+		assert.Equal(t, backoffs, throttle.Stats().Backoffs)
+
+		// faults that are not throttling are returned without retry
+		ref := types.ManagedObjectReference{Type: "VirtualMachine", Value: "vm-enoent"}
+		_, err = methods.PowerOnVM_Task(ctx, c, &types.PowerOnVM_Task{This: ref})
+		assert.True(t, fault.Is(err, &types.ManagedObjectNotFound{}))
+		assert.Equal(t, backoffs, throttle.Stats().Backoffs)
+	})
+}
+
+func TestThrottleRetryClasses(t *testing.T) {
+	simulator.Test(func(ctx context.Context, c *vim25.Client) {
+		throttle := vim25.Throttle(c.Client, vim25.ThrottleOptions{
+			Backoff:      vim25.Backoff{Initial: time.Millisecond},
+			RetryClasses: []string{vim25.MethodClassWrite},
+		})
+		c.RoundTripper = throttle
+
+		simulator.StatusSDK = http.StatusServiceUnavailable
+		_, err := methods.CreateFilter(ctx, c, &types.CreateFilter{
+			This: c.ServiceContent.PropertyCollector,
+			Spec: types.PropertyFilterSpec{
+				ObjectSet: []types.ObjectSpec{{Obj: c.ServiceContent.RootFolder}},
+				PropSet:   []types.PropertySpec{{Type: "Folder", PathSet: []string{"name"}}},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), throttle.Stats().Backoffs)
+	})
+}
+
+// statusError is an HTTP response error
+type statusError int
+
+func (e statusError) Error() string {
+	return http.StatusText(int(e))
+}
+
+func (e statusError) StatusCode() int {
+	return int(e)
+}
+
+// statusRoundTripper fails all calls with the given HTTP status
+type statusRoundTripper struct {
+	status statusError
+	calls  int
+}
+
+func (s *statusRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	s.calls++
+	return s.status
+}
+
+func TestThrottleMaxAttempts(t *testing.T) {
+	rt := &statusRoundTripper{status: http.StatusTooManyRequests}
+	throttle := vim25.Throttle(rt, vim25.ThrottleOptions{
+		Backoff: vim25.Backoff{Initial: time.Millisecond, MaxAttempts: 3},
+	})
+
+	err := throttle.RoundTrip(context.Background(), &methods.CurrentTimeBody{Req: new(types.CurrentTime)}, new(methods.CurrentTimeBody))
+	assert.ErrorIs(t, err, rt.status)
+	assert.Equal(t, 3, rt.calls)
+	assert.Equal(t, int64(2), throttle.Stats().Backoffs)
+}
+
+// blockingRoundTripper blocks calls until released
+type blockingRoundTripper struct {
+	release chan struct{}
+}
+
+func (b *blockingRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	<-b.release
+	return nil
+}
+
+func TestThrottleConcurrency(t *testing.T) {
+	rt := &blockingRoundTripper{release: make(chan struct{})}
+	throttle := vim25.Throttle(rt, vim25.ThrottleOptions{MaxInFlight: 2})
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := &methods.CurrentTimeBody{Req: new(types.CurrentTime)}
+			_ = throttle.RoundTrip(context.Background(), req, new(methods.CurrentTimeBody))
+		}()
+	}
+
+	// long polls are not limited by MaxInFlight
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		req := &methods.WaitForUpdatesExBody{Req: new(types.WaitForUpdatesEx)}
+		_ = throttle.RoundTrip(context.Background(), req, new(methods.WaitForUpdatesExBody))
+	}()
+
+	assert.Eventually(t, func() bool {
+		s := throttle.Stats()
+		return s.InFlight == 3 && s.Waiting == 3 && s.Classes[vim25.MethodClassRead] == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	close(rt.release)
+	wg.Wait()
+
+	s := throttle.Stats()
+	assert.Zero(t, s.InFlight)
+	assert.Zero(t, s.Waiting)
+	assert.Empty(t, s.Classes)
+}
+
+func TestThrottleRate(t *testing.T) {
+	rt := &blockingRoundTripper{release: make(chan struct{})}
+	close(rt.release)
+
+	throttle := vim25.Throttle(rt, vim25.ThrottleOptions{
+		Limits: map[string]vim25.Rate{
+			vim25.MethodClassRead: {PerSecond: 50, Burst: 1},
+		},
+	})
+
+	call := func(req soap.HasFault) {
+		require.NoError(t, throttle.RoundTrip(context.Background(), req, new(methods.CurrentTimeBody)))
+	}
+
+	start := time.Now()
+	for range 4 {
+		call(&methods.CurrentTimeBody{Req: new(types.CurrentTime)})
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// other classes are not limited
+	start = time.Now()
+	for range 4 {
+		call(&methods.PowerOnVM_TaskBody{Req: new(types.PowerOnVM_Task)})
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+	// canceled while waiting for a token
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	call(&methods.CurrentTimeBody{Req: new(types.CurrentTime)})
+	err := throttle.RoundTrip(ctx, &methods.CurrentTimeBody{Req: new(types.CurrentTime)}, new(methods.CurrentTimeBody))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// a canceled call does not consume a token
+	throttle = vim25.Throttle(rt, vim25.ThrottleOptions{
+		Limits: map[string]vim25.Rate{
+			vim25.MethodClassRead: {PerSecond: 1, Burst: 1},
+		},
+	})
+	err = throttle.RoundTrip(ctx, &methods.CurrentTimeBody{Req: new(types.CurrentTime)}, new(methods.CurrentTimeBody))
+	assert.ErrorIs(t, err, context.Canceled)
+	start = time.Now()
+	call(&methods.CurrentTimeBody{Req: new(types.CurrentTime)})
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestMethodClass(t *testing.T) {
+	tests := map[string]string{
+		"Login":              vim25.MethodClassSession,
+		"WaitForUpdatesEx":   vim25.MethodClassWait,
+		"PowerOnVM_Task":     vim25.MethodClassTask,
+		"RetrieveProperties": vim25.MethodClassRead,
+		"QueryPerf":          vim25.MethodClassRead,
+		"CreateFilter":       vim25.MethodClassWrite,
+	}
+
+	for method, class := range tests {
+		assert.Equal(t, class, vim25.MethodClass(method), method)
+	}
+}